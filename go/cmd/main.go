@@ -2,13 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/iv-sukhanov/finance_tracker/internal/api"
 	tbot "github.com/iv-sukhanov/finance_tracker/internal/bot"
+	"github.com/iv-sukhanov/finance_tracker/internal/migration"
 	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 	"github.com/iv-sukhanov/finance_tracker/internal/service"
 	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/migrations"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -21,6 +31,20 @@ var (
 	argAppName          = os.Getenv("APP_NAME")
 	argTelegramBotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
 	argTelegramBotMode  = os.Getenv("TELEGRAM_DEBUG_MODE")
+	argExchangeRates    = os.Getenv("EXCHANGE_RATES_FILE")
+	argWebhookURL       = os.Getenv("TELEGRAM_WEBHOOK_URL")
+	argWebhookAddr      = os.Getenv("TELEGRAM_WEBHOOK_ADDR")
+	argWebhookSecret    = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	argAPIAddr          = os.Getenv("API_ADDR")
+	argBotWorkers       = os.Getenv("BOT_WORKERS")
+	argBotQueueSize     = os.Getenv("BOT_QUEUE_SIZE")
+	argDBQueryTimeout   = os.Getenv("DB_QUERY_TIMEOUT")
+)
+
+const (
+	defaultWebhookAddr = ":8080"
+
+	usage = "usage: ftbot [up | down [N] | version], without a command the migrations are applied and the bot starts"
 )
 
 func main() {
@@ -42,14 +66,149 @@ func main() {
 
 	log.Info("Connected to DB", db.Stats())
 
-	bot, err := utils.NewBot(argTelegramBotToken, argTelegramBotMode == "true")
+	migrator, err := migration.New(db, migrations.FS, log.Logger)
 	if err != nil {
-		log.WithError(err).Fatal("Failed to initialize telegram bot")
+		log.WithError(err).Fatal("Failed to load migrations")
+	}
+	if len(os.Args) > 1 {
+		if err := migrate(context.Background(), migrator, os.Args[1:], log); err != nil {
+			log.WithError(err).Fatal("Failed to migrate DB")
+		}
+		return
+	}
+	if err := migrate(context.Background(), migrator, []string{"up"}, log); err != nil {
+		log.WithError(err).Fatal("Failed to migrate DB")
 	}
 
-	repo := repository.New(db)
+	repo := repository.New(db, repository.WithQueryTimeout(durationArg(argDBQueryTimeout, "DB_QUERY_TIMEOUT", repository.DefaultQueryTimeout, log)))
 	src := service.New(repo)
-	telegramBot := tbot.New(src, bot, log.Logger)
 
-	telegramBot.Start(context.Background())
+	if argExchangeRates != "" {
+		loadExchangeRates(context.Background(), src, argExchangeRates, log)
+	}
+
+	if argTelegramBotToken == "" && argAPIAddr == "" {
+		log.Fatal("Neither TELEGRAM_BOT_TOKEN nor API_ADDR is set, nothing to start")
+	}
+
+	// SIGINT and SIGTERM cancel the context, the bot and the API finish their work before the DB is closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var running sync.WaitGroup
+	if argAPIAddr != "" {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			if err := api.New(src, log.Logger).ListenAndServe(ctx, argAPIAddr); err != nil {
+				log.WithError(err).Error("API server stopped")
+				stop()
+			}
+		}()
+	}
+
+	if argTelegramBotToken != "" {
+		bot, err := utils.NewBot(argTelegramBotToken, argTelegramBotMode == "true")
+		if err != nil {
+			log.WithError(err).Fatal("Failed to initialize telegram bot")
+		}
+
+		opts := []tbot.Option{
+			tbot.WithPersistentSessions(),
+			tbot.WithDispatcher(tbot.DispatcherConfig{
+				Workers:   intArg(argBotWorkers, "BOT_WORKERS", log),
+				QueueSize: intArg(argBotQueueSize, "BOT_QUEUE_SIZE", log),
+			}),
+		}
+		if argWebhookURL != "" {
+			if argWebhookAddr == "" {
+				argWebhookAddr = defaultWebhookAddr
+			}
+			opts = append(opts, tbot.WithWebhook(tbot.WebhookConfig{
+				URL:         argWebhookURL,
+				ListenAddr:  argWebhookAddr,
+				SecretToken: argWebhookSecret,
+			}))
+		}
+
+		telegramBot := tbot.New(src, bot, log.Logger, opts...)
+		telegramBot.Start(ctx)
+	}
+
+	running.Wait()
+	log.Info("Stopped, closing DB")
+}
+
+// migrate runs the migration command, up applies the new migrations, down reverts the last N of them, one by default,
+// version prints the version of the DB
+func migrate(ctx context.Context, migrator *migration.Migrator, args []string, log *logrus.Entry) error {
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Infof("Applied %d migrations, DB is at version %d", n, migrator.Latest())
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			steps = intArg(args[1], "number of migrations", log)
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Infof("Reverted %d migrations", n)
+	case args[0] == "version" && len(args) == 1:
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t, latest: %d\n", version, dirty, migrator.Latest())
+	default:
+		return fmt.Errorf("unknown command %q, %s", args, usage)
+	}
+	return nil
+}
+
+// intArg parses the numeric environment variable, zero if it is not set
+func intArg(value, name string, log *logrus.Entry) int {
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.WithError(err).Fatalf("Invalid %s: %q", name, value)
+	}
+	return n
+}
+
+// durationArg parses the duration environment variable, the default if it is not set
+func durationArg(value, name string, def time.Duration, log *logrus.Entry) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.WithError(err).Fatalf("Invalid %s: %q", name, value)
+	}
+	return d
+}
+
+// loadExchangeRates stores the exchange rates from the file,
+// the bot keeps working with the previously stored rates if the file can't be loaded
+func loadExchangeRates(ctx context.Context, src *service.Service, filename string, log *logrus.Entry) {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.WithError(err).Error("Failed to open exchange rates file")
+		return
+	}
+	defer file.Close()
+
+	n, err := src.LoadExchangeRates(ctx, file)
+	if err != nil {
+		log.WithError(err).Error("Failed to load exchange rates")
+		return
+	}
+	log.Infof("Loaded %d exchange rates", n)
 }
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// time given to read the headers of a request and to finish the requests in flight when the server stops
+	serverTimeout = 10 * time.Second
+
+	// the biggest request body accepted
+	maxBodySize = 1 << 20
+
+	// scheme of the Authorization header carrying the API token
+	bearerPrefix = "Bearer "
+)
+
+var (
+	errUnauthorized   = errors.New("missing or invalid api token")
+	errNotFound       = errors.New("not found")
+	errInvalidGUID    = errors.New("invalid guid")
+	errInvalidLimit   = errors.New("invalid limit")
+	errInvalidOrder   = errors.New("invalid order")
+	errInvalidAsc     = errors.New("invalid asc")
+	errInvalidBody    = errors.New("invalid request body")
+	errAlreadyExists  = errors.New("already exists")
+	errInternal       = errors.New("internal error")
+	errInvalidTime    = errors.New("invalid time, RFC 3339 expected")
+	errInvalidAllTags = errors.New("invalid all_tags")
+)
+
+type (
+	// Server is the HTTP API over the service layer,
+	// every endpoint but the unknown ones requires the API token of the user in the Authorization header
+	Server struct {
+		srvc service.ServiceInterface
+		log  *logrus.Logger
+		mux  *http.ServeMux
+	}
+
+	// userHandler is a handler of the requests of an authenticated user
+	userHandler func(w http.ResponseWriter, r *http.Request, user ftracker.User)
+
+	// errorResponse is the body of every unsuccessful response
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	// createdResponse is the body of the response to a successful creation
+	createdResponse struct {
+		GUID uuid.UUID `json:"guid"`
+	}
+)
+
+// New creates the API server on top of the service.
+//
+// Parameters:
+//   - srvc: The service the requests are served by.
+//   - log: The logger.
+//
+// Returns:
+//   - *Server: The server, ready to be used as an http.Handler.
+func New(srvc service.ServiceInterface, log *logrus.Logger) *Server {
+	s := &Server{
+		srvc: srvc,
+		log:  log,
+		mux:  http.NewServeMux(),
+	}
+
+	s.mux.Handle("GET /api/users/me", s.auth(s.getMe))
+	s.mux.Handle("PATCH /api/users/me", s.auth(s.updateMe))
+
+	s.mux.Handle("GET /api/categories", s.auth(s.getCategories))
+	s.mux.Handle("GET /api/categories/tree", s.auth(s.getCategoryTree))
+	s.mux.Handle("POST /api/categories", s.auth(s.addCategory))
+	s.mux.Handle("GET /api/categories/{guid}", s.auth(s.getCategory))
+	s.mux.Handle("PATCH /api/categories/{guid}", s.auth(s.updateCategory))
+	s.mux.Handle("DELETE /api/categories/{guid}", s.auth(s.deleteCategory))
+
+	s.mux.Handle("GET /api/records", s.auth(s.getRecords))
+	s.mux.Handle("POST /api/records", s.auth(s.addRecord))
+	s.mux.Handle("GET /api/records/{guid}", s.auth(s.getRecord))
+	s.mux.Handle("PATCH /api/records/{guid}", s.auth(s.updateRecord))
+	s.mux.Handle("DELETE /api/records/{guid}", s.auth(s.deleteRecord))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on the address until the context is cancelled,
+// the requests in flight are given some time to finish before returning.
+//
+// Parameters:
+//   - ctx: The context, the server stops when it is cancelled.
+//   - addr: The address to listen on, like :8081.
+//
+// Returns:
+//   - error: The error the server stopped with, nil if stopped by the context.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: serverTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	s.log.Infof("serving api on %s", addr)
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+		s.log.Info("context cancelled, stopping api server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// auth authenticates the user by the API token and passes the request to the handler,
+// the requests without a valid token are rejected
+func (s *Server) auth(next userHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		header := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(header, bearerPrefix)
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			s.writeError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		users, err := s.srvc.GetUsers(r.Context(), s.srvc.UsersWithAPIToken(token), s.srvc.UsersWithLimit(1))
+		if err != nil {
+			s.internalError(w, err, "error getting user by api token")
+			return
+		}
+		if len(users) != 1 {
+			s.log.Warn("api request with wrong token from ", r.RemoteAddr)
+			s.writeError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		next(w, r, users[0])
+	})
+}
+
+// writeJSON writes the value as the JSON body of the response with the status
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.WithError(err).Error("error writing response")
+	}
+}
+
+// writeError writes the error as the JSON body of the response with the status
+func (s *Server) writeError(w http.ResponseWriter, status int, err error) {
+	s.writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// internalError logs the error and responds with a generic internal error, the details are not exposed
+func (s *Server) internalError(w http.ResponseWriter, err error, msg string) {
+	s.log.WithError(err).Error(msg)
+	s.writeError(w, http.StatusInternalServerError, errInternal)
+}
+
+// readJSON decodes the JSON body of the request into v, the unknown fields are rejected
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errInvalidBody
+	}
+	return nil
+}
+
+// pathGUID parses the {guid} wildcard of the request path
+func pathGUID(r *http.Request) (uuid.UUID, error) {
+	guid, err := uuid.Parse(r.PathValue("guid"))
+	if err != nil {
+		return uuid.Nil, errInvalidGUID
+	}
+	return guid, nil
+}
+
+// queryGUIDs parses the GUIDs of the query parameter, given either repeated or comma separated
+func queryGUIDs(query url.Values, key string) ([]uuid.UUID, error) {
+	var guids []uuid.UUID
+	for _, value := range query[key] {
+		for _, raw := range strings.Split(value, ",") {
+			guid, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, errInvalidGUID
+			}
+			guids = append(guids, guid)
+		}
+	}
+	return guids, nil
+}
+
+// queryStrings returns the values of the query parameter, given either repeated or comma separated
+func queryStrings(query url.Values, key string) []string {
+	var values []string
+	for _, value := range query[key] {
+		for _, raw := range strings.Split(value, ",") {
+			if raw = strings.TrimSpace(raw); raw != "" {
+				values = append(values, raw)
+			}
+		}
+	}
+	return values
+}
+
+// queryLimit parses the limit query parameter, 0 if not given
+func queryLimit(query url.Values) (int, error) {
+	if !query.Has("limit") {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		return 0, errInvalidLimit
+	}
+	return limit, nil
+}
+
+// queryBool parses the boolean query parameter, false if not given
+func queryBool(query url.Values, key string, errInvalid error) (bool, error) {
+	if !query.Has(key) {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(query.Get(key))
+	if err != nil {
+		return false, errInvalid
+	}
+	return value, nil
+}
+
+// queryTime parses the RFC 3339 time of the query parameter, def if not given
+func queryTime(query url.Values, key string, def time.Time) (time.Time, error) {
+	if !query.Has(key) {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, query.Get(key))
+	if err != nil {
+		return time.Time{}, errInvalidTime
+	}
+	return t, nil
+}
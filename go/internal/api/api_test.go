@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "token"
+
+var testUser = ftracker.User{
+	GUID:       uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+	Username:   "test_username",
+	TelegramID: "1",
+	Currency:   "EUR",
+}
+
+// expectAuth makes the service authenticate the test user by the test token
+func expectAuth(s *mock_service.MockServiceInterface) {
+	s.EXPECT().UsersWithAPIToken(testToken)
+	s.EXPECT().UsersWithLimit(1)
+	s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return([]ftracker.User{testUser}, nil)
+}
+
+// doRequest serves the request with the test token by the server over the service,
+// returns the status and the body of the response
+func doRequest(t *testing.T, srvc service.ServiceInterface, method, target, body string) (int, string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", bearerPrefix+testToken)
+	rec := httptest.NewRecorder()
+
+	New(srvc, test_log).ServeHTTP(rec, req)
+
+	resp, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return rec.Code, strings.TrimSpace(string(resp))
+}
+
+func Test_auth(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		header     string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "Ok",
+			header: "Bearer token",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"guid":"00000000-0000-0000-0000-000000000001","username":"test_username","telegram_id":"1",` +
+				`"currency":"EUR","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:       "No_header",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"missing or invalid api token"}`,
+		},
+		{
+			name:       "Wrong_scheme",
+			header:     "Basic token",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"missing or invalid api token"}`,
+		},
+		{
+			name:   "Unknown_token",
+			header: "Bearer guess",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().UsersWithAPIToken("guess")
+				s.EXPECT().UsersWithLimit(1)
+				s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"missing or invalid api token"}`,
+		},
+		{
+			name:   "DB_error",
+			header: "Bearer token",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().UsersWithAPIToken(testToken)
+				s.EXPECT().UsersWithLimit(1)
+				s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"internal error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			New(srvc, test_log).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Equal(t, tt.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func Test_updateMe(t *testing.T) {
+
+	updated := testUser
+	updated.Currency = "USD"
+
+	tests := []struct {
+		name       string
+		body       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Ok",
+			body: `{"currency":"usd"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SetUserCurrency(gomock.Any(), testUser.GUID, "usd").Return(nil)
+				s.EXPECT().UsersWithGUIDs([]uuid.UUID{testUser.GUID})
+				s.EXPECT().UsersWithLimit(1)
+				s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return([]ftracker.User{updated}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"guid":"00000000-0000-0000-0000-000000000001","username":"test_username","telegram_id":"1",` +
+				`"currency":"USD","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "Unknown_currency",
+			body: `{"currency":"xyz"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SetUserCurrency(gomock.Any(), testUser.GUID, "xyz").Return(service.ErrUnknownCurrency)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unknown currency"}`,
+		},
+		{
+			name: "Unknown_field",
+			body: `{"username":"other"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid request body"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, body := doRequest(t, srvc, http.MethodPatch, "/api/users/me", tt.body)
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_unknownRoute(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	status, _ := doRequest(t, mock_service.NewMockServiceInterface(controller), http.MethodPut, "/api/records", "")
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+)
+
+var (
+	errCategoryRequired   = errors.New("category is required")
+	errInvalidKind        = errors.New("invalid kind")
+	errNoParentCategory   = errors.New("parent category not found")
+	errNoMoveToCategory   = errors.New("category to move the records to not found")
+	errKindMismatch       = errors.New("kinds of the categories differ")
+	categoryOrdersByQuery = map[string]service.CategoryOrder{
+		"category":   service.OrderCategoriesByCategory,
+		"amount":     service.OrderCategoriesByAmount,
+		"created_at": service.OrderCategoriesByCreatedAt,
+		"updated_at": service.OrderCategoriesByUpdatedAt,
+	}
+)
+
+// categoryRequest is the body of the requests creating and updating a category,
+// only the category and the description can be updated
+type categoryRequest struct {
+	Category    *string   `json:"category"`
+	Description *string   `json:"description"`
+	Kind        string    `json:"kind"`
+	ParentGUID  uuid.UUID `json:"parent_guid"`
+}
+
+// categoryOptions builds the category options from the query parameters
+//
+//   - limit: the maximum number of categories
+//   - guid: the GUIDs of the categories
+//   - category: the names of the categories
+//   - kind: the kinds of the categories
+//   - order, asc: the column to sort by, one of category, amount, created_at and updated_at, and the direction
+func (s *Server) categoryOptions(query url.Values) ([]service.CategoryOption, error) {
+	var opts []service.CategoryOption
+
+	limit, err := queryLimit(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		opts = append(opts, s.srvc.SpendingCategoriesWithLimit(limit))
+	}
+
+	guids, err := queryGUIDs(query, "guid")
+	if err != nil {
+		return nil, err
+	}
+	if len(guids) > 0 {
+		opts = append(opts, s.srvc.SpendingCategoriesWithGUIDs(guids))
+	}
+
+	if categories := queryStrings(query, "category"); len(categories) > 0 {
+		opts = append(opts, s.srvc.SpendingCategoriesWithCategories(categories))
+	}
+
+	kinds := queryStrings(query, "kind")
+	for _, kind := range kinds {
+		if kind != ftracker.KindSpending && kind != ftracker.KindIncome {
+			return nil, errInvalidKind
+		}
+	}
+	if len(kinds) > 0 {
+		opts = append(opts, s.srvc.SpendingCategoriesWithKinds(kinds))
+	}
+
+	asc, err := queryBool(query, "asc", errInvalidAsc)
+	if err != nil {
+		return nil, err
+	}
+	if query.Has("order") {
+		order, ok := categoryOrdersByQuery[query.Get("order")]
+		if !ok {
+			return nil, errInvalidOrder
+		}
+		opts = append(opts, s.srvc.SpendingCategoriesWithOrder(order, asc))
+	}
+
+	return opts, nil
+}
+
+// findCategory returns the category of the user with the GUID, false if there is none
+func (s *Server) findCategory(ctx context.Context, userGUID, guid uuid.UUID) (ftracker.SpendingCategory, bool, error) {
+	categories, err := s.srvc.GetCategories(ctx, userGUID, s.srvc.SpendingCategoriesWithGUIDs([]uuid.UUID{guid}), s.srvc.SpendingCategoriesWithLimit(1))
+	if err != nil || len(categories) != 1 {
+		return ftracker.SpendingCategory{}, false, err
+	}
+	return categories[0], true, nil
+}
+
+// getCategories responds with the categories of the user matching the query parameters
+func (s *Server) getCategories(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	opts, err := s.categoryOptions(r.URL.Query())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	categories, err := s.srvc.GetCategories(r.Context(), user.GUID, opts...)
+	if err != nil {
+		s.internalError(w, err, "error getting categories")
+		return
+	}
+	if categories == nil {
+		categories = []ftracker.SpendingCategory{}
+	}
+	s.writeJSON(w, http.StatusOK, categories)
+}
+
+// getCategoryTree responds with the categories of the user as trees with the rolled-up amounts
+func (s *Server) getCategoryTree(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	opts, err := s.categoryOptions(r.URL.Query())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	trees, err := s.srvc.GetCategoryTree(r.Context(), user.GUID, opts...)
+	if err != nil {
+		s.internalError(w, err, "error getting category tree")
+		return
+	}
+	if trees == nil {
+		trees = []ftracker.CategoryTree{}
+	}
+	s.writeJSON(w, http.StatusOK, trees)
+}
+
+// getCategory responds with the category of the user with the GUID of the path
+func (s *Server) getCategory(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	category, ok, err := s.findCategory(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting category")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, category)
+}
+
+// addCategory creates a category of the user, the parent category has to be of the same kind
+func (s *Server) addCategory(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	var req categoryRequest
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Category == nil || *req.Category == "" {
+		s.writeError(w, http.StatusBadRequest, errCategoryRequired)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = ftracker.KindSpending
+	}
+	if req.Kind != ftracker.KindSpending && req.Kind != ftracker.KindIncome {
+		s.writeError(w, http.StatusBadRequest, errInvalidKind)
+		return
+	}
+
+	if req.ParentGUID != uuid.Nil {
+		parent, ok, err := s.findCategory(r.Context(), user.GUID, req.ParentGUID)
+		if err != nil {
+			s.internalError(w, err, "error getting parent category")
+			return
+		}
+		if !ok {
+			s.writeError(w, http.StatusBadRequest, errNoParentCategory)
+			return
+		}
+		if parent.Kind != req.Kind {
+			s.writeError(w, http.StatusBadRequest, errKindMismatch)
+			return
+		}
+	}
+
+	category := ftracker.SpendingCategory{
+		UserGUID:   user.GUID,
+		ParentGUID: req.ParentGUID,
+		Category:   *req.Category,
+		Kind:       req.Kind,
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+
+	guids, err := s.srvc.AddCategories(r.Context(), user.GUID, []ftracker.SpendingCategory{category})
+	if errors.Is(err, service.ErrInvalidCategoryName) {
+		s.writeError(w, http.StatusBadRequest, service.ErrInvalidCategoryName)
+		return
+	}
+	if utils.IsUniqueConstrainViolation(err) {
+		s.writeError(w, http.StatusConflict, errAlreadyExists)
+		return
+	}
+	if err != nil || len(guids) != 1 {
+		s.internalError(w, err, "error adding category")
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, createdResponse{GUID: guids[0]})
+}
+
+// updateCategory changes the name and the description of the category of the user with the GUID of the path,
+// the fields missing from the body are kept
+func (s *Server) updateCategory(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req categoryRequest
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Category != nil && *req.Category == "" {
+		s.writeError(w, http.StatusBadRequest, errCategoryRequired)
+		return
+	}
+
+	category, ok, err := s.findCategory(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting category")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	if req.Category != nil {
+		category.Category = *req.Category
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+
+	err = s.srvc.UpdateCategories(r.Context(), user.GUID, []ftracker.SpendingCategory{category})
+	if errors.Is(err, service.ErrInvalidCategoryName) {
+		s.writeError(w, http.StatusBadRequest, service.ErrInvalidCategoryName)
+		return
+	}
+	if utils.IsUniqueConstrainViolation(err) {
+		s.writeError(w, http.StatusConflict, errAlreadyExists)
+		return
+	}
+	if err != nil {
+		s.internalError(w, err, "error updating category")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, category)
+}
+
+// deleteCategory deletes the category of the user with the GUID of the path,
+// the records of the category are moved to the category given by the move_to query parameter or deleted if it is missing
+func (s *Server) deleteCategory(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	moveTo := uuid.Nil
+	if raw := r.URL.Query().Get("move_to"); raw != "" {
+		if moveTo, err = uuid.Parse(raw); err != nil {
+			s.writeError(w, http.StatusBadRequest, errInvalidGUID)
+			return
+		}
+	}
+
+	category, ok, err := s.findCategory(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting category")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	if moveTo != uuid.Nil {
+		target, ok, err := s.findCategory(r.Context(), user.GUID, moveTo)
+		if err != nil {
+			s.internalError(w, err, "error getting category to move the records to")
+			return
+		}
+		if !ok || target.GUID == category.GUID {
+			s.writeError(w, http.StatusBadRequest, errNoMoveToCategory)
+			return
+		}
+		if target.Kind != category.Kind {
+			s.writeError(w, http.StatusBadRequest, errKindMismatch)
+			return
+		}
+	}
+
+	err = s.srvc.DeleteCategories(r.Context(), user.GUID, []uuid.UUID{guid}, moveTo)
+	if err != nil {
+		s.internalError(w, err, "error deleting category")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getCategories(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+
+	tests := []struct {
+		name       string
+		target     string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "Ok",
+			target: "/api/categories?limit=5&guid=" + guid.String() + "&kind=income&order=amount&asc=true",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithLimit(5)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithKinds([]string{ftracker.KindIncome})
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByAmount, true)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: guid, UserGUID: testUser.GUID, Category: "salary", Kind: ftracker.KindIncome, Amount: 100},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `[{"guid":"00000000-0000-0000-0000-000000000101","user_guid":"00000000-0000-0000-0000-000000000001",` +
+				`"parent_guid":"00000000-0000-0000-0000-000000000000","category":"salary","description":"","kind":"income",` +
+				`"amount":100,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]`,
+		},
+		{
+			name:   "Empty",
+			target: "/api/categories?category=food,drinks",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food", "drinks"})
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:   "Invalid_limit",
+			target: "/api/categories?limit=-1",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid limit"}`,
+		},
+		{
+			name:   "Invalid_order",
+			target: "/api/categories?order=kind",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid order"}`,
+		},
+		{
+			name:   "Invalid_kind",
+			target: "/api/categories?kind=debt",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid kind"}`,
+		},
+		{
+			name:   "DB_error",
+			target: "/api/categories",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID).Return(nil, errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"internal error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, body := doRequest(t, srvc, http.MethodGet, tt.target, "")
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_addCategory(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	parentGUID := uuid.MustParse("00000000-0000-0000-0000-000000000102")
+
+	tests := []struct {
+		name       string
+		body       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Ok",
+			body: `{"category":"food","description":"all the food"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().AddCategories(gomock.Any(), testUser.GUID, []ftracker.SpendingCategory{
+					{UserGUID: testUser.GUID, Category: "food", Description: "all the food", Kind: ftracker.KindSpending},
+				}).Return([]uuid.UUID{guid}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"guid":"00000000-0000-0000-0000-000000000101"}`,
+		},
+		{
+			name: "Ok_subcategory",
+			body: `{"category":"coffee","parent_guid":"` + parentGUID.String() + `"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{parentGUID})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: parentGUID, Category: "food", Kind: ftracker.KindSpending},
+				}, nil)
+				s.EXPECT().AddCategories(gomock.Any(), testUser.GUID, []ftracker.SpendingCategory{
+					{UserGUID: testUser.GUID, ParentGUID: parentGUID, Category: "coffee", Kind: ftracker.KindSpending},
+				}).Return([]uuid.UUID{guid}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"guid":"00000000-0000-0000-0000-000000000101"}`,
+		},
+		{
+			name: "Parent_kind_mismatch",
+			body: `{"category":"bonus","kind":"income","parent_guid":"` + parentGUID.String() + `"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{parentGUID})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: parentGUID, Category: "food", Kind: ftracker.KindSpending},
+				}, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"kinds of the categories differ"}`,
+		},
+		{
+			name: "No_parent",
+			body: `{"category":"coffee","parent_guid":"` + parentGUID.String() + `"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{parentGUID})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"parent category not found"}`,
+		},
+		{
+			name: "No_category",
+			body: `{"description":"nameless"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"category is required"}`,
+		},
+		{
+			name: "Invalid_kind",
+			body: `{"category":"debts","kind":"debt"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid kind"}`,
+		},
+		{
+			name: "Invalid_name",
+			body: `{"category":"food/groceries"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().AddCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, fmt.Errorf("AddCategories: %w", service.ErrInvalidCategoryName))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid category name"}`,
+		},
+		{
+			name: "Already_exists",
+			body: `{"category":"food"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().AddCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, fmt.Errorf("%w", &pgconn.PgError{Code: "23505"}))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"already exists"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, body := doRequest(t, srvc, http.MethodPost, "/api/categories", tt.body)
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_updateCategory(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	category := ftracker.SpendingCategory{GUID: guid, UserGUID: testUser.GUID, Category: "food", Description: "old", Kind: ftracker.KindSpending}
+
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+	}{
+		{
+			name:   "Ok",
+			target: "/api/categories/" + guid.String(),
+			body:   `{"description":"new"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				updated := category
+				updated.Description = "new"
+				s.EXPECT().UpdateCategories(gomock.Any(), testUser.GUID, []ftracker.SpendingCategory{updated}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Invalid_name",
+			target: "/api/categories/" + guid.String(),
+			body:   `{"category":"*meals*"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().UpdateCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(fmt.Errorf("UpdateCategories: %w", service.ErrInvalidCategoryName))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Not_found",
+			target: "/api/categories/" + guid.String(),
+			body:   `{"category":"meals"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Invalid_guid",
+			target: "/api/categories/food",
+			body:   `{"category":"meals"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, _ := doRequest(t, srvc, http.MethodPatch, tt.target, tt.body)
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+func Test_deleteCategory(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	moveTo := uuid.MustParse("00000000-0000-0000-0000-000000000102")
+
+	tests := []struct {
+		name       string
+		target     string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+	}{
+		{
+			name:   "Ok",
+			target: "/api/categories/" + guid.String(),
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{{GUID: guid, Kind: ftracker.KindSpending}}, nil)
+				s.EXPECT().DeleteCategories(gomock.Any(), testUser.GUID, []uuid.UUID{guid}, uuid.Nil).Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Ok_move_to",
+			target: "/api/categories/" + guid.String() + "?move_to=" + moveTo.String(),
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{moveTo})
+				s.EXPECT().SpendingCategoriesWithLimit(1).Times(2)
+				gomock.InOrder(
+					s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{{GUID: guid, Kind: ftracker.KindSpending}}, nil),
+					s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{{GUID: moveTo, Kind: ftracker.KindSpending}}, nil),
+				)
+				s.EXPECT().DeleteCategories(gomock.Any(), testUser.GUID, []uuid.UUID{guid}, moveTo).Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Move_to_itself",
+			target: "/api/categories/" + guid.String() + "?move_to=" + guid.String(),
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid}).Times(2)
+				s.EXPECT().SpendingCategoriesWithLimit(1).Times(2)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingCategory{{GUID: guid, Kind: ftracker.KindSpending}}, nil).Times(2)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid_move_to",
+			target: "/api/categories/" + guid.String() + "?move_to=food",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Not_found",
+			target: "/api/categories/" + guid.String(),
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingCategoriesWithLimit(1)
+				s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, _ := doRequest(t, srvc, http.MethodDelete, tt.target, "")
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+)
+
+var (
+	errAmountRequired   = errors.New("positive amount is required")
+	errNoCategory       = errors.New("category not found")
+	recordOrdersByQuery = map[string]service.RecordOrder{
+		"amount":     service.OrderRecordsByAmount,
+		"created_at": service.OrderRecordsByCreatedAt,
+		"updated_at": service.OrderRecordsByUpdatedAt,
+		"spent_at":   service.OrderRecordsBySpentAt,
+	}
+
+	// the end of the time frame of the records if only its start is given
+	maxSpentAt = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// recordRequest is the body of the requests creating and updating a record,
+// only the amount, the description and the tags can be updated, the amount is in the minor units of the Currency,
+// e.g. whole yen for JPY or thousandths of a dinar for KWD
+type recordRequest struct {
+	CategoryGUID uuid.UUID       `json:"category_guid"`
+	Amount       *ftracker.Money `json:"amount"`
+	Currency     string          `json:"currency"`
+	Description  *string         `json:"description"`
+	SpentAt      time.Time       `json:"spent_at"`
+	Tags         *[]string       `json:"tags"`
+}
+
+// derefTags returns the tags of the request, nil if they are missing
+func derefTags(tags *[]string) []string {
+	if tags == nil {
+		return nil
+	}
+	return *tags
+}
+
+// recordOptions builds the record options from the query parameters
+//
+//   - limit: the maximum number of records
+//   - guid: the GUIDs of the records
+//   - category_guid: the GUIDs of the categories of the records
+//   - from, to: the RFC 3339 time frame the records were spent in, the end is exclusive
+//   - tag, all_tags: the tags of the records, any of them unless all_tags is set
+//   - order, asc: the column to sort by, one of amount, created_at, updated_at and spent_at, and the direction
+func (s *Server) recordOptions(query url.Values) ([]service.RecordOption, error) {
+	var opts []service.RecordOption
+
+	limit, err := queryLimit(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		opts = append(opts, s.srvc.SpendingRecordsWithLimit(limit))
+	}
+
+	guids, err := queryGUIDs(query, "guid")
+	if err != nil {
+		return nil, err
+	}
+	if len(guids) > 0 {
+		opts = append(opts, s.srvc.SpendingRecordsWithGUIDs(guids))
+	}
+
+	categoryGUIDs, err := queryGUIDs(query, "category_guid")
+	if err != nil {
+		return nil, err
+	}
+	if len(categoryGUIDs) > 0 {
+		opts = append(opts, s.srvc.SpendingRecordsWithCategoryGUIDs(categoryGUIDs))
+	}
+
+	if query.Has("from") || query.Has("to") {
+		from, err := queryTime(query, "from", time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		to, err := queryTime(query, "to", maxSpentAt)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, s.srvc.SpendingRecordsWithTimeFrame(from, to))
+	}
+
+	all, err := queryBool(query, "all_tags", errInvalidAllTags)
+	if err != nil {
+		return nil, err
+	}
+	if tags := queryStrings(query, "tag"); len(tags) > 0 {
+		opts = append(opts, s.srvc.SpendingRecordsWithTags(tags, all))
+	}
+
+	asc, err := queryBool(query, "asc", errInvalidAsc)
+	if err != nil {
+		return nil, err
+	}
+	if query.Has("order") {
+		order, ok := recordOrdersByQuery[query.Get("order")]
+		if !ok {
+			return nil, errInvalidOrder
+		}
+		opts = append(opts, s.srvc.SpendingRecordsWithOrder(order, asc))
+	}
+
+	return opts, nil
+}
+
+// findRecord returns the record of the user with the GUID, false if there is none
+func (s *Server) findRecord(ctx context.Context, userGUID, guid uuid.UUID) (ftracker.SpendingRecord, bool, error) {
+	records, err := s.srvc.GetRecords(ctx, userGUID, s.srvc.SpendingRecordsWithGUIDs([]uuid.UUID{guid}), s.srvc.SpendingRecordsWithLimit(1))
+	if err != nil || len(records) != 1 {
+		return ftracker.SpendingRecord{}, false, err
+	}
+	return records[0], true, nil
+}
+
+// getRecords responds with the records of the user matching the query parameters
+func (s *Server) getRecords(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	opts, err := s.recordOptions(r.URL.Query())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := s.srvc.GetRecords(r.Context(), user.GUID, opts...)
+	if err != nil {
+		s.internalError(w, err, "error getting records")
+		return
+	}
+	if records == nil {
+		records = []ftracker.SpendingRecord{}
+	}
+	s.writeJSON(w, http.StatusOK, records)
+}
+
+// getRecord responds with the record of the user with the GUID of the path
+func (s *Server) getRecord(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	record, ok, err := s.findRecord(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting record")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, record)
+}
+
+// addRecord creates a record in a category of the user,
+// the currency defaults to the base one of the user and the spending time to the current one
+func (s *Server) addRecord(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	var req recordRequest
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Amount == nil || *req.Amount <= 0 {
+		s.writeError(w, http.StatusBadRequest, errAmountRequired)
+		return
+	}
+
+	_, ok, err := s.findCategory(r.Context(), user.GUID, req.CategoryGUID)
+	if err != nil {
+		s.internalError(w, err, "error getting category")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusBadRequest, errNoCategory)
+		return
+	}
+
+	record := ftracker.SpendingRecord{
+		CategoryGUID: req.CategoryGUID,
+		Amount:       *req.Amount,
+		Currency:     req.Currency,
+		SpentAt:      req.SpentAt,
+		Tags:         derefTags(req.Tags),
+	}
+	if req.Description != nil {
+		record.Description = *req.Description
+	}
+
+	guids, err := s.srvc.AddRecords(r.Context(), user.GUID, []ftracker.SpendingRecord{record})
+	if errors.Is(err, service.ErrUnknownCurrency) {
+		s.writeError(w, http.StatusBadRequest, service.ErrUnknownCurrency)
+		return
+	}
+	if err != nil || len(guids) != 1 {
+		s.internalError(w, err, "error adding record")
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, createdResponse{GUID: guids[0]})
+}
+
+// updateRecord changes the amount, the description and the tags of the record of the user with the GUID of the path,
+// the fields missing from the body are kept, the tags given are replaced, an empty list clears them
+func (s *Server) updateRecord(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req recordRequest
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		s.writeError(w, http.StatusBadRequest, errAmountRequired)
+		return
+	}
+
+	record, ok, err := s.findRecord(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting record")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	if req.Amount != nil {
+		record.Amount = *req.Amount
+	}
+	if req.Description != nil {
+		record.Description = *req.Description
+	}
+	if req.Tags != nil {
+		record.Tags = *req.Tags
+	}
+
+	err = s.srvc.UpdateRecords(r.Context(), user.GUID, []ftracker.SpendingRecord{record})
+	if err != nil {
+		s.internalError(w, err, "error updating record")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, record)
+}
+
+// deleteRecord deletes the record of the user with the GUID of the path
+func (s *Server) deleteRecord(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	guid, err := pathGUID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, ok, err := s.findRecord(r.Context(), user.GUID, guid)
+	if err != nil {
+		s.internalError(w, err, "error getting record")
+		return
+	}
+	if !ok {
+		s.writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	err = s.srvc.DeleteRecords(r.Context(), user.GUID, []uuid.UUID{guid})
+	if err != nil {
+		s.internalError(w, err, "error deleting record")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getRecords(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000201")
+	categoryGUID := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	from := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		target     string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Ok",
+			target: "/api/records?limit=10&category_guid=" + categoryGUID.String() +
+				"&from=2024-10-01T00:00:00Z&to=2024-11-01T00:00:00Z&tag=coffee&tag=work&all_tags=true&order=spent_at",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithLimit(10)
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs([]uuid.UUID{categoryGUID})
+				s.EXPECT().SpendingRecordsWithTimeFrame(from, to)
+				s.EXPECT().SpendingRecordsWithTags([]string{"coffee", "work"}, true)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{
+					{GUID: guid, CategoryGUID: categoryGUID, Amount: 350, Currency: "EUR", BaseAmount: 350, Description: "latte", SpentAt: from},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `[{"guid":"00000000-0000-0000-0000-000000000201","category_guid":"00000000-0000-0000-0000-000000000101",` +
+				`"amount":350,"currency":"EUR","base_amount":350,"description":"latte",` +
+				`"recurring_guid":"00000000-0000-0000-0000-000000000000","occurred_at":"0001-01-01T00:00:00Z",` +
+				`"spent_at":"2024-10-01T00:00:00Z","tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]`,
+		},
+		{
+			name:   "Only_from",
+			target: "/api/records?from=2024-10-01T00:00:00Z",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithTimeFrame(from, maxSpentAt)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:   "Invalid_time",
+			target: "/api/records?to=01.11.2024",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid time, RFC 3339 expected"}`,
+		},
+		{
+			name:   "Invalid_guid",
+			target: "/api/records?guid=" + guid.String() + ",latte",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid guid"}`,
+		},
+		{
+			name:   "Invalid_asc",
+			target: "/api/records?order=amount&asc=up",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid asc"}`,
+		},
+		{
+			name:   "DB_error",
+			target: "/api/records",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID).Return(nil, errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"internal error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, body := doRequest(t, srvc, http.MethodGet, tt.target, "")
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_addRecord(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000201")
+	categoryGUID := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	spentAt := time.Date(2024, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	expectCategory := func(s *mock_service.MockServiceInterface, found bool) {
+		s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{categoryGUID})
+		s.EXPECT().SpendingCategoriesWithLimit(1)
+		var categories []ftracker.SpendingCategory
+		if found {
+			categories = []ftracker.SpendingCategory{{GUID: categoryGUID, Kind: ftracker.KindSpending}}
+		}
+		s.EXPECT().GetCategories(gomock.Any(), testUser.GUID, gomock.Any()).Return(categories, nil)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Ok",
+			body: `{"category_guid":"` + categoryGUID.String() + `","amount":350,"currency":"usd","description":"latte",` +
+				`"spent_at":"2024-10-01T12:00:00Z","tags":["coffee"]}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				expectCategory(s, true)
+				s.EXPECT().AddRecords(gomock.Any(), testUser.GUID, []ftracker.SpendingRecord{
+					{CategoryGUID: categoryGUID, Amount: 350, Currency: "usd", Description: "latte", SpentAt: spentAt, Tags: []string{"coffee"}},
+				}).Return([]uuid.UUID{guid}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"guid":"00000000-0000-0000-0000-000000000201"}`,
+		},
+		{
+			name: "No_amount",
+			body: `{"category_guid":"` + categoryGUID.String() + `"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"positive amount is required"}`,
+		},
+		{
+			name: "Negative_amount",
+			body: `{"category_guid":"` + categoryGUID.String() + `","amount":-1}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"positive amount is required"}`,
+		},
+		{
+			name: "No_category",
+			body: `{"category_guid":"` + categoryGUID.String() + `","amount":350}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				expectCategory(s, false)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"category not found"}`,
+		},
+		{
+			name: "Unknown_currency",
+			body: `{"category_guid":"` + categoryGUID.String() + `","amount":350,"currency":"xyz"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				expectCategory(s, true)
+				s.EXPECT().AddRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, fmt.Errorf("Repostiory.AddRecords: %w", service.ErrUnknownCurrency))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unknown currency"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, body := doRequest(t, srvc, http.MethodPost, "/api/records", tt.body)
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_updateRecord(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000201")
+	record := ftracker.SpendingRecord{GUID: guid, Amount: 350, Currency: "EUR", Description: "latte"}
+
+	tests := []struct {
+		name       string
+		body       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+	}{
+		{
+			name: "Ok",
+			body: `{"amount":400,"tags":["coffee"]}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{record}, nil)
+				updated := record
+				updated.Amount = 400
+				updated.Tags = []string{"coffee"}
+				s.EXPECT().UpdateRecords(gomock.Any(), testUser.GUID, []ftracker.SpendingRecord{updated}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Tags_kept",
+			body: `{"amount":400}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				tagged := record
+				tagged.Tags = []string{"coffee", "work"}
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{tagged}, nil)
+				updated := tagged
+				updated.Amount = 400
+				s.EXPECT().UpdateRecords(gomock.Any(), testUser.GUID, []ftracker.SpendingRecord{updated}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Tags_cleared",
+			body: `{"tags":[]}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				tagged := record
+				tagged.Tags = []string{"coffee"}
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{tagged}, nil)
+				updated := record
+				updated.Tags = []string{}
+				s.EXPECT().UpdateRecords(gomock.Any(), testUser.GUID, []ftracker.SpendingRecord{updated}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Zero_amount",
+			body: `{"amount":0}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Not_found",
+			body: `{"description":"tea"}`,
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, _ := doRequest(t, srvc, http.MethodPatch, "/api/records/"+guid.String(), tt.body)
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+func Test_deleteRecord(t *testing.T) {
+
+	guid := uuid.MustParse("00000000-0000-0000-0000-000000000201")
+
+	tests := []struct {
+		name       string
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantStatus int
+	}{
+		{
+			name: "Ok",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{{GUID: guid}}, nil)
+				s.EXPECT().DeleteRecords(gomock.Any(), testUser.GUID, []uuid.UUID{guid}).Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "Not_found",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return(nil, nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "DB_error",
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				expectAuth(s)
+				s.EXPECT().SpendingRecordsWithGUIDs([]uuid.UUID{guid})
+				s.EXPECT().SpendingRecordsWithLimit(1)
+				s.EXPECT().GetRecords(gomock.Any(), testUser.GUID, gomock.Any()).Return([]ftracker.SpendingRecord{{GUID: guid}}, nil)
+				s.EXPECT().DeleteRecords(gomock.Any(), testUser.GUID, []uuid.UUID{guid}).Return(errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(srvc)
+
+			status, _ := doRequest(t, srvc, http.MethodDelete, "/api/records/"+guid.String(), "")
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+)
+
+// userRequest is the body of the request updating the user
+type userRequest struct {
+	Currency string `json:"currency"`
+}
+
+// getMe responds with the authenticated user
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+	s.writeJSON(w, http.StatusOK, user)
+}
+
+// updateMe changes the base currency of the authenticated user and responds with the updated user
+func (s *Server) updateMe(w http.ResponseWriter, r *http.Request, user ftracker.User) {
+
+	var req userRequest
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Currency == "" {
+		s.writeJSON(w, http.StatusOK, user)
+		return
+	}
+
+	err := s.srvc.SetUserCurrency(r.Context(), user.GUID, req.Currency)
+	if errors.Is(err, service.ErrUnknownCurrency) {
+		s.writeError(w, http.StatusBadRequest, service.ErrUnknownCurrency)
+		return
+	}
+	if err != nil {
+		s.internalError(w, err, "error setting user currency")
+		return
+	}
+
+	users, err := s.srvc.GetUsers(r.Context(), s.srvc.UsersWithGUIDs([]uuid.UUID{user.GUID}), s.srvc.UsersWithLimit(1))
+	if err != nil || len(users) != 1 {
+		s.internalError(w, err, "error getting updated user")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, users[0])
+}
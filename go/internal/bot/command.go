@@ -2,9 +2,14 @@ package bot
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -31,43 +36,111 @@ type command struct {
 	ID     int
 	isBase bool
 	rgx    *regexp.Regexp
-	action func(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command)
+	action func(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command)
 
 	child int
 }
 
 const (
-	formatOut = "Monday, 02 Jan, 15:04"
-	formatIn  = "02.01.2006"
-
-	CommandAddCategory    = "\U0000270Fadd category"
-	CommandAddRecord      = "\U0000270Fadd record"
-	CommandShowCategories = "\U0001F9FEshow categories"
-	CommandShowRecords    = "\U0001F9FEshow records"
+	formatOut   = "Monday, 02 Jan, 15:04"
+	formatIn    = "02.01.2006"
+	formatMonth = "January 2006"
+
+	categoryPathSeparator = "/"
+
+	CommandAddCategory       = "\U0000270Fadd category"
+	CommandAddIncomeCategory = "\U0000270Fadd income category"
+	CommandEditCategory      = "\U0000270Fedit category"
+	CommandDeleteCategory    = "\U0001F5D1delete category"
+	CommandAddRecord         = "\U0000270Fadd record"
+	CommandAddIncome         = "\U0000270Fadd income"
+	CommandShowCategories    = "\U0001F9FEshow categories"
+	CommandShowRecords       = "\U0001F9FEshow records"
+	CommandBalance           = "\U0001F4B0balance"
+	CommandCurrency          = "\U0001F4B1currency"
+	CommandShowBudgets       = "\U0001F4CAshow budgets"
+	CommandSetBudget         = "\U0001F3AFset budget"
+	CommandAddRecurring      = "\U0001F501add recurring"
+	CommandShowRecurring     = "\U0001F501show recurring"
+	CommandShowTags          = "\U0001F3F7show tags"
+	CommandAPIToken          = "\U0001F511api token"
+	CommandImport            = "\U0001F4E5import"
+	CommandBankImport        = "\U0001F3E6bank import"
+	CommandPayeeRules        = "\U0001F4CCpayee rules"
 
 	CallbackDataYesRecordsExel    = "yes_records_exel"
 	CallbackDataNoRecordsExel     = "no_records_exel"
 	CallbackDataYesCategoriesExel = "yes_categories_exel"
 	CallbackDataNoCategoriesExel  = "no_categories_exel"
-
-	filename = "report.xlsx"
+	CallbackDataCSVRecords        = "csv_records"
+	CallbackDataCSVCategories     = "csv_categories"
+	CallbackDataEditRecords       = "edit_records"
+	CallbackDataCreateCategories  = "create_categories"
+	CallbackDataSkipCategories    = "skip_categories"
+	CallbackDataCancelImport      = "cancel_import"
+	CallbackDataApplyImport       = "apply_import"
+	CallbackDataBankCategory      = "bank_category:"
+	CallbackDataBankSkip          = "bank_skip"
+	CallbackDataBankDone          = "bank_done"
+	CallbackDataBankCancel        = "bank_cancel"
+
+	filename    = "report.xlsx"
+	csvFilename = "report.csv"
+
+	// the number of the rejected rows of an import listed to the user, the rest are only counted
+	maxRejectedShown = 20
+
+	// the categories offered for a reviewed bank transaction, Telegram limits the buttons of a keyboard
+	maxReviewCategories = 60
+	// the number of the category buttons in a row of the review keyboard
+	reviewCategoriesPerRow = 3
 )
 
 var (
 	// translates string commands to command IDs
 	commandsToIDs = map[string]int{
-		CommandAddCategory:    1,
-		CommandAddRecord:      3,
-		CommandShowCategories: 4,
-		CommandShowRecords:    5,
+		CommandAddCategory:       1,
+		CommandAddRecord:         3,
+		CommandShowCategories:    4,
+		CommandShowRecords:       5,
+		CommandEditCategory:      10,
+		CommandDeleteCategory:    12,
+		CommandCurrency:          14,
+		CommandAddIncomeCategory: 15,
+		CommandAddIncome:         16,
+		CommandBalance:           17,
+		CommandShowBudgets:       18,
+		CommandSetBudget:         19,
+		CommandAddRecurring:      20,
+		CommandShowRecurring:     21,
+		CommandShowTags:          23,
+		CommandAPIToken:          24,
+		CommandImport:            25,
+		CommandBankImport:        27,
+		CommandPayeeRules:        29,
 	}
 
 	// contains replies for each base command
 	commandReplies = map[int]string{
-		1: MessageAddCategory,
-		3: MessageAddRecord,
-		4: MessageShowCategories,
-		5: MessageShowRecords,
+		1:  MessageAddCategory,
+		3:  MessageAddRecord,
+		4:  MessageShowCategories,
+		5:  MessageShowRecords,
+		10: MessageEditCategory,
+		12: MessageDeleteCategory,
+		14: MessageSetCurrency,
+		15: MessageAddIncomeCategory,
+		16: MessageAddIncome,
+		17: MessageBalance,
+		18: MessageShowBudgets,
+		19: MessageSetBudget,
+		20: MessageAddRecurring,
+		21: MessageShowRecurring,
+		23: MessageShowTags,
+		24: MessageAPIToken,
+		25: MessageImport,
+		27: MessageBankImport,
+		29: MessagePayeeRules,
 	}
 
 	// contains all registered commands
@@ -76,7 +149,7 @@ var (
 		1: {
 			ID:     1,
 			isBase: true,
-			rgx:    regexp.MustCompile(`^(?<category_name>[a-zA-Z0-9 ]{1,20})$`),
+			rgx:    regexp.MustCompile(`^(?<category_name>[a-zA-Z0-9 ]{1,20}(?:/[a-zA-Z0-9 ]{1,20})*)$`),
 			action: addCategoryAction,
 			child:  2,
 		},
@@ -90,21 +163,21 @@ var (
 		3: {
 			ID:     3,
 			isBase: true,
-			rgx:    regexp.MustCompile(`^\s*(?P<category>[a-zA-Z0-9]{1,10})\s*(?P<amount>\d+(?:\.\d{1,2})?)(?:\s+(?<description>[a-zA-Z0-9 ]+))?$`),
+			rgx:    regexp.MustCompile(`^\s*(?P<category>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)\s*(?P<amount>\d+(?:[.,]\d+)?)(?:\s*(?P<currency>[A-Z]{3}))?(?:\s+(?<description>[a-zA-Z0-9#_ ]+))?(?:\s+@(?P<spent_at>\d{2}\.\d{2}\.\d{4}))?$`),
 			action: addRecordAction,
 			child:  0,
 		},
 		4: {
 			ID:     4,
 			isBase: true,
-			rgx:    regexp.MustCompile(`^(?:(?P<number>\d+)|(?P<category_or_all>[a-zA-Z0-9]{1,10}))(?:\s+(?P<isfull>full))?$`),
+			rgx:    regexp.MustCompile(`^(?:(?P<number>\d+)|(?P<category_or_all>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*))(?:\s+(?P<isfull>full))?$`),
 			action: showCategoriesAction,
 			child:  8,
 		},
 		5: {
 			ID:     5,
 			isBase: true,
-			rgx:    regexp.MustCompile(`^(?P<category>[a-zA-Z0-9]{1,10})$`),
+			rgx:    regexp.MustCompile(`^(?P<category>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)$`),
 			action: showRecordsAction,
 			child:  6,
 		},
@@ -124,45 +197,254 @@ var (
 			ID:     7,
 			isBase: false,
 			rgx: regexp.MustCompile(
-				`^(?P<y_or_n>(?:` + CallbackDataYesRecordsExel + `)|(?:` + CallbackDataNoRecordsExel + `))$`,
+				`^(?P<y_or_n>(?:` + CallbackDataYesRecordsExel + `)|(?:` + CallbackDataNoRecordsExel + `)|(?:` + CallbackDataCSVRecords + `)|(?:` + CallbackDataEditRecords + `))$`,
 			),
 			action: returnRecordsExelAction,
-			child:  0,
+			child:  9,
 		},
 		8: {
 			ID:     8,
 			isBase: false,
 			rgx: regexp.MustCompile(
-				`^(?P<y_or_n>(?:` + CallbackDataYesCategoriesExel + `)|(?:` + CallbackDataNoCategoriesExel + `))$`,
+				`^(?P<y_or_n>(?:` + CallbackDataYesCategoriesExel + `)|(?:` + CallbackDataNoCategoriesExel + `)|(?:` + CallbackDataCSVCategories + `))$`,
 			),
 			action: returnCategoriesExelAction,
 			child:  0,
 		},
+		9: {
+			ID:     9,
+			isBase: false,
+			rgx: regexp.MustCompile(
+				`^\s*(?P<number>\d+)\s+(?:(?P<delete>delete)|(?P<amount>\d+(?:[.,]\d+)?)(?:\s+(?P<description>[a-zA-Z0-9#_ ]+))?)$`,
+			),
+			action: editRecordAction,
+			child:  0,
+		},
+		10: {
+			ID:     10,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^(?<category_name>[a-zA-Z0-9 ]{1,20})$`),
+			action: pickCategoryToEditAction,
+			child:  11,
+		},
+		11: {
+			ID:     11,
+			isBase: false,
+			rgx: regexp.MustCompile(
+				`^(?:name\s+(?P<category_name>[a-zA-Z0-9 ]{1,20})|description\s+(?P<category_descr>[a-zA-Z0-9 .,!]+))$`,
+			),
+			action: editCategoryAction,
+			child:  0,
+		},
+		12: {
+			ID:     12,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^(?<category_name>[a-zA-Z0-9 ]{1,20})$`),
+			action: pickCategoryToDeleteAction,
+			child:  13,
+		},
+		13: {
+			ID:     13,
+			isBase: false,
+			rgx: regexp.MustCompile(
+				`^(?:(?P<delete>delete)|move\s+(?P<category_name>[a-zA-Z0-9 ]{1,20}))$`,
+			),
+			action: deleteCategoryAction,
+			child:  0,
+		},
+		14: {
+			ID:     14,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^\s*(?P<currency>[a-zA-Z]{3})\s*$`),
+			action: setCurrencyAction,
+			child:  0,
+		},
+		15: {
+			ID:     15,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^(?<category_name>[a-zA-Z0-9 ]{1,20}(?:/[a-zA-Z0-9 ]{1,20})*)$`),
+			action: addCategoryAction,
+			child:  2,
+		},
+		16: {
+			ID:     16,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^\s*(?P<category>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)\s*(?P<amount>\d+(?:[.,]\d+)?)(?:\s*(?P<currency>[A-Z]{3}))?(?:\s+(?<description>[a-zA-Z0-9#_ ]+))?(?:\s+@(?P<spent_at>\d{2}\.\d{2}\.\d{4}))?$`),
+			action: addIncomeAction,
+			child:  0,
+		},
+		17: {
+			ID:     17,
+			isBase: true,
+			rgx: regexp.MustCompile(
+				`^(?:(?:last)?\s*(?P<ymd>(?:year)|(?:month)|(?:day))|` +
+					`(?P<from>\d{2}.\d{2}.\d{4})\s*(?P<to>\d{2}.\d{2}.\d{4})?)$`,
+			),
+			action: showBalanceAction,
+			child:  0,
+		},
+		18: {
+			ID:     18,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^(?P<category_or_all>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)$`),
+			action: showBudgetsAction,
+			child:  0,
+		},
+		19: {
+			ID:     19,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^\s*(?P<category>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)\s+(?:(?P<delete>delete)|(?P<amount>\d+(?:[.,]\d+)?))\s*$`),
+			action: setBudgetAction,
+			child:  0,
+		},
+		20: {
+			ID:     20,
+			isBase: true,
+			rgx: regexp.MustCompile(
+				`^\s*(?P<category>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)\s*(?P<amount>\d+(?:[.,]\d+)?)(?:\s*(?P<currency>[A-Z]{3}))?` +
+					`\s+(?:(?P<every>daily|weekly|monthly)|\[(?P<cron>[0-9*/,\- ]+)\])(?:\s+(?<description>[a-zA-Z0-9#_ ]+))?$`,
+			),
+			action: addRecurringAction,
+			child:  0,
+		},
+		21: {
+			ID:     21,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^(?P<category_or_all>[a-zA-Z0-9]{1,10}(?:/[a-zA-Z0-9]{1,10})*)$`),
+			action: showRecurringAction,
+			child:  22,
+		},
+		22: {
+			ID:     22,
+			isBase: false,
+			rgx:    regexp.MustCompile(`^\s*(?P<number>\d+)\s+delete\s*$`),
+			action: deleteRecurringAction,
+			child:  0,
+		},
+		23: {
+			ID:     23,
+			isBase: true,
+			rgx: regexp.MustCompile(
+				`^(?:(?:last)?\s*(?P<ymd>(?:year)|(?:month)|(?:day))|` +
+					`(?P<from>\d{2}.\d{2}.\d{4})\s*(?P<to>\d{2}.\d{2}.\d{4})?)$`,
+			),
+			action: showTagsAction,
+			child:  0,
+		},
+		24: {
+			ID:     24,
+			isBase: true,
+			rgx:    regexp.MustCompile(`^\s*(?P<new>new)\s*$`),
+			action: issueAPITokenAction,
+			child:  0,
+		},
+		25: {
+			ID:     25,
+			isBase: true,
+			rgx:    regexp.MustCompile(`(?s)^(?P<file>.*\S.*)$`),
+			action: importRecordsAction,
+			child:  26,
+		},
+		26: {
+			ID:     26,
+			isBase: false,
+			rgx: regexp.MustCompile(
+				`^(?P<choice>(?:` + CallbackDataCreateCategories + `)|(?:` + CallbackDataSkipCategories + `)|(?:` + CallbackDataApplyImport + `)|(?:` + CallbackDataCancelImport + `))$`,
+			),
+			action: importDecisionAction,
+			child:  0,
+		},
+		27: {
+			ID:     27,
+			isBase: true,
+			rgx:    regexp.MustCompile(`(?s)^(?P<file>.*\S.*)$`),
+			action: bankImportAction,
+			child:  28,
+		},
+		28: { // repeats itself until every transaction is reviewed
+			ID:     28,
+			isBase: false,
+			rgx: regexp.MustCompile(
+				`^(?:` + CallbackDataBankCategory + `(?P<category>[0-9a-f-]{36})|` +
+					`(?P<choice>(?:` + CallbackDataBankSkip + `)|(?:` + CallbackDataBankDone + `)|(?:` + CallbackDataBankCancel + `)))$`,
+			),
+			action: reviewBankTransactionAction,
+			child:  28,
+		},
+		29: {
+			ID:     29,
+			isBase: true,
+			rgx: regexp.MustCompile(
+				`^\s*(?:(?P<all>all)|(?P<pattern>[^=]{1,255}?)\s*=\s*(?P<category>[a-zA-Z0-9 ]{1,20}(?:/[a-zA-Z0-9 ]{1,20})*))$`,
+			),
+			action: payeeRulesAction,
+			child:  30,
+		},
+		30: {
+			ID:     30,
+			isBase: false,
+			rgx:    regexp.MustCompile(`^\s*(?P<number>\d+)\s+delete\s*$`),
+			action: deletePayeeRuleAction,
+			child:  0,
+		},
 	}
 
-	// inline keyboard asking the user if they want to receive an EXEL file
-	// with the records
+	// inline keyboard asking the user if they want to receive an EXEL or a CSV file
+	// with the records or to edit them
 	wantExelRecordsKeyboard = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Yes", CallbackDataYesRecordsExel),
+			tgbotapi.NewInlineKeyboardButtonData("EXEL", CallbackDataYesRecordsExel),
+			tgbotapi.NewInlineKeyboardButtonData("CSV", CallbackDataCSVRecords),
 			tgbotapi.NewInlineKeyboardButtonData("No", CallbackDataNoRecordsExel),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Edit records", CallbackDataEditRecords),
+		),
 	)
 
-	// inline keyboard asking the user if they want to receive an EXEL file
+	// inline keyboard asking the user if they want to receive an EXEL or a CSV file
 	// with the categories
 	wantExelCategoriesKeyboard = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Yes", CallbackDataYesCategoriesExel),
+			tgbotapi.NewInlineKeyboardButtonData("EXEL", CallbackDataYesCategoriesExel),
+			tgbotapi.NewInlineKeyboardButtonData("CSV", CallbackDataCSVCategories),
 			tgbotapi.NewInlineKeyboardButtonData("No", CallbackDataNoCategoriesExel),
 		),
 	)
+
+	// inline keyboard asking the user what to do with the rows of the imported file
+	// in the categories they don't have
+	importCategoriesKeyboard = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Create them", CallbackDataCreateCategories),
+			tgbotapi.NewInlineKeyboardButtonData("Skip their rows", CallbackDataSkipCategories),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", CallbackDataCancelImport),
+		),
+	)
+
+	// inline keyboard asking the user whether to apply the changes of the imported workbook
+	applyImportKeyboard = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Apply", CallbackDataApplyImport),
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", CallbackDataCancelImport),
+		),
+	)
+
+	// signs of the well known currencies, the rest are shown by their codes
+	currencySigns = map[string]string{
+		"EUR": "\u20AC",
+		"USD": "$",
+		"GBP": "\u00A3",
+	}
 )
 
 // action function for the add category command, id 1
 //
-// it takes takes the input category name, stores it in the batch, and prompts the user to send the category description
-func addCategoryAction(input []string, batch *any, _ service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+// it takes takes the input category name, stores it in the batch, and prompts the user to send the category description,
+// the name may be a path like food/groceries, then the category is added as a subcategory of the preceding one
+func addCategoryAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	// specified regex allways returns 2 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes
@@ -176,8 +458,43 @@ func addCategoryAction(input []string, batch *any, _ service.ServiceInterface, l
 		return
 	}
 
-	(*batch).(*ftracker.SpendingCategory).Category = input[1]
 	log.Debug("action on add category command")
+
+	category := (*batch).(*ftracker.SpendingCategory)
+	path := strings.Split(input[1], categoryPathSeparator)
+	category.Category = path[len(path)-1]
+
+	if len(path) > 1 {
+		msg := tgbotapi.NewMessage(cl.chanID, "")
+		msg.ReplyMarkup = baseKeyboard
+
+		parent, err := getUserCategory(ctx, strings.Join(path[:len(path)-1], categoryPathSeparator), srvc, log, cl)
+		if err != nil {
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			cmd.becomeLast()
+			sender.Send(msg)
+			return
+		}
+		if parent == nil {
+			msg.Text = MessageNoParentCategory
+			cmd.becomeLast()
+			sender.Send(msg)
+			return
+		}
+
+		kind := category.Kind
+		if kind == "" {
+			kind = ftracker.KindSpending
+		}
+		if parent.Kind != kind {
+			msg.Text = MessageCategoryKindMismatch
+			cmd.becomeLast()
+			sender.Send(msg)
+			return
+		}
+		category.ParentGUID = parent.GUID
+	}
+
 	sender.Send(
 		tgbotapi.NewMessage(cl.chanID, MessageAddCategoryDescription),
 	)
@@ -186,7 +503,7 @@ func addCategoryAction(input []string, batch *any, _ service.ServiceInterface, l
 // action function for the add description to a category command, id 2
 //
 // it takes takes the input description and puts the category to the service.repository
-func addCategoryDescriptionAction(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+func addCategoryDescriptionAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	// specified regex allways returns 2 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes
@@ -209,7 +526,7 @@ func addCategoryDescriptionAction(input []string, batch *any, srvc service.Servi
 		sender.Send(msg)
 	}()
 
-	err := cl.populateUserGUID(srvc, log)
+	err := cl.populateUserGUID(ctx, srvc, log)
 	if err != nil {
 		log.WithError(err).Error("error on fill user guid")
 		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
@@ -218,7 +535,7 @@ func addCategoryDescriptionAction(input []string, batch *any, srvc service.Servi
 
 	categoryToAdd := *(*batch).(*ftracker.SpendingCategory)
 	categoryToAdd.UserGUID = cl.userGUID
-	_, err = srvc.AddCategories([]ftracker.SpendingCategory{categoryToAdd})
+	_, err = srvc.AddCategories(ctx, cl.userGUID, []ftracker.SpendingCategory{categoryToAdd})
 	if err != nil {
 
 		if utils.IsUniqueConstrainViolation(err) {
@@ -233,25 +550,191 @@ func addCategoryDescriptionAction(input []string, batch *any, srvc service.Servi
 	}
 }
 
+// action function for the edit category command, id 10
+//
+// it takes the category name, looks the category up among the user's categories, stores it in the batch,
+// and prompts the user to send the new name or description
+func pickCategoryToEditAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+	pickCategory(ctx, input, batch, srvc, log, sender, cl, cmd, MessageEditCategoryDetails)
+}
+
+// action function for the delete category command, id 12
+//
+// it takes the category name, looks the category up among the user's categories, stores it in the batch,
+// and asks the user what to do with the category's records
+func pickCategoryToDeleteAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+	pickCategory(ctx, input, batch, srvc, log, sender, cl, cmd, MessageDeleteCategoryDetails)
+}
+
+// pickCategory looks up the user's category by the name from the input and stores it in the batch,
+// on success it sends the reply to the user, otherwise it finishes the command sequence
+func pickCategory(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command, reply string) {
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for pick category command")
+		cmd.becomeLast()
+		msg := tgbotapi.NewMessage(cl.chanID, MessageInvalidNumberOfTockensAction+"\n"+internalErrorAditionalInfo)
+		msg.ReplyMarkup = baseKeyboard
+		sender.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	category, err := getUserCategory(ctx, input[1], srvc, log, cl)
+	if err != nil {
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		msg.ReplyMarkup = baseKeyboard
+		cmd.becomeLast()
+		return
+	}
+
+	if category == nil {
+		msg.Text = MessageNoCategoryFound
+		msg.ReplyMarkup = baseKeyboard
+		cmd.becomeLast()
+		return
+	}
+
+	*(*batch).(*ftracker.SpendingCategory) = *category
+	msg.Text = reply
+}
+
+// action function for the edit category details command, id 11
+//
+// it takes either the new name or the new description of the category from the batch
+// and updates the category in the service.repository
+func editCategoryAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 3 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes
+	// or to catch some errors I am unaware of
+	if len(input) != 3 {
+		log.Error("wrong tocken number for edit category command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	categoryToUpdate := *(*batch).(*ftracker.SpendingCategory)
+	if input[1] != "" {
+		categoryToUpdate.Category = input[1]
+	} else {
+		categoryToUpdate.Description = input[2]
+	}
+
+	err := srvc.UpdateCategories(ctx, cl.userGUID, []ftracker.SpendingCategory{categoryToUpdate})
+	if err != nil {
+
+		if utils.IsUniqueConstrainViolation(err) {
+			msg.Text = MessageCategoryDuplicate
+			return
+		}
+
+		log.WithError(err).Error("error on update category")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessageCategoryUpdateSuccess
+}
+
+// action function for the delete category details command, id 13
+//
+// it takes either the 'delete' keyword to delete the category from the batch together with its records,
+// or the name of another category to move the records to, and deletes the category in the service.repository
+func deleteCategoryAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 3 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes
+	// or to catch some errors I am unaware of
+	if len(input) != 3 {
+		log.Error("wrong tocken number for delete category command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	categoryToDelete := (*batch).(*ftracker.SpendingCategory)
+	moveTo := uuid.Nil
+	if input[1] == "" {
+		target, err := getUserCategory(ctx, input[2], srvc, log, cl)
+		if err != nil {
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+
+		if target == nil {
+			msg.Text = MessageNoCategoryFound
+			return
+		}
+
+		if target.GUID == categoryToDelete.GUID {
+			msg.Text = MessageCategoryMoveSame
+			return
+		}
+
+		if target.Kind != categoryToDelete.Kind {
+			msg.Text = MessageCategoryKindMismatch
+			return
+		}
+		moveTo = target.GUID
+	}
+
+	err := srvc.DeleteCategories(ctx, cl.userGUID, []uuid.UUID{categoryToDelete.GUID}, moveTo)
+	if err != nil {
+		log.WithError(err).Error("error on delete category")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessageCategoryDeleteSuccess
+}
+
 // action function for the add record, id 3
 //
-// it takes the input category name, amount and description, and adds the record to the service.repository
-func addRecordAction(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+// it takes the input spending category name, amount, optional currency and description, and adds the record to the service.repository,
+// the record without a currency is in the user's base currency
+func addRecordAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+	addRecord(ctx, input, batch, srvc, log, sender, cl, ftracker.KindSpending)
+}
 
-	// specified regex allways returns 4 tokens, so this check may be redundant,
+// action function for the add income, id 16
+//
+// it does the same as the add record action, but the record goes to an income category
+func addIncomeAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+	addRecord(ctx, input, batch, srvc, log, sender, cl, ftracker.KindIncome)
+}
+
+// addRecord adds the record from the input to the user's category of the given kind
+func addRecord(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, kind string) {
+
+	// specified regex allways returns 6 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
 	// or to catch some errors I am unaware of
-	if len(input) != 4 {
+	if len(input) != 6 {
 		log.Error("wrong tocken number for add record command")
 		return
 	}
-	recordCategory := input[1:2]
-	recordAmountLeft, recordAmountRight := utils.ExtractAmountParts(input[2])
+	recordCategory := input[1]
+	recordCurrency := input[3]
 
-	recordDescription := input[3]
-	if len(recordDescription) == 0 {
-		recordDescription = "spending"
-	}
+	// a record without a description is described by its kind
+	recordDescription := cmp.Or(input[4], kind)
 
 	msg := tgbotapi.NewMessage(cl.chanID, "")
 	msg.ReplyMarkup = baseKeyboard
@@ -259,42 +742,89 @@ func addRecordAction(input []string, batch *any, srvc service.ServiceInterface,
 		sender.Send(msg)
 	}()
 
-	if recordAmountLeft == "0" && recordAmountRight == "00" { //zero amount
-		msg.Text = MessageZeroAmount
-		return
+	// a record without the date is spent now, the repository sets the time
+	var recordSpentAt time.Time
+	if input[5] != "" {
+		var err error
+		recordSpentAt, err = time.Parse(formatIn, input[5])
+		if err != nil {
+			msg.Text = MessageInvalidSpentAt
+			return
+		}
+		if recordSpentAt.After(time.Now()) {
+			msg.Text = MessageFutureSpentAt
+			return
+		}
 	}
 
 	log.Debug("category to lookup: ", recordCategory)
 
-	categories, err := srvc.GetCategories(srvc.SpendingCategoriesWithCategories(recordCategory))
+	category, err := getUserCategory(ctx, recordCategory, srvc, log, cl)
 	if err != nil {
-		log.WithError(err).Error("error on get category")
 		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
 		return
 	}
 
-	if len(categories) == 0 {
+	if category == nil {
 		msg.Text = MessageNoCategoryFound
 		return
 	}
 
-	(*batch).(*ftracker.SpendingRecord).CategoryGUID = categories[0].GUID
-	amount, err := strconv.ParseUint(recordAmountLeft+recordAmountRight, 10, 32)
-	if err != nil {
-		log.WithError(err).Error("error on parsing amount")
-		msg.Text = MessageAmountError + "\n" + internalErrorAditionalInfo
+	if category.Kind != kind {
+		msg.Text = MessageCategoryKindMismatch
+		return
+	}
+
+	// the base currency of the user is known once the category is looked up
+	amount, errText := parseAmount(input[2], cmp.Or(recordCurrency, cl.baseCurrency()))
+	if errText != "" {
+		msg.Text = errText
 		return
 	}
-	(*batch).(*ftracker.SpendingRecord).Amount = uint32(amount)
+
+	(*batch).(*ftracker.SpendingRecord).CategoryGUID = category.GUID
+	(*batch).(*ftracker.SpendingRecord).Amount = amount
+	(*batch).(*ftracker.SpendingRecord).Currency = recordCurrency
 	(*batch).(*ftracker.SpendingRecord).Description = recordDescription
+	(*batch).(*ftracker.SpendingRecord).SpentAt = recordSpentAt
 
 	recordToAdd := *(*batch).(*ftracker.SpendingRecord)
-	_, err = srvc.AddRecords([]ftracker.SpendingRecord{recordToAdd})
+	guids, err := srvc.AddRecords(ctx, cl.userGUID, []ftracker.SpendingRecord{recordToAdd})
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownCurrency) {
+			msg.Text = MessageUnknownCurrency
+			return
+		}
+
 		log.WithError(err).Error("error on add record")
 		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
-	} else {
-		msg.Text = MessageRecordSuccess
+		return
+	}
+	msg.Text = MessageRecordSuccess
+
+	if kind != ftracker.KindSpending || len(guids) == 0 {
+		return
+	}
+
+	// the record is already added, so a failed check only costs the user the warning
+	alert, budget, err := srvc.EvaluateBudget(ctx, cl.userGUID, guids[0])
+	if err != nil {
+		log.WithError(err).Error("error on evaluate budget")
+		return
+	}
+	switch alert {
+	case service.BudgetAlertWarning:
+		msg.Text += fmt.Sprintf(MessageBudgetWarningFormat,
+			formatAmount(budget.Spent, cl.baseCurrency()),
+			formatAmount(budget.Amount, cl.baseCurrency()),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, budget.Category),
+		)
+	case service.BudgetAlertExceeded:
+		msg.Text += fmt.Sprintf(MessageBudgetExceededFormat,
+			formatAmount(budget.Spent, cl.baseCurrency()),
+			formatAmount(budget.Amount, cl.baseCurrency()),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, budget.Category),
+		)
 	}
 }
 
@@ -302,7 +832,7 @@ func addRecordAction(input []string, batch *any, srvc service.ServiceInterface,
 //
 // it takes the number of categories to display from the user and shows the categories from the service.repository
 // then it asks the user if they want to receive an EXEL file with the categories
-func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+func showCategoriesAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	// specified regex allways returns 4 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
@@ -320,7 +850,7 @@ func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterf
 	}()
 
 	var categoriesLimit int
-	var categoryNames []string
+	var categoryName string
 	var err error
 
 	switch instruction := input[2]; instruction {
@@ -336,17 +866,22 @@ func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterf
 	case "all":
 		categoriesLimit = 0
 	default:
-		categoriesLimit = 1
-		categoryNames = []string{instruction}
+		categoryName = instruction
 	}
 	addDescription := input[3] == "full"
 
-	cl.populateUserGUID(srvc, log)
-	categories, err := srvc.GetCategories(
-		srvc.SpendingCategoriesWithUserGUIDs([]uuid.UUID{cl.userGUID}),
-		srvc.SpendingCategoriesWithLimit(categoriesLimit),
-		srvc.SpendingCategoriesWithCategories(categoryNames),
-		srvc.SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false),
+	err = cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		cmd.becomeLast()
+		return
+	}
+
+	trees, err := srvc.GetCategoryTree(ctx,
+		cl.userGUID,
+		srvc.SpendingCategoriesWithLimit(categoriesLimit),
+		srvc.SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false),
 	)
 	if err != nil {
 		log.WithError(err).Error("error on get categories")
@@ -356,8 +891,12 @@ func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterf
 		return
 	}
 
-	if len(categories) == 0 {
-		if len(categoryNames) == 0 {
+	if categoryName != "" {
+		trees = findCategoryTree(trees, categoryName)
+	}
+
+	if len(trees) == 0 {
+		if categoryName == "" {
 			msg.Text = MessageUnderflowCategories
 		} else {
 			msg.Text = MessageNoCategoryFound
@@ -367,19 +906,20 @@ func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterf
 		return
 	}
 
-	*batch = categories
+	// subcategories are listed right after their parents, indented by their depth,
+	// the amounts of the parents include the amounts of their subcategories
+	var categories []ftracker.SpendingCategory
 	msg.Text = "Your categories:\n"
-	if addDescription {
-		for i, category := range categories {
-			leftAmount, rightAmount := utils.ExtractAmountParts(category.Amount)
-			msg.Text += fmt.Sprintf(MessageShowCategoriesFormatFull, i+1, category.Category, leftAmount, rightAmount, category.Description)
-		}
-	} else {
-		for i, category := range categories {
-			leftAmount, rightAmount := utils.ExtractAmountParts(category.Amount)
-			msg.Text += fmt.Sprintf(MessageShowCategoriesFormat, i+1, category.Category, leftAmount, rightAmount)
+	walkCategoryTree(trees, 0, func(category ftracker.SpendingCategory, depth int) {
+		categories = append(categories, category)
+		msg.Text += strings.Repeat(MessageSubcategoryIndent, depth)
+		if addDescription {
+			msg.Text += fmt.Sprintf(MessageShowCategoriesFormatFull, len(categories), formatCategoryName(category), formatAmount(category.Amount, cl.baseCurrency()), tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, category.Description))
+		} else {
+			msg.Text += fmt.Sprintf(MessageShowCategoriesFormat, len(categories), formatCategoryName(category), formatAmount(category.Amount, cl.baseCurrency()))
 		}
-	}
+	})
+	*batch = categories
 
 	msg.Text += MessageWantEXEL
 	msg.ReplyMarkup = wantExelCategoriesKeyboard
@@ -388,7 +928,7 @@ func showCategoriesAction(input []string, batch *any, srvc service.ServiceInterf
 // action function for the show records command, id 5
 //
 // it takes the category name from the user and prompts then to input the time boundaries
-func showRecordsAction(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+func showRecordsAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	// specified regex allways returns 2 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
@@ -398,29 +938,28 @@ func showRecordsAction(input []string, batch *any, srvc service.ServiceInterface
 		log.Error("wrong tocken number for show records command")
 		return
 	}
-	recordCategory := input[1:2]
+	recordCategory := input[1]
 
 	msg := tgbotapi.NewMessage(cl.chanID, "")
 	defer func() {
 		sender.Send(msg)
 	}()
 
-	categories, err := srvc.GetCategories(srvc.SpendingCategoriesWithCategories(recordCategory))
+	category, err := getUserCategory(ctx, recordCategory, srvc, log, cl)
 	if err != nil {
-		log.WithError(err).Error("error on get category")
 		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
 		msg.ReplyMarkup = baseKeyboard
 		cmd.becomeLast()
 		return
 	}
 
-	if len(categories) == 0 {
+	if category == nil {
 		msg.Text = MessageNoCategoryFound
 		msg.ReplyMarkup = baseKeyboard
 		cmd.becomeLast()
 		return
 	}
-	(*batch).(*repository.RecordOptions).CategoryGUIDs = []uuid.UUID{categories[0].GUID}
+	(*batch).(*repository.RecordOptions).CategoryGUIDs = []uuid.UUID{category.GUID}
 	msg.Text = MessageAddTimeDetails
 }
 
@@ -428,7 +967,7 @@ func showRecordsAction(input []string, batch *any, srvc service.ServiceInterface
 //
 // it takes the the nubmer of records to display, the time boundaries and it the desctiption needed,
 // then it diplays the records from the service.repository and asks the user if they want to receive an EXEL file
-func getTimeBoundariesAction(input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+func getTimeBoundariesAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	// specified regex allways returns 6 tokens, so this check may be redundant,
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
@@ -461,56 +1000,22 @@ func getTimeBoundariesAction(input []string, batch *any, srvc service.ServiceInt
 		}
 	}
 
-	var timeFrom, timeTo time.Time
-	if input[2] == "" {
-		timeFrom, err = time.Parse(formatIn, input[3])
-		if err != nil {
-			log.WithError(err).Error("error on parsing time from")
-			msg.Text = MessageInvalidFromDate
-			msg.ReplyMarkup = baseKeyboard
-			cmd.becomeLast()
-			return
-		}
-
-		if input[4] == "" {
-			timeTo = time.Now()
-		} else {
-			timeTo, err = time.Parse(formatIn, input[4])
-			if err != nil {
-				log.WithError(err).Error("error on parsing time to")
-				msg.Text = MessageInvalidToDate
-				msg.ReplyMarkup = baseKeyboard
-				cmd.becomeLast()
-				return
-			}
-		}
-	} else {
-		switch input[2] {
-		case "year":
-			timeTo = time.Now()
-			timeFrom = timeTo.AddDate(-1, 0, 0)
-		case "month":
-			timeTo = time.Now()
-			timeFrom = timeTo.AddDate(0, -1, 0)
-		case "day":
-			timeTo = time.Now()
-			timeFrom = timeTo.AddDate(0, 0, -1)
-		default:
-			log.Error("invalid token for ymd time boundaries")
-			msg.Text = MessageInvalidFixedTime + "\n" + internalErrorAditionalInfo
-			msg.ReplyMarkup = baseKeyboard
-			cmd.becomeLast()
-			return
-		}
+	timeFrom, timeTo, errText := parseTimeBoundaries(input[2], input[3], input[4], log)
+	if errText != "" {
+		msg.Text = errText
+		msg.ReplyMarkup = baseKeyboard
+		cmd.becomeLast()
+		return
 	}
 
 	log.Debug("time boundaries: ", timeFrom, timeTo)
 	recordOption := *(*batch).(*repository.RecordOptions)
-	records, err := srvc.GetRecords(
+	records, err := srvc.GetRecords(ctx,
+		cl.userGUID,
 		srvc.SpendingRecordsWithCategoryGUIDs(recordOption.CategoryGUIDs),
 		srvc.SpendingRecordsWithTimeFrame(timeFrom, timeTo),
 		srvc.SpendingRecordsWithLimit(recordsLimit),
-		srvc.SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false),
+		srvc.SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false),
 	)
 	if err != nil {
 		log.WithError(err).Error("error on get records")
@@ -527,24 +1032,35 @@ func getTimeBoundariesAction(input []string, batch *any, srvc service.ServiceInt
 		return
 	}
 
+	// the subtotal is summed in the base currency, it can't overflow unless the records are absurdly large
+	var subtotal ftracker.Money
+	for _, record := range records {
+		var err error
+		if subtotal, err = subtotal.Add(record.BaseAmount); err != nil {
+			log.WithError(err).Error("error on summing records")
+			msg.Text = MessageAmountTooLarge
+			msg.ReplyMarkup = baseKeyboard
+			cmd.becomeLast()
+			return
+		}
+	}
+
 	*batch = records
-	var subtotal uint32 = 0
 	if addDescription {
 		for _, record := range records {
-			leftAmount, rightAmount := utils.ExtractAmountParts(record.Amount)
-			msg.Text += fmt.Sprintf(MessageShowRecordsFormatFull, record.CreatedAt.Format(formatOut), leftAmount, rightAmount, record.Description) //mb updated?
-			subtotal += uint32(record.Amount)
+			msg.Text += fmt.Sprintf(MessageShowRecordsFormatFull,
+				record.SpentAt.Format(formatOut),
+				formatRecordAmount(record, cl.baseCurrency()),
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, record.Description),
+			)
 		}
 	} else {
 		for _, record := range records {
-			leftAmount, rightAmount := utils.ExtractAmountParts(record.Amount)
-			msg.Text += fmt.Sprintf(MessageShowRecordsFormat, record.CreatedAt.Format(formatOut), leftAmount, rightAmount)
-			subtotal += uint32(record.Amount)
+			msg.Text += fmt.Sprintf(MessageShowRecordsFormat, record.SpentAt.Format(formatOut), formatRecordAmount(record, cl.baseCurrency()))
 		}
 	}
 
-	leftSubtotal, rightSubtotal := utils.ExtractAmountParts(subtotal)
-	msg.Text = fmt.Sprintf(MessageShowRecordsFormatHeader, leftSubtotal, rightSubtotal) +
+	msg.Text = fmt.Sprintf(MessageShowRecordsFormatHeader, formatAmount(subtotal, cl.baseCurrency())) +
 		msg.Text +
 		"\n" +
 		MessageWantEXEL
@@ -554,9 +1070,10 @@ func getTimeBoundariesAction(input []string, batch *any, srvc service.ServiceInt
 
 // action function for the exel records command, id 7
 //
-// it retrieves the records from the batch and creates an EXEL file with them
-// then it sends the file to the user
-func returnRecordsExelAction(input []string, batch *any, service service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+// it retrieves the records from the batch and creates an EXEL or a CSV file with them
+// then it sends the file to the user,
+// if the user wants to edit the records, it lists them and prompts to pick one
+func returnRecordsExelAction(ctx context.Context, input []string, batch *any, service service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	msg := tgbotapi.NewMessage(cl.chanID, "")
 	msg.ReplyMarkup = baseKeyboard
@@ -568,12 +1085,17 @@ func returnRecordsExelAction(input []string, batch *any, service service.Service
 	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
 	// or to catch some errors I am unaware of
 	if len(input) != 2 {
+		cmd.becomeLast()
 		log.Error("wrong callback input")
 		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
 		return
 	}
 	log.Debug("action on return records exel command, got: ", input[1])
 
+	if input[1] != CallbackDataEditRecords { // only editing continues the sequence
+		cmd.becomeLast()
+	}
+
 	if input[1] == CallbackDataNoRecordsExel {
 		msg.Text = MessageRecordsExelNo
 		return
@@ -581,12 +1103,56 @@ func returnRecordsExelAction(input []string, batch *any, service service.Service
 
 	records, ok := (*batch).([]ftracker.SpendingRecord)
 	if !ok {
+		cmd.becomeLast()
 		log.Errorf("wrong batch type for exel: %T", *batch)
 		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
 		return
 	}
 
-	file, err := service.CreateExelFromRecords(records)
+	if input[1] == CallbackDataEditRecords {
+		msg.Text = MessageEditRecordsHeader
+		for i, record := range records {
+			msg.Text += fmt.Sprintf(MessageEditRecordsFormat,
+				i+1,
+				record.SpentAt.Format(formatOut),
+				formatAmount(record.Amount, record.Currency),
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, record.Description),
+			)
+		}
+		msg.Text += MessageEditRecords
+		msg.ReplyMarkup = nil
+		return
+	}
+
+	// both files name the categories of the records
+	categoryGUIDs := make([]uuid.UUID, 0, len(records))
+	seen := make(map[uuid.UUID]bool, len(records))
+	for _, record := range records {
+		if !seen[record.CategoryGUID] {
+			seen[record.CategoryGUID] = true
+			categoryGUIDs = append(categoryGUIDs, record.CategoryGUID)
+		}
+	}
+	categories, err := service.GetCategories(ctx, cl.userGUID, service.SpendingCategoriesWithGUIDs(categoryGUIDs))
+	if err != nil {
+		log.WithError(err).Error("error on get categories of records")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if input[1] == CallbackDataCSVRecords {
+		content, err := service.CreateCSVFromRecords(records, categories)
+		if err != nil {
+			log.WithError(err).Error("error on create csv")
+			msg.Text = MessageCSVError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		msg.Text = MessageRecordsExelYes
+		sender.SendDoc(tgbotapi.NewDocument(cl.chanID, tgbotapi.FileBytes{Name: csvFilename, Bytes: content}))
+		return
+	}
+
+	file, err := service.CreateExelFromRecords(records, categories, cl.baseCurrency())
 	if err != nil {
 		log.WithError(err).Error("error on create exel")
 		msg.Text = MessageExelError + "\n" + internalErrorAditionalInfo
@@ -608,11 +1174,76 @@ func returnRecordsExelAction(input []string, batch *any, service service.Service
 	sender.SendDoc(document)
 }
 
+// action function for the edit record command, id 9
+//
+// it takes the number of the record from the listing and either the new amount with optional description,
+// or the 'delete' keyword, then it updates or deletes the record in the service.repository
+func editRecordAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 5 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 5 {
+		log.Error("wrong tocken number for edit record command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	records, ok := (*batch).([]ftracker.SpendingRecord)
+	if !ok {
+		log.Errorf("wrong batch type for edit record: %T", *batch)
+		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	number, err := strconv.Atoi(input[1])
+	if err != nil || number < 1 || number > len(records) {
+		msg.Text = MessageRecordNumberError
+		return
+	}
+	record := records[number-1]
+
+	if input[2] == "delete" {
+		err = srvc.DeleteRecords(ctx, cl.userGUID, []uuid.UUID{record.GUID})
+		if err != nil {
+			log.WithError(err).Error("error on delete record")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		msg.Text = MessageRecordDeleteSuccess
+		return
+	}
+
+	amount, errText := parseAmount(input[3], record.Currency)
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+	record.Amount = amount
+	if input[4] != "" {
+		record.Description = input[4]
+	}
+
+	err = srvc.UpdateRecords(ctx, cl.userGUID, []ftracker.SpendingRecord{record})
+	if err != nil {
+		log.WithError(err).Error("error on update record")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessageRecordUpdateSuccess
+}
+
 // action function for the exel categories command, id 8
 //
-// it retrieves the categories from the batch and creates an EXEL file with them
+// it retrieves the categories from the batch and creates an EXEL or a CSV file with them
 // then it sends the file to the user
-func returnCategoriesExelAction(input []string, batch *any, service service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+func returnCategoriesExelAction(ctx context.Context, input []string, batch *any, service service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
 
 	msg := tgbotapi.NewMessage(cl.chanID, "")
 	msg.ReplyMarkup = baseKeyboard
@@ -642,7 +1273,19 @@ func returnCategoriesExelAction(input []string, batch *any, service service.Serv
 		return
 	}
 
-	file, err := service.CreateExelFromCategories(categories)
+	if input[1] == CallbackDataCSVCategories {
+		content, err := service.CreateCSVFromCategories(categories, cl.baseCurrency())
+		if err != nil {
+			log.WithError(err).Error("error on create csv")
+			msg.Text = MessageCSVError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		msg.Text = MessageRecordsExelYes
+		sender.SendDoc(tgbotapi.NewDocument(cl.chanID, tgbotapi.FileBytes{Name: csvFilename, Bytes: content}))
+		return
+	}
+
+	file, err := service.CreateExelFromCategories(categories, cl.baseCurrency())
 	if err != nil {
 		log.WithError(err).Error("error on create exel")
 		msg.Text = MessageExelError + "\n" + internalErrorAditionalInfo
@@ -664,6 +1307,1378 @@ func returnCategoriesExelAction(input []string, batch *any, service service.Serv
 	sender.SendDoc(document)
 }
 
+// action function for the balance command, id 17
+//
+// it takes the time boundaries in the same form as the get time boundaries command
+// and shows the income, the spending and their difference for that time
+func showBalanceAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 4 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 4 {
+		log.Error("wrong tocken number for balance command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	timeFrom, timeTo, errText := parseTimeBoundaries(input[1], input[2], input[3], log)
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	log.Debug("time boundaries: ", timeFrom, timeTo)
+	balance, err := srvc.GetBalance(ctx, cl.userGUID, timeFrom, timeTo)
+	if err != nil {
+		log.WithError(err).Error("error on get balance")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	sign, total := "", balance.Income-balance.Spending
+	if balance.Spending > balance.Income {
+		sign, total = "\\-", balance.Spending-balance.Income
+	}
+	msg.Text = fmt.Sprintf(MessageBalanceFormat,
+		formatAmount(balance.Income, cl.baseCurrency()),
+		formatAmount(balance.Spending, cl.baseCurrency()),
+		sign,
+		formatAmount(total, cl.baseCurrency()),
+	)
+}
+
+// action function for the show tags command, id 23
+//
+// it takes the time period and shows how much the user has spent on the records with each tag in it
+func showTagsAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 4 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 4 {
+		log.Error("wrong tocken number for show tags command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	timeFrom, timeTo, errText := parseTimeBoundaries(input[1], input[2], input[3], log)
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	log.Debug("time boundaries: ", timeFrom, timeTo)
+	spending, err := srvc.GetTagSpending(ctx, cl.userGUID, timeFrom, timeTo)
+	if err != nil {
+		log.WithError(err).Error("error on get tag spending")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if len(spending) == 0 {
+		msg.Text = MessageUnderflowTags
+		return
+	}
+
+	msg.Text = MessageShowTagsHeader
+	for _, tag := range spending {
+		msg.Text += fmt.Sprintf(MessageShowTagsFormat,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, tag.Tag),
+			formatAmount(tag.Spent, cl.baseCurrency()),
+		)
+	}
+}
+
+// action function for the currency command, id 14
+//
+// it takes the currency code and makes it the user's base currency,
+// the amounts of the user's records and categories are converted into it in the service.repository
+func setCurrencyAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for currency command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+	currency := strings.ToUpper(input[1])
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	err = srvc.SetUserCurrency(ctx, cl.userGUID, currency)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownCurrency) {
+			msg.Text = MessageUnknownCurrency
+			return
+		}
+
+		log.WithError(err).Error("error on set user currency")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	cl.currency = currency
+	msg.Text = MessageCurrencySuccess
+}
+
+// action function for the api token command, id 24
+//
+// it issues a new API token for the user and shows it once, the previous token stops working
+func issueAPITokenAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for api token command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	token, err := srvc.IssueAPIToken(ctx, cl.userGUID)
+	if err != nil {
+		log.WithError(err).Error("error on issue api token")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = fmt.Sprintf(MessageAPITokenFormat, token)
+}
+
+// action function for the show budgets command, id 18
+//
+// it takes the category name or 'all' and shows how much is spent and left
+// of the budgets of the user's categories in the current month
+func showBudgetsAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for show budgets command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	var categoryGUIDs []uuid.UUID
+	if input[1] != "all" {
+		category, err := getUserCategory(ctx, input[1], srvc, log, cl)
+		if err != nil {
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		if category == nil {
+			msg.Text = MessageNoCategoryFound
+			return
+		}
+		categoryGUIDs = []uuid.UUID{category.GUID}
+	} else {
+		err := cl.populateUserGUID(ctx, srvc, log)
+		if err != nil {
+			log.WithError(err).Error("error on fill user guid")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+	}
+
+	now := time.Now()
+	budgets, err := srvc.GetBudgets(ctx, cl.userGUID, now, categoryGUIDs...)
+	if err != nil {
+		log.WithError(err).Error("error on get budgets")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if len(budgets) == 0 {
+		if categoryGUIDs != nil {
+			msg.Text = MessageNoBudgetFound
+		} else {
+			msg.Text = MessageUnderflowBudgets
+		}
+		return
+	}
+
+	var resp strings.Builder
+	resp.WriteString(fmt.Sprintf(MessageShowBudgetsHeader, now.Format(formatMonth)))
+	for _, budget := range budgets {
+		format, rest := MessageShowBudgetsFormat, budget.Amount-budget.Spent
+		if budget.Spent > budget.Amount {
+			format, rest = MessageShowBudgetsFormatOver, budget.Spent-budget.Amount
+		}
+		resp.WriteString(fmt.Sprintf(format,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, budget.Category),
+			formatAmount(budget.Spent, cl.baseCurrency()),
+			formatAmount(budget.Amount, cl.baseCurrency()),
+			formatAmount(rest, cl.baseCurrency()),
+		))
+	}
+	msg.Text = resp.String()
+}
+
+// action function for the set budget command, id 19
+//
+// it takes the category name and either its monthly limit in the user's base currency,
+// or 'delete' to remove the limit
+func setBudgetAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 4 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 4 {
+		log.Error("wrong tocken number for set budget command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	category, err := getUserCategory(ctx, input[1], srvc, log, cl)
+	if err != nil {
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	if category == nil {
+		msg.Text = MessageNoCategoryFound
+		return
+	}
+	if category.Kind == ftracker.KindIncome {
+		msg.Text = MessageBudgetIncomeCategory
+		return
+	}
+
+	if input[2] != "" {
+		budgets, err := srvc.GetBudgets(ctx, cl.userGUID, time.Now(), category.GUID)
+		if err != nil {
+			log.WithError(err).Error("error on get budgets")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		if len(budgets) == 0 {
+			msg.Text = MessageNoBudgetFound
+			return
+		}
+
+		err = srvc.DeleteBudgets(ctx, cl.userGUID, []uuid.UUID{category.GUID})
+		if err != nil {
+			log.WithError(err).Error("error on delete budgets")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		msg.Text = MessageBudgetDeleteSuccess
+		return
+	}
+
+	amount, errText := parseAmount(input[3], cl.baseCurrency())
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+
+	err = srvc.SetBudgets(ctx, cl.userGUID, []ftracker.Budget{{CategoryGUID: category.GUID, Amount: amount}})
+	if err != nil {
+		log.WithError(err).Error("error on set budgets")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessageBudgetSuccess
+}
+
+// action function for the add recurring command, id 20
+//
+// it takes the category name, amount, optional currency, the schedule and optional description,
+// and adds the recurring record to the service.repository, the scheduler makes the records from it
+func addRecurringAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 7 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 7 {
+		log.Error("wrong tocken number for add recurring command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	category, err := getUserCategory(ctx, input[1], srvc, log, cl)
+	if err != nil {
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	if category == nil {
+		msg.Text = MessageNoCategoryFound
+		return
+	}
+
+	amount, errText := parseAmount(input[2], cmp.Or(input[3], cl.baseCurrency()))
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+
+	schedule := input[4]
+	if schedule == "" {
+		schedule = input[5]
+	}
+	description := input[6]
+	if len(description) == 0 {
+		description = "recurring"
+	}
+
+	_, err = srvc.AddRecurring(ctx, cl.userGUID, []ftracker.RecurringRecord{{
+		CategoryGUID: category.GUID,
+		Amount:       amount,
+		Currency:     input[3],
+		Description:  description,
+		Schedule:     schedule,
+	}})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSchedule):
+			msg.Text = MessageInvalidSchedule
+		case errors.Is(err, service.ErrUnknownCurrency):
+			msg.Text = MessageUnknownCurrency
+		default:
+			log.WithError(err).Error("error on add recurring")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		}
+		return
+	}
+	msg.Text = MessageRecurringSuccess
+}
+
+// action function for the show recurring command, id 21
+//
+// it takes the category name or 'all', lists the recurring records and prompts to pick one to delete
+func showRecurringAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		cmd.becomeLast()
+		log.Error("wrong tocken number for show recurring command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	var options []service.RecurringOption
+	if input[1] != "all" {
+		category, err := getUserCategory(ctx, input[1], srvc, log, cl)
+		if err != nil {
+			cmd.becomeLast()
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		if category == nil {
+			cmd.becomeLast()
+			msg.Text = MessageNoCategoryFound
+			return
+		}
+		options = append(options, srvc.RecurringRecordsWithCategoryGUIDs([]uuid.UUID{category.GUID}))
+	} else {
+		err := cl.populateUserGUID(ctx, srvc, log)
+		if err != nil {
+			cmd.becomeLast()
+			log.WithError(err).Error("error on fill user guid")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+	}
+
+	records, err := srvc.GetRecurring(ctx, cl.userGUID, options...)
+	if err != nil {
+		cmd.becomeLast()
+		log.WithError(err).Error("error on get recurring")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if len(records) == 0 {
+		cmd.becomeLast()
+		msg.Text = MessageUnderflowRecurring
+		return
+	}
+
+	*batch = records
+	for i, record := range records {
+		msg.Text += fmt.Sprintf(MessageShowRecurringFormat,
+			i+1,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, record.Category),
+			formatAmount(record.Amount, record.Currency),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, record.Schedule),
+			record.NextRunAt.Format(formatOut),
+		)
+	}
+	msg.Text += MessageDeleteRecurring
+	msg.ReplyMarkup = nil
+}
+
+// action function for the delete recurring command, id 22
+//
+// it takes the number of the recurring record from the listing and deletes it from the service.repository,
+// the records already made from it are kept
+func deleteRecurringAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for delete recurring command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	records, ok := (*batch).([]ftracker.RecurringRecord)
+	if !ok {
+		log.Errorf("wrong batch type for delete recurring: %T", *batch)
+		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	number, err := strconv.Atoi(input[1])
+	if err != nil || number < 1 || number > len(records) {
+		msg.Text = MessageRecurringNumberError
+		return
+	}
+
+	err = srvc.DeleteRecurring(ctx, cl.userGUID, []uuid.UUID{records[number-1].GUID})
+	if err != nil {
+		log.WithError(err).Error("error on delete recurring")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessageRecurringDeleteSuccess
+}
+
+// action function for the import command, id 25
+//
+// it takes the content of the CSV file sent or pasted by the user and validates it,
+// if all the categories of the file exist the records are imported at once,
+// otherwise it lists the new categories and asks whether to create them,
+// the changes of a workbook are always shown and wait for the user to apply them
+func importRecordsAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		cmd.becomeLast()
+		log.Error("wrong tocken number for import command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		cmd.becomeLast()
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if isWorkbook(input[1]) {
+		previewWorkbook(ctx, input[1], batch, srvc, log, cl, cmd, &msg)
+		return
+	}
+
+	report, errText := importRecords(ctx, input[1], service.ImportOptions{Currency: cl.baseCurrency(), DryRun: true}, srvc, log, cl)
+	if errText != "" {
+		cmd.becomeLast()
+		msg.Text = errText
+		return
+	}
+
+	if len(report.NewCategories) == 0 {
+		cmd.becomeLast()
+		if report.Imported == 0 {
+			msg.Text = MessageImportNothing + formatRejected(report.Rejected)
+			return
+		}
+		report, errText = importRecords(ctx, input[1], service.ImportOptions{Currency: cl.baseCurrency()}, srvc, log, cl)
+		if errText != "" {
+			msg.Text = errText
+			return
+		}
+		msg.Text = formatImportReport(report, false)
+		return
+	}
+
+	*batch = []byte(input[1])
+	var categories string
+	for _, category := range report.NewCategories {
+		categories += fmt.Sprintf(MessageImportNewCategoryFormat, tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, category))
+	}
+	msg.Text = fmt.Sprintf(MessageImportNewCategoriesFormat, categories)
+	msg.ReplyMarkup = importCategoriesKeyboard
+}
+
+// action function for the import decision command, id 26
+//
+// it imports the file kept in the batch either creating the new categories
+// or skipping their rows, applies the changes of a workbook, or cancels the import
+func importDecisionAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong callback input")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+	log.Debug("action on import decision command, got: ", input[1])
+
+	if input[1] == CallbackDataCancelImport {
+		msg.Text = MessageImportCancelled
+		return
+	}
+
+	file, ok := (*batch).([]byte)
+	if !ok {
+		log.Errorf("wrong batch type for import: %T", *batch)
+		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	opts := service.ImportOptions{
+		Currency:         cl.baseCurrency(),
+		CreateCategories: input[1] == CallbackDataCreateCategories,
+	}
+	importFile := importRecords
+	if isWorkbook(string(file)) {
+		importFile = importWorkbook
+	}
+	report, errText := importFile(ctx, string(file), opts, srvc, log, cl)
+	if errText != "" {
+		msg.Text = errText
+		return
+	}
+	if report.Imported+report.Updated == 0 {
+		msg.Text = MessageImportNothing + formatRejected(report.Rejected)
+		return
+	}
+	msg.Text = formatImportReport(report, opts.CreateCategories)
+}
+
+// previewWorkbook validates the workbook sent by the user and lists the records it adds and changes,
+// the workbook is kept in the batch until the user applies the changes, creating the new categories if there are any
+func previewWorkbook(ctx context.Context, content string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, cl *client, cmd *command, msg *tgbotapi.MessageConfig) {
+
+	// the rows of the new categories are counted as if the categories were created, the user decides on them later
+	report, errText := importWorkbook(ctx, content, service.ImportOptions{Currency: cl.baseCurrency(), CreateCategories: true, DryRun: true}, srvc, log, cl)
+	if errText != "" {
+		cmd.becomeLast()
+		msg.Text = errText
+		return
+	}
+	if report.Imported+report.Updated == 0 {
+		cmd.becomeLast()
+		msg.Text = MessageImportNoChanges + formatRejected(report.Rejected)
+		return
+	}
+
+	*batch = []byte(content)
+	msg.Text = formatChanges(report) + formatRejected(report.Rejected)
+	if len(report.NewCategories) == 0 {
+		msg.Text += MessageImportApply
+		msg.ReplyMarkup = applyImportKeyboard
+		return
+	}
+
+	var categories string
+	for _, category := range report.NewCategories {
+		categories += fmt.Sprintf(MessageImportNewCategoryFormat, tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, category))
+	}
+	msg.Text += "\n" + fmt.Sprintf(MessageImportNewCategoriesFormat, categories)
+	msg.ReplyMarkup = importCategoriesKeyboard
+}
+
+// importRecords imports the content of the CSV file for the client,
+// on failure it returns the text of the message for the user
+func importRecords(ctx context.Context, content string, opts service.ImportOptions, srvc service.ServiceInterface, log *logrus.Logger, cl *client) (service.ImportReport, string) {
+	report, err := srvc.ImportRecordsCSV(ctx, cl.userGUID, strings.NewReader(content), opts)
+	switch {
+	case errors.Is(err, service.ErrInvalidCSV):
+		return report, MessageImportInvalidFile
+	case errors.Is(err, service.ErrTooManyRows):
+		return report, fmt.Sprintf(MessageImportTooManyRowsFormat, service.MaxImportRows)
+	case err != nil:
+		log.WithError(err).Error("error on import records")
+		return report, MessageDatabaseError + "\n" + internalErrorAditionalInfo
+	}
+	return report, ""
+}
+
+// importWorkbook imports the records of the workbook for the client,
+// on failure it returns the text of the message for the user
+func importWorkbook(ctx context.Context, content string, opts service.ImportOptions, srvc service.ServiceInterface, log *logrus.Logger, cl *client) (service.ImportReport, string) {
+	report, err := srvc.ImportRecordsExel(ctx, cl.userGUID, strings.NewReader(content), opts)
+	switch {
+	case errors.Is(err, service.ErrInvalidExel):
+		return report, MessageImportInvalidWorkbook
+	case errors.Is(err, service.ErrTooManyRows):
+		return report, fmt.Sprintf(MessageImportTooManyRowsFormat, service.MaxImportRows)
+	case err != nil:
+		log.WithError(err).Error("error on import workbook")
+		return report, MessageDatabaseError + "\n" + internalErrorAditionalInfo
+	}
+	return report, ""
+}
+
+// isWorkbook tells the workbooks from the CSV files, the workbooks are zip archives
+func isWorkbook(content string) bool {
+	return strings.HasPrefix(content, "PK\x03\x04")
+}
+
+// formatChanges lists the first maxRejectedShown rows of the workbook adding or changing records
+// with their changed columns, the rest of them are only counted
+func formatChanges(report service.ImportReport) string {
+	text := MessageImportChangesHeader
+	for i, change := range report.Changes {
+		if i == maxRejectedShown {
+			text += fmt.Sprintf(MessageImportRejectedMoreFormat, len(report.Changes)-maxRejectedShown)
+			break
+		}
+
+		fields := make([]string, len(change.Fields))
+		for j, field := range change.Fields {
+			if change.GUID == uuid.Nil {
+				fields[j] = fmt.Sprintf(MessageImportNewFieldFormat,
+					tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, field.Column),
+					tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, field.New),
+				)
+				continue
+			}
+			fields[j] = fmt.Sprintf(MessageImportChangedFieldFormat,
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, field.Column),
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, field.Old),
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, field.New),
+			)
+		}
+
+		format := MessageImportChangedRowFormat
+		if change.GUID == uuid.Nil {
+			format = MessageImportNewRowFormat
+		}
+		text += fmt.Sprintf(format, change.Line, strings.Join(fields, ", "))
+	}
+	return text + fmt.Sprintf(MessageImportChangesSummaryFormat, report.Imported, report.Updated, report.Unchanged)
+}
+
+// formatImportReport formats the outcome of the import for the user,
+// the new categories are listed if they were created
+func formatImportReport(report service.ImportReport, created bool) string {
+	var text string
+	if report.Imported > 0 || report.Updated == 0 {
+		text = fmt.Sprintf(MessageImportedFormat, report.Imported)
+	}
+	if report.Updated > 0 {
+		text += fmt.Sprintf(MessageImportUpdatedFormat, report.Updated)
+	}
+	if created && len(report.NewCategories) > 0 {
+		names := make([]string, len(report.NewCategories))
+		for i, category := range report.NewCategories {
+			names[i] = tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, category)
+		}
+		text += fmt.Sprintf(MessageImportCreatedFormat, strings.Join(names, ", "))
+	}
+	return text + formatRejected(report.Rejected)
+}
+
+// formatRejected lists the first maxRejectedShown rejected rows of the import
+// and counts the rest of them
+func formatRejected(rejected []service.RejectedRow) string {
+	if len(rejected) == 0 {
+		return ""
+	}
+
+	text := MessageImportRejectedHeader
+	for i, row := range rejected {
+		if i == maxRejectedShown {
+			text += fmt.Sprintf(MessageImportRejectedMoreFormat, len(rejected)-maxRejectedShown)
+			break
+		}
+		text += fmt.Sprintf(MessageImportRejectedFormat, row.Line, tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, row.Reason))
+	}
+	return text
+}
+
+// action function for the bank import command, id 27
+//
+// it takes the content of the bank statement sent by the user, the transactions matched by the payee rules
+// are imported at once if all of them are, otherwise it starts the review of the unmatched ones
+func bankImportAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		cmd.becomeLast()
+		log.Error("wrong tocken number for bank import command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		cmd.becomeLast()
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	statement, err := srvc.ParseBankStatement(ctx, cl.userGUID, strings.NewReader(input[1]), cl.baseCurrency())
+	if err != nil {
+		cmd.becomeLast()
+		switch {
+		case errors.Is(err, service.ErrUnknownStatement), errors.Is(err, service.ErrInvalidStatement):
+			msg.Text = MessageBankInvalidFile
+		case errors.Is(err, service.ErrTooManyRows):
+			msg.Text = fmt.Sprintf(MessageImportTooManyRowsFormat, service.MaxImportRows)
+		default:
+			log.WithError(err).Error("error on parse bank statement")
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		}
+		return
+	}
+
+	if len(statement.Transactions) == 0 {
+		cmd.becomeLast()
+		msg.Text = MessageBankNothing + "\n" + fmt.Sprintf(MessageBankStatementFormat, 0, statement.Duplicates) + formatRejected(statement.Rejected)
+		return
+	}
+
+	summary := fmt.Sprintf(MessageBankStatementFormat, len(statement.Transactions), statement.Duplicates) + formatRejected(statement.Rejected)
+	*batch = statement.Transactions
+	text, keyboard, done := nextBankReview(ctx, statement.Transactions, srvc, log, cl)
+	if done {
+		cmd.becomeLast()
+		msg.Text = summary + "\n" + importBankTransactions(ctx, statement.Transactions, srvc, log, cl)
+		return
+	}
+	if keyboard == nil {
+		cmd.becomeLast()
+		msg.Text = text
+		return
+	}
+	msg.Text = summary + text
+	msg.ReplyMarkup = keyboard
+}
+
+// action function for the bank review command, id 28
+//
+// it puts the first unmatched transaction of the statement into the picked category together with
+// the other transactions of its payee and saves the payee rule, or skips the transaction,
+// then shows the next one, the reviewed transactions are imported once none is left or the user is done
+func reviewBankTransactionAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 3 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 3 {
+		cmd.becomeLast()
+		log.Error("wrong callback input")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+	log.Debug("action on bank review command, got: ", input[0])
+
+	transactions, ok := (*batch).([]ftracker.BankTransaction)
+	if !ok {
+		cmd.becomeLast()
+		log.Errorf("wrong batch type for bank review: %T", *batch)
+		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	current := slices.IndexFunc(transactions, func(transaction ftracker.BankTransaction) bool {
+		return transaction.CategoryGUID == uuid.Nil
+	})
+
+	switch {
+	case input[2] == CallbackDataBankCancel:
+		cmd.becomeLast()
+		msg.Text = MessageImportCancelled
+		return
+	case input[2] == CallbackDataBankDone || current < 0:
+		cmd.becomeLast()
+		msg.Text = importBankTransactions(ctx, transactions, srvc, log, cl)
+		return
+	case input[2] == CallbackDataBankSkip:
+		transactions = slices.Delete(transactions, current, current+1)
+	default:
+		transaction := transactions[current]
+		category, errText := reviewCategory(ctx, input[1], transaction.Kind, srvc, log, cl)
+		if errText != "" {
+			msg.Text = errText
+			msg.ReplyMarkup = nil
+			return
+		}
+
+		payee := bankPayee(transaction)
+		for i := range transactions {
+			if transactions[i].CategoryGUID == uuid.Nil && transactions[i].Kind == transaction.Kind &&
+				strings.EqualFold(bankPayee(transactions[i]), payee) {
+				transactions[i].CategoryGUID = category.GUID
+			}
+		}
+		savePayeeRule(ctx, payee, category, srvc, log, cl)
+	}
+	*batch = transactions
+
+	text, keyboard, done := nextBankReview(ctx, transactions, srvc, log, cl)
+	if done {
+		cmd.becomeLast()
+		msg.Text = importBankTransactions(ctx, transactions, srvc, log, cl)
+		return
+	}
+	if keyboard == nil {
+		cmd.becomeLast()
+		msg.Text = text
+		return
+	}
+	msg.Text = text
+	msg.ReplyMarkup = keyboard
+}
+
+// nextBankReview composes the message asking for the category of the first unmatched transaction
+// together with the keyboard of the categories of its kind, done is true if every transaction is matched,
+// the keyboard is nil if the categories can't be got, then the text is the message for the user
+func nextBankReview(ctx context.Context, transactions []ftracker.BankTransaction, srvc service.ServiceInterface, log *logrus.Logger, cl *client) (text string, keyboard *tgbotapi.InlineKeyboardMarkup, done bool) {
+	current := slices.IndexFunc(transactions, func(transaction ftracker.BankTransaction) bool {
+		return transaction.CategoryGUID == uuid.Nil
+	})
+	if current < 0 {
+		return "", nil, true
+	}
+	transaction := transactions[current]
+
+	left := 0
+	for _, transaction := range transactions {
+		if transaction.CategoryGUID == uuid.Nil {
+			left++
+		}
+	}
+
+	categories, err := srvc.GetCategories(ctx, cl.userGUID,
+		srvc.SpendingCategoriesWithKinds([]string{transaction.Kind}),
+		srvc.SpendingCategoriesWithOrder(service.OrderCategoriesByCategory, true),
+		srvc.SpendingCategoriesWithLimit(maxReviewCategories),
+	)
+	if err != nil {
+		log.WithError(err).Error("error on get categories")
+		return MessageDatabaseError + "\n" + internalErrorAditionalInfo, nil, false
+	}
+
+	text = fmt.Sprintf(MessageBankReviewFormat,
+		left,
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, cmp.Or(transaction.Payee, "?")),
+		formatAmount(transaction.Amount, transaction.Currency),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, transaction.PostedAt.Format(formatIn)),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, transaction.Description),
+	)
+	if len(categories) == 0 {
+		text += MessageBankNoCategories
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for start := 0; start < len(categories); start += reviewCategoriesPerRow {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, category := range categories[start:min(start+reviewCategoriesPerRow, len(categories))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(category.Category, CallbackDataBankCategory+category.GUID.String()))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Skip", CallbackDataBankSkip),
+			tgbotapi.NewInlineKeyboardButtonData("Import reviewed", CallbackDataBankDone),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", CallbackDataBankCancel),
+		),
+	)
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return text, &markup, false
+}
+
+// reviewCategory looks up the category picked for the reviewed transaction among the categories of the client,
+// on failure it returns the text of the message for the user
+func reviewCategory(ctx context.Context, guid, kind string, srvc service.ServiceInterface, log *logrus.Logger, cl *client) (ftracker.SpendingCategory, string) {
+	categoryGUID, err := uuid.Parse(guid)
+	if err != nil {
+		return ftracker.SpendingCategory{}, MessageBankCategoryError
+	}
+
+	categories, err := srvc.GetCategories(ctx, cl.userGUID, srvc.SpendingCategoriesWithGUIDs([]uuid.UUID{categoryGUID}))
+	if err != nil {
+		log.WithError(err).Error("error on get categories")
+		return ftracker.SpendingCategory{}, MessageDatabaseError + "\n" + internalErrorAditionalInfo
+	}
+	if len(categories) != 1 || categories[0].Kind != kind {
+		return ftracker.SpendingCategory{}, MessageBankCategoryError
+	}
+	return categories[0], ""
+}
+
+// savePayeeRule remembers the category of the payee for the next statements,
+// the transactions are imported anyway, so the failures are only logged
+func savePayeeRule(ctx context.Context, payee string, category ftracker.SpendingCategory, srvc service.ServiceInterface, log *logrus.Logger, cl *client) {
+	if strings.TrimSpace(payee) == "" {
+		return
+	}
+
+	_, err := srvc.AddPayeeRules(ctx, cl.userGUID, []ftracker.PayeeRule{{CategoryGUID: category.GUID, Pattern: payee}})
+	switch {
+	case err == nil, utils.IsUniqueConstrainViolation(err), errors.Is(err, service.ErrInvalidPattern):
+	default:
+		log.WithError(err).Error("error on add payee rule")
+	}
+}
+
+// importBankTransactions imports the transactions put into the categories and returns the message for the user
+func importBankTransactions(ctx context.Context, transactions []ftracker.BankTransaction, srvc service.ServiceInterface, log *logrus.Logger, cl *client) string {
+	imported, err := srvc.ImportBankTransactions(ctx, cl.userGUID, transactions)
+	if err != nil {
+		log.WithError(err).Error("error on import bank transactions")
+		return MessageDatabaseError + "\n" + internalErrorAditionalInfo
+	}
+
+	text := fmt.Sprintf(MessageImportedFormat, imported)
+	skipped := 0
+	for _, transaction := range transactions {
+		if transaction.CategoryGUID == uuid.Nil {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		text += fmt.Sprintf(MessageBankSkippedFormat, skipped)
+	}
+	return text
+}
+
+// bankPayee returns the payee of the transaction the payee rules match, the description if it has none
+func bankPayee(transaction ftracker.BankTransaction) string {
+	return strings.TrimSpace(cmp.Or(transaction.Payee, transaction.Description))
+}
+
+// action function for the payee rules command, id 29
+//
+// it either adds the rule putting the transactions of the payee into the category,
+// or lists the rules of the user and prompts to pick one to delete
+func payeeRulesAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 4 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 4 {
+		cmd.becomeLast()
+		log.Error("wrong tocken number for payee rules command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if input[1] == "" {
+		cmd.becomeLast()
+		category, err := getUserCategory(ctx, strings.TrimSpace(input[3]), srvc, log, cl)
+		if err != nil {
+			msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			return
+		}
+		if category == nil {
+			msg.Text = MessageNoCategoryFound
+			return
+		}
+
+		_, err = srvc.AddPayeeRules(ctx, cl.userGUID, []ftracker.PayeeRule{{CategoryGUID: category.GUID, Pattern: input[2]}})
+		if err != nil {
+			switch {
+			case utils.IsUniqueConstrainViolation(err):
+				msg.Text = MessagePayeeRuleExists
+			case errors.Is(err, service.ErrInvalidPattern):
+				msg.Text = MessageInvalidPayeeRule
+			default:
+				log.WithError(err).Error("error on add payee rule")
+				msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+			}
+			return
+		}
+		msg.Text = MessagePayeeRuleSuccess
+		return
+	}
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		cmd.becomeLast()
+		log.WithError(err).Error("error on fill user guid")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	rules, err := srvc.GetPayeeRules(ctx, cl.userGUID)
+	if err != nil {
+		cmd.becomeLast()
+		log.WithError(err).Error("error on get payee rules")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	if len(rules) == 0 {
+		cmd.becomeLast()
+		msg.Text = MessageUnderflowPayeeRules
+		return
+	}
+
+	*batch = rules
+	for i, rule := range rules {
+		msg.Text += fmt.Sprintf(MessageShowPayeeRulesFormat,
+			i+1,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, rule.Pattern),
+			formatCategoryName(ftracker.SpendingCategory{Category: rule.Category, Kind: rule.Kind}),
+		)
+	}
+	msg.Text += MessageDeletePayeeRule
+	msg.ReplyMarkup = nil
+}
+
+// action function for the delete payee rule command, id 30
+//
+// it takes the number of the payee rule from the listing and deletes it from the service.repository,
+// the records already imported with it are kept
+func deletePayeeRuleAction(ctx context.Context, input []string, batch *any, srvc service.ServiceInterface, log *logrus.Logger, sender Sender, cl *client, cmd *command) {
+
+	msg := tgbotapi.NewMessage(cl.chanID, "")
+	msg.ReplyMarkup = baseKeyboard
+	defer func() {
+		sender.Send(msg)
+	}()
+
+	// specified regex allways returns 2 tokens, so this check may be redundant,
+	// but in case of future changes, it is better to keep it, to catch invalid regex changes,
+	// or to catch some errors I am unaware of
+	if len(input) != 2 {
+		log.Error("wrong tocken number for delete payee rule command")
+		msg.Text = MessageInvalidNumberOfTockensAction + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	rules, ok := (*batch).([]ftracker.PayeeRule)
+	if !ok {
+		log.Errorf("wrong batch type for delete payee rule: %T", *batch)
+		msg.Text = MessageInternalError + "\n" + internalErrorAditionalInfo
+		return
+	}
+
+	number, err := strconv.Atoi(input[1])
+	if err != nil || number < 1 || number > len(rules) {
+		msg.Text = MessagePayeeRuleNumberError
+		return
+	}
+
+	err = srvc.DeletePayeeRules(ctx, cl.userGUID, []uuid.UUID{rules[number-1].GUID})
+	if err != nil {
+		log.WithError(err).Error("error on delete payee rule")
+		msg.Text = MessageDatabaseError + "\n" + internalErrorAditionalInfo
+		return
+	}
+	msg.Text = MessagePayeeRuleDeleteSuccess
+}
+
+// parseTimeBoundaries turns either the fixed period (year, month or day back from now)
+// or the dates of the custom range into the time boundaries,
+// on failure it returns the text of the message for the user
+func parseTimeBoundaries(ymd, from, to string, log *logrus.Logger) (timeFrom, timeTo time.Time, errText string) {
+	var err error
+	if ymd == "" {
+		timeFrom, err = time.Parse(formatIn, from)
+		if err != nil {
+			log.WithError(err).Error("error on parsing time from")
+			return timeFrom, timeTo, MessageInvalidFromDate
+		}
+
+		if to == "" {
+			timeTo = time.Now()
+		} else {
+			timeTo, err = time.Parse(formatIn, to)
+			if err != nil {
+				log.WithError(err).Error("error on parsing time to")
+				return timeFrom, timeTo, MessageInvalidToDate
+			}
+		}
+		return timeFrom, timeTo, ""
+	}
+
+	timeTo = time.Now()
+	switch ymd {
+	case "year":
+		timeFrom = timeTo.AddDate(-1, 0, 0)
+	case "month":
+		timeFrom = timeTo.AddDate(0, -1, 0)
+	case "day":
+		timeFrom = timeTo.AddDate(0, 0, -1)
+	default:
+		log.Error("invalid token for ymd time boundaries")
+		return timeFrom, timeTo, MessageInvalidFixedTime + "\n" + internalErrorAditionalInfo
+	}
+	return timeFrom, timeTo, ""
+}
+
+// formatCategoryName returns the name of the category escaped for MarkdownV2, income categories are marked
+func formatCategoryName(category ftracker.SpendingCategory) string {
+	name := tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, category.Category)
+	if category.Kind == ftracker.KindIncome {
+		return MessageIncomeMark + name
+	}
+	return name
+}
+
+// formatAmount formats the amount in the minor units of the currency followed by the sign of the currency,
+// an empty currency stands for ftracker.DefaultCurrency
+func formatAmount(amount ftracker.Money, currency string) string {
+	if currency == "" {
+		currency = ftracker.DefaultCurrency
+	}
+	sign, ok := currencySigns[currency]
+	if !ok {
+		sign = " " + currency
+	}
+
+	return fmt.Sprintf(MessageAmountFormat, tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, amount.Format(currency)), sign)
+}
+
+// parseAmount parses the positive amount entered by the user in the currency,
+// the decimal places are separated by a point or a comma, it returns the text for the user if the amount is invalid
+func parseAmount(input, currency string) (ftracker.Money, string) {
+	amount, err := ftracker.ParseMoney(input, currency)
+	switch {
+	case errors.Is(err, ftracker.ErrAmountOverflow):
+		return 0, MessageAmountTooLarge
+	case err != nil:
+		return 0, MessageAmountError
+	case amount == 0:
+		return 0, MessageZeroAmount
+	}
+	return amount, ""
+}
+
+// formatRecordAmount formats the amount of the record in the base currency,
+// the original amount is added if the record was made in another currency
+func formatRecordAmount(record ftracker.SpendingRecord, baseCurrency string) string {
+	formatted := formatAmount(record.BaseAmount, baseCurrency)
+	if record.Currency != "" && record.Currency != baseCurrency {
+		formatted += fmt.Sprintf(MessageOriginalAmountFormat, formatAmount(record.Amount, record.Currency))
+	}
+	return formatted
+}
+
+// getUserCategory looks up the category with the given name among the categories of the client,
+// the name may be a path like food/groceries, which is resolved segment by segment among the subcategories
+// of the previous one. The first segment is a top level category, or the only category with that name
+// if none is on the top level. It returns nil if there is no such category
+func getUserCategory(ctx context.Context, name string, srvc service.ServiceInterface, log *logrus.Logger, cl *client) (*ftracker.SpendingCategory, error) {
+
+	err := cl.populateUserGUID(ctx, srvc, log)
+	if err != nil {
+		log.WithError(err).Error("error on fill user guid")
+		return nil, fmt.Errorf("getUserCategory: %w", err)
+	}
+
+	path := strings.Split(name, categoryPathSeparator)
+	categories, err := srvc.GetCategories(ctx,
+		cl.userGUID,
+		srvc.SpendingCategoriesWithCategories(path),
+	)
+	if err != nil {
+		log.WithError(err).Error("error on get category")
+		return nil, fmt.Errorf("getUserCategory: %w", err)
+	}
+
+	// the names are unique among the subcategories of the same parent only
+	type sibling struct {
+		parentGUID uuid.UUID
+		name       string
+	}
+	bySibling := make(map[sibling]ftracker.SpendingCategory, len(categories))
+	byName := make(map[string][]ftracker.SpendingCategory, len(categories))
+	for _, category := range categories {
+		bySibling[sibling{category.ParentGUID, category.Category}] = category
+		byName[category.Category] = append(byName[category.Category], category)
+	}
+
+	found, ok := bySibling[sibling{uuid.Nil, path[0]}]
+	if !ok {
+		if len(byName[path[0]]) != 1 {
+			return nil, nil
+		}
+		found = byName[path[0]][0]
+	}
+	for _, segment := range path[1:] {
+		if found, ok = bySibling[sibling{found.GUID, segment}]; !ok {
+			return nil, nil
+		}
+	}
+	return &found, nil
+}
+
+// findCategoryTree looks up the category with the given name among the trees and their subcategories,
+// the name may be a path resolved like in getUserCategory,
+// it returns the tree of that category alone, or nil if there is no such category
+func findCategoryTree(trees []ftracker.CategoryTree, name string) []ftracker.CategoryTree {
+	path := strings.Split(name, categoryPathSeparator)
+
+	found, ok := findCategoryChild(trees, path[0])
+	if !ok {
+		var matches []ftracker.CategoryTree
+		var collect func(trees []ftracker.CategoryTree)
+		collect = func(trees []ftracker.CategoryTree) {
+			for _, tree := range trees {
+				if tree.Category == path[0] {
+					matches = append(matches, tree)
+				}
+				collect(tree.Children)
+			}
+		}
+		collect(trees)
+		if len(matches) != 1 {
+			return nil
+		}
+		found = matches[0]
+	}
+	for _, segment := range path[1:] {
+		if found, ok = findCategoryChild(found.Children, segment); !ok {
+			return nil
+		}
+	}
+	return []ftracker.CategoryTree{found}
+}
+
+// findCategoryChild returns the tree with the given name among the trees, not looking into their subcategories
+func findCategoryChild(trees []ftracker.CategoryTree, name string) (ftracker.CategoryTree, bool) {
+	for _, tree := range trees {
+		if tree.Category == name {
+			return tree, true
+		}
+	}
+	return ftracker.CategoryTree{}, false
+}
+
+// walkCategoryTree calls visit for every category of the trees, parents before their subcategories,
+// the depth of the top level categories is the given one
+func walkCategoryTree(trees []ftracker.CategoryTree, depth int, visit func(category ftracker.SpendingCategory, depth int)) {
+	for _, tree := range trees {
+		visit(tree.SpendingCategory, depth)
+		walkCategoryTree(tree.Children, depth+1, visit)
+	}
+}
+
 // validateInput function checks if the input matches the command's regex
 // and returns the matched group if it does
 func (c *command) validateInput(input string) []string {
@@ -703,9 +2718,11 @@ func isCommand(s string) (command, bool) {
 // inits the batch for the command
 func initBatch(id int) any {
 	switch id {
-	case 1:
+	case 1, 10, 12:
 		return &ftracker.SpendingCategory{}
-	case 3:
+	case 15:
+		return &ftracker.SpendingCategory{Kind: ftracker.KindIncome}
+	case 3, 16:
 		return &ftracker.SpendingRecord{}
 	case 5:
 		return &repository.RecordOptions{}
@@ -1,8 +1,10 @@
 package bot
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"testing"
 	"time"
@@ -14,18 +16,26 @@ import (
 	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 	"github.com/iv-sukhanov/finance_tracker/internal/service"
 	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_addCategoryAction(t *testing.T) {
 
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	groceries := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: food.GUID, Category: "groceries", Kind: ftracker.KindSpending}
+	travel := ftracker.SpendingCategory{GUID: uuid.New(), Category: "travel", Kind: ftracker.KindSpending}
+	foodOther := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: food.GUID, Category: "other", Kind: ftracker.KindSpending}
+	travelOther := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: travel.GUID, Category: "other", Kind: ftracker.KindSpending}
+
 	tt := []struct {
-		name      string
-		input     []string
-		batch     any
-		senderBeh func(*MockSender)
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		want       ftracker.SpendingCategory
 	}{
 		{
 			name:  "OK",
@@ -34,6 +44,110 @@ func Test_addCategoryAction(t *testing.T) {
 			senderBeh: func(s *MockSender) {
 				s.EXPECT().Send(tgbotapi.NewMessage(int64(1), MessageAddCategoryDescription))
 			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			want:       ftracker.SpendingCategory{Category: "test"},
+		},
+		{
+			name:  "Subcategory",
+			input: []string{"food/groceries/fruits", "food/groceries/fruits"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(tgbotapi.NewMessage(int64(1), MessageAddCategoryDescription))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food", "groceries"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{groceries, food}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "fruits", ParentGUID: groceries.GUID},
+		},
+		{
+			name:  "No_parent",
+			input: []string{"food/fruits/apples", "food/fruits/apples"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoParentCategory)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food", "fruits"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "apples"},
+		},
+		{
+			name:  "Same_name_under_another_parent",
+			input: []string{"travel/other/tickets", "travel/other/tickets"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(tgbotapi.NewMessage(int64(1), MessageAddCategoryDescription))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"travel", "other"})
+				// the other of travel comes first, the later one of food doesn't hide it
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{travelOther, foodOther, food, travel}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "tickets", ParentGUID: travelOther.GUID},
+		},
+		{
+			name:  "Ambiguous_name",
+			input: []string{"other/tickets", "other/tickets"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoParentCategory)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"other"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{foodOther, travelOther}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "tickets"},
+		},
+		{
+			name:  "Broken_path",
+			input: []string{"groceries/food/apples", "groceries/food/apples"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoParentCategory)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"groceries", "food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food, groceries}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "apples"},
+		},
+		{
+			name:  "Kind_mismatch",
+			input: []string{"food/bonus", "food/bonus"},
+			batch: any(&ftracker.SpendingCategory{Kind: ftracker.KindIncome}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryKindMismatch)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+			},
+			want: ftracker.SpendingCategory{Category: "bonus", Kind: ftracker.KindIncome},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"food/groceries", "food/groceries"},
+			batch: any(&ftracker.SpendingCategory{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return(nil, errors.New("error"))
+			},
+			want: ftracker.SpendingCategory{Category: "groceries"},
 		},
 		{
 			name:  "Internal_#tocken_error",
@@ -44,6 +158,7 @@ func Test_addCategoryAction(t *testing.T) {
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
 		},
 	}
 	for _, tc := range tt {
@@ -53,12 +168,14 @@ func Test_addCategoryAction(t *testing.T) {
 
 			sender := NewMockSender(controller)
 			tc.senderBeh(sender)
+			service := mock_service.NewMockServiceInterface(controller)
+			tc.serviceBeh(service)
 
 			cmd := commandsByIDs[1]
-			client := &client{chanID: 1}
+			client := &client{chanID: 1, userGUID: userGUID}
 
-			addCategoryAction(tc.input, &tc.batch, nil, test_log, sender, client, &cmd)
-			require.Equal(t, tc.input[1], tc.batch.(*ftracker.SpendingCategory).Category)
+			addCategoryAction(context.Background(), tc.input, &tc.batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tc.want, *tc.batch.(*ftracker.SpendingCategory))
 		})
 	}
 }
@@ -92,7 +209,7 @@ func Test_addCategoryDescriptionAction(t *testing.T) {
 					Description: "testdescr",
 					UserGUID:    guids[0],
 				}
-				s.EXPECT().AddCategories([]ftracker.SpendingCategory{category}).Return(nil, nil)
+				s.EXPECT().AddCategories(gomock.Any(), guids[0], []ftracker.SpendingCategory{category}).Return(nil, nil)
 			},
 			userGUID: guids[0],
 		},
@@ -107,7 +224,7 @@ func Test_addCategoryDescriptionAction(t *testing.T) {
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
 				s.EXPECT().UsersWithTelegramIDs(gomock.Any()).Return(nil)
-				s.EXPECT().GetUsers(gomock.Any()).Return(nil, errors.New("error"))
+				s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 			},
 			userGUID: uuid.Nil,
 		},
@@ -122,7 +239,7 @@ func Test_addCategoryDescriptionAction(t *testing.T) {
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
 				err := fmt.Errorf("%w", &pgconn.PgError{Code: "23505"})
-				s.EXPECT().AddCategories(gomock.Any()).Return(nil, err)
+				s.EXPECT().AddCategories(gomock.Any(), guids[0], gomock.Any()).Return(nil, err)
 			},
 			userGUID: guids[0],
 		},
@@ -152,128 +269,154 @@ func Test_addCategoryDescriptionAction(t *testing.T) {
 			cmd := commandsByIDs[2]
 			client := &client{userGUID: tt.userGUID, chanID: 1}
 
-			addCategoryDescriptionAction(tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+			addCategoryDescriptionAction(context.Background(), tt.input, &tt.batch, service, test_log, sender, client, &cmd)
 		})
 	}
 }
 
-func Test_addRecordAction(t *testing.T) {
+func Test_pickCategoryToEditAction(t *testing.T) {
+
+	guids := []uuid.UUID{
+		uuid.New(),
+		uuid.New(),
+	}
 
 	tests := []struct {
 		name       string
 		input      []string
-		batch      any
+		want       ftracker.SpendingCategory
 		senderBeh  func(*MockSender)
 		serviceBeh func(*mock_service.MockServiceInterface)
 	}{
 		{
-			name:  "No_description",
-			input: []string{"", "category", "100", ""},
-			batch: any(&ftracker.SpendingRecord{}),
+			name:  "Ok",
+			input: []string{"beer", "beer"},
+			want:  ftracker.SpendingCategory{GUID: guids[1], UserGUID: guids[0], Category: "beer"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				s.EXPECT().Send(tgbotapi.NewMessage(int64(1), MessageEditCategoryDetails))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: guids[1], UserGUID: guids[0], Category: "beer"},
+				}, nil)
+			},
+		},
+		{
+			name:  "No_category_found",
+			input: []string{"beer", "beer"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"category"})
-				category := ftracker.SpendingCategory{
-					Category: "category",
-					GUID:     uuid.New(),
-				}
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
-				record := ftracker.SpendingRecord{
-					CategoryGUID: category.GUID,
-					Amount:       10000,
-					Description:  "spending",
-				}
-				s.EXPECT().AddRecords([]ftracker.SpendingRecord{record}).Return(nil, nil)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
 			},
 		},
 		{
-			name:  "With_description",
-			input: []string{"", "sweets", "100", "heroin"},
-			batch: any(&ftracker.SpendingRecord{}),
+			name:  "DB_error",
+			input: []string{"beer", "beer"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"sweets"})
-				category := ftracker.SpendingCategory{
-					Category: "sweets",
-					GUID:     uuid.New(),
-				}
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
-				record := ftracker.SpendingRecord{
-					CategoryGUID: category.GUID,
-					Amount:       10000,
-					Description:  "heroin",
-				}
-				s.EXPECT().AddRecords([]ftracker.SpendingRecord{record}).Return(nil, nil)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return(nil, errors.New("error"))
 			},
 		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			batch := initBatch(10)
+			cmd := commandsByIDs[10]
+			client := &client{chanID: 1, userGUID: guids[0]}
+
+			pickCategoryToEditAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.want, *batch.(*ftracker.SpendingCategory))
+			require.Equal(t, tt.want.GUID == uuid.Nil, cmd.isLast())
+		})
+	}
+}
+
+func Test_editCategoryAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	category := ftracker.SpendingCategory{
+		GUID:        uuid.New(),
+		UserGUID:    userGUID,
+		Category:    "beer",
+		Description: "money spent on beer",
+	}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
 		{
-			name:  "Zero_amount",
-			input: []string{"", "online shoping", "0", ""},
-			batch: any(&ftracker.SpendingRecord{}),
+			name:  "Rename",
+			input: []string{"name craft beer", "craft beer", ""},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageZeroAmount)
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryUpdateSuccess)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
-			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				renamed := category
+				renamed.Category = "craft beer"
+				s.EXPECT().UpdateCategories(gomock.Any(), userGUID, []ftracker.SpendingCategory{renamed}).Return(nil)
+			},
 		},
 		{
-			name:  "No_category_found",
-			input: []string{"", "flowers", "35", "birsday gift"},
-			batch: any(&ftracker.SpendingRecord{}),
+			name:  "Description",
+			input: []string{"description only the good one", "", "only the good one"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryUpdateSuccess)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"flowers"})
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
+				described := category
+				described.Description = "only the good one"
+				s.EXPECT().UpdateCategories(gomock.Any(), userGUID, []ftracker.SpendingCategory{described}).Return(nil)
 			},
 		},
 		{
-			name:  "Overflow_amount",
-			input: []string{"", "gambling", "42949673", "went perfect"},
-			batch: any(&ftracker.SpendingRecord{}),
+			name:  "Unique_constrain_error",
+			input: []string{"name food", "food", ""},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageAmountError+"\n"+internalErrorAditionalInfo)
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryDuplicate)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"gambling"})
-				category := ftracker.SpendingCategory{
-					Category: "gambling",
-					GUID:     uuid.New(),
-				}
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				err := fmt.Errorf("%w", &pgconn.PgError{Code: "23505"})
+				s.EXPECT().UpdateCategories(gomock.Any(), userGUID, gomock.Any()).Return(err)
 			},
 		},
 		{
 			name:  "DB_error",
-			input: []string{"", "electricity bills", "120.21", "why the fuck so expencive.."},
-			batch: any(&ftracker.SpendingRecord{}),
+			input: []string{"name food", "food", ""},
 			senderBeh: func(s *MockSender) {
 				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"electricity bills"})
-				category := ftracker.SpendingCategory{
-					Category: "electricity bills",
-					GUID:     uuid.New(),
-				}
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
-				s.EXPECT().AddRecords(gomock.Any()).Return(nil, errors.New("error"))
+				s.EXPECT().UpdateCategories(gomock.Any(), userGUID, gomock.Any()).Return(errors.New("error"))
 			},
 		},
 	}
@@ -287,186 +430,111 @@ func Test_addRecordAction(t *testing.T) {
 			sender := NewMockSender(controller)
 			tt.senderBeh(sender)
 
-			cmd := commandsByIDs[3]
-			client := &client{chanID: 1}
+			batchCategory := category
+			batch := any(&batchCategory)
+			cmd := commandsByIDs[11]
+			client := &client{chanID: 1, userGUID: userGUID}
 
-			addRecordAction(tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+			editCategoryAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
 		})
 	}
 }
 
-func Test_showCategoriesAction(t *testing.T) {
+func Test_deleteCategoryAction(t *testing.T) {
 
 	guids := []uuid.UUID{
 		uuid.New(),
+		uuid.New(),
+		uuid.New(),
 	}
+	category := ftracker.SpendingCategory{GUID: guids[1], UserGUID: guids[0], Category: "beer"}
 
 	tests := []struct {
 		name       string
 		input      []string
 		senderBeh  func(*MockSender)
 		serviceBeh func(*mock_service.MockServiceInterface)
-		clientGUID uuid.UUID
 	}{
 		{
-			name:  "All",
-			input: []string{"", "", "all", ""},
-			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1),
-					"Your categories:\n"+
-						"1\\. test1 \\- 11\\.01\u20AC\n"+
-						"2\\. test2 \\- 11\\.02\u20AC\n"+
-						"3\\. test3 \\- 11\\.03\u20AC\n"+
-						MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelCategoriesKeyboard
-				s.EXPECT().Send(msg)
-			},
-			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(0)
-				s.EXPECT().SpendingCategoriesWithCategories([]string(nil))
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{
-						{Category: "test1", Description: "test1descr", Amount: 1101},
-						{Category: "test2", Description: "test2descr", Amount: 1102},
-						{Category: "test3", Description: "test3descr", Amount: 1103},
-					}, nil)
-			},
-			clientGUID: guids[0],
-		},
-		{
-			name:  "All_full",
-			input: []string{"", "", "all", "full"},
+			name:  "Delete_with_records",
+			input: []string{"delete", "delete", ""},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1),
-					"Your categories:\n"+
-						"1\\. test1 \\- 11\\.01\u20AC\ntest1descr\n\n"+
-						"2\\. test2 \\- 11\\.02\u20AC\ntest2descr\n\n"+
-						"3\\. test3 \\- 11\\.03\u20AC\ntest3descr\n\n"+
-						MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(0)
-				s.EXPECT().SpendingCategoriesWithCategories([]string(nil))
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{
-						{Category: "test1", Description: "test1descr", Amount: 1101},
-						{Category: "test2", Description: "test2descr", Amount: 1102},
-						{Category: "test3", Description: "test3descr", Amount: 1103},
-					}, nil)
+				s.EXPECT().DeleteCategories(gomock.Any(), guids[0], []uuid.UUID{guids[1]}, uuid.Nil).Return(nil)
 			},
-			clientGUID: guids[0],
 		},
 		{
-			name:  "Limited",
-			input: []string{"", "2", "", "full"},
+			name:  "Move_records",
+			input: []string{"move drinks", "", "drinks"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1),
-					"Your categories:\n"+
-						"1\\. test1 \\- 11\\.01\u20AC\ntest1descr\n\n"+
-						"2\\. test2 \\- 11\\.02\u20AC\ntest2descr\n\n"+
-						MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(2)
-				s.EXPECT().SpendingCategoriesWithCategories([]string(nil))
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{
-						{Category: "test1", Description: "test1descr", Amount: 1101},
-						{Category: "test2", Description: "test2descr", Amount: 1102},
-					}, nil)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"drinks"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: guids[2], UserGUID: guids[0], Category: "drinks"},
+				}, nil)
+				s.EXPECT().DeleteCategories(gomock.Any(), guids[0], []uuid.UUID{guids[1]}, guids[2]).Return(nil)
 			},
-			clientGUID: guids[0],
 		},
 		{
-			name:  "Category_specified",
-			input: []string{"", "", "beer", "full"},
+			name:  "Move_to_itself",
+			input: []string{"move beer", "", "beer"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1),
-					"Your categories:\n"+
-						"1\\. beer \\- 11\\.01\u20AC\nmoney spent on beer\n\n"+
-						MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryMoveSame)
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(1)
 				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{
-						{Category: "beer", Description: "money spent on beer", Amount: 1101},
-					}, nil)
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
 			},
-			clientGUID: guids[0],
 		},
 		{
-			name:  "Categories_underflow_1",
-			input: []string{"", "", "beer", "full"},
+			name:  "Move_to_other_kind",
+			input: []string{"move salary", "", "salary"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryKindMismatch)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(1)
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{}, nil)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"salary"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{
+					{GUID: guids[2], UserGUID: guids[0], Category: "salary", Kind: ftracker.KindIncome},
+				}, nil)
 			},
-			clientGUID: guids[0],
 		},
 		{
-			name:  "Categories_underflow_2",
-			input: []string{"", "", "all", "full"},
+			name:  "No_target_found",
+			input: []string{"move drinks", "", "drinks"},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowCategories)
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(0)
-				s.EXPECT().SpendingCategoriesWithCategories([]string(nil))
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					[]ftracker.SpendingCategory{}, nil)
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"drinks"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
 			},
-			clientGUID: guids[0],
 		},
 		{
 			name:  "DB_error",
-			input: []string{"", "", "all", "full"},
+			input: []string{"delete", "delete", ""},
 			senderBeh: func(s *MockSender) {
 				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithUserGUIDs([]uuid.UUID{guids[0]})
-				s.EXPECT().SpendingCategoriesWithLimit(0)
-				s.EXPECT().SpendingCategoriesWithCategories([]string(nil))
-				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
-				s.EXPECT().GetCategories(gomock.Any()).Return(
-					nil, errors.New("error"))
+				s.EXPECT().DeleteCategories(gomock.Any(), guids[0], gomock.Any(), gomock.Any()).Return(errors.New("error"))
 			},
-			clientGUID: guids[0],
 		},
 	}
 	for _, tt := range tests {
@@ -479,74 +547,2439 @@ func Test_showCategoriesAction(t *testing.T) {
 			sender := NewMockSender(controller)
 			tt.senderBeh(sender)
 
-			var batch any
-			cmd := commandsByIDs[4]
-			client := &client{chanID: 1, userGUID: tt.clientGUID}
+			batchCategory := category
+			batch := any(&batchCategory)
+			cmd := commandsByIDs[13]
+			client := &client{chanID: 1, userGUID: guids[0]}
 
-			showCategoriesAction(tt.input, &batch, service, test_log, sender, client, &cmd)
+			deleteCategoryAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_addRecordAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "No_description",
+			input: []string{"", "category", "100", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"category"})
+				category := ftracker.SpendingCategory{
+					Category: "category",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				record := ftracker.SpendingRecord{
+					CategoryGUID: category.GUID,
+					Amount:       10000,
+					Description:  ftracker.KindSpending,
+				}
+				recordGUID := uuid.New()
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{record}).Return([]uuid.UUID{recordGUID}, nil)
+				s.EXPECT().EvaluateBudget(gomock.Any(), userGUID, recordGUID).Return(service.BudgetAlertNone, ftracker.BudgetStatus{}, nil)
+			},
+		},
+		{
+			name:  "With_description",
+			input: []string{"", "sweets", "100", "", "heroin", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess+"\n\n\U000026A0You have spent 850\\.00\u20AC of the 1000\\.00\u20AC budget for sweets this month")
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"sweets"})
+				category := ftracker.SpendingCategory{
+					Category: "sweets",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				record := ftracker.SpendingRecord{
+					CategoryGUID: category.GUID,
+					Amount:       10000,
+					Description:  "heroin",
+				}
+				recordGUID := uuid.New()
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{record}).Return([]uuid.UUID{recordGUID}, nil)
+				budget := ftracker.BudgetStatus{Budget: ftracker.Budget{Amount: 100000}, Category: "sweets", Spent: 85000}
+				s.EXPECT().EvaluateBudget(gomock.Any(), userGUID, recordGUID).Return(service.BudgetAlertWarning, budget, nil)
+			},
+		},
+		{
+			name:  "Other_currency",
+			input: []string{"", "travel", "12.5", "USD", "taxi", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"travel"})
+				category := ftracker.SpendingCategory{
+					Category: "travel",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				record := ftracker.SpendingRecord{
+					CategoryGUID: category.GUID,
+					Amount:       1250,
+					Currency:     "USD",
+					Description:  "taxi",
+				}
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{record}).Return(nil, nil)
+			},
+		},
+		{
+			name:  "Backdated",
+			input: []string{"", "food", "12.5", "", "dinner", "15.03.2025"},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				category := ftracker.SpendingCategory{
+					Category: "food",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				record := ftracker.SpendingRecord{
+					CategoryGUID: category.GUID,
+					Amount:       1250,
+					Description:  "dinner",
+					SpentAt:      time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+				}
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{record}).Return(nil, nil)
+			},
+		},
+		{
+			name:  "Invalid_date",
+			input: []string{"", "food", "12.5", "", "", "31.02.2025"},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidSpentAt)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "Future_date",
+			input: []string{"", "food", "12.5", "", "", time.Now().AddDate(0, 0, 2).Format(formatIn)},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageFutureSpentAt)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "Budget_exceeded",
+			input: []string{"", "travel", "20", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess+"\n\n\U0001F6A8You have spent 110\\.00\u20AC, the 100\\.00\u20AC budget for travel is exceeded this month")
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"travel"})
+				category := ftracker.SpendingCategory{
+					Category: "travel",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				recordGUID := uuid.New()
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, gomock.Any()).Return([]uuid.UUID{recordGUID}, nil)
+				budget := ftracker.BudgetStatus{Budget: ftracker.Budget{Amount: 10000}, Category: "travel", Spent: 11000}
+				s.EXPECT().EvaluateBudget(gomock.Any(), userGUID, recordGUID).Return(service.BudgetAlertExceeded, budget, nil)
+			},
+		},
+		{
+			name:  "Budget_error",
+			input: []string{"", "travel", "20", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"travel"})
+				category := ftracker.SpendingCategory{
+					Category: "travel",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				recordGUID := uuid.New()
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, gomock.Any()).Return([]uuid.UUID{recordGUID}, nil)
+				s.EXPECT().EvaluateBudget(gomock.Any(), userGUID, recordGUID).Return(service.BudgetAlertNone, ftracker.BudgetStatus{}, errors.New("error"))
+			},
+		},
+		{
+			name:  "Unknown_currency",
+			input: []string{"", "travel", "12.5", "XXX", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnknownCurrency)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"travel"})
+				category := ftracker.SpendingCategory{
+					Category: "travel",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, gomock.Any()).Return(nil, fmt.Errorf("Repostiory.AddRecords: %w", service.ErrUnknownCurrency))
+			},
+		},
+		{
+			name:  "Income_category",
+			input: []string{"", "salary", "1000", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageCategoryKindMismatch)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"salary"})
+				category := ftracker.SpendingCategory{
+					Category: "salary",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindIncome,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "Zero_amount",
+			input: []string{"", "online shoping", "0", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageZeroAmount)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"online shoping"})
+				category := ftracker.SpendingCategory{
+					Category: "online shoping",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "Too_many_decimals",
+			input: []string{"", "sushi", "1500.5", "JPY", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageAmountError)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"sushi"})
+				category := ftracker.SpendingCategory{
+					Category: "sushi",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "No_category_found",
+			input: []string{"", "flowers", "35", "", "birsday gift", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"flowers"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
+			},
+		},
+		{
+			name:  "Overflow_amount",
+			input: []string{"", "gambling", "92233720368547758.08", "", "went perfect", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageAmountTooLarge)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"gambling"})
+				category := ftracker.SpendingCategory{
+					Category: "gambling",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"", "electricity bills", "120.21", "", "why the fuck so expencive..", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"electricity bills"})
+				category := ftracker.SpendingCategory{
+					Category: "electricity bills",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindSpending,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, gomock.Any()).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			cmd := commandsByIDs[3]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			addRecordAction(context.Background(), tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_addIncomeAction(t *testing.T) {
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(s *MockSender)
+		serviceBeh func(s *mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "No_description",
+			input: []string{"", "salary", "100", "", "", ""},
+			batch: any(&ftracker.SpendingRecord{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"salary"})
+				category := ftracker.SpendingCategory{
+					Category: "salary",
+					GUID:     uuid.New(),
+					Kind:     ftracker.KindIncome,
+				}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				record := ftracker.SpendingRecord{
+					CategoryGUID: category.GUID,
+					Amount:       10000,
+					Description:  ftracker.KindIncome,
+				}
+				s.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{record}).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			cmd := commandsByIDs[16]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			addIncomeAction(context.Background(), tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_showCategoriesAction(t *testing.T) {
+
+	guids := []uuid.UUID{
+		uuid.New(),
+	}
+
+	flat := []ftracker.CategoryTree{
+		{SpendingCategory: ftracker.SpendingCategory{Category: "test1", Description: "test1descr", Amount: 1101}},
+		{SpendingCategory: ftracker.SpendingCategory{Category: "test2", Description: "test2descr", Amount: 1102}},
+		{SpendingCategory: ftracker.SpendingCategory{Category: "test3", Description: "test3descr", Amount: 1103}},
+	}
+	nested := []ftracker.CategoryTree{
+		{
+			SpendingCategory: ftracker.SpendingCategory{Category: "food", Description: "all the food", Amount: 3000},
+			Children: []ftracker.CategoryTree{
+				{
+					SpendingCategory: ftracker.SpendingCategory{Category: "groceries", Description: "supermarket", Amount: 2000},
+					Children: []ftracker.CategoryTree{
+						{SpendingCategory: ftracker.SpendingCategory{Category: "fruits", Description: "apples", Amount: 500}},
+					},
+				},
+			},
+		},
+		{SpendingCategory: ftracker.SpendingCategory{Category: "beer", Description: "money spent on beer", Amount: 1101}},
+	}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		clientGUID uuid.UUID
+	}{
+		{
+			name:  "All",
+			input: []string{"", "", "all", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. test1 \\- 11\\.01\u20AC\n"+
+						"2\\. test2 \\- 11\\.02\u20AC\n"+
+						"3\\. test3 \\- 11\\.03\u20AC\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(flat, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "All_full",
+			input: []string{"", "", "all", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. test1 \\- 11\\.01\u20AC\ntest1descr\n\n"+
+						"2\\. test2 \\- 11\\.02\u20AC\ntest2descr\n\n"+
+						"3\\. test3 \\- 11\\.03\u20AC\ntest3descr\n\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(flat, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Nested",
+			input: []string{"", "", "all", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. food \\- 30\\.00\u20AC\n"+
+						MessageSubcategoryIndent+"2\\. groceries \\- 20\\.00\u20AC\n"+
+						MessageSubcategoryIndent+MessageSubcategoryIndent+"3\\. fruits \\- 5\\.00\u20AC\n"+
+						"4\\. beer \\- 11\\.01\u20AC\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Limited",
+			input: []string{"", "2", "", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. test1 \\- 11\\.01\u20AC\ntest1descr\n\n"+
+						"2\\. test2 \\- 11\\.02\u20AC\ntest2descr\n\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(2)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(flat[:2], nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Escaped",
+			input: []string{"", "", "all", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. take\\_out \\- 11\\.01\u20AC\nchips \\(salty\\)\\!\n\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return([]ftracker.CategoryTree{
+					{SpendingCategory: ftracker.SpendingCategory{Category: "take_out", Description: "chips (salty)!", Amount: 1101}},
+				}, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Category_specified",
+			input: []string{"", "", "beer", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. beer \\- 11\\.01\u20AC\nmoney spent on beer\n\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Subcategory_specified",
+			input: []string{"", "", "groceries", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. groceries \\- 20\\.00\u20AC\n"+
+						MessageSubcategoryIndent+"2\\. fruits \\- 5\\.00\u20AC\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Subcategory_path",
+			input: []string{"", "", "food/groceries/fruits", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1),
+					"Your categories:\n"+
+						"1\\. fruits \\- 5\\.00\u20AC\n"+
+						MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Broken_path",
+			input: []string{"", "", "beer/fruits", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Categories_underflow_1",
+			input: []string{"", "", "wine", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nested, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "Categories_underflow_2",
+			input: []string{"", "", "all", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowCategories)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(nil, nil)
+			},
+			clientGUID: guids[0],
+		},
+		{
+			name:  "DB_error",
+			input: []string{"", "", "all", "full"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithLimit(0)
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByUpdatedAt, false)
+				s.EXPECT().GetCategoryTree(gomock.Any(), guids[0], gomock.Any()).Return(
+					nil, errors.New("error"))
+			},
+			clientGUID: guids[0],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[4]
+			client := &client{chanID: 1, userGUID: tt.clientGUID}
+
+			showCategoriesAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
 		})
 	}
 }
 
 func Test_showRecordsAction(t *testing.T) {
 
-	guids := []uuid.UUID{
-		uuid.New(),
+	guids := []uuid.UUID{
+		uuid.New(),
+		uuid.New(),
+	}
+
+	tests := []struct {
+		name         string
+		input        []string
+		batch        any
+		categoryGUID uuid.UUID
+		senderBeh    func(*MockSender)
+		serviceBeh   func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:         "Ok",
+			input:        []string{"", "beer"},
+			batch:        any(&repository.RecordOptions{}),
+			categoryGUID: guids[0],
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageAddTimeDetails)
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				category := ftracker.SpendingCategory{
+					Category: "beer",
+					GUID:     guids[0],
+				}
+				s.EXPECT().GetCategories(gomock.Any(), guids[1], gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "No_category_found",
+			input: []string{"", "beer"},
+			batch: any(&repository.RecordOptions{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[1], gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"", "beer"},
+			batch: any(&repository.RecordOptions{}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
+				s.EXPECT().GetCategories(gomock.Any(), guids[1], gomock.Any()).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			cmd := commandsByIDs[5]
+			client := &client{chanID: 1, userGUID: guids[1]}
+
+			showRecordsAction(context.Background(), tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+			if tt.categoryGUID != uuid.Nil {
+				require.Equal(t, tt.categoryGUID, tt.batch.(*repository.RecordOptions).CategoryGUIDs[0])
+			} else {
+				require.True(t, cmd.isLast())
+			}
+		})
+	}
+}
+
+func Test_getTimeBoundariesAction(t *testing.T) {
+
+	guids := []uuid.UUID{
+		uuid.New(),
+	}
+	userGUID := uuid.New()
+	timeNow := time.Now()
+
+	tests := []struct {
+		name         string
+		input        []string
+		batch        any
+		categoryGUID uuid.UUID
+		senderBeh    func(*MockSender)
+		serviceBeh   func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "All_full",
+			input: []string{"", "all", "day", "", "", "full"},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				timeNowStr := timeNow.Format(formatOut)
+				msg := tgbotapi.NewMessage(int64(1),
+					"Subtotal: 24\\.32\u20AC\n\n"+
+						"["+timeNowStr+"] 11\\.22\u20AC \\- test1\n"+
+						"["+timeNowStr+"] 12\\.20\u20AC \\- test2\n"+
+						"["+timeNowStr+"] 0\\.90\u20AC \\(1\\.80$\\) \\- test3\n"+
+						"\n"+MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelRecordsKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo := time.Now()
+				timeFrom := timeTo.AddDate(0, 0, -1)
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(0)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					[]ftracker.SpendingRecord{
+						{Amount: 1122, BaseAmount: 1122, Currency: "EUR", Description: "test1", SpentAt: timeNow},
+						{Amount: 1220, BaseAmount: 1220, Currency: "EUR", Description: "test2", SpentAt: timeNow},
+						{Amount: 180, BaseAmount: 90, Currency: "USD", Description: "test3", SpentAt: timeNow},
+					}, nil)
+			},
+		},
+		{
+			name:  "All",
+			input: []string{"", "all", "month", "", "", ""},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				timeNowStr := timeNow.Format(formatOut)
+				msg := tgbotapi.NewMessage(int64(1),
+					"Subtotal: 24\\.32\u20AC\n\n"+
+						"["+timeNowStr+"] 11\\.22\u20AC\n"+
+						"["+timeNowStr+"] 12\\.20\u20AC\n"+
+						"["+timeNowStr+"] 0\\.90\u20AC \\(1\\.80$\\)\n"+
+						"\n"+MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelRecordsKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo := time.Now()
+				timeFrom := timeTo.AddDate(0, -1, 0)
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(0)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					[]ftracker.SpendingRecord{
+						{Amount: 1122, BaseAmount: 1122, Currency: "EUR", Description: "test1", SpentAt: timeNow},
+						{Amount: 1220, BaseAmount: 1220, Currency: "EUR", Description: "test2", SpentAt: timeNow},
+						{Amount: 180, BaseAmount: 90, Currency: "USD", Description: "test3", SpentAt: timeNow},
+					}, nil)
+			},
+		},
+		{
+			name:  "Limited",
+			input: []string{"", "2", "", "24.02.2025", "26.02.2025", ""},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				timeNowStr := timeNow.Format(formatOut)
+				msg := tgbotapi.NewMessage(int64(1),
+					"Subtotal: 24\\.32\u20AC\n\n"+
+						"["+timeNowStr+"] 11\\.22\u20AC\n"+
+						"["+timeNowStr+"] 12\\.20\u20AC\n"+
+						"["+timeNowStr+"] 0\\.90\u20AC \\(1\\.80$\\)\n"+
+						"\n"+MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelRecordsKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo, _ := time.Parse(formatIn, "26.02.2025")
+				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(2)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					[]ftracker.SpendingRecord{
+						{Amount: 1122, BaseAmount: 1122, Currency: "EUR", Description: "test1", SpentAt: timeNow},
+						{Amount: 1220, BaseAmount: 1220, Currency: "EUR", Description: "test2", SpentAt: timeNow},
+						{Amount: 180, BaseAmount: 90, Currency: "USD", Description: "test3", SpentAt: timeNow},
+					}, nil)
+			},
+		},
+		{
+			name:  "One_side_boundaries",
+			input: []string{"", "all", "", "24.02.2025", "", ""},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				timeNowStr := timeNow.Format(formatOut)
+				msg := tgbotapi.NewMessage(int64(1),
+					"Subtotal: 24\\.32\u20AC\n\n"+
+						"["+timeNowStr+"] 11\\.22\u20AC\n"+
+						"["+timeNowStr+"] 12\\.20\u20AC\n"+
+						"["+timeNowStr+"] 0\\.90\u20AC \\(1\\.80$\\)\n"+
+						"\n"+MessageWantEXEL,
+				)
+				msg.ReplyMarkup = wantExelRecordsKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo := time.Now()
+				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(0)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					[]ftracker.SpendingRecord{
+						{Amount: 1122, BaseAmount: 1122, Currency: "EUR", Description: "test1", SpentAt: timeNow},
+						{Amount: 1220, BaseAmount: 1220, Currency: "EUR", Description: "test2", SpentAt: timeNow},
+						{Amount: 180, BaseAmount: 90, Currency: "USD", Description: "test3", SpentAt: timeNow},
+					}, nil)
+			},
+		},
+		{
+			name:  "No_records",
+			input: []string{"", "all", "month", "", "", ""},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowRecords)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo := time.Now()
+				timeFrom := timeTo.AddDate(0, -1, 0)
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(0)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					[]ftracker.SpendingRecord{}, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"", "all", "month", "", "", ""},
+			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
+				timeTo := time.Now()
+				timeFrom := timeTo.AddDate(0, -1, 0)
+				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(from, to time.Time) interface{} {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return nil
+					})
+				s.EXPECT().SpendingRecordsWithLimit(0)
+				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsBySpentAt, false)
+				s.EXPECT().GetRecords(gomock.Any(), userGUID, gomock.Any()).Return(
+					nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			cmd := commandsByIDs[6]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			getTimeBoundariesAction(context.Background(), tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_returnRecordsExelAction_edit(t *testing.T) {
+
+	timeNow := time.Now()
+	timeNowStr := timeNow.Format(formatOut)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	sender := NewMockSender(controller)
+	msg := tgbotapi.NewMessage(int64(1),
+		MessageEditRecordsHeader+
+			"1\\. ["+timeNowStr+"] 11\\.22\u20AC \\- test1\n"+
+			"2\\. ["+timeNowStr+"] 0\\.90\u20AC \\- test2\n"+
+			MessageEditRecords,
+	)
+	msg.ReplyMarkup = nil
+	sender.EXPECT().Send(msg)
+
+	batch := any([]ftracker.SpendingRecord{
+		{Amount: 1122, Description: "test1", SpentAt: timeNow},
+		{Amount: 90, Description: "test2", SpentAt: timeNow},
+	})
+	cmd := commandsByIDs[7]
+	client := &client{chanID: 1}
+
+	returnRecordsExelAction(context.Background(), []string{CallbackDataEditRecords, CallbackDataEditRecords}, &batch, nil, test_log, sender, client, &cmd)
+	require.False(t, cmd.isLast())
+	require.Equal(t, 9, cmd.next().ID)
+}
+
+func Test_editRecordAction(t *testing.T) {
+
+	guids := []uuid.UUID{
+		uuid.New(),
+		uuid.New(),
+		uuid.New(),
+	}
+	records := []ftracker.SpendingRecord{
+		{GUID: guids[0], Amount: 1122, Description: "test1"},
+		{GUID: guids[1], Amount: 90, Description: "test2"},
+	}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Update_amount",
+			input: []string{"", "2", "", "1.5", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordUpdateSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().UpdateRecords(gomock.Any(), guids[2], []ftracker.SpendingRecord{
+					{GUID: guids[1], Amount: 150, Description: "test2"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:  "Update_with_description",
+			input: []string{"", "1", "", "12", "fixed typo"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordUpdateSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().UpdateRecords(gomock.Any(), guids[2], []ftracker.SpendingRecord{
+					{GUID: guids[0], Amount: 1200, Description: "fixed typo"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:  "Delete",
+			input: []string{"", "1", "delete", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().DeleteRecords(gomock.Any(), guids[2], []uuid.UUID{guids[0]}).Return(nil)
+			},
+		},
+		{
+			name:  "Wrong_number",
+			input: []string{"", "3", "delete", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecordNumberError)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "Zero_amount",
+			input: []string{"", "1", "", "0", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageZeroAmount)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"", "1", "delete", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().DeleteRecords(gomock.Any(), guids[2], gomock.Any()).Return(errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			batch := any(append([]ftracker.SpendingRecord(nil), records...))
+			cmd := commandsByIDs[9]
+			client := &client{chanID: 1, userGUID: guids[2]}
+
+			editRecordAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_showBalanceAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Positive",
+			input: []string{"last month", "month", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), "Income: 100\\.00\u20AC\nSpending: 12\\.50\u20AC\nBalance: 87\\.50\u20AC\n")
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				timeTo := time.Now()
+				timeFrom := timeTo.AddDate(0, -1, 0)
+				s.EXPECT().GetBalance(gomock.Any(), userGUID, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+						require.True(t, from.Sub(timeFrom) < time.Second)
+						require.True(t, to.Sub(timeTo) < time.Second)
+						return ftracker.Balance{Income: 10000, Spending: 1250}, nil
+					})
+			},
+		},
+		{
+			name:  "Negative",
+			input: []string{"24.02.2025 26.02.2025", "", "24.02.2025", "26.02.2025"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), "Income: 0\\.00\u20AC\nSpending: 12\\.50\u20AC\nBalance: \\-12\\.50\u20AC\n")
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
+				timeTo, _ := time.Parse(formatIn, "26.02.2025")
+				s.EXPECT().GetBalance(gomock.Any(), userGUID, timeFrom, timeTo).Return(ftracker.Balance{Spending: 1250}, nil)
+			},
+		},
+		{
+			name:  "Invalid_date",
+			input: []string{"24.13.2025", "", "24.13.2025", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidFromDate)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"day", "day", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetBalance(gomock.Any(), userGUID, gomock.Any(), gomock.Any()).Return(ftracker.Balance{}, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[17]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			showBalanceAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_showTagsAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Ok",
+			input: []string{"24.02.2025 26.02.2025", "", "24.02.2025", "26.02.2025"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageShowTagsHeader+
+					"\\#summer\\_trip \\- 15\\.00\u20AC\n"+
+					"\\#food \\- 2\\.50\u20AC\n")
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
+				timeTo, _ := time.Parse(formatIn, "26.02.2025")
+				s.EXPECT().GetTagSpending(gomock.Any(), userGUID, timeFrom, timeTo).Return([]ftracker.TagSpending{
+					{Tag: "summer_trip", Spent: 1500},
+					{Tag: "food", Spent: 250},
+				}, nil)
+			},
+		},
+		{
+			name:  "Empty",
+			input: []string{"last month", "month", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowTags)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetTagSpending(gomock.Any(), userGUID, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:  "Invalid_date",
+			input: []string{"24.13.2025", "", "24.13.2025", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidFromDate)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"day", "day", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetTagSpending(gomock.Any(), userGUID, gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[23]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			showTagsAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_showBudgetsAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "All",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), ""+
+					fmt.Sprintf("Budgets for %s:\n\n", time.Now().Format(formatMonth))+
+					"food: 85\\.00\u20AC of 100\\.00\u20AC, 15\\.00\u20AC left\n"+
+					"travel: 120\\.50\u20AC of 100\\.00\u20AC, 20\\.50\u20AC over\U0001F6A8\n",
+				)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.BudgetStatus{
+					{Budget: ftracker.Budget{Amount: 10000}, Category: "food", Spent: 8500},
+					{Budget: ftracker.Budget{Amount: 10000}, Category: "travel", Spent: 12050},
+				}, nil)
+			},
+		},
+		{
+			name:  "No_budgets",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowBudgets)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:  "Category_without_budget",
+			input: []string{"food", "food"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoBudgetFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				category := ftracker.SpendingCategory{Category: "food", GUID: uuid.New(), Kind: ftracker.KindSpending}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any(), category.GUID).Return(nil, nil)
+			},
+		},
+		{
+			name:  "No_category_found",
+			input: []string{"food", "food"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any()).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[18]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			showBudgetsAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_setBudgetAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	category := ftracker.SpendingCategory{Category: "food", GUID: uuid.New(), Kind: ftracker.KindSpending}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Set",
+			input: []string{"food 150.5", "food", "", "150.5"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageBudgetSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().SetBudgets(gomock.Any(), userGUID, []ftracker.Budget{{CategoryGUID: category.GUID, Amount: 15050}}).Return(nil)
+			},
+		},
+		{
+			name:  "Delete",
+			input: []string{"food delete", "food", "delete", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageBudgetDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any(), category.GUID).Return([]ftracker.BudgetStatus{{Category: "food"}}, nil)
+				s.EXPECT().DeleteBudgets(gomock.Any(), userGUID, []uuid.UUID{category.GUID}).Return(nil)
+			},
+		},
+		{
+			name:  "Delete_without_budget",
+			input: []string{"food delete", "food", "delete", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoBudgetFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().GetBudgets(gomock.Any(), userGUID, gomock.Any(), category.GUID).Return(nil, nil)
+			},
+		},
+		{
+			name:  "Income_category",
+			input: []string{"salary 100", "salary", "", "100"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageBudgetIncomeCategory)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"salary"})
+				income := ftracker.SpendingCategory{Category: "salary", GUID: uuid.New(), Kind: ftracker.KindIncome}
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{income}, nil)
+			},
+		},
+		{
+			name:  "Zero_amount",
+			input: []string{"food 0", "food", "", "0"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageZeroAmount)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"food 10", "food", "", "10"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().SetBudgets(gomock.Any(), userGUID, gomock.Any()).Return(errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[19]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			setBudgetAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_addRecurringAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	category := ftracker.SpendingCategory{Category: "rent", GUID: uuid.New()}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Ok",
+			input: []string{"rent 500 monthly", "rent", "500", "", "monthly", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecurringSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecurring(gomock.Any(), userGUID, []ftracker.RecurringRecord{{
+					CategoryGUID: category.GUID,
+					Amount:       50000,
+					Description:  "recurring",
+					Schedule:     "monthly",
+				}}).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+		},
+		{
+			name:  "Ok_cron",
+			input: []string{"rent 30.5 USD [0 9 1 * *] flat", "rent", "30.5", "USD", "", "0 9 1 * *", "flat"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecurringSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecurring(gomock.Any(), userGUID, []ftracker.RecurringRecord{{
+					CategoryGUID: category.GUID,
+					Amount:       3050,
+					Currency:     "USD",
+					Description:  "flat",
+					Schedule:     "0 9 1 * *",
+				}}).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+		},
+		{
+			name:  "Invalid_schedule",
+			input: []string{"rent 500 [61 9 1 * *]", "rent", "500", "", "", "61 9 1 * *", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidSchedule)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecurring(gomock.Any(), userGUID, gomock.Any()).Return(nil, fmt.Errorf("AddRecurring: %w", service.ErrInvalidSchedule))
+			},
+		},
+		{
+			name:  "No_category",
+			input: []string{"rent 500 daily", "rent", "500", "", "daily", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"rent 500 daily", "rent", "500", "", "daily", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().AddRecurring(gomock.Any(), userGUID, gomock.Any()).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[20]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			addRecurringAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_showRecurringAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	nextRunAt := time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC)
+	records := []ftracker.RecurringRecord{
+		{GUID: uuid.New(), Category: "rent", Amount: 50000, Currency: ftracker.DefaultCurrency, Schedule: "monthly", NextRunAt: nextRunAt},
+		{GUID: uuid.New(), Category: "gym", Amount: 3050, Currency: "USD", Schedule: "0 9 * * 1-5", NextRunAt: nextRunAt},
+	}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantBatch  any
+		wantLast   bool
+	}{
+		{
+			name:  "All",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), ""+
+					"1\\. rent \\- "+formatAmount(ftracker.Money(50000), ftracker.DefaultCurrency)+" monthly, next on "+nextRunAt.Format(formatOut)+"\n"+
+					"2\\. gym \\- "+formatAmount(ftracker.Money(3050), "USD")+" 0 9 \\* \\* 1\\-5, next on "+nextRunAt.Format(formatOut)+"\n"+
+					MessageDeleteRecurring)
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetRecurring(gomock.Any(), userGUID).Return(records, nil)
+			},
+			wantBatch: records,
+		},
+		{
+			name:  "Empty",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowRecurring)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetRecurring(gomock.Any(), userGUID).Return(nil, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "No_category",
+			input: []string{"rent", "rent"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"rent"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return(nil, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "DB_error",
+			input: []string{"all", "all"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetRecurring(gomock.Any(), userGUID).Return(nil, errors.New("error"))
+			},
+			wantLast: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[21]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			showRecurringAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantBatch, batch)
+			require.Equal(t, tt.wantLast, cmd.isLast())
+		})
+	}
+}
+
+func Test_deleteRecurringAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	records := []ftracker.RecurringRecord{{GUID: uuid.New()}, {GUID: uuid.New()}}
+
+	tests := []struct {
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Ok",
+			input: []string{"2 delete", "2"},
+			batch: records,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecurringDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().DeleteRecurring(gomock.Any(), userGUID, []uuid.UUID{records[1].GUID}).Return(nil)
+			},
+		},
+		{
+			name:  "Wrong_number",
+			input: []string{"3 delete", "3"},
+			batch: records,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageRecurringNumberError)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "Wrong_batch",
+			input: []string{"1 delete", "1"},
+			batch: "wrong",
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInternalError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"1 delete", "1"},
+			batch: records,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().DeleteRecurring(gomock.Any(), userGUID, gomock.Any()).Return(errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			batch := tt.batch
+			cmd := commandsByIDs[22]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			deleteRecurringAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_setCurrencyAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name         string
+		input        []string
+		senderBeh    func(*MockSender)
+		serviceBeh   func(*mock_service.MockServiceInterface)
+		wantCurrency string
+	}{
+		{
+			name:  "Ok",
+			input: []string{"usd", "usd"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageCurrencySuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SetUserCurrency(gomock.Any(), userGUID, "USD").Return(nil)
+			},
+			wantCurrency: "USD",
+		},
+		{
+			name:  "Unknown_currency",
+			input: []string{"XXX", "XXX"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnknownCurrency)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SetUserCurrency(gomock.Any(), userGUID, "XXX").Return(fmt.Errorf("Repository.SetUserCurrency: %w", service.ErrUnknownCurrency))
+			},
+			wantCurrency: "GBP",
+		},
+		{
+			name:  "DB_error",
+			input: []string{"USD", "USD"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SetUserCurrency(gomock.Any(), userGUID, "USD").Return(errors.New("error"))
+			},
+			wantCurrency: "GBP",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[14]
+			client := &client{chanID: 1, userGUID: userGUID, currency: "GBP"}
+
+			setCurrencyAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantCurrency, client.currency)
+		})
+	}
+}
+
+func Test_issueAPITokenAction(t *testing.T) {
+
+	userGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Ok",
+			input: []string{"new", "new"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageAPITokenFormat, "0123abcd"))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().IssueAPIToken(gomock.Any(), userGUID).Return("0123abcd", nil)
+			},
+		},
+		{
+			name:  "DB_error",
+			input: []string{"new", "new"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().IssueAPIToken(gomock.Any(), userGUID).Return("", errors.New("error"))
+			},
+		},
+		{
+			name:  "Internal_#tocken_error",
+			input: []string{"new"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidNumberOfTockensAction+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[24]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			issueAPITokenAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_returnExelAction_csv(t *testing.T) {
+
+	spentAt := time.Date(2024, 11, 26, 18, 30, 0, 0, time.UTC)
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending, Amount: 1122}
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mock_service.NewMockServiceInterface(controller)
+	service.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{food.GUID}).Return(nil)
+	service.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+	service.EXPECT().CreateCSVFromRecords(gomock.Any(), []ftracker.SpendingCategory{food}).Return([]byte("records"), nil)
+	service.EXPECT().CreateCSVFromCategories([]ftracker.SpendingCategory{food}, "USD").Return([]byte("categories"), nil)
+
+	sender := NewMockSender(controller)
+	msg := tgbotapi.NewMessage(int64(1), MessageRecordsExelYes)
+	msg.ReplyMarkup = baseKeyboard
+	sender.EXPECT().Send(msg).Times(2)
+	sender.EXPECT().SendDoc(tgbotapi.NewDocument(int64(1), tgbotapi.FileBytes{Name: csvFilename, Bytes: []byte("records")}))
+	sender.EXPECT().SendDoc(tgbotapi.NewDocument(int64(1), tgbotapi.FileBytes{Name: csvFilename, Bytes: []byte("categories")}))
+
+	client := &client{chanID: 1, userGUID: userGUID, currency: "USD"}
+
+	batch := any([]ftracker.SpendingRecord{
+		{CategoryGUID: food.GUID, Amount: 1122, SpentAt: spentAt},
+		{CategoryGUID: food.GUID, Amount: 90, SpentAt: spentAt},
+	})
+	cmd := commandsByIDs[7]
+	returnRecordsExelAction(context.Background(), []string{CallbackDataCSVRecords, CallbackDataCSVRecords}, &batch, service, test_log, sender, client, &cmd)
+	require.True(t, cmd.isLast())
+
+	batch = any([]ftracker.SpendingCategory{food})
+	cmd = commandsByIDs[8]
+	returnCategoriesExelAction(context.Background(), []string{CallbackDataCSVCategories, CallbackDataCSVCategories}, &batch, service, test_log, sender, client, &cmd)
+	require.True(t, cmd.isLast())
+}
+
+func Test_importRecordsAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	file := "category,amount\nfood,12.34\ntravel,100\n"
+	dryRun := service.ImportOptions{Currency: "EUR", DryRun: true}
+	workbook := "PK\x03\x04workbook"
+	workbookDryRun := service.ImportOptions{Currency: "EUR", CreateCategories: true, DryRun: true}
+	recordGUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantBatch  any
+		wantLast   bool
+	}{
+		{
+			name:  "Ok",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 2))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				gomock.InOrder(
+					s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).Return(service.ImportReport{Imported: 2}, nil),
+					s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), service.ImportOptions{Currency: "EUR"}).Return(service.ImportReport{Imported: 2}, nil),
+				)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "New_categories",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportNewCategoriesFormat,
+					fmt.Sprintf(MessageImportNewCategoryFormat, "travel")+fmt.Sprintf(MessageImportNewCategoryFormat, "car\\.wash"),
+				))
+				msg.ReplyMarkup = importCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).
+					Return(service.ImportReport{Imported: 2, NewCategories: []string{"travel", "car.wash"}}, nil)
+			},
+			wantBatch: []byte(file),
+		},
+		{
+			name:  "Nothing_to_import",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportNothing+MessageImportRejectedHeader+
+					fmt.Sprintf(MessageImportRejectedFormat, 2, "invalid amount 1\\.2\\.3"))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).
+					Return(service.ImportReport{Rejected: []service.RejectedRow{{Line: 2, Reason: "invalid amount 1.2.3"}}}, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Invalid_file",
+			input: []string{"hello", "hello"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportInvalidFile)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).
+					Return(service.ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", service.ErrInvalidCSV))
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Too_many_rows",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportTooManyRowsFormat, service.MaxImportRows))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).
+					Return(service.ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", service.ErrTooManyRows))
+			},
+			wantLast: true,
+		},
+		{
+			name:  "DB_error",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), dryRun).Return(service.ImportReport{}, errors.New("error"))
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Workbook_changes",
+			input: []string{workbook, workbook},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportChangesHeader+
+					fmt.Sprintf(MessageImportChangedRowFormat, 2, fmt.Sprintf(MessageImportChangedFieldFormat, "Amount", "21\\.23", "25\\.00"))+
+					fmt.Sprintf(MessageImportNewRowFormat, 3, fmt.Sprintf(MessageImportNewFieldFormat, "Category", "food")+", "+
+						fmt.Sprintf(MessageImportNewFieldFormat, "Amount", "3\\.50"))+
+					fmt.Sprintf(MessageImportChangesSummaryFormat, 1, 1, 2)+
+					MessageImportApply,
+				)
+				msg.ReplyMarkup = applyImportKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), workbookDryRun).Return(service.ImportReport{
+					Imported:  1,
+					Updated:   1,
+					Unchanged: 2,
+					Changes: []service.RowChange{
+						{Line: 2, GUID: recordGUID, Fields: []service.FieldChange{{Column: "Amount", Old: "21.23", New: "25.00"}}},
+						{Line: 3, Fields: []service.FieldChange{{Column: "Category", New: "food"}, {Column: "Amount", New: "3.50"}}},
+					},
+				}, nil)
+			},
+			wantBatch: []byte(workbook),
+		},
+		{
+			name:  "Workbook_new_categories",
+			input: []string{workbook, workbook},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportChangesHeader+
+					fmt.Sprintf(MessageImportNewRowFormat, 2, fmt.Sprintf(MessageImportNewFieldFormat, "Category", "travel"))+
+					fmt.Sprintf(MessageImportChangesSummaryFormat, 1, 0, 0)+
+					"\n"+fmt.Sprintf(MessageImportNewCategoriesFormat, fmt.Sprintf(MessageImportNewCategoryFormat, "travel")),
+				)
+				msg.ReplyMarkup = importCategoriesKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), workbookDryRun).Return(service.ImportReport{
+					Imported:      1,
+					NewCategories: []string{"travel"},
+					Changes:       []service.RowChange{{Line: 2, Fields: []service.FieldChange{{Column: "Category", New: "travel"}}}},
+				}, nil)
+			},
+			wantBatch: []byte(workbook),
+		},
+		{
+			name:  "Workbook_no_changes",
+			input: []string{workbook, workbook},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportNoChanges)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), workbookDryRun).Return(service.ImportReport{Unchanged: 3}, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Invalid_workbook",
+			input: []string{workbook, workbook},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportInvalidWorkbook)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), workbookDryRun).
+					Return(service.ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", service.ErrInvalidExel))
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Internal_#tocken_error",
+			input: []string{file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInvalidNumberOfTockensAction+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			wantLast:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[25]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			importRecordsAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantLast, cmd.isLast())
+			require.Equal(t, tt.wantBatch, batch)
+		})
+	}
+}
+
+func Test_importDecisionAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	file := []byte("category,amount\ntravel,100\n")
+	workbook := []byte("PK\x03\x04workbook")
+
+	rejected := make([]service.RejectedRow, maxRejectedShown+2)
+	rejectedText := MessageImportRejectedHeader
+	for i := range rejected {
+		rejected[i] = service.RejectedRow{Line: i + 2, Reason: "zero amount"}
+		if i < maxRejectedShown {
+			rejectedText += fmt.Sprintf(MessageImportRejectedFormat, i+2, "zero amount")
+		}
+	}
+	rejectedText += fmt.Sprintf(MessageImportRejectedMoreFormat, 2)
+
+	tests := []struct {
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name:  "Create",
+			input: []string{CallbackDataCreateCategories, CallbackDataCreateCategories},
+			batch: file,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 1)+fmt.Sprintf(MessageImportCreatedFormat, "travel, car\\-wash"))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), service.ImportOptions{Currency: "EUR", CreateCategories: true}).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, r io.Reader, _ service.ImportOptions) (service.ImportReport, error) {
+						content, err := io.ReadAll(r)
+						require.NoError(t, err)
+						require.Equal(t, file, content)
+						return service.ImportReport{Imported: 1, NewCategories: []string{"travel", "car-wash"}}, nil
+					})
+			},
+		},
+		{
+			name:  "Skip",
+			input: []string{CallbackDataSkipCategories, CallbackDataSkipCategories},
+			batch: file,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 3)+rejectedText)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), service.ImportOptions{Currency: "EUR"}).
+					Return(service.ImportReport{Imported: 3, NewCategories: []string{"travel"}, Rejected: rejected}, nil)
+			},
+		},
+		{
+			name:  "Apply_workbook",
+			input: []string{CallbackDataApplyImport, CallbackDataApplyImport},
+			batch: workbook,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportUpdatedFormat, 2))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), service.ImportOptions{Currency: "EUR"}).
+					Return(service.ImportReport{Updated: 2, Unchanged: 1}, nil)
+			},
+		},
+		{
+			name:  "Create_workbook",
+			input: []string{CallbackDataCreateCategories, CallbackDataCreateCategories},
+			batch: workbook,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 1)+fmt.Sprintf(MessageImportUpdatedFormat, 1)+
+					fmt.Sprintf(MessageImportCreatedFormat, "travel"))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsExel(gomock.Any(), userGUID, gomock.Any(), service.ImportOptions{Currency: "EUR", CreateCategories: true}).
+					Return(service.ImportReport{Imported: 1, Updated: 1, NewCategories: []string{"travel"}}, nil)
+			},
+		},
+		{
+			name:  "Cancel",
+			input: []string{CallbackDataCancelImport, CallbackDataCancelImport},
+			batch: file,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportCancelled)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "Wrong_batch",
+			input: []string{CallbackDataSkipCategories, CallbackDataSkipCategories},
+			batch: []ftracker.SpendingRecord{},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInternalError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+		},
+		{
+			name:  "DB_error",
+			input: []string{CallbackDataCreateCategories, CallbackDataCreateCategories},
+			batch: file,
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportRecordsCSV(gomock.Any(), userGUID, gomock.Any(), gomock.Any()).Return(service.ImportReport{}, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			batch := tt.batch
+			cmd := commandsByIDs[26]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			importDecisionAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+		})
+	}
+}
+
+func Test_bankImportAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	postedAt := time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC)
+	matched := ftracker.BankTransaction{ID: "1", PostedAt: postedAt, Amount: 2345, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "LIDL", CategoryGUID: food.GUID}
+	unmatched := ftracker.BankTransaction{ID: "2", PostedAt: postedAt, Amount: 420, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "Café", Description: "coffee & cake"}
+	file := "<OFX></OFX>"
+
+	reviewKeyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("food", CallbackDataBankCategory+food.GUID.String())),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Skip", CallbackDataBankSkip),
+			tgbotapi.NewInlineKeyboardButtonData("Import reviewed", CallbackDataBankDone),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Cancel", CallbackDataBankCancel)),
+	)
+	categoriesOfKind := func(s *mock_service.MockServiceInterface, categories []ftracker.SpendingCategory) {
+		s.EXPECT().SpendingCategoriesWithKinds([]string{ftracker.KindSpending})
+		s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByCategory, true)
+		s.EXPECT().SpendingCategoriesWithLimit(maxReviewCategories)
+		s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any(), gomock.Any(), gomock.Any()).Return(categories, nil)
 	}
 
 	tests := []struct {
-		name         string
-		input        []string
-		batch        any
-		categoryGUID uuid.UUID
-		senderBeh    func(*MockSender)
-		serviceBeh   func(*mock_service.MockServiceInterface)
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantBatch  any
+		wantLast   bool
 	}{
 		{
-			name:         "Ok",
-			input:        []string{"", "beer"},
-			batch:        any(&repository.RecordOptions{}),
-			categoryGUID: guids[0],
+			name:  "All_matched",
+			input: []string{file, file},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageAddTimeDetails)
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageBankStatementFormat, 1, 2)+"\n"+fmt.Sprintf(MessageImportedFormat, 1))
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
-				category := ftracker.SpendingCategory{
-					Category: "beer",
-					GUID:     guids[0],
-				}
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{category}, nil)
+				s.EXPECT().ParseBankStatement(gomock.Any(), userGUID, gomock.Any(), "EUR").
+					Return(service.BankStatement{Transactions: []ftracker.BankTransaction{matched}, Duplicates: 2}, nil)
+				s.EXPECT().ImportBankTransactions(gomock.Any(), userGUID, []ftracker.BankTransaction{matched}).Return(1, nil)
 			},
+			wantBatch: []ftracker.BankTransaction{matched},
+			wantLast:  true,
 		},
 		{
-			name:  "No_category_found",
-			input: []string{"", "beer"},
-			batch: any(&repository.RecordOptions{}),
+			name:  "Review",
+			input: []string{file, file},
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg := tgbotapi.NewMessage(int64(1), ""+
+					"Found 2 new transactions, 0 are already imported\n"+
+					"\nSkipped lines:\n  line 7: zero amount\n"+
+					"\nPlease, pick the category of this transaction, 1 left to review:\n\n"+
+					"*Café*\n4\\.20€ on 01\\.11\\.2024\ncoffee & cake\n\n"+
+					"The other transactions of the payee go there too, and I will remember it for the next statements")
+				msg.ReplyMarkup = &reviewKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ParseBankStatement(gomock.Any(), userGUID, gomock.Any(), "EUR").Return(service.BankStatement{
+					Transactions: []ftracker.BankTransaction{matched, unmatched},
+					Rejected:     []service.RejectedRow{{Line: 7, Reason: "zero amount"}},
+				}, nil)
+				categoriesOfKind(s, []ftracker.SpendingCategory{food})
+			},
+			wantBatch: []ftracker.BankTransaction{matched, unmatched},
+		},
+		{
+			name:  "Nothing_new",
+			input: []string{file, file},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageBankNothing+"\n"+fmt.Sprintf(MessageBankStatementFormat, 0, 3))
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
-				s.EXPECT().GetCategories(gomock.Any()).Return([]ftracker.SpendingCategory{}, nil)
+				s.EXPECT().ParseBankStatement(gomock.Any(), userGUID, gomock.Any(), "EUR").Return(service.BankStatement{Duplicates: 3}, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Invalid_file",
+			input: []string{"hello", "hello"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageBankInvalidFile)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ParseBankStatement(gomock.Any(), userGUID, gomock.Any(), "EUR").
+					Return(service.BankStatement{}, fmt.Errorf("ParseBankStatement: %w", service.ErrUnknownStatement))
 			},
+			wantLast: true,
 		},
 		{
 			name:  "DB_error",
-			input: []string{"", "beer"},
-			batch: any(&repository.RecordOptions{}),
+			input: []string{file, file},
 			senderBeh: func(s *MockSender) {
 				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingCategoriesWithCategories([]string{"beer"})
-				s.EXPECT().GetCategories(gomock.Any()).Return(nil, errors.New("error"))
+				s.EXPECT().ParseBankStatement(gomock.Any(), userGUID, gomock.Any(), "EUR").Return(service.BankStatement{}, errors.New("error"))
 			},
+			wantLast: true,
 		},
 	}
 	for _, tt := range tests {
@@ -559,226 +2992,380 @@ func Test_showRecordsAction(t *testing.T) {
 			sender := NewMockSender(controller)
 			tt.senderBeh(sender)
 
-			cmd := commandsByIDs[5]
-			client := &client{chanID: 1}
+			var batch any
+			cmd := commandsByIDs[27]
+			client := &client{chanID: 1, userGUID: userGUID}
 
-			showRecordsAction(tt.input, &tt.batch, service, test_log, sender, client, &cmd)
-			if tt.categoryGUID != uuid.Nil {
-				require.Equal(t, tt.categoryGUID, tt.batch.(*repository.RecordOptions).CategoryGUIDs[0])
-			} else {
-				require.True(t, cmd.isLast())
-			}
+			bankImportAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantLast, cmd.isLast())
+			require.Equal(t, tt.wantBatch, batch)
 		})
 	}
 }
 
-func Test_getTimeBoundariesAction(t *testing.T) {
+func Test_reviewBankTransactionAction(t *testing.T) {
 
-	guids := []uuid.UUID{
-		uuid.New(),
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	salary := ftracker.SpendingCategory{GUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome}
+	postedAt := time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC)
+	transactions := func() []ftracker.BankTransaction {
+		return []ftracker.BankTransaction{
+			{ID: "1", PostedAt: postedAt, Amount: 420, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "Café"},
+			{ID: "2", PostedAt: postedAt, Amount: 2500, Currency: "EUR", Kind: ftracker.KindIncome, Payee: "ACME"},
+			{ID: "3", PostedAt: postedAt, Amount: 380, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "CAFÉ"},
+		}
+	}
+	pick := func(category ftracker.SpendingCategory) []string {
+		return []string{CallbackDataBankCategory + category.GUID.String(), category.GUID.String(), ""}
+	}
+	choice := func(data string) []string {
+		return []string{data, "", data}
 	}
-	timeNow := time.Now()
 
 	tests := []struct {
-		name         string
-		input        []string
-		batch        any
-		categoryGUID uuid.UUID
-		senderBeh    func(*MockSender)
-		serviceBeh   func(*mock_service.MockServiceInterface)
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantBatch  any
+		wantLast   bool
 	}{
 		{
-			name:  "All_full",
-			input: []string{"", "all", "day", "", "", "full"},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			name:  "Category",
+			input: pick(food),
+			batch: transactions(),
 			senderBeh: func(s *MockSender) {
-				timeNowStr := timeNow.Format(formatOut)
-				msg := tgbotapi.NewMessage(int64(1),
-					"Subtotal: 24\\.32\u20AC\n\n"+
-						"["+timeNowStr+"] 11\\.22\u20AC \\- test1\n"+
-						"["+timeNowStr+"] 12\\.20\u20AC \\- test2\n"+
-						"["+timeNowStr+"] 0\\.90\u20AC \\- test3\n"+
-						"\n"+MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelRecordsKeyboard
+				s.EXPECT().Send(gomock.Any()).Do(func(msg tgbotapi.MessageConfig) {
+					require.Contains(t, msg.Text, "1 left to review")
+					require.Contains(t, msg.Text, "*ACME*")
+					keyboard, ok := msg.ReplyMarkup.(*tgbotapi.InlineKeyboardMarkup)
+					require.True(t, ok)
+					require.Equal(t, CallbackDataBankCategory+salary.GUID.String(), *keyboard.InlineKeyboard[0][0].CallbackData)
+				})
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{food.GUID})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+				s.EXPECT().AddPayeeRules(gomock.Any(), userGUID, []ftracker.PayeeRule{{CategoryGUID: food.GUID, Pattern: "Café"}}).
+					Return([]uuid.UUID{uuid.New()}, nil)
+				s.EXPECT().SpendingCategoriesWithKinds([]string{ftracker.KindIncome})
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByCategory, true)
+				s.EXPECT().SpendingCategoriesWithLimit(maxReviewCategories)
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]ftracker.SpendingCategory{salary}, nil)
+			},
+			wantBatch: func() []ftracker.BankTransaction {
+				want := transactions()
+				want[0].CategoryGUID = food.GUID
+				want[2].CategoryGUID = food.GUID
+				return want
+			}(),
+		},
+		{
+			name:  "Last_category_imports",
+			input: pick(salary),
+			batch: func() []ftracker.BankTransaction {
+				batch := transactions()
+				batch[0].CategoryGUID = food.GUID
+				batch[2].CategoryGUID = food.GUID
+				return batch
+			}(),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 3))
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo := time.Now()
-				timeFrom := timeTo.AddDate(0, 0, -1)
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(0)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					[]ftracker.SpendingRecord{
-						{Amount: 1122, Description: "test1", CreatedAt: timeNow},
-						{Amount: 1220, Description: "test2", CreatedAt: timeNow},
-						{Amount: 90, Description: "test3", CreatedAt: timeNow},
-					}, nil)
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{salary.GUID})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{salary}, nil)
+				s.EXPECT().AddPayeeRules(gomock.Any(), userGUID, gomock.Any()).Return(nil, fmt.Errorf("%w", &pgconn.PgError{Code: "23505"}))
+				s.EXPECT().ImportBankTransactions(gomock.Any(), userGUID, gomock.Len(3)).Return(3, nil)
+			},
+			wantBatch: func() []ftracker.BankTransaction {
+				want := transactions()
+				want[0].CategoryGUID = food.GUID
+				want[1].CategoryGUID = salary.GUID
+				want[2].CategoryGUID = food.GUID
+				return want
+			}(),
+			wantLast: true,
+		},
+		{
+			name:  "Wrong_kind",
+			input: pick(salary),
+			batch: transactions(),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(tgbotapi.NewMessage(int64(1), MessageBankCategoryError))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithGUIDs([]uuid.UUID{salary.GUID})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{salary}, nil)
+			},
+			wantBatch: transactions(),
+		},
+		{
+			name:  "Skip",
+			input: choice(CallbackDataBankSkip),
+			batch: transactions(),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(gomock.Any()).Do(func(msg tgbotapi.MessageConfig) {
+					require.Contains(t, msg.Text, "2 left to review")
+					require.Contains(t, msg.Text, "*ACME*")
+				})
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithKinds([]string{ftracker.KindIncome})
+				s.EXPECT().SpendingCategoriesWithOrder(service.OrderCategoriesByCategory, true)
+				s.EXPECT().SpendingCategoriesWithLimit(maxReviewCategories)
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			wantBatch: transactions()[1:],
+		},
+		{
+			name:  "Done",
+			input: choice(CallbackDataBankDone),
+			batch: func() []ftracker.BankTransaction {
+				batch := transactions()
+				batch[2].CategoryGUID = food.GUID
+				return batch
+			}(),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), fmt.Sprintf(MessageImportedFormat, 1)+fmt.Sprintf(MessageBankSkippedFormat, 2))
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().ImportBankTransactions(gomock.Any(), userGUID, gomock.Len(3)).Return(1, nil)
+			},
+			wantBatch: func() []ftracker.BankTransaction {
+				batch := transactions()
+				batch[2].CategoryGUID = food.GUID
+				return batch
+			}(),
+			wantLast: true,
+		},
+		{
+			name:  "Cancel",
+			input: choice(CallbackDataBankCancel),
+			batch: transactions(),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageImportCancelled)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			wantBatch:  transactions(),
+			wantLast:   true,
+		},
+		{
+			name:  "Wrong_batch",
+			input: choice(CallbackDataBankSkip),
+			batch: []byte("file"),
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageInternalError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
+			wantBatch:  []byte("file"),
+			wantLast:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			batch := tt.batch
+			cmd := commandsByIDs[28]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			reviewBankTransactionAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantLast, cmd.isLast())
+			require.Equal(t, tt.wantBatch, batch)
+		})
+	}
+}
+
+func Test_payeeRulesAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	rules := []ftracker.PayeeRule{
+		{GUID: uuid.New(), CategoryGUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome, Pattern: "acme payroll"},
+		{GUID: uuid.New(), CategoryGUID: food.GUID, Category: "food", Kind: ftracker.KindSpending, Pattern: "lidl"},
+	}
+
+	tests := []struct {
+		name       string
+		input      []string
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+		wantBatch  any
+		wantLast   bool
+	}{
+		{
+			name:  "Add",
+			input: []string{"Lidl = food", "", "Lidl", "food"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessagePayeeRuleSuccess)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+				s.EXPECT().AddPayeeRules(gomock.Any(), userGUID, []ftracker.PayeeRule{{CategoryGUID: food.GUID, Pattern: "Lidl"}}).
+					Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			wantLast: true,
+		},
+		{
+			name:  "Exists",
+			input: []string{"lidl = food", "", "lidl", "food"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessagePayeeRuleExists)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"food"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return([]ftracker.SpendingCategory{food}, nil)
+				s.EXPECT().AddPayeeRules(gomock.Any(), userGUID, gomock.Any()).Return(nil, fmt.Errorf("%w", &pgconn.PgError{Code: "23505"}))
+			},
+			wantLast: true,
+		},
+		{
+			name:  "No_category",
+			input: []string{"lidl = car", "", "lidl", "car"},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageNoCategoryFound)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
 			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().SpendingCategoriesWithCategories([]string{"car"})
+				s.EXPECT().GetCategories(gomock.Any(), userGUID, gomock.Any()).Return(nil, nil)
+			},
+			wantLast: true,
 		},
 		{
 			name:  "All",
-			input: []string{"", "all", "month", "", "", ""},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			input: []string{"all", "all", "", ""},
 			senderBeh: func(s *MockSender) {
-				timeNowStr := timeNow.Format(formatOut)
-				msg := tgbotapi.NewMessage(int64(1),
-					"Subtotal: 24\\.32\u20AC\n\n"+
-						"["+timeNowStr+"] 11\\.22\u20AC\n"+
-						"["+timeNowStr+"] 12\\.20\u20AC\n"+
-						"["+timeNowStr+"] 0\\.90\u20AC\n"+
-						"\n"+MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelRecordsKeyboard
+				msg := tgbotapi.NewMessage(int64(1), ""+
+					"1\\. acme payroll \\- "+MessageIncomeMark+"salary\n"+
+					"2\\. lidl \\- food\n"+
+					MessageDeletePayeeRule)
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo := time.Now()
-				timeFrom := timeTo.AddDate(0, -1, 0)
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(0)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					[]ftracker.SpendingRecord{
-						{Amount: 1122, Description: "test1", CreatedAt: timeNow},
-						{Amount: 1220, Description: "test2", CreatedAt: timeNow},
-						{Amount: 90, Description: "test3", CreatedAt: timeNow},
-					}, nil)
+				s.EXPECT().GetPayeeRules(gomock.Any(), userGUID).Return(rules, nil)
+			},
+			wantBatch: rules,
+		},
+		{
+			name:  "Empty",
+			input: []string{"all", "all", "", ""},
+			senderBeh: func(s *MockSender) {
+				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowPayeeRules)
+				msg.ReplyMarkup = baseKeyboard
+				s.EXPECT().Send(msg)
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {
+				s.EXPECT().GetPayeeRules(gomock.Any(), userGUID).Return(nil, nil)
 			},
+			wantLast: true,
 		},
 		{
-			name:  "Limited",
-			input: []string{"", "2", "", "24.02.2025", "26.02.2025", ""},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			name:  "DB_error",
+			input: []string{"all", "all", "", ""},
 			senderBeh: func(s *MockSender) {
-				timeNowStr := timeNow.Format(formatOut)
-				msg := tgbotapi.NewMessage(int64(1),
-					"Subtotal: 24\\.32\u20AC\n\n"+
-						"["+timeNowStr+"] 11\\.22\u20AC\n"+
-						"["+timeNowStr+"] 12\\.20\u20AC\n"+
-						"["+timeNowStr+"] 0\\.90\u20AC\n"+
-						"\n"+MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelRecordsKeyboard
+				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo, _ := time.Parse(formatIn, "26.02.2025")
-				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(2)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					[]ftracker.SpendingRecord{
-						{Amount: 1122, Description: "test1", CreatedAt: timeNow},
-						{Amount: 1220, Description: "test2", CreatedAt: timeNow},
-						{Amount: 90, Description: "test3", CreatedAt: timeNow},
-					}, nil)
+				s.EXPECT().GetPayeeRules(gomock.Any(), userGUID).Return(nil, errors.New("error"))
 			},
+			wantLast: true,
 		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			var batch any
+			cmd := commandsByIDs[29]
+			client := &client{chanID: 1, userGUID: userGUID}
+
+			payeeRulesAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
+			require.Equal(t, tt.wantBatch, batch)
+			require.Equal(t, tt.wantLast, cmd.isLast())
+		})
+	}
+}
+
+func Test_deletePayeeRuleAction(t *testing.T) {
+
+	userGUID := uuid.New()
+	rules := []ftracker.PayeeRule{{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending, Pattern: "lidl"}}
+
+	tests := []struct {
+		name       string
+		input      []string
+		batch      any
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
 		{
-			name:  "One_side_boundaries",
-			input: []string{"", "all", "", "24.02.2025", "", ""},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			name:  "Ok",
+			input: []string{"1 delete", "1"},
+			batch: rules,
 			senderBeh: func(s *MockSender) {
-				timeNowStr := timeNow.Format(formatOut)
-				msg := tgbotapi.NewMessage(int64(1),
-					"Subtotal: 24\\.32\u20AC\n\n"+
-						"["+timeNowStr+"] 11\\.22\u20AC\n"+
-						"["+timeNowStr+"] 12\\.20\u20AC\n"+
-						"["+timeNowStr+"] 0\\.90\u20AC\n"+
-						"\n"+MessageWantEXEL,
-				)
-				msg.ReplyMarkup = wantExelRecordsKeyboard
+				msg := tgbotapi.NewMessage(int64(1), MessagePayeeRuleDeleteSuccess)
+				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo := time.Now()
-				timeFrom, _ := time.Parse(formatIn, "24.02.2025")
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(0)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					[]ftracker.SpendingRecord{
-						{Amount: 1122, Description: "test1", CreatedAt: timeNow},
-						{Amount: 1220, Description: "test2", CreatedAt: timeNow},
-						{Amount: 90, Description: "test3", CreatedAt: timeNow},
-					}, nil)
+				s.EXPECT().DeletePayeeRules(gomock.Any(), userGUID, []uuid.UUID{rules[0].GUID}).Return(nil)
 			},
 		},
 		{
-			name:  "No_records",
-			input: []string{"", "all", "month", "", "", ""},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			name:  "Wrong_number",
+			input: []string{"2 delete", "2"},
+			batch: rules,
 			senderBeh: func(s *MockSender) {
-				msg := tgbotapi.NewMessage(int64(1), MessageUnderflowRecords)
+				msg := tgbotapi.NewMessage(int64(1), MessagePayeeRuleNumberError)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
-			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo := time.Now()
-				timeFrom := timeTo.AddDate(0, -1, 0)
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(0)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					[]ftracker.SpendingRecord{}, nil)
-			},
+			serviceBeh: func(s *mock_service.MockServiceInterface) {},
 		},
 		{
 			name:  "DB_error",
-			input: []string{"", "all", "month", "", "", ""},
-			batch: any(&repository.RecordOptions{CategoryGUIDs: guids}),
+			input: []string{"1 delete", "1"},
+			batch: rules,
 			senderBeh: func(s *MockSender) {
 				msg := tgbotapi.NewMessage(int64(1), MessageDatabaseError+"\n"+internalErrorAditionalInfo)
 				msg.ReplyMarkup = baseKeyboard
 				s.EXPECT().Send(msg)
 			},
 			serviceBeh: func(s *mock_service.MockServiceInterface) {
-				s.EXPECT().SpendingRecordsWithCategoryGUIDs(guids)
-				timeTo := time.Now()
-				timeFrom := timeTo.AddDate(0, -1, 0)
-				s.EXPECT().SpendingRecordsWithTimeFrame(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(from, to time.Time) interface{} {
-						require.True(t, from.Sub(timeFrom) < time.Second)
-						require.True(t, to.Sub(timeTo) < time.Second)
-						return nil
-					})
-				s.EXPECT().SpendingRecordsWithLimit(0)
-				s.EXPECT().SpendingRecordsWithOrder(service.OrderRecordsByCreatedAt, false)
-				s.EXPECT().GetRecords(gomock.Any()).Return(
-					nil, errors.New("error"))
+				s.EXPECT().DeletePayeeRules(gomock.Any(), userGUID, gomock.Any()).Return(errors.New("error"))
 			},
 		},
 	}
@@ -792,10 +3379,11 @@ func Test_getTimeBoundariesAction(t *testing.T) {
 			sender := NewMockSender(controller)
 			tt.senderBeh(sender)
 
-			cmd := commandsByIDs[6]
-			client := &client{chanID: 1}
+			batch := tt.batch
+			cmd := commandsByIDs[30]
+			client := &client{chanID: 1, userGUID: userGUID}
 
-			getTimeBoundariesAction(tt.input, &tt.batch, service, test_log, sender, client, &cmd)
+			deletePayeeRuleAction(context.Background(), tt.input, &batch, service, test_log, sender, client, &cmd)
 		})
 	}
 }
@@ -820,6 +3408,18 @@ func Test_command_validateInput(t *testing.T) {
 			input: "!_sH1pU4kA_!",
 			want:  []string(nil),
 		},
+		{
+			name:  "Cat_name_path_ok",
+			cmdID: 1,
+			input: "food/groceries",
+			want:  []string{"food/groceries", "food/groceries"},
+		},
+		{
+			name:  "Cat_name_path_err",
+			cmdID: 1,
+			input: "food//groceries",
+			want:  []string(nil),
+		},
 		{
 			name:  "Cat_descr_ok",
 			cmdID: 2,
@@ -836,18 +3436,180 @@ func Test_command_validateInput(t *testing.T) {
 			name:  "Record_ok",
 			cmdID: 3,
 			input: "category 100.5 description",
-			want:  []string{"category 100.5 description", "category", "100.5", "description"},
+			want:  []string{"category 100.5 description", "category", "100.5", "", "description", ""},
+		},
+		{
+			name:  "Record_ok_subcategory",
+			cmdID: 3,
+			input: "food/groceries 100.5 milk",
+			want:  []string{"food/groceries 100.5 milk", "food/groceries", "100.5", "", "milk", ""},
 		},
 		{
 			name:  "Record_ok_no_descr",
 			cmdID: 3,
 			input: "category 100.5",
-			want:  []string{"category 100.5", "category", "100.5", ""},
+			want:  []string{"category 100.5", "category", "100.5", "", "", ""},
+		},
+		{
+			name:  "Record_ok_currency",
+			cmdID: 3,
+			input: "category 100.5 USD taxi",
+			want:  []string{"category 100.5 USD taxi", "category", "100.5", "USD", "taxi", ""},
+		},
+		{
+			name:  "Record_ok_attached_currency",
+			cmdID: 3,
+			input: "category 100.5GBP",
+			want:  []string{"category 100.5GBP", "category", "100.5", "GBP", "", ""},
+		},
+		{
+			name:  "Record_ok_uppercase_descr",
+			cmdID: 3,
+			input: "category 100.5 TAXI",
+			want:  []string{"category 100.5 TAXI", "category", "100.5", "", "TAXI", ""},
+		},
+		{
+			name:  "Record_ok_tags",
+			cmdID: 3,
+			input: "category 12.50 dinner #vacation #summer_2025",
+			want:  []string{"category 12.50 dinner #vacation #summer_2025", "category", "12.50", "", "dinner #vacation #summer_2025", ""},
+		},
+		{
+			name:  "Tags_ok",
+			cmdID: 23,
+			input: "last month",
+			want:  []string{"last month", "month", "", ""},
+		},
+		{
+			name:  "Record_ok_spent_at",
+			cmdID: 3,
+			input: "category 12.50 dinner @15.03.2025",
+			want:  []string{"category 12.50 dinner @15.03.2025", "category", "12.50", "", "dinner", "15.03.2025"},
+		},
+		{
+			name:  "Record_ok_spent_at_no_descr",
+			cmdID: 3,
+			input: "category 12.50 EUR @15.03.2025",
+			want:  []string{"category 12.50 EUR @15.03.2025", "category", "12.50", "EUR", "", "15.03.2025"},
+		},
+		{
+			name:  "Record_err_spent_at",
+			cmdID: 3,
+			input: "category 12.50 dinner @15.03.25",
+			want:  []string(nil),
+		},
+		{
+			name:  "Balance_ymd",
+			cmdID: 17,
+			input: "last year",
+			want:  []string{"last year", "year", "", ""},
+		},
+		{
+			name:  "Balance_range",
+			cmdID: 17,
+			input: "02.11.2024 16.11.2024",
+			want:  []string{"02.11.2024 16.11.2024", "", "02.11.2024", "16.11.2024"},
+		},
+		{
+			name:  "Balance_err",
+			cmdID: 17,
+			input: "all last year",
+			want:  []string(nil),
+		},
+		{
+			name:  "Show_budgets_ok",
+			cmdID: 18,
+			input: "all",
+			want:  []string{"all", "all"},
+		},
+		{
+			name:  "Show_budgets_subcategory",
+			cmdID: 18,
+			input: "food/groceries",
+			want:  []string{"food/groceries", "food/groceries"},
+		},
+		{
+			name:  "Set_budget_subcategory",
+			cmdID: 19,
+			input: "food/groceries 150.5",
+			want:  []string{"food/groceries 150.5", "food/groceries", "", "150.5"},
+		},
+		{
+			name:  "Set_budget_ok",
+			cmdID: 19,
+			input: "food 150.5",
+			want:  []string{"food 150.5", "food", "", "150.5"},
+		},
+		{
+			name:  "Set_budget_delete",
+			cmdID: 19,
+			input: "food delete",
+			want:  []string{"food delete", "food", "delete", ""},
+		},
+		{
+			name:  "Set_budget_err",
+			cmdID: 19,
+			input: "food -150",
+			want:  []string(nil),
+		},
+		{
+			name:  "Add_recurring_ok",
+			cmdID: 20,
+			input: "rent 500 monthly",
+			want:  []string{"rent 500 monthly", "rent", "500", "", "monthly", "", ""},
+		},
+		{
+			name:  "Add_recurring_cron",
+			cmdID: 20,
+			input: "gym 30.5 USD [0 9 1,15 * *] yearly pass",
+			want:  []string{"gym 30.5 USD [0 9 1,15 * *] yearly pass", "gym", "30.5", "USD", "", "0 9 1,15 * *", "yearly pass"},
+		},
+		{
+			name:  "Add_recurring_err",
+			cmdID: 20,
+			input: "rent 500 yearly",
+			want:  []string(nil),
+		},
+		{
+			name:  "Show_recurring_ok",
+			cmdID: 21,
+			input: "all",
+			want:  []string{"all", "all"},
+		},
+		{
+			name:  "Show_recurring_subcategory",
+			cmdID: 21,
+			input: "food/groceries",
+			want:  []string{"food/groceries", "food/groceries"},
+		},
+		{
+			name:  "Delete_recurring_ok",
+			cmdID: 22,
+			input: "2 delete",
+			want:  []string{"2 delete", "2"},
+		},
+		{
+			name:  "Delete_recurring_err",
+			cmdID: 22,
+			input: "delete",
+			want:  []string(nil),
+		},
+		{
+			name:  "Currency_ok",
+			cmdID: 14,
+			input: "usd",
+			want:  []string{"usd", "usd"},
+		},
+		{
+			name:  "Currency_err",
+			cmdID: 14,
+			input: "dollars",
+			want:  []string(nil),
 		},
 		{
 			name:  "Record_err_amount",
 			cmdID: 3,
-			input: "category 100.512 description",
+			input: "category 100.5.12 description",
 			want:  []string(nil),
 		},
 		{
@@ -874,6 +3636,12 @@ func Test_command_validateInput(t *testing.T) {
 			input: "beer",
 			want:  []string{"beer", "", "beer", ""},
 		},
+		{
+			name:  "Show_cat_path_ok",
+			cmdID: 4,
+			input: "food/groceries full",
+			want:  []string{"food/groceries full", "", "food/groceries", "full"},
+		},
 		{
 			name:  "Show_cat_err_1",
 			cmdID: 4,
@@ -886,6 +3654,18 @@ func Test_command_validateInput(t *testing.T) {
 			input: "category",
 			want:  []string{"category", "category"},
 		},
+		{
+			name:  "Show_rec_path_ok",
+			cmdID: 5,
+			input: "food/groceries",
+			want:  []string{"food/groceries", "food/groceries"},
+		},
+		{
+			name:  "Show_rec_path_err",
+			cmdID: 5,
+			input: "food/",
+			want:  []string(nil),
+		},
 		{
 			name:  "Show_rec_err",
 			cmdID: 5,
@@ -928,87 +3708,177 @@ func Test_command_validateInput(t *testing.T) {
 			input: "-4 02.02.2025",
 			want:  []string(nil),
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cmd := commandsByIDs[tt.cmdID]
-			if got := cmd.validateInput(tt.input); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("command.validateInput() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_splitAmount(t *testing.T) {
-	tests := []struct {
-		name      string
-		amount    any
-		wantLeft  string
-		wantRignt string
-	}{
 		{
-			name:      "String_amount_no_decimal",
-			amount:    "123",
-			wantLeft:  "123",
-			wantRignt: "00",
+			name:  "Records_exel_edit_ok",
+			cmdID: 7,
+			input: CallbackDataEditRecords,
+			want:  []string{CallbackDataEditRecords, CallbackDataEditRecords},
+		},
+		{
+			name:  "Edit_record_ok",
+			cmdID: 9,
+			input: "2 12.5 new description",
+			want:  []string{"2 12.5 new description", "2", "", "12.5", "new description"},
+		},
+		{
+			name:  "Edit_record_no_descr_ok",
+			cmdID: 9,
+			input: "2 12.5",
+			want:  []string{"2 12.5", "2", "", "12.5", ""},
+		},
+		{
+			name:  "Edit_record_delete_ok",
+			cmdID: 9,
+			input: "1 delete",
+			want:  []string{"1 delete", "1", "delete", "", ""},
+		},
+		{
+			name:  "Edit_category_name_ok",
+			cmdID: 11,
+			input: "name craft beer",
+			want:  []string{"name craft beer", "craft beer", ""},
+		},
+		{
+			name:  "Edit_category_descr_ok",
+			cmdID: 11,
+			input: "description money spent on beer, mostly",
+			want:  []string{"description money spent on beer, mostly", "", "money spent on beer, mostly"},
+		},
+		{
+			name:  "Edit_category_err",
+			cmdID: 11,
+			input: "craft beer",
+			want:  []string(nil),
+		},
+		{
+			name:  "Delete_category_ok",
+			cmdID: 13,
+			input: "delete",
+			want:  []string{"delete", "delete", ""},
+		},
+		{
+			name:  "Delete_category_move_ok",
+			cmdID: 13,
+			input: "move drinks",
+			want:  []string{"move drinks", "", "drinks"},
 		},
 		{
-			name:      "String_amount_one_decimal",
-			amount:    "123.4",
-			wantLeft:  "123",
-			wantRignt: "40",
+			name:  "Delete_category_err",
+			cmdID: 13,
+			input: "move",
+			want:  []string(nil),
+		},
+		{
+			name:  "Edit_record_err",
+			cmdID: 9,
+			input: "1 delete 12.5",
+			want:  []string(nil),
+		},
+		{
+			name:  "API_token_ok",
+			cmdID: 24,
+			input: "new",
+			want:  []string{"new", "new"},
+		},
+		{
+			name:  "API_token_err",
+			cmdID: 24,
+			input: "old",
+			want:  []string(nil),
+		},
+		{
+			name:  "Records_csv_ok",
+			cmdID: 7,
+			input: CallbackDataCSVRecords,
+			want:  []string{CallbackDataCSVRecords, CallbackDataCSVRecords},
+		},
+		{
+			name:  "Categories_csv_ok",
+			cmdID: 8,
+			input: CallbackDataCSVCategories,
+			want:  []string{CallbackDataCSVCategories, CallbackDataCSVCategories},
+		},
+		{
+			name:  "Import_ok",
+			cmdID: 25,
+			input: "category,amount\nfood,12.34\n",
+			want:  []string{"category,amount\nfood,12.34\n", "category,amount\nfood,12.34\n"},
+		},
+		{
+			name:  "Import_err",
+			cmdID: 25,
+			input: " \n ",
+			want:  []string(nil),
+		},
+		{
+			name:  "Import_decision_ok",
+			cmdID: 26,
+			input: CallbackDataSkipCategories,
+			want:  []string{CallbackDataSkipCategories, CallbackDataSkipCategories},
+		},
+		{
+			name:  "Import_apply_ok",
+			cmdID: 26,
+			input: CallbackDataApplyImport,
+			want:  []string{CallbackDataApplyImport, CallbackDataApplyImport},
+		},
+		{
+			name:  "Import_workbook_ok",
+			cmdID: 25,
+			input: "PK\x03\x04\x14\x00\x06\x00\x08\x00\x00\x00!\x00\xff",
+			want:  []string{"PK\x03\x04\x14\x00\x06\x00\x08\x00\x00\x00!\x00\xff", "PK\x03\x04\x14\x00\x06\x00\x08\x00\x00\x00!\x00\xff"},
 		},
 		{
-			name:      "String_amount_two_decimals",
-			amount:    "123.45",
-			wantLeft:  "123",
-			wantRignt: "45",
+			name:  "Import_decision_err",
+			cmdID: 26,
+			input: "skip",
+			want:  []string(nil),
 		},
 		{
-			name:      "String_amount_zero_decimal",
-			amount:    "123.00",
-			wantLeft:  "123",
-			wantRignt: "00",
+			name:  "Bank_review_category_ok",
+			cmdID: 28,
+			input: CallbackDataBankCategory + "0a1b2c3d-0000-4000-8000-000000000001",
+			want: []string{
+				CallbackDataBankCategory + "0a1b2c3d-0000-4000-8000-000000000001",
+				"0a1b2c3d-0000-4000-8000-000000000001", "",
+			},
 		},
 		{
-			name:      "Uint32_amount",
-			amount:    uint32(12345),
-			wantLeft:  "123",
-			wantRignt: "45",
+			name:  "Bank_review_choice_ok",
+			cmdID: 28,
+			input: CallbackDataBankDone,
+			want:  []string{CallbackDataBankDone, "", CallbackDataBankDone},
 		},
 		{
-			name:      "Uint64_amount",
-			amount:    uint64(12345),
-			wantLeft:  "123",
-			wantRignt: "45",
+			name:  "Bank_review_err",
+			cmdID: 28,
+			input: CallbackDataBankCategory + "food",
+			want:  []string(nil),
 		},
 		{
-			name:      "String_amount_no_decimal_zero",
-			amount:    "0",
-			wantLeft:  "0",
-			wantRignt: "00",
+			name:  "Payee_rule_ok",
+			cmdID: 29,
+			input: "Lidl Sagt Danke = food/groceries",
+			want:  []string{"Lidl Sagt Danke = food/groceries", "", "Lidl Sagt Danke", "food/groceries"},
 		},
 		{
-			name:      "Uint32_amount_zero",
-			amount:    uint32(0),
-			wantLeft:  "0",
-			wantRignt: "00",
+			name:  "Payee_rules_all_ok",
+			cmdID: 29,
+			input: "all",
+			want:  []string{"all", "all", "", ""},
 		},
 		{
-			name:      "Uint64_amount_zero",
-			amount:    uint64(0),
-			wantLeft:  "0",
-			wantRignt: "00",
+			name:  "Payee_rule_err",
+			cmdID: 29,
+			input: "lidl food",
+			want:  []string(nil),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotLeft, gotRignt := utils.ExtractAmountParts(tt.amount)
-			if gotLeft != tt.wantLeft {
-				t.Errorf("splitAmount() gotLeft = %v, want %v", gotLeft, tt.wantLeft)
-			}
-			if gotRignt != tt.wantRignt {
-				t.Errorf("splitAmount() gotRignt = %v, want %v", gotRignt, tt.wantRignt)
+			cmd := commandsByIDs[tt.cmdID]
+			if got := cmd.validateInput(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("command.validateInput() = %v, want %v", got, tt.want)
 			}
 		})
 	}
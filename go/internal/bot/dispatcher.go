@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// number of the workers handling the updates if it is not configured
+	defaultWorkers = 16
+	// number of the updates waiting for every worker if it is not configured
+	defaultQueueSize = 64
+
+	// time the webhook waits for a full queue before asking Telegram to deliver the update later
+	webhookDispatchWait = 5 * time.Second
+)
+
+// DispatcherConfig is a struct that configures handling of the updates
+//
+//   - Workers: the number of the updates handled in parallel, the updates of a chat are always handled by the same worker
+//
+//   - QueueSize: the number of the updates waiting for every worker, when the queue is full,
+//     receiving of the updates waits for a room in it
+type DispatcherConfig struct {
+	Workers   int
+	QueueSize int
+}
+
+// dispatcher shards the updates by the chat onto the workers,
+// so the updates of a chat are handled in the order they came and the chats don't wait for each other
+//
+//   - log: a logger to log messages
+//
+//   - handle: the function handling an update
+//
+//   - queues: the queues of the updates, one per worker
+//
+//   - workers: the running workers
+type dispatcher struct {
+	log     *logrus.Logger
+	handle  func(ctx context.Context, update tgbotapi.Update)
+	queues  []chan tgbotapi.Update
+	workers sync.WaitGroup
+}
+
+// newDispatcher creates a new instance of dispatcher, the config values not set are replaced with the defaults.
+//
+// Parameters:
+//   - config: The number of the workers and the size of their queues.
+//   - handle: The function handling an update.
+//   - log: A logger to log messages.
+//
+// Returns:
+//   - A pointer to the dispatcher, it handles nothing until it is started.
+func newDispatcher(config DispatcherConfig, handle func(ctx context.Context, update tgbotapi.Update), log *logrus.Logger) *dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+
+	d := &dispatcher{
+		log:    log,
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, config.Workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, config.QueueSize)
+	}
+	return d
+}
+
+// start starts the workers handling the updates with the context
+func (d *dispatcher) start(ctx context.Context) {
+	for _, queue := range d.queues {
+		d.workers.Add(1)
+		go func(queue chan tgbotapi.Update) {
+			defer d.workers.Done()
+			for update := range queue {
+				d.handle(ctx, update)
+			}
+		}(queue)
+	}
+}
+
+// stop waits for the workers to handle the updates left in the queues and stops them, but no longer than grace,
+// nothing can be dispatched after that, returns false if some of the workers are still handling the updates
+func (d *dispatcher) stop(grace time.Duration) bool {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// dispatch puts the update into the queue of the worker of its chat,
+// if the queue is full, it waits for a room in it, so the updates are received no faster than they are handled.
+//
+// Parameters:
+//   - ctx: The context limiting the waiting for a full queue.
+//   - update: The update to be handled.
+//
+// Returns:
+//   - error: An error if the context is done before the update is queued, otherwise nil.
+func (d *dispatcher) dispatch(ctx context.Context, update tgbotapi.Update) error {
+	queue := d.queues[d.shard(update)]
+
+	select {
+	case queue <- update:
+		return nil
+	default:
+	}
+
+	d.log.Warnf("queue of update %d is full, waiting", update.UpdateID)
+	select {
+	case queue <- update:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("dispatcher.dispatch: update %d: %w", update.UpdateID, ctx.Err())
+	}
+}
+
+// shard returns the index of the worker handling the updates of the chat of the update,
+// the updates without a chat are sharded by the user
+func (d *dispatcher) shard(update tgbotapi.Update) int {
+	var key int64
+	if chat := update.FromChat(); chat != nil {
+		key = chat.ID
+	} else if user := update.SentFrom(); user != nil {
+		key = user.ID
+	}
+
+	shard := key % int64(len(d.queues))
+	if shard < 0 {
+		shard = -shard // the IDs of the group chats are negative
+	}
+	return int(shard)
+}
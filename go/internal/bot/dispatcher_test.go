@@ -0,0 +1,288 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newChatUpdate(updateID int, chatID int64, text string) tgbotapi.Update {
+	update := tgbotapi.Update{
+		UpdateID: updateID,
+		Message: &tgbotapi.Message{
+			Text: text,
+			Chat: &tgbotapi.Chat{ID: chatID},
+			From: &tgbotapi.User{ID: chatID, UserName: "test_username"},
+		},
+	}
+	if len(text) > 0 && text[0] == '/' {
+		update.Message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(text)}}
+	}
+	return update
+}
+
+func Test_dispatcher_shard(t *testing.T) {
+
+	tt := []struct {
+		name   string
+		update tgbotapi.Update
+		want   int
+	}{
+		{
+			name:   "Message",
+			update: newChatUpdate(1, 13, "test"),
+			want:   5,
+		},
+		{
+			name: "Callback",
+			update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+				From:    &tgbotapi.User{ID: 1},
+				Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 13}},
+			}},
+			want: 5,
+		},
+		{
+			name:   "Group_chat",
+			update: newChatUpdate(1, -13, "test"),
+			want:   5,
+		},
+		{
+			name:   "No_chat",
+			update: tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{From: &tgbotapi.User{ID: 10}}},
+			want:   2,
+		},
+		{
+			name:   "Nothing",
+			update: tgbotapi.Update{},
+			want:   0,
+		},
+	}
+
+	d := newDispatcher(DispatcherConfig{Workers: 8}, func(context.Context, tgbotapi.Update) {}, test_log)
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, d.shard(tc.update))
+		})
+	}
+}
+
+func Test_dispatcher_order(t *testing.T) {
+
+	const (
+		chats   = 40
+		updates = 50
+	)
+
+	var (
+		mu      sync.Mutex
+		handled = make(map[int64][]int)
+	)
+	d := newDispatcher(DispatcherConfig{Workers: 4, QueueSize: 2}, func(_ context.Context, update tgbotapi.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		handled[chatID] = append(handled[chatID], update.UpdateID)
+	}, test_log)
+	d.start(context.Background())
+
+	for i := 0; i < updates; i++ {
+		for chatID := int64(1); chatID <= chats; chatID++ {
+			require.NoError(t, d.dispatch(context.Background(), newChatUpdate(i, chatID, "test")))
+		}
+	}
+	require.True(t, d.stop(5*time.Second))
+
+	require.Len(t, handled, chats)
+	for chatID, ids := range handled {
+		require.Len(t, ids, updates, "chat %d", chatID)
+		for i, id := range ids {
+			require.Equal(t, i, id, "updates of chat %d are out of order", chatID)
+		}
+	}
+}
+
+func Test_dispatcher_parallel(t *testing.T) {
+
+	release := make(chan struct{})
+	handled := make(chan int64, 1)
+	d := newDispatcher(DispatcherConfig{Workers: 2}, func(_ context.Context, update tgbotapi.Update) {
+		if update.Message.Chat.ID == 1 {
+			<-release // a slow chat
+			return
+		}
+		handled <- update.Message.Chat.ID
+	}, test_log)
+	d.start(context.Background())
+	defer d.stop(5 * time.Second)
+	defer close(release)
+
+	require.NoError(t, d.dispatch(context.Background(), newChatUpdate(1, 1, "test")))
+	require.NoError(t, d.dispatch(context.Background(), newChatUpdate(2, 2, "test")))
+
+	select {
+	case chatID := <-handled:
+		require.Equal(t, int64(2), chatID)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the slow chat stalls the other one")
+	}
+}
+
+func Test_dispatcher_backpressure(t *testing.T) {
+
+	release := make(chan struct{})
+	var (
+		mu      sync.Mutex
+		handled []int
+	)
+	d := newDispatcher(DispatcherConfig{Workers: 1, QueueSize: 1}, func(_ context.Context, update tgbotapi.Update) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, update.UpdateID)
+	}, test_log)
+	d.start(context.Background())
+
+	require.NoError(t, d.dispatch(context.Background(), newChatUpdate(1, 1, "test"))) // taken by the worker
+	require.Eventually(t, func() bool { return len(d.queues[0]) == 0 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, d.dispatch(context.Background(), newChatUpdate(2, 1, "test"))) // fills the queue
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, d.dispatch(ctx, newChatUpdate(3, 1, "test")), context.DeadlineExceeded)
+
+	queued := make(chan error)
+	go func() {
+		queued <- d.dispatch(context.Background(), newChatUpdate(4, 1, "test"))
+	}()
+	select {
+	case <-queued:
+		require.FailNow(t, "the update is queued while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-queued)
+	require.True(t, d.stop(5*time.Second))
+	require.Equal(t, []int{1, 2, 4}, handled)
+}
+
+func Test_dispatcher_stop_grace(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+	d := newDispatcher(DispatcherConfig{Workers: 1}, func(context.Context, tgbotapi.Update) {
+		<-release // a stuck update
+	}, test_log)
+	d.start(context.Background())
+	require.NoError(t, d.dispatch(context.Background(), newChatUpdate(1, 1, "test")))
+
+	begin := time.Now()
+	require.False(t, d.stop(50*time.Millisecond))
+	require.Less(t, time.Since(begin), 5*time.Second, "the stop is not bounded by the grace")
+}
+
+func TestTelegramBot_HandleUpdate_stopped(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockSender := NewMockSender(controller)
+	mockSender.EXPECT().Send(tgbotapi.NewMessage(1, MessageAbort)).Times(2)
+
+	b := &TelegramBot{
+		sender:   mockSender,
+		sessions: NewMockSessions(controller), // no session is looked up
+		log:      test_log,
+	}
+	b.updates = newDispatcher(DispatcherConfig{Workers: 1}, b.HandleUpdate, test_log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, b.updates.dispatch(ctx, newChatUpdate(1, 1, CommandShowCategories)))
+	require.NoError(t, b.updates.dispatch(ctx, newChatUpdate(2, 1, "food")))
+	require.NoError(t, b.updates.dispatch(ctx, tgbotapi.Update{UpdateID: 3})) // no chat to answer
+	cancel()
+
+	b.updates.start(ctx)
+	require.True(t, b.updates.stop(5*time.Second))
+}
+
+func TestTelegramBot_dispatch_load(t *testing.T) {
+
+	const (
+		chats   = 64
+		rounds  = 5
+		workers = 8
+		dbDelay = 2 * time.Millisecond // a slow DB call for every /abort
+	)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	var (
+		mu     sync.Mutex
+		events = make(map[int64][]string)
+	)
+	record := func(chatID int64, event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[chatID] = append(events[chatID], event)
+	}
+
+	mockSender := NewMockSender(controller)
+	mockSender.EXPECT().Send(gomock.Any()).Do(func(msg tgbotapi.MessageConfig) {
+		record(msg.ChatID, "start")
+	}).Times(chats * rounds)
+
+	mockSessions := NewMockSessions(controller)
+	mockSessions.EXPECT().TerminateSession(gomock.Any()).DoAndReturn(func(chatID int64) error {
+		time.Sleep(dbDelay)
+		record(chatID, "abort")
+		return nil
+	}).Times(chats * rounds)
+
+	b := &TelegramBot{
+		sender:   mockSender,
+		sessions: mockSessions,
+		log:      test_log,
+	}
+	b.updates = newDispatcher(DispatcherConfig{Workers: workers, QueueSize: 4}, b.HandleUpdate, test_log)
+	b.updates.start(context.Background())
+
+	begin := time.Now()
+	var wg sync.WaitGroup
+	for chatID := int64(1); chatID <= chats; chatID++ {
+		wg.Add(1)
+		go func(chatID int64) { // the updates of a chat come in order, the chats race each other
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if err := b.updates.dispatch(context.Background(), newChatUpdate(2*i, chatID, "/start")); err != nil {
+					t.Error(err)
+				}
+				if err := b.updates.dispatch(context.Background(), newChatUpdate(2*i+1, chatID, "/abort")); err != nil {
+					t.Error(err)
+				}
+			}
+		}(chatID)
+	}
+	wg.Wait()
+	require.True(t, b.updates.stop(5*time.Second))
+	elapsed := time.Since(begin)
+
+	require.Less(t, elapsed, chats*rounds*dbDelay, "the chats are not handled in parallel")
+	require.Len(t, events, chats)
+	for chatID, chatEvents := range events {
+		require.Len(t, chatEvents, 2*rounds, "chat %d", chatID)
+		for i, event := range chatEvents {
+			want := "start"
+			if i%2 == 1 {
+				want = "abort"
+			}
+			require.Equal(t, want, event, "updates of chat %d are out of order", chatID)
+		}
+	}
+}
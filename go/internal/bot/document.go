@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// the largest file the users may send, the imported files are read into memory
+	maxDocumentSize = 1 << 20
+
+	// time given to download a file sent by the user
+	downloadTimeout = 30 * time.Second
+)
+
+// ErrDocumentTooLarge is returned when the file sent by the user is larger than maxDocumentSize.
+var ErrDocumentTooLarge = errors.New("document is too large")
+
+type (
+	// Downloader is an interface that defines the method for downloading the files the users send to the bot.
+	Downloader interface {
+		Download(ctx context.Context, fileID string) ([]byte, error)
+	}
+
+	// apiDownloader is a struct that implements the Downloader interface with the Telegram API.
+	//
+	//  - api: the Telegram API the paths of the files are got from
+	//
+	//  - endpoint: the format of the links to the files, filled with the token of the bot and the path of the file
+	//
+	//  - client: the HTTP client the files are downloaded with
+	apiDownloader struct {
+		api      *tgbotapi.BotAPI
+		endpoint string
+		client   *http.Client
+	}
+)
+
+// newAPIDownloader creates a new instance of apiDownloader with the provided API.
+func newAPIDownloader(api *tgbotapi.BotAPI) *apiDownloader {
+	return &apiDownloader{
+		api:      api,
+		endpoint: tgbotapi.FileEndpoint,
+		client:   &http.Client{Timeout: downloadTimeout},
+	}
+}
+
+// Download downloads the file sent to the bot.
+//
+// Parameters:
+//   - ctx: The context of the download.
+//   - fileID: The ID of the file in Telegram.
+//
+// Returns:
+//   - []byte: The content of the file.
+//   - error: ErrDocumentTooLarge if the file is larger than maxDocumentSize,
+//     an error if the file can't be downloaded, or nil if successful.
+func (d *apiDownloader) Download(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := d.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("Download: %w", err)
+	}
+
+	url := fmt.Sprintf(d.endpoint, d.api.Token, file.FilePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Download: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Download: unexpected status %s", resp.Status)
+	}
+
+	// the size Telegram reports is checked before the download, the limit here is for the files it doesn't report it for
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Download: %w", err)
+	}
+	if len(content) > maxDocumentSize {
+		return nil, ErrDocumentTooLarge
+	}
+	return content, nil
+}
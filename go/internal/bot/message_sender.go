@@ -2,8 +2,11 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
@@ -20,15 +23,112 @@ type (
 	}
 
 	// messageSender is a struct that implements the Sender interface.
+	//
+	//  - messagesChan, documentsChan, callbackChan: the channels the senders pass the outgoing items through
+	//
+	//  - api: the Telegram API the items are sent with
+	//
+	//  - log: a logger to log messages
+	//
+	//  - deadLetters: the log of the items that could not be delivered
+	//
+	//  - limits: the rate limits and the retry policy
+	//
+	//  - global: the rate limit of the bot across all chats
+	//
+	//  - queues: the items waiting to be sent by the chat, the items of a chat are sent in order
+	//
+	//  - pausedUntil: the time Telegram asked the bot to wait until with retry_after
 	messageSender struct {
 		messagesChan  chan tgbotapi.MessageConfig
 		documentsChan chan tgbotapi.DocumentConfig
 		callbackChan  chan tgbotapi.CallbackConfig
 		api           *tgbotapi.BotAPI
 		log           *logrus.Logger
+		deadLetters   *logrus.Entry
+
+		limits      sendLimits
+		global      *bucket
+		queues      map[int64]*chatQueue
+		pausedUntil time.Time
+	}
+
+	// sendLimits configures how fast the sender sends and how it retries
+	//
+	//  - globalRate, globalBurst: the items per second across all chats and how many of them may go at once
+	//
+	//  - chatRate, chatBurst: the messages per second in a chat and how many of them may go at once
+	//
+	//  - maxAttempts: the number of attempts to send an item before it goes to the dead letters
+	//
+	//  - backoff, maxBackoff: the delay before the first retry, it doubles for every next one up to maxBackoff
+	//
+	//  - flushTimeout: the time the queued items are still sent and retried for after the sender is stopped
+	sendLimits struct {
+		globalRate   float64
+		globalBurst  int
+		chatRate     float64
+		chatBurst    int
+		maxAttempts  int
+		backoff      time.Duration
+		maxBackoff   time.Duration
+		flushTimeout time.Duration
+	}
+
+	// outgoing is an item waiting to be sent
+	//
+	//  - chatID: the chat the item is sent to, 0 for the callbacks
+	//
+	//  - kind: the kind of the item for the logs
+	//
+	//  - config: the request to the Telegram API
+	//
+	//  - attempts: the number of the attempts made
+	outgoing struct {
+		chatID   int64
+		kind     string
+		config   tgbotapi.Chattable
+		attempts int
+	}
+
+	// chatQueue holds the items of a chat waiting to be sent
+	//
+	//  - items: the items in the order they were sent
+	//
+	//  - limit: the rate limit of the chat, nil for the callbacks
+	//
+	//  - retryAt: the time the first item is retried at after a failure
+	chatQueue struct {
+		items   []*outgoing
+		limit   *bucket
+		retryAt time.Time
 	}
 )
 
+// the number of messages of each kind the sender queues before Send blocks,
+// the queued messages are sent even when the bot stops
+const senderBuffer = 100
+
+// kinds of the outgoing items
+const (
+	outgoingMessage  = "message"
+	outgoingDocument = "document"
+	outgoingCallback = "callback"
+)
+
+// defaultSendLimits follows the limits of Telegram: about 30 messages per second across the chats
+// and about one message per second in a chat, where short bursts are tolerated
+var defaultSendLimits = sendLimits{
+	globalRate:   30,
+	globalBurst:  30,
+	chatRate:     1,
+	chatBurst:    3,
+	maxAttempts:  5,
+	backoff:      500 * time.Millisecond,
+	maxBackoff:   30 * time.Second,
+	flushTimeout: 5 * time.Second,
+}
+
 var (
 	// this message is used in case of internal error
 	internalErrorAditionalInfo = fmt.Sprintf("Please, contact @%s to share this interesting case\U0001F62E\U0001F915", os.Getenv("TELEGRAM_USERNAME"))
@@ -40,18 +140,23 @@ const (
 	MessageInternalError                = "Ooopsie, there is something reeealy wrong with the bot\U0001F914"
 	MessageUnknownCommand               = "There is no such command\U0001F921\U0001F921"
 	MessageProcessInterrupted           = "Please, wait, I'm still processing your previous request\U0001F624"
+	MessageTooManySessions              = "I'm too busy right now\U0001F975 Please, try again in a minute"
 	MessageStart                        = "Hello\\!\U0001F44B I'm finance tracker bot\U0001F978\\. Please, select an option:"
 	MessageTimeout                      = "You were thinking too long \U000023F0, the operation was aborted"
 	MessageAbort                        = "The operation was aborted\U0000274C"
+	MessageSessionSuspended             = "I'm restarting\U0001F504 Your operation is saved, you can continue it when I'm back in a moment"
+	MessageSessionResumed               = "I'm back\U0001F60E Let's continue where we stopped, I'm waiting for your input or /abort"
+	MessageSessionExpired               = "I was restarted and your operation expired in the meantime\U0000231B Please, start it again"
 	MessageWrongInput                   = "Wrond input, please try again\U0001F92D\U0001FAF5"
-	MessageAddCategory                  = "\U00002757\U0001F4C3Please, input category name:"
-	MessageShowRecords                  = "\U00002757\U0001F4C3Please, input the category name"
+	MessageAddCategory                  = "\U00002757\U0001F4C3Please, input category name, put the parent category before a subcategory like `food/groceries`:"
+	MessageShowRecords                  = "\U00002757\U0001F4C3Please, input the category name, put the parent category before a subcategory like `food/groceries`"
 	MessageAddCategoryDescription       = "\U00002757\U0001F4C3Please, input description to a new category, just a few words\U0001F646"
 	MessageDatabaseError                = "Sorry, something went wrong with the database\U0001F912"
 	MessageCategoryDuplicate            = "Category with that name already exist\U0001FAE0"
 	MessageCategorySuccess              = "Category added successfully\\!\\!\U0001F31E\U0001FAE1"
 	MessageZeroAmount                   = "Sorry, but zero records are discarded\U0001F605\U0001F921"
 	MessageAmountError                  = "Wow, there is something wrong with the amount you've entered\U0001F914"
+	MessageAmountTooLarge               = "Wow, that is too much money to keep track of\U0001F4B8"
 	MessageRecordSuccess                = "Record was added successfully\\!\\!\U0001F31E\U0001FAE1"
 	MessageLimitError                   = "Ooopsie, there is something wrong with the number you've entered\U0001F914"
 	MessageUnderflowCategories          = "You don't have any categories yet\U0001F62C\U0001F642"
@@ -62,23 +167,183 @@ const (
 	MessageUnderflowRecords             = "There are no records for this category and time period\U0001F979"
 	MessageInvalidNumberOfTockensAction = "There were some really serious internal problems with your input\U0001F912"
 	MessageNoActiveSession              = "There is no operation in progress\U0001F605\U0001F921"
-	MessageWantEXEL                     = "Do you want to get the report in EXEL format?\U0001F60E\U0001F601"
-	MessageRecordsExelNo                = "Ok\\.\\.\\. I will not create the report in EXEL format\U0001F61E"
+	MessageWantEXEL                     = "Do you want to get the report as an EXEL or a CSV file?\U0001F60E\U0001F601"
+	MessageRecordsExelNo                = "Ok\\.\\.\\. I will not create the report file\U0001F61E"
 	MessageRecordsExelYes               = "Sure\\! Here it is\U00002934\U00002934\U0001F917\U0001F642\U0000200D\U00002195\U0000FE0F"
 	MessageExelError                    = "Ooopsie, there is something wrong with the EXEL report\U0001F914\U0001F615"
+	MessageCSVError                     = "Ooopsie, there is something wrong with the CSV report\U0001F914\U0001F615"
+	MessageRecordNumberError            = "There is no record with such number\U0001F615"
+	MessageRecordUpdateSuccess          = "Record was updated successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageRecordDeleteSuccess          = "Record was deleted successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageEditRecordsHeader            = "\U00002757\U0001F4C3Please, pick the record you want to change:\n\n"
+	MessageEditCategory                 = "\U00002757\U0001F4C3Please, input the name of the category you want to edit:"
+	MessageDeleteCategory               = "\U00002757\U0001F4C3Please, input the name of the category you want to delete:"
+	MessageCategoryUpdateSuccess        = "Category updated successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageCategoryDeleteSuccess        = "Category deleted successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageCategoryMoveSame             = "The records can't be moved to the category you are deleting\U0001F92A"
+	MessageUnknownCurrency              = "Sorry, I don't know the exchange rate of this currency\U0001F615"
+	MessageCurrencySuccess              = "Base currency changed successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageAddIncomeCategory            = "\U00002757\U0001F4C3Please, input income category name, put the parent category before a subcategory like `salary/bonus`:"
+	MessageNoParentCategory             = "There is no such parent category, may be you spelled it wrong\U0001F615"
+	MessageCategoryKindMismatch         = "Spending and income don't mix, please pick a category of the right kind\U0001F92A"
+	MessageIncomeMark                   = "\U0001F4B0"
+	MessageUnderflowBudgets             = "You don't have any budgets yet\U0001F62C\U0001F642"
+	MessageNoBudgetFound                = "There is no budget for this category\U0001F615"
+	MessageBudgetSuccess                = "Budget set successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageBudgetDeleteSuccess          = "Budget removed successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageBudgetIncomeCategory         = "Budgets are only for spending categories\U0001F92A"
+	MessageInvalidSpentAt               = "Wow, there is something wrong with the date you've entered\U0001F914"
+	MessageFutureSpentAt                = "Hmm, this date is in the future, you can't have spent the money yet\U0001F914"
+	MessageInvalidSchedule              = "Wow, there is something wrong with the schedule you've entered\U0001F914"
+	MessageRecurringSuccess             = "Recurring record added successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageUnderflowRecurring           = "You don't have any recurring records yet\U0001F62C\U0001F642"
+	MessageRecurringNumberError         = "There is no recurring record with such number\U0001F615"
+	MessageUnderflowTags                = "You haven't spent anything on tagged records in this period\U0001F62C\U0001F642"
+	MessageRecurringDeleteSuccess       = "Recurring record deleted successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessageImportInvalidFile            = "Hmm, this doesn't look like a CSV file with the `category` and `amount` columns in its header\U0001F914"
+	MessageImportNothing                = "There is nothing I could import from this file\U0001F615"
+	MessageImportCancelled              = "Ok\\.\\.\\. I will not import anything\U0001F61E"
+	MessageImportInvalidWorkbook        = "Hmm, this doesn't look like a workbook with the `Category` and `Amount` columns in its header\U0001F914"
+	MessageImportNoChanges              = "Your workbook doesn't change anything\U0001F642"
+	MessageImportApply                  = "\nShould I apply the changes?"
+	MessageDocumentTooLarge             = "Sorry, this file is too large, please send a file under 1 MB or split it\U0001F605"
+	MessageDocumentError                = "Sorry, I couldn't download the file, please try again\U0001F912"
+	MessageBankInvalidFile              = "Hmm, this doesn't look like an OFX or a CAMT\\.053 bank statement\U0001F914"
+	MessageBankNothing                  = "There are no new transactions in this statement\U0001F62C\U0001F642"
+	MessageBankNoCategories             = "\nYou don't have categories of this kind yet, skip it and add one later\U0001F615"
+	MessageBankCategoryError            = "This category can't take the transaction, please pick one of the offered ones\U0001F615"
+	MessageUnderflowPayeeRules          = "You don't have any payee rules yet\U0001F62C\U0001F642"
+	MessagePayeeRuleSuccess             = "Payee rule added successfully\\!\\!\U0001F31E\U0001FAE1"
+	MessagePayeeRuleExists              = "The category already has this payee rule\U0001F92A"
+	MessageInvalidPayeeRule             = "Wow, there is something wrong with the payee you've entered\U0001F914"
+	MessagePayeeRuleNumberError         = "There is no payee rule with such number\U0001F615"
+	MessagePayeeRuleDeleteSuccess       = "Payee rule deleted successfully\\!\\!\U0001F31E\U0001FAE1"
 
 	MessageAddRecord = "" +
 		"\U00002757\U0001F4C3Please, input category name and amount:\n\n" +
 		"    \U000027A1 `category 12.34`\n\n" +
-		"Optionally you can add description:\n\n" +
+		"Optionally you can add the currency code, your base currency is used otherwise:\n\n" +
+		"    \U000027A1 `category 12.34 USD`\n\n" +
+		"And description:\n\n" +
 		"    \U000027A1 `category 12\\.34 description`\n\n" +
+		"Hashtags in the description become tags of the record:\n\n" +
+		"    \U000027A1 `category 12\\.34 dinner #vacation`\n\n" +
+		"A subcategory goes after its parent category:\n\n" +
+		"    \U000027A1 `food/groceries 12\\.34`\n\n" +
+		"If you spent the money on another day, add the date at the end:\n\n" +
+		"    \U000027A1 `category 12\\.34 dinner @15\\.03\\.2025`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageAddIncome = "" +
+		"\U00002757\U0001F4C3Please, input income category name and amount:\n\n" +
+		"    \U000027A1 `salary 1234.56`\n\n" +
+		"Optionally you can add the currency code and description:\n\n" +
+		"    \U000027A1 `salary 1234.56 USD bonus`\n\n" +
+		"And the date if you got it on another day:\n\n" +
+		"    \U000027A1 `salary 1234.56 @01.03.2025`\n\n" +
 		"You can tap to copy the examples\U0001F60B"
 
+	MessageBalance = "" +
+		"\U00002757\U0001F4C3Please, input the time period to see your balance for:\n\n" +
+		"  \U000027A1 `last month`\n  for the last month\n\n" +
+		"  \U000027A1 `02.11.2024`\n  since 2 November 2024\n\n" +
+		"  \U000027A1 `02.11.2024 16.11.2024`\n  between 2 and 16 November 2024\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageShowTags = "" +
+		"\U00002757\U0001F4C3Please, input the time period to see your spending by tags for:\n\n" +
+		"  \U000027A1 `last month`\n  for the last month\n\n" +
+		"  \U000027A1 `02.11.2024`\n  since 2 November 2024\n\n" +
+		"  \U000027A1 `02.11.2024 16.11.2024`\n  between 2 and 16 November 2024\n\n" +
+		"Records get tags from the hashtags of their descriptions, like `dinner \\#vacation`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageShowBudgets = "" +
+		"\U00002757\U0001F4C3Please, input the category to see how much is left of its budget this month:\n\n" +
+		"  \U000027A1 `category`\n  for one specific category\n\n" +
+		"  \U000027A1 `food/groceries`\n  for a subcategory\n\n" +
+		"  \U000027A1 `all`\n  for all categories with a budget\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageSetBudget = "" +
+		"\U00002757\U0001F4C3Please, input the category and its monthly limit in your base currency:\n\n" +
+		"  \U000027A1 `category 123.45`\n\n" +
+		"A subcategory goes after its parent category:\n\n" +
+		"  \U000027A1 `food/groceries 123.45`\n\n" +
+		"Or remove the limit:\n\n" +
+		"  \U000027A1 `category delete`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageAddRecurring = "" +
+		"\U00002757\U0001F4C3Please, input category name, amount and how often it repeats:\n\n" +
+		"    \U000027A1 `rent 500 monthly`\n\n" +
+		"Daily, weekly and monthly records repeat from now on, " +
+		"a cron expression in brackets sets the exact time in UTC:\n\n" +
+		"    \U000027A1 `gym 30 [0 9 1 * *]`\n  at 9:00 on the 1st of every month\n\n" +
+		"Optionally you can add the currency code and description:\n\n" +
+		"    \U000027A1 `phone 20 USD monthly prepaid plan`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageShowRecurring = "" +
+		"\U00002757\U0001F4C3Please, input the category to see its recurring records:\n\n" +
+		"  \U000027A1 `category`\n  for one specific category\n\n" +
+		"  \U000027A1 `food/groceries`\n  for a subcategory\n\n" +
+		"  \U000027A1 `all`\n  for all your recurring records\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageDeleteRecurring = "" +
+		"\nTo stop one of them, input its number:\n\n" +
+		"  \U000027A1 `1 delete`\n\n" +
+		"Or /abort to keep them all"
+
+	MessageAPIToken = "" +
+		"\U00002757\U0001F4C3The API token lets your scripts log and query your spending over HTTP\\. " +
+		"Issuing a new one revokes the previous token, type to confirm:\n\n" +
+		"  \U000027A1 `new`\n\n" +
+		"Or /abort to keep the current one"
+
+	MessageImport = "" +
+		"\U00002757\U0001F4C3Please, send me a CSV file with your records or paste its content, " +
+		"the first line names the columns:\n\n" +
+		"  \U000027A1 `spent_at,category,kind,amount,currency,description`\n\n" +
+		"Only the category and the amount are required, your base currency is used for the rows without one\\. " +
+		"The CSV report of your records has exactly these columns, so you can bring it back the same way\\. " +
+		"The EXEL report can be edited and sent back too, I will show you what it changes before applying it\n\n" +
+		"Or /abort to keep your records as they are"
+
+	MessageBankImport = "" +
+		"\U00002757\U0001F4C3Please, send me the statement file your bank exports, " +
+		"OFX and CAMT\\.053 XML are supported\\.\n\n" +
+		"The transactions you have already imported are skipped, " +
+		"the ones matching your payee rules go straight to their categories, " +
+		"the rest I will show you one by one before importing\n\n" +
+		"Or /abort to keep your records as they are"
+
+	MessagePayeeRules = "" +
+		"\U00002757\U0001F4C3Payee rules put the transactions of the bank statements into your categories, " +
+		"a rule matches the payees containing its text\\. Please, input the text and the category:\n\n" +
+		"  \U000027A1 `lidl = groceries`\n\n" +
+		"Or see all your rules to delete some:\n\n" +
+		"  \U000027A1 `all`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageDeletePayeeRule = "" +
+		"\nTo delete one of them, input its number:\n\n" +
+		"  \U000027A1 `1 delete`\n\n" +
+		"Or /abort to keep them all"
+
+	MessageSetCurrency = "" +
+		"\U00002757\U0001F4C3Please, input the code of your new base currency:\n\n" +
+		"  \U000027A1 `USD`\n\n" +
+		"All your totals will be converted into it\\. " +
+		"You can tap to copy the example\U0001F60B"
+
 	MessageShowCategories = "" +
 		"\U00002757\U0001F4C3Please, input the number of categories you want to see:\n\n" +
 		"  \U000027A1 `n`\n  for *n* number of categories\n\n" +
 		"  \U000027A1 `all`\n  for all categories\n\n" +
 		"  \U000027A1 `category`\n  for one specific category\n\n" +
+		"  \U000027A1 `food/groceries`\n  for a subcategory\n\n" +
 		"Optionally you can add 'full' to see descriptions as well:\n\n" +
 		"  \U000027A1 `all full`\n  for all categories with descriptions\n\n" +
 		"You can tap to copy the examples\U0001F60B	"
@@ -94,22 +359,99 @@ const (
 		"Additionally, *last* word is optional, so you can ommit it\U0001F627\n" +
 		"You can tap to copy the examples\U0001F60B"
 
-	MessageShowRecordsFormat       = "[%s] %s\\.%s\u20AC\n"
-	MessageShowRecordsFormatFull   = "[%s] %s\\.%s\u20AC \\- %s\n"
-	MessageShowRecordsFormatHeader = "Subtotal: %s\\.%s\u20AC\n\n"
+	MessageEditCategoryDetails = "" +
+		"\U00002757\U0001F4C3Please, input the new name of the category:\n\n" +
+		"  \U000027A1 `name new name`\n\n" +
+		"Or its new description:\n\n" +
+		"  \U000027A1 `description new description`\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageDeleteCategoryDetails = "" +
+		"\U00002757\U0001F4C3What should I do with the records of this category?\n\n" +
+		"  \U000027A1 `delete`\n  to delete them together with the category\n\n" +
+		"  \U000027A1 `move category`\n  to move them to another category\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageEditRecords = "" +
+		"\nInput the number of the record and its new amount:\n\n" +
+		"  \U000027A1 `1 12.34`\n  to change the amount of the first record\n\n" +
+		"Optionally you can add a new description:\n\n" +
+		"  \U000027A1 `1 12.34 description`\n\n" +
+		"Or remove the record completely:\n\n" +
+		"  \U000027A1 `1 delete`\n  to delete the first record\n\n" +
+		"You can tap to copy the examples\U0001F60B"
+
+	MessageAmountFormat         = "%s%s"
+	MessageOriginalAmountFormat = " \\(%s\\)"
+
+	MessageShowRecordsFormat       = "[%s] %s\n"
+	MessageShowRecordsFormatFull   = "[%s] %s \\- %s\n"
+	MessageShowRecordsFormatHeader = "Subtotal: %s\n\n"
+	MessageEditRecordsFormat       = "%d\\. [%s] %s \\- %s\n"
+
+	MessageBalanceFormat = "Income: %s\nSpending: %s\nBalance: %s%s\n"
+
+	MessageBudgetWarningFormat   = "\n\n\U000026A0You have spent %s of the %s budget for %s this month"
+	MessageBudgetExceededFormat  = "\n\n\U0001F6A8You have spent %s, the %s budget for %s is exceeded this month"
+	MessageShowBudgetsFormat     = "%s: %s of %s, %s left\n"
+	MessageShowBudgetsFormatOver = "%s: %s of %s, %s over\U0001F6A8\n"
+	MessageShowBudgetsHeader     = "Budgets for %s:\n\n"
 
-	MessageShowCategoriesFormat     = "%d\\. %s \\- %s\\.%s\u20AC\n"
-	MessageShowCategoriesFormatFull = "%d\\. %s \\- %s\\.%s\u20AC\n%s\n\n"
+	MessageShowTagsHeader = "Spending by tags:\n\n"
+	MessageShowTagsFormat = "\\#%s \\- %s\n"
+
+	MessageShowRecurringFormat     = "%d\\. %s \\- %s %s, next on %s\n"
+	MessageRecurringAddedFormat    = "\U0001F501Recurring record of %s added to %s"
+	MessageRecurringCaughtUpFormat = "\U0001F501%d missed recurring records of %s each added to %s"
+
+	MessageImportTooManyRowsFormat    = "Sorry, I can import at most %d rows at once, please split the file\U0001F605"
+	MessageImportNewCategoriesFormat  = "These categories are new to you:\n\n%s\nShould I create them, or skip their rows?"
+	MessageImportNewCategoryFormat    = "  \U000027A1 %s\n"
+	MessageImportedFormat             = "Imported %d records\\!\\!\U0001F31E\U0001FAE1\n"
+	MessageImportCreatedFormat        = "\nCreated categories: %s\n"
+	MessageImportRejectedHeader       = "\nSkipped lines:\n"
+	MessageImportRejectedFormat       = "  line %d: %s\n"
+	MessageImportRejectedMoreFormat   = "  and %d more\n"
+	MessageImportUpdatedFormat        = "Updated %d records\\!\\!\U0001F31E\n"
+	MessageImportChangesHeader        = "Here is what your workbook changes:\n\n"
+	MessageImportNewRowFormat         = "  line %d: new record, %s\n"
+	MessageImportChangedRowFormat     = "  line %d: %s\n"
+	MessageImportNewFieldFormat       = "%s %s"
+	MessageImportChangedFieldFormat   = "%s %s \U000027A1 %s"
+	MessageImportChangesSummaryFormat = "\nNew records: %d, changed: %d, unchanged: %d\n"
+
+	MessageBankStatementFormat = "Found %d new transactions, %d are already imported\n"
+	MessageBankReviewFormat    = "" +
+		"\nPlease, pick the category of this transaction, %d left to review:\n\n" +
+		"*%s*\n%s on %s\n%s\n\n" +
+		"The other transactions of the payee go there too, and I will remember it for the next statements"
+	MessageBankSkippedFormat    = "%d transactions without a category were not imported\n"
+	MessageShowPayeeRulesFormat = "%d\\. %s \\- %s\n"
+
+	MessageAPITokenFormat = "Here is your new API token, it won't be shown again\U0001F510\n\n`%s`\n\nSend it in the `Authorization: Bearer` header"
+
+	MessageShowCategoriesFormat     = "%d\\. %s \\- %s\n"
+	MessageSubcategoryIndent        = "    "
+	MessageShowCategoriesFormatFull = "%d\\. %s \\- %s\n%s\n\n"
 )
 
 // NewMessageSender creates a new instance of MessageSender with the provided API and logger.
 func NewMessageSender(api *tgbotapi.BotAPI, log *logrus.Logger) *messageSender {
+	return newMessageSender(api, log, defaultSendLimits)
+}
+
+// newMessageSender creates a new instance of MessageSender sending within the limits
+func newMessageSender(api *tgbotapi.BotAPI, log *logrus.Logger, limits sendLimits) *messageSender {
 	return &messageSender{
-		messagesChan:  make(chan tgbotapi.MessageConfig),
-		documentsChan: make(chan tgbotapi.DocumentConfig),
-		callbackChan:  make(chan tgbotapi.CallbackConfig),
+		messagesChan:  make(chan tgbotapi.MessageConfig, senderBuffer),
+		documentsChan: make(chan tgbotapi.DocumentConfig, senderBuffer),
+		callbackChan:  make(chan tgbotapi.CallbackConfig, senderBuffer),
 		log:           log,
+		deadLetters:   log.WithField("dead_letter", true),
 		api:           api,
+		limits:        limits,
+		global:        newBucket(limits.globalRate, limits.globalBurst, time.Now()),
+		queues:        make(map[int64]*chatQueue),
 	}
 }
 
@@ -131,29 +473,225 @@ func (s *messageSender) SendCallback(cb tgbotapi.CallbackConfig) {
 
 // Run function starts the message sender goroutine.
 //
-// It listens for messages, documents, and callbacks on their respective channels.
-// When a message is received, it sends the message using the Telegram API to send them.
+// It listens for messages, documents, and callbacks on their respective channels
+// and sends them using the Telegram API within the rate limits of Telegram, in order within a chat.
+// The items failed because of the network or Telegram are retried with exponential backoff,
+// Telegram asking to wait with retry_after pauses all sending, the items failed for good
+// or too many times are written to the dead letters.
+// When the context is cancelled, the queued messages are sent before returning.
 func (s *messageSender) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
 	for {
+		var wakeUp <-chan time.Time
+		stopTimer(timer)
+		if wait := s.sendDue(); wait >= 0 {
+			timer.Reset(wait)
+			wakeUp = timer.C
+		}
+
 		select {
 		case msg := <-s.messagesChan:
-			_, err := s.api.Send(msg)
-			if err != nil {
-				s.log.WithError(err).Error("error on send message")
-			}
+			s.enqueue(msg.ChatID, outgoingMessage, msg)
 		case doc := <-s.documentsChan:
-			_, err := s.api.Send(doc)
-			if err != nil {
-				s.log.WithError(err).Error("error on send domcument")
-			}
+			s.enqueue(doc.ChatID, outgoingDocument, doc)
 		case cb := <-s.callbackChan:
-			_, err := s.api.Request(cb)
-			if err != nil {
-				s.log.WithError(err).Error("error on requesting callback")
-			}
+			s.enqueue(0, outgoingCallback, cb)
+		case <-wakeUp:
 		case <-ctx.Done():
-			s.log.Info("context cancelled, stopping message sender")
+			s.log.Info("context cancelled, flushing message sender")
+			s.flush()
+			s.log.Info("message sender stopped")
+			return
+		}
+	}
+}
+
+// flush sends everything queued in the channels and the chat queues,
+// the items left after flushTimeout are written to the dead letters
+func (s *messageSender) flush() {
+	deadline := time.Now().Add(s.limits.flushTimeout)
+	for {
+		s.receiveQueued()
+		wait := s.sendDue()
+		if wait < 0 {
+			return
+		}
+		if time.Now().Add(wait).After(deadline) {
+			s.dropQueued(errors.New("sender stopped"))
+			return
+		}
+		time.Sleep(wait)
+	}
+}
+
+// receiveQueued moves the items waiting in the channels to the chat queues
+func (s *messageSender) receiveQueued() {
+	for {
+		select {
+		case msg := <-s.messagesChan:
+			s.enqueue(msg.ChatID, outgoingMessage, msg)
+		case doc := <-s.documentsChan:
+			s.enqueue(doc.ChatID, outgoingDocument, doc)
+		case cb := <-s.callbackChan:
+			s.enqueue(0, outgoingCallback, cb)
+		default:
 			return
 		}
 	}
 }
+
+// enqueue puts the item to the end of the queue of the chat
+func (s *messageSender) enqueue(chatID int64, kind string, config tgbotapi.Chattable) {
+	queue, ok := s.queues[chatID]
+	if !ok {
+		queue = &chatQueue{}
+		if kind != outgoingCallback {
+			queue.limit = newBucket(s.limits.chatRate, s.limits.chatBurst, time.Now())
+		}
+		s.queues[chatID] = queue
+	}
+	queue.items = append(queue.items, &outgoing{chatID: chatID, kind: kind, config: config})
+}
+
+// sendDue sends the first items of the chat queues as long as the limits allow it,
+// the queues sent out are removed once their chats may send a burst again.
+//
+// Returns:
+//   - time.Duration: The time until the next item may be sent, -1 if nothing is queued.
+func (s *messageSender) sendDue() time.Duration {
+	next := time.Duration(-1)
+	for chatID, queue := range s.queues {
+		for len(queue.items) > 0 {
+			now := time.Now()
+			wait := s.delay(queue, now)
+			if wait > 0 {
+				if next < 0 || wait < next {
+					next = wait
+				}
+				break
+			}
+			s.sendFirst(queue, now)
+		}
+
+		if len(queue.items) == 0 && (queue.limit == nil || queue.limit.full(time.Now())) {
+			delete(s.queues, chatID)
+		}
+	}
+	return next
+}
+
+// delay returns the time until the first item of the queue may be sent, zero if it may be sent now
+func (s *messageSender) delay(queue *chatQueue, now time.Time) time.Duration {
+	wait := max(s.pausedUntil.Sub(now), queue.retryAt.Sub(now), s.global.delay(now))
+	if queue.limit != nil {
+		wait = max(wait, queue.limit.delay(now))
+	}
+	return wait
+}
+
+// sendFirst sends the first item of the queue, it is removed from the queue unless it has to be retried
+func (s *messageSender) sendFirst(queue *chatQueue, now time.Time) {
+	item := queue.items[0]
+	s.global.take(now)
+	if queue.limit != nil {
+		queue.limit.take(now)
+	}
+
+	item.attempts++
+	_, err := s.api.Request(item.config)
+	if err == nil {
+		queue.pop()
+		return
+	}
+
+	retryAfter, retry := retryDelay(err)
+	log := s.log.WithError(err).WithFields(logrus.Fields{
+		"chat_id":  item.chatID,
+		"kind":     item.kind,
+		"attempts": item.attempts,
+	})
+	switch {
+	case !retry || item.attempts >= s.limits.maxAttempts:
+		s.deadLetter(item, err)
+		queue.pop()
+	case retryAfter > 0: // the flood control of Telegram applies to the whole bot
+		log.Warnf("too many requests, pausing for %s", retryAfter)
+		s.pausedUntil = now.Add(retryAfter)
+	default:
+		backoff := s.backoff(item.attempts)
+		log.Warnf("error on send %s, retrying in %s", item.kind, backoff)
+		queue.retryAt = now.Add(backoff)
+	}
+}
+
+// backoff returns the delay before the retry following the attempt
+func (s *messageSender) backoff(attempts int) time.Duration {
+	backoff := s.limits.backoff
+	for i := 1; i < attempts && backoff < s.limits.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, s.limits.maxBackoff)
+}
+
+// dropQueued writes all the queued items to the dead letters with the error
+func (s *messageSender) dropQueued(err error) {
+	for chatID, queue := range s.queues {
+		for _, item := range queue.items {
+			s.deadLetter(item, err)
+		}
+		delete(s.queues, chatID)
+	}
+}
+
+// deadLetter logs the item that could not be delivered,
+// the text of the messages is written only on the debug level, like the other user data
+func (s *messageSender) deadLetter(item *outgoing, err error) {
+	entry := s.deadLetters.WithError(err).WithFields(logrus.Fields{
+		"chat_id":  item.chatID,
+		"kind":     item.kind,
+		"attempts": item.attempts,
+	})
+	entry.Errorf("%s is not delivered", item.kind)
+	if msg, ok := item.config.(tgbotapi.MessageConfig); ok {
+		entry.WithField("text", msg.Text).Debug("text of the message not delivered")
+	}
+}
+
+// pop removes the first item of the queue
+func (q *chatQueue) pop() {
+	q.items[0] = nil
+	q.items = q.items[1:]
+	q.retryAt = time.Time{}
+}
+
+// retryDelay tells if the failed request is worth retrying.
+//
+// Parameters:
+//   - err: The error of the request.
+//
+// Returns:
+//   - time.Duration: The time Telegram asked to wait with retry_after, zero if it did not.
+//   - bool: True for the flood control, the errors of Telegram servers and the network errors,
+//     false for the requests Telegram rejected, like the ones to the chats blocking the bot.
+func retryDelay(err error) (time.Duration, bool) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return 0, true
+	}
+	if tgErr.RetryAfter > 0 {
+		return time.Duration(tgErr.RetryAfter) * time.Second, true
+	}
+	return 0, tgErr.Code == http.StatusTooManyRequests || tgErr.Code >= http.StatusInternalServerError
+}
+
+// stopTimer stops the timer and drains its channel, so it can be reset
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// sentRequest is a sendMessage request the fake Telegram got
+type sentRequest struct {
+	chatID string
+	text   string
+	at     time.Time
+}
+
+// fakeResponse is the answer of the fake Telegram
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+var (
+	okResponse          = fakeResponse{http.StatusOK, `{"ok":true,"result":{"message_id":1,"date":1700000000,"chat":{"id":1,"type":"private"}}}`}
+	retryAfterResponse  = fakeResponse{http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`}
+	serverErrorResponse = fakeResponse{http.StatusInternalServerError, `{"ok":false,"error_code":500,"description":"Internal Server Error"}`}
+	badGatewayResponse  = fakeResponse{http.StatusBadGateway, `<html>Bad Gateway</html>`}
+	badRequestResponse  = fakeResponse{http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`}
+)
+
+// newFakeSendAPI starts a fake Telegram answering sendMessage with the responses in order, the last one repeats,
+// it returns the server, the API using it and the function returning the requests got so far
+func newFakeSendAPI(t *testing.T, responses ...fakeResponse) (*httptest.Server, *tgbotapi.BotAPI, func() []sentRequest) {
+	var (
+		mu   sync.Mutex
+		sent []sentRequest
+	)
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/getMe") {
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		response := responses[min(len(sent), len(responses)-1)]
+		sent = append(sent, sentRequest{chatID: r.PostForm.Get("chat_id"), text: r.PostForm.Get("text"), at: time.Now()})
+		mu.Unlock()
+
+		w.WriteHeader(response.status)
+		w.Write([]byte(response.body))
+	}))
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", telegram.URL+"/bot%s/%s")
+	require.NoError(t, err)
+	return telegram, api, func() []sentRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]sentRequest(nil), sent...)
+	}
+}
+
+// runFlushed runs the sender with a cancelled context, so it returns once everything is sent or dropped
+func runFlushed(sender *messageSender) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sender.Run(ctx)
+}
+
+func Test_messageSender_Run_flush(t *testing.T) {
+
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			require.NoError(t, r.ParseForm())
+			mu.Lock()
+			sent = append(sent, r.PostForm.Get("text"))
+			mu.Unlock()
+			w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":1700000000,"chat":{"id":1,"type":"private"}}}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer telegram.Close()
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", telegram.URL+"/bot%s/%s")
+	require.NoError(t, err)
+
+	sender := NewMessageSender(api, test_log)
+	sender.Send(tgbotapi.NewMessage(1, "first"))
+	sender.Send(tgbotapi.NewMessage(1, "second"))
+	sender.Send(tgbotapi.NewMessage(1, "third"))
+
+	// the context is cancelled before the sender starts, the queued messages still have to be sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sender.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"first", "second", "third"}, sent)
+}
+
+func Test_messageSender_Run_retry(t *testing.T) {
+
+	limits := sendLimits{
+		globalRate:   1000,
+		globalBurst:  10,
+		chatRate:     1000,
+		chatBurst:    10,
+		maxAttempts:  3,
+		backoff:      5 * time.Millisecond,
+		maxBackoff:   20 * time.Millisecond,
+		flushTimeout: 5 * time.Second,
+	}
+
+	tt := []struct {
+		name            string
+		responses       []fakeResponse
+		expectRequests  int
+		expectDelivered bool
+		minElapsed      time.Duration
+	}{
+		{
+			name:            "Ok",
+			responses:       []fakeResponse{okResponse},
+			expectRequests:  1,
+			expectDelivered: true,
+		},
+		{
+			name:            "Retry_after",
+			responses:       []fakeResponse{retryAfterResponse, okResponse},
+			expectRequests:  2,
+			expectDelivered: true,
+			minElapsed:      time.Second,
+		},
+		{
+			name:            "Transient_errors",
+			responses:       []fakeResponse{serverErrorResponse, badGatewayResponse, okResponse},
+			expectRequests:  3,
+			expectDelivered: true,
+			minElapsed:      limits.backoff + 2*limits.backoff,
+		},
+		{
+			name:            "Keeps_failing",
+			responses:       []fakeResponse{serverErrorResponse},
+			expectRequests:  limits.maxAttempts,
+			expectDelivered: false,
+		},
+		{
+			name:            "Rejected",
+			responses:       []fakeResponse{badRequestResponse, okResponse},
+			expectRequests:  1,
+			expectDelivered: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			telegram, api, sent := newFakeSendAPI(t, tc.responses...)
+			defer telegram.Close()
+
+			log, hook := logtest.NewNullLogger()
+			sender := newMessageSender(api, log, limits)
+			sender.Send(tgbotapi.NewMessage(1, "test"))
+
+			begin := time.Now()
+			runFlushed(sender)
+
+			require.Len(t, sent(), tc.expectRequests)
+			require.GreaterOrEqual(t, time.Since(begin), tc.minElapsed)
+
+			var deadLetters int
+			for _, entry := range hook.AllEntries() {
+				if entry.Data["dead_letter"] == true && entry.Level == logrus.ErrorLevel {
+					deadLetters++
+					require.Equal(t, int64(1), entry.Data["chat_id"])
+					require.Equal(t, tc.expectRequests, entry.Data["attempts"])
+				}
+			}
+			if tc.expectDelivered {
+				require.Zero(t, deadLetters)
+			} else {
+				require.Equal(t, 1, deadLetters)
+			}
+		})
+	}
+}
+
+func Test_messageSender_Run_limits(t *testing.T) {
+
+	const interval = 50 * time.Millisecond
+
+	tt := []struct {
+		name   string
+		limits sendLimits
+		// the chats of the requests spaced by the interval at least
+		limitedChats map[string]bool
+	}{
+		{
+			name: "Per_chat",
+			limits: sendLimits{
+				globalRate: 1000, globalBurst: 10,
+				chatRate: float64(time.Second / interval), chatBurst: 1,
+				maxAttempts: 1, flushTimeout: 5 * time.Second,
+			},
+			limitedChats: map[string]bool{"1": true},
+		},
+		{
+			name: "Global",
+			limits: sendLimits{
+				globalRate: float64(time.Second / interval), globalBurst: 1,
+				chatRate: 1000, chatBurst: 10,
+				maxAttempts: 1, flushTimeout: 5 * time.Second,
+			},
+			limitedChats: map[string]bool{"1": true, "2": true},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			telegram, api, sent := newFakeSendAPI(t, okResponse)
+			defer telegram.Close()
+
+			sender := newMessageSender(api, test_log, tc.limits)
+			sender.Send(tgbotapi.NewMessage(1, "first"))
+			sender.Send(tgbotapi.NewMessage(1, "second"))
+			sender.Send(tgbotapi.NewMessage(1, "third"))
+			sender.Send(tgbotapi.NewMessage(2, "other"))
+			runFlushed(sender)
+
+			requests := sent()
+			require.Len(t, requests, 4)
+
+			var (
+				texts []string
+				last  time.Time
+			)
+			for _, request := range requests {
+				if request.chatID == "1" {
+					texts = append(texts, request.text)
+				}
+				if !tc.limitedChats[request.chatID] {
+					continue
+				}
+				if !last.IsZero() {
+					// the clock of the bucket and the one of the server differ slightly
+					require.GreaterOrEqual(t, request.at.Sub(last), interval-5*time.Millisecond)
+				}
+				last = request.at
+			}
+			require.Equal(t, []string{"first", "second", "third"}, texts)
+
+			if len(tc.limitedChats) == 1 { // the other chat doesn't wait for the limited one
+				require.Equal(t, "third", requests[3].text)
+			}
+		})
+	}
+}
+
+func Test_retryDelay(t *testing.T) {
+
+	tt := []struct {
+		name        string
+		err         error
+		expectDelay time.Duration
+		expectRetry bool
+	}{
+		{
+			name:        "Network",
+			err:         errors.New("connection reset by peer"),
+			expectRetry: true,
+		},
+		{
+			name:        "Retry_after",
+			err:         &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}},
+			expectDelay: 3 * time.Second,
+			expectRetry: true,
+		},
+		{
+			name:        "Too_many_requests",
+			err:         &tgbotapi.Error{Code: 429},
+			expectRetry: true,
+		},
+		{
+			name:        "Server_error",
+			err:         &tgbotapi.Error{Code: 502},
+			expectRetry: true,
+		},
+		{
+			name:        "Blocked",
+			err:         &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			expectRetry: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, retry := retryDelay(tc.err)
+			require.Equal(t, tc.expectDelay, delay)
+			require.Equal(t, tc.expectRetry, retry)
+		})
+	}
+}
+
+func Test_messageSender_backoff(t *testing.T) {
+
+	sender := newMessageSender(nil, test_log, defaultSendLimits)
+	require.Equal(t, 500*time.Millisecond, sender.backoff(1))
+	require.Equal(t, time.Second, sender.backoff(2))
+	require.Equal(t, 4*time.Second, sender.backoff(4))
+	require.Equal(t, 30*time.Second, sender.backoff(10))
+}
@@ -0,0 +1,291 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// the sessions saved longer ago than that are expired instead of resumed on start
+	resumeWindow = 10 * time.Minute
+)
+
+// kinds of the batches the sessions are saved with
+const (
+	batchCategory      = "category"
+	batchRecord        = "record"
+	batchRecordOptions = "record_options"
+	batchCategories    = "categories"
+	batchRecords       = "records"
+	batchRecurring     = "recurring"
+	batchFile          = "file"
+	batchTransactions  = "bank_transactions"
+	batchPayeeRules    = "payee_rules"
+)
+
+var (
+	errSessionExpired = errors.New("session expired")
+	errSessionsFull   = errors.New("sessions cache is full")
+)
+
+type (
+	// sessionStore saves the state of the sessions, so they survive the restarts of the bot
+	sessionStore interface {
+		save(ctx context.Context, cl *client, cmdID int, batch any) error
+		forget(ctx context.Context, chatID int64) error
+	}
+
+	// PersistentSessions is a Sessions implementation that keeps the sessions in memory
+	// and saves their state with the service after every step,
+	// the sessions interrupted by a restart are resumed when the bot starts
+	//
+	//  - sessionsCache: the sessions of the running bot
+	//
+	//  - srvc: the service the state is saved with
+	PersistentSessions struct {
+		*sessionsCache
+		srvc service.ServiceInterface
+	}
+)
+
+// NewPersistentSessions creates a new instance of PersistentSessions saving the sessions with the service.
+func NewPersistentSessions(srvc service.ServiceInterface) *PersistentSessions {
+	return &PersistentSessions{
+		sessionsCache: NewSessionsCache(),
+		srvc:          srvc,
+	}
+}
+
+// AddSession creates a new session like sessionsCache does, the session saves its state with the service.
+//
+// Parameters:
+//   - chatID: The unique identifier for the chat.
+//   - userID: The unique identifier for the user.
+//   - username: The username of the user.
+//
+// Returns:
+//   - A pointer to the newly created session, nil if the cache is full of active sessions.
+func (p *PersistentSessions) AddSession(chatID int64, userID int64, username string) *session {
+	s := p.sessionsCache.AddSession(chatID, userID, username)
+	if s != nil {
+		s.store = p
+	}
+	return s
+}
+
+// save saves the command waiting for the input of the client and the batch collected so far
+func (p *PersistentSessions) save(ctx context.Context, cl *client, cmdID int, batch any) error {
+	kind, data, err := encodeBatch(batch)
+	if err != nil {
+		return fmt.Errorf("PersistentSessions.save: %w", err)
+	}
+	return p.srvc.SaveSession(ctx, ftracker.Session{
+		ChatID:    cl.chanID,
+		UserID:    cl.userID,
+		Username:  cl.username,
+		CommandID: cmdID,
+		BatchKind: kind,
+		Batch:     data,
+	})
+}
+
+// forget deletes the saved state of the session in the chat
+func (p *PersistentSessions) forget(ctx context.Context, chatID int64) error {
+	return p.srvc.DeleteSession(ctx, chatID)
+}
+
+// restore makes the session of the running bot from the saved one.
+//
+// Parameters:
+//   - saved: The saved session.
+//   - now: The current time, the sessions saved more than resumeWindow before it are expired.
+//
+// Returns:
+//   - *session: The restored session.
+//   - command: The command waiting for the input.
+//   - any: The batch collected by the previous commands.
+//   - error: errSessionExpired if the session is too old, an error if it can't be restored, otherwise nil.
+func (p *PersistentSessions) restore(saved ftracker.Session, now time.Time) (*session, command, any, error) {
+	if now.Sub(saved.UpdatedAt) > resumeWindow {
+		return nil, command{}, nil, errSessionExpired
+	}
+
+	cmd, ok := commandsByIDs[saved.CommandID]
+	if !ok {
+		return nil, command{}, nil, fmt.Errorf("PersistentSessions.restore: unknown command %d", saved.CommandID)
+	}
+
+	batch, err := decodeBatch(saved.BatchKind, saved.Batch)
+	if err != nil {
+		return nil, command{}, nil, fmt.Errorf("PersistentSessions.restore: %w", err)
+	}
+
+	s := p.AddSession(saved.ChatID, saved.UserID, saved.Username)
+	if s == nil {
+		return nil, command{}, nil, fmt.Errorf("PersistentSessions.restore: %w", errSessionsFull)
+	}
+	return s, cmd, batch, nil
+}
+
+// resumeSessions resumes the sessions saved before the restart, the users are told their operations go on,
+// the sessions that can't be resumed are deleted and their users are told to start over
+func (b *TelegramBot) resumeSessions(ctx context.Context, sessions *PersistentSessions) {
+
+	saved, err := b.service.GetSessions(ctx)
+	if err != nil {
+		b.log.WithError(err).Error("error getting saved sessions")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, stored := range saved {
+
+		s, cmd, batch, err := sessions.restore(stored, now)
+		if err != nil {
+			b.log.WithError(err).Infof("dropping saved session of %s", stored.Username)
+			if err := b.service.DeleteSession(ctx, stored.ChatID); err != nil {
+				b.log.WithError(err).Errorf("error deleting saved session of %s", stored.Username)
+			}
+			msg := tgbotapi.NewMessage(stored.ChatID, MessageSessionExpired)
+			msg.ReplyMarkup = baseKeyboard
+			b.sender.Send(msg)
+			continue
+		}
+
+		b.log.WithFields(logrus.Fields{
+			"user":    stored.Username,
+			"command": cmd.ID,
+		}).Info("resuming saved session")
+		b.sender.Send(tgbotapi.NewMessage(stored.ChatID, MessageSessionResumed))
+
+		sessionCtx := s.setUpActive(ctx, b.log)
+		b.running.Add(1)
+		go func() {
+			defer b.running.Done()
+			s.process(sessionCtx, b.log, cmd, batch, b.sender, b.service)
+		}()
+	}
+}
+
+// WithPersistentSessions is a function that makes the bot save the sessions with its service,
+// so the operations in progress survive the restarts.
+func WithPersistentSessions() Option {
+	return func(b *TelegramBot) {
+		b.sessions = NewPersistentSessions(b.service)
+	}
+}
+
+// encodeBatch serializes the batch of a session.
+//
+// Parameters:
+//   - batch: One of the batches the commands collect, see initBatch and the actions.
+//
+// Returns:
+//   - string: The kind of the batch, empty for a nil batch.
+//   - []byte: The JSON of the batch, nil for a nil batch.
+//   - error: An error if the batch is of an unknown type.
+func encodeBatch(batch any) (string, []byte, error) {
+	var kind string
+	switch batch.(type) {
+	case nil:
+		return "", nil, nil
+	case *ftracker.SpendingCategory:
+		kind = batchCategory
+	case *ftracker.SpendingRecord:
+		kind = batchRecord
+	case *repository.RecordOptions:
+		kind = batchRecordOptions
+	case []ftracker.SpendingCategory:
+		kind = batchCategories
+	case []ftracker.SpendingRecord:
+		kind = batchRecords
+	case []ftracker.RecurringRecord:
+		kind = batchRecurring
+	case []byte:
+		kind = batchFile
+	case []ftracker.BankTransaction:
+		kind = batchTransactions
+	case []ftracker.PayeeRule:
+		kind = batchPayeeRules
+	default:
+		return "", nil, fmt.Errorf("encodeBatch: unknown batch %T", batch)
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return "", nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	return kind, data, nil
+}
+
+// decodeBatch deserializes the batch of a session encoded by encodeBatch.
+//
+// Parameters:
+//   - kind: The kind of the batch.
+//   - data: The JSON of the batch.
+//
+// Returns:
+//   - any: The batch of the same type it was encoded from.
+//   - error: An error if the kind is unknown or the JSON is malformed.
+func decodeBatch(kind string, data []byte) (any, error) {
+	var (
+		batch any
+		err   error
+	)
+	switch kind {
+	case "":
+		return nil, nil
+	case batchCategory:
+		category := &ftracker.SpendingCategory{}
+		err = json.Unmarshal(data, category)
+		batch = category
+	case batchRecord:
+		record := &ftracker.SpendingRecord{}
+		err = json.Unmarshal(data, record)
+		batch = record
+	case batchRecordOptions:
+		options := &repository.RecordOptions{}
+		err = json.Unmarshal(data, options)
+		batch = options
+	case batchCategories:
+		var categories []ftracker.SpendingCategory
+		err = json.Unmarshal(data, &categories)
+		batch = categories
+	case batchRecords:
+		var records []ftracker.SpendingRecord
+		err = json.Unmarshal(data, &records)
+		batch = records
+	case batchRecurring:
+		var records []ftracker.RecurringRecord
+		err = json.Unmarshal(data, &records)
+		batch = records
+	case batchFile:
+		var file []byte
+		err = json.Unmarshal(data, &file)
+		batch = file
+	case batchTransactions:
+		var transactions []ftracker.BankTransaction
+		err = json.Unmarshal(data, &transactions)
+		batch = transactions
+	case batchPayeeRules:
+		var rules []ftracker.PayeeRule
+		err = json.Unmarshal(data, &rules)
+		batch = rules
+	default:
+		return nil, fmt.Errorf("decodeBatch: unknown batch kind %q", kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decodeBatch: %w", err)
+	}
+	return batch, nil
+}
@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_encodeBatch(t *testing.T) {
+
+	spentAt := time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		batch    any
+		wantKind string
+		wantErr  bool
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name:     "Category",
+			batch:    &ftracker.SpendingCategory{Category: "food", Kind: ftracker.KindIncome, ParentGUID: uuid.New()},
+			wantKind: batchCategory,
+		},
+		{
+			name:     "Record",
+			batch:    &ftracker.SpendingRecord{CategoryGUID: uuid.New(), Amount: 1234, Currency: "USD", SpentAt: spentAt, Tags: []string{"vacation"}},
+			wantKind: batchRecord,
+		},
+		{
+			name: "Record_options",
+			batch: &repository.RecordOptions{
+				Limit:         10,
+				CategoryGUIDs: []uuid.UUID{uuid.New()},
+				TimeFrom:      spentAt,
+				TimeTo:        spentAt.AddDate(0, 1, 0),
+				ByTime:        true,
+				Order:         repository.RecordOrder{Column: "spent_at"},
+			},
+			wantKind: batchRecordOptions,
+		},
+		{
+			name:     "Categories",
+			batch:    []ftracker.SpendingCategory{{GUID: uuid.New(), Category: "food"}, {GUID: uuid.New(), Category: "drinks"}},
+			wantKind: batchCategories,
+		},
+		{
+			name:     "Records",
+			batch:    []ftracker.SpendingRecord{{GUID: uuid.New(), Amount: 1}},
+			wantKind: batchRecords,
+		},
+		{
+			name:     "No_records",
+			batch:    []ftracker.SpendingRecord(nil),
+			wantKind: batchRecords,
+		},
+		{
+			name:     "Recurring",
+			batch:    []ftracker.RecurringRecord{{GUID: uuid.New(), Schedule: "monthly", StartsAt: spentAt}},
+			wantKind: batchRecurring,
+		},
+		{
+			name:     "File",
+			batch:    []byte("category,amount\nfood,12.34\n"),
+			wantKind: batchFile,
+		},
+		{
+			name: "Bank_transactions",
+			batch: []ftracker.BankTransaction{{
+				ID: "20241101-001", PostedAt: time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC), Amount: 2345, Currency: "EUR",
+				Kind: ftracker.KindSpending, Payee: "LIDL", CategoryGUID: uuid.New(),
+			}},
+			wantKind: batchTransactions,
+		},
+		{
+			name:     "Payee_rules",
+			batch:    []ftracker.PayeeRule{{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending, Pattern: "lidl"}},
+			wantKind: batchPayeeRules,
+		},
+		{
+			name:    "Unknown",
+			batch:   &ftracker.Budget{},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, data, err := encodeBatch(tc.batch)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantKind, kind)
+
+			got, err := decodeBatch(kind, data)
+			require.NoError(t, err)
+			require.Equal(t, tc.batch, got)
+		})
+	}
+}
+
+func Test_decodeBatch_errors(t *testing.T) {
+	_, err := decodeBatch("budget", []byte(`{}`))
+	require.Error(t, err)
+
+	_, err = decodeBatch(batchRecord, []byte(`{"amount":`))
+	require.Error(t, err)
+}
+
+func TestSession_process_persistent(t *testing.T) {
+
+	// the first step of adding a category without the database work
+	first := commandsByIDs[1]
+	first.action = func(_ context.Context, input []string, batch *any, _ service.ServiceInterface, _ *logrus.Logger, _ Sender, _ *client, _ *command) {
+		(*batch).(*ftracker.SpendingCategory).Category = input[1]
+	}
+
+	saved := func(cmdID int, category ftracker.SpendingCategory) ftracker.Session {
+		data, err := json.Marshal(&category)
+		require.NoError(t, err)
+		return ftracker.Session{ChatID: 1, UserID: 2, Username: "test_username", CommandID: cmdID, BatchKind: batchCategory, Batch: data}
+	}
+
+	tt := []struct {
+		name       string
+		stop       func(s *session, cancel context.CancelFunc)
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface)
+	}{
+		{
+			name: "Aborted_by_user",
+			stop: func(s *session, cancel context.CancelFunc) {
+				require.NoError(t, s.terminateSession())
+			},
+			senderBeh: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageAbort))
+			},
+			serviceBeh: func(srvc *mock_service.MockServiceInterface) {
+				gomock.InOrder(
+					srvc.EXPECT().SaveSession(gomock.Any(), saved(1, ftracker.SpendingCategory{})),
+					srvc.EXPECT().SaveSession(gomock.Any(), saved(2, ftracker.SpendingCategory{Category: "food"})),
+					srvc.EXPECT().DeleteSession(gomock.Any(), int64(1)),
+				)
+			},
+		},
+		{
+			name: "Bot_stopped",
+			stop: func(s *session, cancel context.CancelFunc) {
+				cancel()
+			},
+			senderBeh: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageSessionSuspended))
+			},
+			serviceBeh: func(srvc *mock_service.MockServiceInterface) {
+				gomock.InOrder(
+					srvc.EXPECT().SaveSession(gomock.Any(), saved(1, ftracker.SpendingCategory{})),
+					srvc.EXPECT().SaveSession(gomock.Any(), saved(2, ftracker.SpendingCategory{Category: "food"})),
+				)
+			},
+		},
+		{
+			name: "Save_error",
+			stop: func(s *session, cancel context.CancelFunc) {
+				require.NoError(t, s.terminateSession())
+			},
+			senderBeh: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageAbort))
+			},
+			serviceBeh: func(srvc *mock_service.MockServiceInterface) {
+				srvc.EXPECT().SaveSession(gomock.Any(), gomock.Any()).Return(errors.New("error")).Times(2)
+				srvc.EXPECT().DeleteSession(gomock.Any(), int64(1)).Return(errors.New("error"))
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			sender := NewMockSender(controller)
+			tc.senderBeh(sender)
+			srvc := mock_service.NewMockServiceInterface(controller)
+			tc.serviceBeh(srvc)
+
+			s := NewPersistentSessions(srvc).AddSession(1, 2, "test_username")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				s.Process(s.setUpActive(ctx, test_log), test_log, first, sender, srvc)
+				close(done)
+			}()
+
+			require.NoError(t, s.TransmitInput("food"))
+			tc.stop(s, cancel)
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				require.FailNow(t, "the session did not stop")
+			}
+		})
+	}
+}
+
+func TestTelegramBot_resumeSessions(t *testing.T) {
+
+	now := time.Now().UTC()
+	record, err := json.Marshal(&ftracker.SpendingRecord{Amount: 1234})
+	require.NoError(t, err)
+
+	fresh := ftracker.Session{ChatID: 1, UserID: 1, Username: "fresh", CommandID: 2, BatchKind: batchCategory, Batch: []byte(`{"category":"food"}`), UpdatedAt: now.Add(-time.Minute)}
+	expired := ftracker.Session{ChatID: 2, UserID: 2, Username: "expired", CommandID: 2, UpdatedAt: now.Add(-resumeWindow - time.Minute)}
+	unknown := ftracker.Session{ChatID: 3, UserID: 3, Username: "unknown", CommandID: 1000, UpdatedAt: now}
+	broken := ftracker.Session{ChatID: 4, UserID: 4, Username: "broken", CommandID: 2, BatchKind: batchRecord, Batch: record[:5], UpdatedAt: now}
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	srvc := mock_service.NewMockServiceInterface(controller)
+	srvc.EXPECT().GetSessions(gomock.Any()).Return([]ftracker.Session{expired, fresh, unknown, broken}, nil)
+	srvc.EXPECT().DeleteSession(gomock.Any(), int64(2))
+	srvc.EXPECT().DeleteSession(gomock.Any(), int64(3))
+	srvc.EXPECT().DeleteSession(gomock.Any(), int64(4))
+	srvc.EXPECT().SaveSession(gomock.Any(), ftracker.Session{
+		ChatID: 1, UserID: 1, Username: "fresh", CommandID: 2, BatchKind: batchCategory, Batch: mustEncode(t, &ftracker.SpendingCategory{Category: "food"}),
+	})
+
+	sender := NewMockSender(controller)
+	for _, chatID := range []int64{2, 3, 4} {
+		msg := tgbotapi.NewMessage(chatID, MessageSessionExpired)
+		msg.ReplyMarkup = baseKeyboard
+		sender.EXPECT().Send(msg)
+	}
+	sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageSessionResumed))
+	sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageSessionSuspended))
+
+	sessions := NewPersistentSessions(srvc)
+	b := &TelegramBot{
+		log:      test_log,
+		service:  srvc,
+		sender:   sender,
+		sessions: sessions,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.resumeSessions(ctx, sessions)
+
+	resumed := sessions.GetSession(1)
+	require.NotNil(t, resumed)
+	require.True(t, resumed.isActive())
+	require.Nil(t, sessions.GetSession(2))
+	require.Nil(t, sessions.GetSession(3))
+	require.Nil(t, sessions.GetSession(4))
+
+	cancel()
+	require.True(t, b.waitRunning(time.Second))
+}
+
+func TestTelegramBot_resumeSessions_error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	srvc := mock_service.NewMockServiceInterface(controller)
+	srvc.EXPECT().GetSessions(gomock.Any()).Return(nil, errors.New("error"))
+
+	sessions := NewPersistentSessions(srvc)
+	b := &TelegramBot{
+		log:      test_log,
+		service:  srvc,
+		sender:   NewMockSender(controller),
+		sessions: sessions,
+	}
+	b.resumeSessions(context.Background(), sessions)
+}
+
+// mustEncode returns the JSON of the batch
+func mustEncode(t *testing.T, batch any) []byte {
+	_, data, err := encodeBatch(batch)
+	require.NoError(t, err)
+	return data
+}
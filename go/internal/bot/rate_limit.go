@@ -0,0 +1,67 @@
+package bot
+
+import "time"
+
+// bucket is a token bucket limiting the rate of the requests,
+// it is not safe for concurrent use, the sender goroutine owns it
+//
+//   - rate: the tokens added per second
+//
+//   - burst: the maximum number of the tokens
+//
+//   - tokens: the tokens available at the last update
+//
+//   - last: the time of the last update
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newBucket creates a new full bucket.
+//
+// Parameters:
+//   - rate: The requests allowed per second.
+//   - burst: The requests allowed at once.
+//   - now: The current time.
+//
+// Returns:
+//   - A pointer to the bucket.
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// refill adds the tokens accumulated since the last update
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// delay returns the time until a token is available, zero if there is one now
+func (b *bucket) delay(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// take takes a token, the tokens go below zero if there are none, so the next requests wait longer
+func (b *bucket) take(now time.Time) {
+	b.refill(now)
+	b.tokens--
+}
+
+// full checks if the bucket allows a whole burst
+func (b *bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
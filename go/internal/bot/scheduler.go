@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/service"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// schedulerInterval is how often the scheduler looks for due recurring records
+	schedulerInterval = 1 * time.Minute
+	// schedulerBatch limits the recurring records processed on a tick
+	schedulerBatch = 100
+	// maxCatchUp limits the records made for one recurring record on a tick,
+	// the rest of the occurrences missed during downtime are made on the next ticks
+	maxCatchUp = 50
+)
+
+// runScheduler makes the records of the due recurring records every schedulerInterval
+// until the context is cancelled, the occurrences missed while the bot was down are made on the first run
+func (b *TelegramBot) runScheduler(ctx context.Context) {
+	b.log.Info("scheduler started")
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	makeDueRecords(ctx, time.Now().UTC(), b.service, b.sender, b.log)
+	for {
+		select {
+		case <-ticker.C:
+			makeDueRecords(ctx, time.Now().UTC(), b.service, b.sender, b.log)
+		case <-ctx.Done():
+			b.log.Info("context cancelled, stopping scheduler")
+			return
+		}
+	}
+}
+
+// makeDueRecords makes a record for every occurrence of the recurring records due by now
+// and notifies their owners
+//
+// a recurring record is advanced only after its occurrence is recorded, an occurrence recorded
+// before a crash is recognized by the unique violation and skipped, so it is never recorded twice
+func makeDueRecords(ctx context.Context, now time.Time, srvc service.ServiceInterface, sender Sender, log *logrus.Logger) {
+
+	due, err := srvc.GetDueRecurring(ctx, now, schedulerBatch)
+	if err != nil {
+		log.WithError(err).Error("error on get due recurring records")
+		return
+	}
+
+	for _, recurring := range due {
+		made := 0
+		for i := 0; i < maxCatchUp && !recurring.NextRunAt.After(now); i++ {
+
+			record := ftracker.SpendingRecord{
+				CategoryGUID:  recurring.CategoryGUID,
+				Amount:        recurring.Amount,
+				Currency:      recurring.Currency,
+				Description:   recurring.Description,
+				RecurringGUID: recurring.GUID,
+				OccurredAt:    recurring.NextRunAt,
+				SpentAt:       recurring.NextRunAt,
+			}
+			_, err = srvc.AddRecords(ctx, recurring.UserGUID, []ftracker.SpendingRecord{record})
+			if err != nil && !utils.IsUniqueConstrainViolation(err) {
+				log.WithError(err).Errorf("error on add record of recurring record %s", recurring.GUID)
+				break
+			}
+			if err == nil {
+				made++
+			} else {
+				log.Debugf("occurrence %s of recurring record %s is already recorded", recurring.NextRunAt, recurring.GUID)
+			}
+
+			next, err := srvc.AdvanceRecurring(ctx, recurring)
+			if err != nil {
+				log.WithError(err).Errorf("error on advance recurring record %s", recurring.GUID)
+				break
+			}
+			recurring.NextRunAt = next
+		}
+
+		if made > 0 {
+			notifyRecurring(ctx, recurring, made, srvc, sender, log)
+		}
+	}
+}
+
+// notifyRecurring tells the owner of the recurring record how many records were made from it
+func notifyRecurring(ctx context.Context, recurring ftracker.RecurringRecord, made int, srvc service.ServiceInterface, sender Sender, log *logrus.Logger) {
+
+	users, err := srvc.GetUsers(ctx, srvc.UsersWithGUIDs([]uuid.UUID{recurring.UserGUID}))
+	if err != nil || len(users) == 0 {
+		log.WithError(err).Errorf("error on get owner of recurring record %s", recurring.GUID)
+		return
+	}
+	// the bot talks to the users in private chats, their ids are the same as the user ids
+	chatID, err := strconv.ParseInt(users[0].TelegramID, 10, 64)
+	if err != nil {
+		log.WithError(err).Errorf("invalid telegram id of user %s", users[0].GUID)
+		return
+	}
+
+	amount := formatAmount(recurring.Amount, recurring.Currency)
+	text := fmt.Sprintf(MessageRecurringAddedFormat, amount, recurring.Category)
+	if made > 1 {
+		text = fmt.Sprintf(MessageRecurringCaughtUpFormat, made, amount, recurring.Category)
+	}
+	sender.Send(tgbotapi.NewMessage(chatID, text))
+}
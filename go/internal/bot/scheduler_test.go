@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func Test_makeDueRecords(t *testing.T) {
+
+	now := time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+	user := ftracker.User{GUID: uuid.New(), TelegramID: "42"}
+	recurring := ftracker.RecurringRecord{
+		GUID:         uuid.New(),
+		UserGUID:     user.GUID,
+		CategoryGUID: uuid.New(),
+		Category:     "rent",
+		Amount:       50000,
+		Currency:     ftracker.DefaultCurrency,
+		Description:  "flat",
+		Schedule:     "monthly",
+		StartsAt:     time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+	}
+	occurrence := func(at time.Time) []ftracker.SpendingRecord {
+		return []ftracker.SpendingRecord{{
+			CategoryGUID:  recurring.CategoryGUID,
+			Amount:        recurring.Amount,
+			Currency:      recurring.Currency,
+			Description:   recurring.Description,
+			RecurringGUID: recurring.GUID,
+			OccurredAt:    at,
+			SpentAt:       at,
+		}}
+	}
+	expectOwner := func(s *mock_service.MockServiceInterface) {
+		s.EXPECT().UsersWithGUIDs([]uuid.UUID{user.GUID})
+		s.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Return([]ftracker.User{user}, nil)
+	}
+	amount := formatAmount(recurring.Amount, recurring.Currency)
+
+	tests := []struct {
+		name       string
+		nextRunAt  time.Time
+		senderBeh  func(*MockSender)
+		serviceBeh func(*mock_service.MockServiceInterface, ftracker.RecurringRecord)
+	}{
+		{
+			name:      "One_occurrence",
+			nextRunAt: time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(tgbotapi.NewMessage(42, fmt.Sprintf(MessageRecurringAddedFormat, amount, "rent")))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface, r ftracker.RecurringRecord) {
+				s.EXPECT().GetDueRecurring(gomock.Any(), now, schedulerBatch).Return([]ftracker.RecurringRecord{r}, nil)
+				s.EXPECT().AddRecords(gomock.Any(), user.GUID, occurrence(r.NextRunAt)).Return([]uuid.UUID{uuid.New()}, nil)
+				s.EXPECT().AdvanceRecurring(gomock.Any(), r).Return(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), nil)
+				expectOwner(s)
+			},
+		},
+		{
+			name:      "Catch_up",
+			nextRunAt: time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+			senderBeh: func(s *MockSender) {
+				s.EXPECT().Send(tgbotapi.NewMessage(42, fmt.Sprintf(MessageRecurringCaughtUpFormat, 3, amount, "rent")))
+			},
+			serviceBeh: func(s *mock_service.MockServiceInterface, r ftracker.RecurringRecord) {
+				s.EXPECT().GetDueRecurring(gomock.Any(), now, schedulerBatch).Return([]ftracker.RecurringRecord{r}, nil)
+				for _, month := range []time.Month{10, 11, 12} {
+					at := time.Date(2024, month, 1, 9, 0, 0, 0, time.UTC)
+					s.EXPECT().AddRecords(gomock.Any(), user.GUID, occurrence(at)).Return([]uuid.UUID{uuid.New()}, nil)
+					r.NextRunAt = at
+					s.EXPECT().AdvanceRecurring(gomock.Any(), r).Return(at.AddDate(0, 1, 0), nil)
+				}
+				expectOwner(s)
+			},
+		},
+		{
+			name:      "Already_recorded",
+			nextRunAt: time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC),
+			senderBeh: func(s *MockSender) {},
+			serviceBeh: func(s *mock_service.MockServiceInterface, r ftracker.RecurringRecord) {
+				s.EXPECT().GetDueRecurring(gomock.Any(), now, schedulerBatch).Return([]ftracker.RecurringRecord{r}, nil)
+				s.EXPECT().AddRecords(gomock.Any(), user.GUID, occurrence(r.NextRunAt)).Return(nil, fmt.Errorf("%w", &pgconn.PgError{Code: "23505"}))
+				s.EXPECT().AdvanceRecurring(gomock.Any(), r).Return(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), nil)
+			},
+		},
+		{
+			name:      "Add_error",
+			nextRunAt: time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC),
+			senderBeh: func(s *MockSender) {},
+			serviceBeh: func(s *mock_service.MockServiceInterface, r ftracker.RecurringRecord) {
+				s.EXPECT().GetDueRecurring(gomock.Any(), now, schedulerBatch).Return([]ftracker.RecurringRecord{r}, nil)
+				s.EXPECT().AddRecords(gomock.Any(), user.GUID, occurrence(r.NextRunAt)).Return(nil, errors.New("error"))
+			},
+		},
+		{
+			name:      "Get_error",
+			senderBeh: func(s *MockSender) {},
+			serviceBeh: func(s *mock_service.MockServiceInterface, r ftracker.RecurringRecord) {
+				s.EXPECT().GetDueRecurring(gomock.Any(), now, schedulerBatch).Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			r := recurring
+			r.NextRunAt = tt.nextRunAt
+
+			service := mock_service.NewMockServiceInterface(controller)
+			tt.serviceBeh(service, r)
+			sender := NewMockSender(controller)
+			tt.senderBeh(sender)
+
+			makeDueRecords(context.Background(), now, service, sender, test_log)
+		})
+	}
+}
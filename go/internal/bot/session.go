@@ -2,7 +2,9 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,6 +17,20 @@ import (
 
 const (
 	timeout = 1 * time.Minute
+
+	// the inactive sessions not used for that long are evicted from the cache
+	sessionTTL = 30 * time.Minute
+	// the maximum number of the sessions kept in the cache
+	maxSessions = 10000
+	// how often the idle sessions are evicted
+	evictionInterval = time.Minute
+)
+
+var (
+	// errSessionAborted is the cause of the context of a session aborted by the user
+	errSessionAborted = errors.New("session aborted")
+	// errSessionFinished is returned when the input comes after the process of the session stopped taking it
+	errSessionFinished = errors.New("session finished")
 )
 
 type (
@@ -25,9 +41,42 @@ type (
 		TerminateSession(chatID int64) error
 	}
 
-	// sessionsCache is a map that stores user sessions and
-	// previously aquired data.
-	sessionsCache map[int64]*session
+	// sessionsCache stores user sessions and previously aquired data,
+	// it is safe for concurrent use
+	//
+	//  - mu: guards the sessions and the time they were used last
+	//
+	//  - sessions: the sessions by the chat IDs
+	//
+	//  - ttl: the inactive sessions not used for that long are evicted
+	//
+	//  - maxSize: the maximum number of the sessions, when it is reached the least recently used inactive session
+	//   is evicted to make room for a new one
+	//
+	//  - evicted: the number of the sessions evicted so far, it is atomic
+	//
+	//  - now: returns the current time, replaced in tests
+	sessionsCache struct {
+		mu       sync.Mutex
+		sessions map[int64]*session
+		ttl      time.Duration
+		maxSize  int
+		evicted  uint64
+		now      func() time.Time
+	}
+
+	// SessionsStats is a snapshot of the metrics of the sessions cache
+	//
+	//  - Cached: the number of the sessions in the cache
+	//
+	//  - Active: the number of the sessions processing a command
+	//
+	//  - Evicted: the number of the sessions evicted since the bot started
+	SessionsStats struct {
+		Cached  int
+		Active  int
+		Evicted uint64
+	}
 
 	// session represents a user session
 	//
@@ -43,28 +92,50 @@ type (
 	//
 	//  - messageChanel: a channel to recieve input from the user
 	//
+	//  - mu: guards the abort function and the input channel, they are set by the goroutine handling the updates
+	//   and cleared by the processing goroutine
+	//
 	//  - abortFunc: a function to abort the session, usually it is a ctx.CancelFunc
+	//
+	//  - inputDone: closed when the process stops taking the input, so the input sent after that isn't waited for,
+	//   a new one is made for every process
+	//
+	//  - store: saves the state of the session after every step, nil if the session is kept only in memory
+	//
+	//  - lastUsed: the time the session was got from the cache last, guarded by the mutex of the cache
 	session struct {
 		client *client
 
 		active        int32
 		expectInput   int32
 		messageChanel chan string
+		mu            sync.Mutex
 		abortFunc     func()
+		inputDone     chan struct{}
+		store         sessionStore
+		lastUsed      time.Time
 	}
 
-	// client contains information about the user
+	// client contains information about the user,
+	// currency is the user's base currency, empty until the user is populated
 	client struct {
 		chanID   int64
 		userID   int64
 		userGUID uuid.UUID
 		username string
+		currency string
 	}
 )
 
-// NewSessionsCache creates a new instance of sessionsCache.
+// NewSessionsCache creates a new instance of sessionsCache
+// evicting the sessions idle for sessionTTL and holding at most maxSessions of them.
 func NewSessionsCache() *sessionsCache {
-	return &sessionsCache{}
+	return &sessionsCache{
+		sessions: make(map[int64]*session),
+		ttl:      sessionTTL,
+		maxSize:  maxSessions,
+		now:      time.Now,
+	}
 }
 
 // GetSession retrieves the session associated with the given chatID from the sessionsCache.
@@ -76,15 +147,22 @@ func NewSessionsCache() *sessionsCache {
 // Returns:
 //   - A pointer to the session if it exists, or nil if no session is found.
 func (s *sessionsCache) GetSession(chatID int64) *session {
-	session, ok := (*s)[chatID]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[chatID]
 	if !ok {
 		return nil
 	}
+	session.lastUsed = s.now()
 	return session
 }
 
-// AddSession creates a new session for a given chat ID and user details,
-// adds it to the sessions cache, and returns the created session.
+// AddSession returns the session of the chat ready to process a command, marked active,
+// so it can't be evicted before the process is set up.
+// The cached inactive session of the chat is reused with the data aquired before,
+// otherwise a new one is added, if the cache is full, the idle sessions are evicted first,
+// then the least recently used inactive one.
 //
 // Parameters:
 //   - chatID: The unique identifier for the chat.
@@ -92,17 +170,36 @@ func (s *sessionsCache) GetSession(chatID int64) *session {
 //   - username: The username of the user.
 //
 // Returns:
-//   - A pointer to the newly created session.
+//   - A pointer to the session, nil if the cache is full of active sessions.
 func (s *sessionsCache) AddSession(chatID int64, userID int64, username string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if cached, ok := s.sessions[chatID]; ok && !cached.isActive() {
+		cached.setActive(true)
+		cached.lastUsed = now
+		return cached
+	}
+
+	if len(s.sessions) >= s.maxSize {
+		s.evictIdleLocked(now)
+		if len(s.sessions) >= s.maxSize && !s.evictLeastRecentLocked() {
+			return nil
+		}
+	}
+
 	newSession := &session{
 		client: &client{
 			chanID:   chatID,
 			userID:   userID,
 			username: username,
 		},
+		active:        1,
 		messageChanel: make(chan string),
+		lastUsed:      now,
 	}
-	(*s)[chatID] = newSession
+	s.sessions[chatID] = newSession
 
 	return newSession
 }
@@ -116,19 +213,138 @@ func (s *sessionsCache) AddSession(chatID int64, userID int64, username string)
 // Returns:
 //   - error: An error if the session does not exist or if the termination process fails.
 func (s *sessionsCache) TerminateSession(chatID int64) error {
-	session, ok := (*s)[chatID]
+	s.mu.Lock()
+	session, ok := s.sessions[chatID]
+	s.mu.Unlock()
+
 	if !ok {
 		return fmt.Errorf("sessionsCache.TerminateSession: there is no session with chatID %d", chatID)
 	}
 	return session.terminateSession()
 }
 
+// EvictIdle removes the inactive sessions not used for the ttl of the cache.
+//
+// Returns:
+//   - int: The number of the evicted sessions.
+func (s *sessionsCache) EvictIdle() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evictIdleLocked(s.now())
+}
+
+// Stats returns the current metrics of the cache.
+func (s *sessionsCache) Stats() SessionsStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SessionsStats{
+		Cached:  len(s.sessions),
+		Evicted: atomic.LoadUint64(&s.evicted),
+	}
+	for _, session := range s.sessions {
+		if session.isActive() {
+			stats.Active++
+		}
+	}
+	return stats
+}
+
+// runEviction evicts the idle sessions every evictionInterval and logs the metrics of the cache
+// until the context is cancelled
+func (s *sessionsCache) runEviction(ctx context.Context, log *logrus.Logger) {
+	ticker := time.NewTicker(evictionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			evicted := s.EvictIdle()
+			stats := s.Stats()
+			log.WithFields(logrus.Fields{
+				"cached":        stats.Cached,
+				"active":        stats.Active,
+				"evicted":       evicted,
+				"evicted_total": stats.Evicted,
+			}).Debug("sessions evicted")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// evictIdleLocked removes the inactive sessions not used since now minus the ttl, the mutex has to be held
+func (s *sessionsCache) evictIdleLocked(now time.Time) int {
+	var evicted int
+	for chatID, session := range s.sessions {
+		if !session.isActive() && now.Sub(session.lastUsed) > s.ttl {
+			delete(s.sessions, chatID)
+			evicted++
+		}
+	}
+	atomic.AddUint64(&s.evicted, uint64(evicted))
+	return evicted
+}
+
+// evictLeastRecentLocked removes the least recently used inactive session, the mutex has to be held,
+// returns false if all the sessions are active
+func (s *sessionsCache) evictLeastRecentLocked() bool {
+	var (
+		oldestID int64
+		oldest   *session
+	)
+	for chatID, session := range s.sessions {
+		if session.isActive() {
+			continue
+		}
+		if oldest == nil || session.lastUsed.Before(oldest.lastUsed) {
+			oldestID, oldest = chatID, session
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+
+	delete(s.sessions, oldestID)
+	atomic.AddUint64(&s.evicted, 1)
+	return true
+}
+
 // TransmitInput transmits input to the session's message channel.
 //
 // Parameters:
 //   - input: The input string to be transmitted.
-func (s *session) TransmitInput(input string) {
-	s.messageChanel <- input
+//
+// Returns:
+//   - error: errSessionFinished if the process stopped taking the input before it got the input, otherwise nil.
+func (s *session) TransmitInput(input string) error {
+	s.mu.Lock()
+	done := s.inputDone
+	s.mu.Unlock()
+
+	select {
+	case s.messageChanel <- input:
+		return nil
+	case <-done:
+		return fmt.Errorf("session.TransmitInput: %w", errSessionFinished)
+	}
+}
+
+// stopInput makes the session stop taking the input, the input transmitted after that is rejected
+// instead of waiting for the process that won't receive it.
+func (s *session) stopInput() {
+	s.setExpectInput(false)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inputDone == nil {
+		return
+	}
+	select {
+	case <-s.inputDone:
+	default:
+		close(s.inputDone)
+	}
 }
 
 // SetUpActive initializes the session as active and sets up a context for it.
@@ -136,24 +352,39 @@ func (s *session) setUpActive(ctx context.Context, log *logrus.Logger) context.C
 	defer log.Debug("the process is set up")
 	s.setExpectInput(true)
 	s.setActive(true)
-	newContext, abort := context.WithCancel(ctx)
-	s.abortFunc = abort
+	newContext, abort := context.WithCancelCause(ctx)
+	s.mu.Lock()
+	s.abortFunc = func() { abort(errSessionAborted) }
+	s.inputDone = make(chan struct{})
+	s.mu.Unlock()
 	return newContext
 }
 
-// close closes the session making it inactive and setting the abort function to nil.
+// close closes the session making it inactive and setting the abort function to nil,
+// the context of the finished process is cancelled to release it.
 func (s *session) close() {
-	s.setExpectInput(false)
-	s.setActive(false)
+	s.mu.Lock()
+	abort := s.abortFunc
 	s.abortFunc = nil
+	s.mu.Unlock()
+
+	s.stopInput()
+	s.setActive(false)
+	if abort != nil {
+		abort()
+	}
 }
 
 // terminateSession terminates the session by calling the abort function.
 func (s *session) terminateSession() error {
-	if s.abortFunc == nil {
+	s.mu.Lock()
+	abort := s.abortFunc
+	s.mu.Unlock()
+
+	if abort == nil {
 		return fmt.Errorf("session.Abort: the session is not active now")
 	}
-	s.abortFunc()
+	abort()
 	return nil
 }
 
@@ -173,8 +404,16 @@ func (s *session) terminateSession() error {
 //   - Processes each message using the `processInput` method.
 //   - Resets a timeout timer after each processed message.
 //   - Sends a timeout message and terminates if no input is received within the timeout period.
-//   - Terminates the session if the context is canceled or the last command is reached.
+//   - Terminates the session if the context is canceled or the last command is reached,
+//     the session stops taking the input before anything else is done, so no input waits for it.
+//   - Saves the state after every step if the session has a store, the state is kept
+//     when the context is cancelled because the bot stops, so the session can be resumed.
 func (s *session) Process(ctx context.Context, log *logrus.Logger, cmd command, sender Sender, srvc service.ServiceInterface) {
+	s.process(ctx, log, cmd, initBatch(cmd.ID), sender, srvc)
+}
+
+// process runs the processing loop of Process starting from the command with the batch collected so far
+func (s *session) process(ctx context.Context, log *logrus.Logger, cmd command, batch any, sender Sender, srvc service.ServiceInterface) {
 
 	log.Info(fmt.Sprintf("processing goroutine for %s started", s.client.username))
 	defer func() {
@@ -182,7 +421,7 @@ func (s *session) Process(ctx context.Context, log *logrus.Logger, cmd command,
 		s.close()
 	}()
 
-	batch := initBatch(cmd.ID)
+	s.saveState(ctx, cmd, batch, log)
 	timer := time.NewTimer(timeout)
 	for {
 		select {
@@ -190,19 +429,31 @@ func (s *session) Process(ctx context.Context, log *logrus.Logger, cmd command,
 			timer.Stop()
 
 			log.Debugf("in goroutine for %s got message: %s", s.client.username, msg)
-			if s.processInput(msg, &cmd, log, srvc, sender, &batch) {
+			if s.processInput(ctx, msg, &cmd, log, srvc, sender, &batch) {
 				log.Info("last command reached")
+				s.stopInput()
+				s.forgetState(ctx, log)
 				return
 			}
+			s.saveState(ctx, cmd, batch, log)
 			s.setExpectInput(true)
 
 			timer.Reset(timeout)
 		case <-timer.C:
+			s.stopInput()
 			log.Infof("timeout for goroutine for %s", s.client.username)
+			s.forgetState(ctx, log)
 			sender.Send(tgbotapi.NewMessage(s.client.chanID, MessageTimeout))
 			return
 		case <-ctx.Done():
+			s.stopInput()
+			if s.store != nil && !errors.Is(context.Cause(ctx), errSessionAborted) {
+				log.Infof("suspended goroutine for %s because the bot stops", s.client.username)
+				sender.Send(tgbotapi.NewMessage(s.client.chanID, MessageSessionSuspended))
+				return
+			}
 			log.Infof("interrupted goroutine for %s because of the context", s.client.username)
+			s.forgetState(context.WithoutCancel(ctx), log) // the session context is done, but the state still has to be deleted
 			sender.Send(tgbotapi.NewMessage(s.client.chanID, MessageAbort))
 			return
 		}
@@ -210,10 +461,31 @@ func (s *session) Process(ctx context.Context, log *logrus.Logger, cmd command,
 
 }
 
+// saveState saves the command waiting for the input and the batch, if the session has a store
+func (s *session) saveState(ctx context.Context, cmd command, batch any, log *logrus.Logger) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.save(ctx, s.client, cmd.ID, batch); err != nil {
+		log.WithError(err).Errorf("error saving session of %s", s.client.username)
+	}
+}
+
+// forgetState deletes the saved state of the finished session, if the session has a store
+func (s *session) forgetState(ctx context.Context, log *logrus.Logger) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.forget(ctx, s.client.chanID); err != nil {
+		log.WithError(err).Errorf("error deleting session of %s", s.client.username)
+	}
+}
+
 // processInput processes the user input for a given command in the session.
 // It validates the input, executes the associated action, and determines if the command sequence is complete.
 //
 // Parameters:
+//   - ctx: The context of the handling, the calls to the service are cancelled with it.
 //   - input: The user-provided input string to be processed.
 //   - cmd: A pointer to the current command being processed.
 //   - log: A logger instance for logging purposes.
@@ -223,14 +495,14 @@ func (s *session) Process(ctx context.Context, log *logrus.Logger, cmd command,
 //
 // Returns:
 //   - finished: A boolean indicating whether the last command reached.
-func (s *session) processInput(input string, cmd *command, log *logrus.Logger, srvc service.ServiceInterface, sender Sender, batch *any) (finished bool) {
+func (s *session) processInput(ctx context.Context, input string, cmd *command, log *logrus.Logger, srvc service.ServiceInterface, sender Sender, batch *any) (finished bool) {
 	matches := cmd.validateInput(input)
 	if matches == nil {
 		sender.Send(tgbotapi.NewMessage(s.client.chanID, MessageWrongInput))
 		return false
 	}
 
-	cmd.action(matches, batch, srvc, log, sender, s.client, cmd)
+	cmd.action(ctx, matches, batch, srvc, log, sender, s.client, cmd)
 	if cmd.isLast() {
 		return true
 	}
@@ -242,9 +514,9 @@ func (s *session) processInput(input string, cmd *command, log *logrus.Logger, s
 // populateUserGUID populates the userGUID field of the client struct.
 //
 // It makes sure the client has a userGUID
-func (cl *client) populateUserGUID(srvc service.ServiceInterface, log *logrus.Logger) error {
+func (cl *client) populateUserGUID(ctx context.Context, srvc service.ServiceInterface, log *logrus.Logger) error {
 	if cl.userGUID == uuid.Nil {
-		user, err := srvc.GetUsers(srvc.UsersWithTelegramIDs([]string{fmt.Sprint(cl.userID)}))
+		user, err := srvc.GetUsers(ctx, srvc.UsersWithTelegramIDs([]string{fmt.Sprint(cl.userID)}))
 		if err != nil {
 			log.WithError(err).Error("error on get user")
 			return fmt.Errorf("fillUserGUID: %w", err)
@@ -253,7 +525,7 @@ func (cl *client) populateUserGUID(srvc service.ServiceInterface, log *logrus.Lo
 		if len(user) == 0 {
 			log.Debug("adding user with username: ", cl.username)
 			var addedUserGUID []uuid.UUID
-			addedUserGUID, err = srvc.AddUsers([]ftracker.User{{TelegramID: fmt.Sprint(cl.userID), Username: cl.username}})
+			addedUserGUID, err = srvc.AddUsers(ctx, []ftracker.User{{TelegramID: fmt.Sprint(cl.userID), Username: cl.username}})
 			if err != nil {
 				log.WithError(err).Error("error on add user")
 				return fmt.Errorf("fillUserGUID: %w", err)
@@ -261,12 +533,21 @@ func (cl *client) populateUserGUID(srvc service.ServiceInterface, log *logrus.Lo
 			cl.userGUID = addedUserGUID[0]
 		} else {
 			cl.userGUID = user[0].GUID
+			cl.currency = user[0].Currency
 		}
 	}
 
 	return nil
 }
 
+// baseCurrency returns the currency the user's totals are shown in.
+func (cl *client) baseCurrency() string {
+	if cl.currency == "" {
+		return ftracker.DefaultCurrency
+	}
+	return cl.currency
+}
+
 // isActive checks if the session is active.
 func (s *session) isActive() bool {
 	return atomic.LoadInt32(&s.active) == 1
@@ -1,9 +1,12 @@
 package bot
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	gomock "github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
@@ -34,7 +37,7 @@ func TestClient_populateUserGUID(t *testing.T) {
 			},
 			mockBeh: func(srv *mock_service.MockServiceInterface) {
 				srv.EXPECT().UsersWithTelegramIDs([]string{"1"})
-				srv.EXPECT().GetUsers(nil).Return([]ftracker.User{{
+				srv.EXPECT().GetUsers(gomock.Any(), nil).Return([]ftracker.User{{
 					GUID: uuid.New(),
 				}}, nil)
 			},
@@ -48,8 +51,8 @@ func TestClient_populateUserGUID(t *testing.T) {
 			},
 			mockBeh: func(srv *mock_service.MockServiceInterface) {
 				srv.EXPECT().UsersWithTelegramIDs([]string{"1"})
-				srv.EXPECT().GetUsers(nil).Return([]ftracker.User{}, nil)
-				srv.EXPECT().AddUsers([]ftracker.User{{Username: "test", TelegramID: "1"}}).Return([]uuid.UUID{uuid.New()}, nil)
+				srv.EXPECT().GetUsers(gomock.Any(), nil).Return([]ftracker.User{}, nil)
+				srv.EXPECT().AddUsers(gomock.Any(), []ftracker.User{{Username: "test", TelegramID: "1"}}).Return([]uuid.UUID{uuid.New()}, nil)
 			},
 			wantErr: false,
 		},
@@ -61,8 +64,8 @@ func TestClient_populateUserGUID(t *testing.T) {
 			},
 			mockBeh: func(srv *mock_service.MockServiceInterface) {
 				srv.EXPECT().UsersWithTelegramIDs([]string{"1"})
-				srv.EXPECT().GetUsers(nil).Return([]ftracker.User{}, nil)
-				srv.EXPECT().AddUsers([]ftracker.User{{Username: "test", TelegramID: "1"}}).Return(nil, errors.New("error"))
+				srv.EXPECT().GetUsers(gomock.Any(), nil).Return([]ftracker.User{}, nil)
+				srv.EXPECT().AddUsers(gomock.Any(), []ftracker.User{{Username: "test", TelegramID: "1"}}).Return(nil, errors.New("error"))
 			},
 			wantErr: true,
 		},
@@ -73,7 +76,7 @@ func TestClient_populateUserGUID(t *testing.T) {
 			defer controller.Finish()
 			srvc := mock_service.NewMockServiceInterface(controller)
 			tc.mockBeh(srvc)
-			err := tc.cl.populateUserGUID(srvc, test_log)
+			err := tc.cl.populateUserGUID(context.Background(), srvc, test_log)
 			if tc.wantErr {
 				require.Error(t, err)
 				return
@@ -83,3 +86,129 @@ func TestClient_populateUserGUID(t *testing.T) {
 		})
 	}
 }
+
+func Test_sessionsCache_EvictIdle(t *testing.T) {
+
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name          string
+		active        []int64
+		elapsed       time.Duration
+		expectEvicted int
+		expectCached  int
+	}{
+		{
+			name:          "Nothing_idle",
+			elapsed:       sessionTTL,
+			expectEvicted: 0,
+			expectCached:  3,
+		},
+		{
+			name:          "All_idle",
+			elapsed:       sessionTTL + time.Second,
+			expectEvicted: 3,
+			expectCached:  0,
+		},
+		{
+			name:          "Active_kept",
+			active:        []int64{2},
+			elapsed:       sessionTTL + time.Second,
+			expectEvicted: 2,
+			expectCached:  1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			now := start
+			cache := NewSessionsCache()
+			cache.now = func() time.Time { return now }
+
+			for chatID := int64(1); chatID <= 3; chatID++ {
+				cache.AddSession(chatID, chatID, "test_username").setActive(false)
+			}
+			for _, chatID := range tc.active {
+				cache.GetSession(chatID).setActive(true)
+			}
+
+			now = now.Add(tc.elapsed)
+			require.Equal(t, tc.expectEvicted, cache.EvictIdle())
+			require.Equal(t, SessionsStats{
+				Cached:  tc.expectCached,
+				Active:  len(tc.active),
+				Evicted: uint64(tc.expectEvicted),
+			}, cache.Stats())
+		})
+	}
+}
+
+func Test_sessionsCache_AddSession_full(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewSessionsCache()
+	cache.maxSize = 2
+	cache.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	first := cache.AddSession(1, 1, "first")
+	require.True(t, first.isActive(), "the added session is not active")
+	first.setActive(false)
+	cache.AddSession(2, 2, "second").setActive(false)
+	cache.GetSession(1) // the first one is used more recently now
+
+	third := cache.AddSession(3, 3, "third")
+	require.NotNil(t, third)
+	require.Nil(t, cache.GetSession(2), "the least recently used session is not evicted")
+	require.Equal(t, uint64(1), cache.Stats().Evicted)
+
+	require.Same(t, first, cache.AddSession(1, 1, "first"), "the cached session is not reused")
+	require.Nil(t, cache.AddSession(4, 4, "fourth"), "an active session is evicted")
+	require.Equal(t, SessionsStats{Cached: 2, Active: 2, Evicted: 1}, cache.Stats())
+}
+
+func Test_session_terminateSession(t *testing.T) {
+
+	s := &session{client: &client{}, messageChanel: make(chan string)}
+	require.Error(t, s.terminateSession())
+
+	ctx := s.setUpActive(context.Background(), test_log)
+	require.NoError(t, s.terminateSession())
+	require.ErrorIs(t, context.Cause(ctx), errSessionAborted)
+
+	s.close()
+	require.False(t, s.isActive())
+	require.Error(t, s.terminateSession())
+}
+
+func Test_session_TransmitInput_finished(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	sender := NewMockSender(controller)
+	sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageAbort))
+
+	s := &session{client: &client{chanID: 1}, messageChanel: make(chan string)}
+	ctx := s.setUpActive(context.Background(), test_log)
+	done := make(chan struct{})
+	go func() {
+		s.Process(ctx, test_log, commandsByIDs[1], sender, nil)
+		close(done)
+	}()
+	require.NoError(t, s.terminateSession())
+	<-done
+
+	// the input checked in before the process ended is rejected instead of blocking the worker
+	transmitted := make(chan error)
+	go func() { transmitted <- s.TransmitInput("food") }()
+	select {
+	case err := <-transmitted:
+		require.ErrorIs(t, err, errSessionFinished)
+	case <-time.After(time.Second):
+		require.FailNow(t, "the input is waiting for the finished session")
+	}
+	require.False(t, s.isExpectingInput())
+}
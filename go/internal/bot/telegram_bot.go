@@ -2,30 +2,71 @@ package bot
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/iv-sukhanov/finance_tracker/internal/service"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// time given to the session processes and the scheduler to finish when the bot stops,
+	// the messages they send before it ends are still delivered
+	shutdownGrace = 10 * time.Second
+)
+
 var (
 	// base keyboard with the commands that could be called form the /start state
 	baseKeyboard = tgbotapi.NewOneTimeReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton(CommandAddCategory),
+			tgbotapi.NewKeyboardButton(CommandAddIncomeCategory),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandEditCategory),
+			tgbotapi.NewKeyboardButton(CommandDeleteCategory),
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton(CommandShowCategories),
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton(CommandAddRecord),
+			tgbotapi.NewKeyboardButton(CommandAddIncome),
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton(CommandShowRecords),
+			tgbotapi.NewKeyboardButton(CommandBalance),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandShowBudgets),
+			tgbotapi.NewKeyboardButton(CommandSetBudget),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandAddRecurring),
+			tgbotapi.NewKeyboardButton(CommandShowRecurring),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandShowTags),
+			tgbotapi.NewKeyboardButton(CommandCurrency),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandImport),
+			tgbotapi.NewKeyboardButton(CommandBankImport),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(CommandPayeeRules),
+			tgbotapi.NewKeyboardButton(CommandAPIToken),
 		),
 	)
 )
 
+// evictingSessions is implemented by the sessions evicting the idle ones in the background
+type evictingSessions interface {
+	runEviction(ctx context.Context, log *logrus.Logger)
+}
+
 // TelegramBot is a struct that represents a telegram bot
 //
 //   - log: a logger to log messages
@@ -37,50 +78,161 @@ var (
 //   - sender: a sender to send messages to the user
 //
 //   - sessions: a sessions cache to store and retrieve the sessions
+//
+//   - files: a downloader of the files the users send as the input
+//
+//   - webhook: the webhook to receive the updates with, if nil, the updates are polled
+//
+//   - dispatch: the config of the workers handling the updates
+//
+//   - updates: the dispatcher of the updates to the workers, set when the bot starts
+//
+//   - running: the session processes and the scheduler the bot waits for when it stops
 type TelegramBot struct {
 	log      *logrus.Logger
 	api      *tgbotapi.BotAPI
 	service  service.ServiceInterface
 	sender   Sender
 	sessions Sessions
+	files    Downloader
+	webhook  *WebhookConfig
+	dispatch DispatcherConfig
+	updates  *dispatcher
+	running  sync.WaitGroup
 }
 
+// Option is a function to configure the TelegramBot.
+type Option func(*TelegramBot)
+
 // New creates a new instance of TelegramBot
-func New(service *service.Service, api *tgbotapi.BotAPI, log *logrus.Logger) *TelegramBot {
+func New(service *service.Service, api *tgbotapi.BotAPI, log *logrus.Logger, options ...Option) *TelegramBot {
 	sender := NewMessageSender(api, log)
-	return &TelegramBot{
+	b := &TelegramBot{
 		log:      log,
 		sender:   sender,
 		api:      api,
 		service:  service,
 		sessions: NewSessionsCache(),
+		files:    newAPIDownloader(api),
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// WithWebhook is a function that makes the bot receive the updates with the webhook instead of long polling.
+func WithWebhook(config WebhookConfig) Option {
+	return func(b *TelegramBot) {
+		b.webhook = &config
+	}
+}
+
+// WithDispatcher is a function that sets the number of the workers handling the updates and the size of their queues.
+func WithDispatcher(config DispatcherConfig) Option {
+	return func(b *TelegramBot) {
+		b.dispatch = config
 	}
 }
 
-// Start starts the bot and listens for updates
+// Start starts the bot and listens for updates,
+// either with the webhook if it is configured or with long polling.
+// The updates are handled by the workers of the dispatcher, in parallel across the chats
+// and in order within a chat.
+//
+// When the context is cancelled, the bot stops receiving updates, the queued ones are answered with MessageAbort,
+// the idle sessions are aborted and the busy ones get shutdownGrace to finish together with the workers,
+// then the messages left in the sender are sent.
+// Start returns after that, so the resources the bot uses can be released.
 func (b *TelegramBot) Start(ctx context.Context) {
 	b.log.Info("bot started successfully")
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
 
 	b.populateCommands()
-	go b.sender.Run(ctx)
+
+	// the sender outlives the context, the sessions still send their last messages while the bot stops
+	senderCtx, stopSender := context.WithCancel(context.WithoutCancel(ctx))
+	senderDone := make(chan struct{})
+	go func() {
+		defer close(senderDone)
+		b.sender.Run(senderCtx)
+	}()
+
+	if sessions, ok := b.sessions.(*PersistentSessions); ok {
+		b.resumeSessions(ctx, sessions)
+	}
+
+	b.running.Add(1)
+	go func() {
+		defer b.running.Done()
+		b.runScheduler(ctx)
+	}()
+
+	if cache, ok := b.sessions.(evictingSessions); ok {
+		go cache.runEviction(ctx, b.log)
+	}
 
 	//for debuging, disabled for now
 	//go b.displayMap()
 
+	b.updates = newDispatcher(b.dispatch, b.HandleUpdate, b.log)
+	b.updates.start(ctx)
+
+	if b.webhook != nil {
+		b.listenWebhook(ctx)
+	} else {
+		b.pollUpdates(ctx)
+	}
+	deadline := time.Now().Add(shutdownGrace)
+	if !b.updates.stop(shutdownGrace) {
+		b.log.Warn("some updates were not handled in time")
+	}
+	if !b.waitRunning(time.Until(deadline)) {
+		b.log.Warn("some sessions did not finish in time, their messages may be lost")
+	}
+	stopSender()
+	<-senderDone
+	b.log.Info("bot stopped")
+}
+
+// pollUpdates dispatches the updates received with long polling until the context is cancelled
+func (b *TelegramBot) pollUpdates(ctx context.Context) {
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = 60
+
 	updates := b.api.GetUpdatesChan(updateConfig)
 	for {
 		select {
 		case update := <-updates:
-			b.HandleUpdate(ctx, update)
+			if err := b.updates.dispatch(ctx, update); err != nil {
+				b.log.WithError(err).Warn("update dropped")
+			}
 		case <-ctx.Done():
 			b.log.Info("context cancelled, stopping updates loop")
+			b.api.StopReceivingUpdates()
 			return
 		}
 	}
 }
 
+// waitRunning waits for the session processes and the scheduler to finish, but no longer than grace,
+// returns false if some of them are still running
+func (b *TelegramBot) waitRunning(grace time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // HandleUpdate processes incoming updates from the Telegram Bot API.
 // It handles both messages and callback queries, routing them to the appropriate
 // handlers based on their type and content. The function also manages user sessions
@@ -97,15 +249,23 @@ func (b *TelegramBot) Start(ctx context.Context) {
 //	- Checks if the message is a command and processes it accordingly.
 //	- If the message is not a command, it checks for an active session
 //	  and if the session is active and expects input it transmits the string to its goroutine.
-//	- If the session is not active, it creates a new session and starts processing the base command.
+//	- If the session is not active, it takes the session from the cache and starts processing the base command,
+//	  the user is told to wait if the cache is full of active sessions.
 //	If the update contains a callback query:
 //	- Processes the callback query and checks for an active session.
+//	If the context is done, the update is answered with MessageAbort, nothing else is done.
 func (b *TelegramBot) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 
 	b.log.Debug("processing started for update: ", update.UpdateID)
 	defer b.log.Debug("processing finished for update: ", update.UpdateID)
 
+	if ctx.Err() != nil { // the update was queued before the bot started to stop, the sessions can't take it anymore
+		b.rejectUpdate(update)
+		return
+	}
+
 	var recievedText string
+	var document *tgbotapi.Document
 	var chatID int64
 	var processingCallback bool = false
 	if update.Message == nil {
@@ -145,6 +305,7 @@ func (b *TelegramBot) HandleUpdate(ctx context.Context, update tgbotapi.Update)
 		}
 
 		recievedText = update.Message.Text
+		document = update.Message.Document
 		chatID = update.Message.Chat.ID
 		b.log.Debug("recieved text: ", update.Message.Text)
 	}
@@ -153,9 +314,19 @@ func (b *TelegramBot) HandleUpdate(ctx context.Context, update tgbotapi.Update)
 
 	if session != nil && session.isActive() { //check if the session is active and expects input
 		if session.isExpectingInput() {
+			if document != nil { // the content of the file is the input, the session keeps waiting if it can't be read
+				content, errText := b.readDocument(ctx, document)
+				if errText != "" {
+					b.sender.Send(tgbotapi.NewMessage(chatID, errText))
+					return
+				}
+				recievedText = content
+			}
 			b.log.Debugf("transmiting %s to %s", recievedText, session.client.username)
 			session.setExpectInput(false)
-			session.TransmitInput(recievedText)
+			if err := session.TransmitInput(recievedText); err != nil { // the process ended after the check
+				b.log.WithError(err).Debugf("input of %s dropped", session.client.username)
+			}
 			return
 		}
 		b.log.Debug("session is active, but not expecting input")
@@ -174,19 +345,57 @@ func (b *TelegramBot) HandleUpdate(ctx context.Context, update tgbotapi.Update)
 		b.sender.Send(tgbotapi.NewMessage(chatID, MessageUnknownCommand))
 		return
 	}
-	b.sender.Send(composeBaseReply(command.ID, update.Message))
 	b.log.Debug("Command check done, command id: ", command.ID)
 
-	if session == nil { // conpose a new session if there is no cached one
-		b.log.Debugf("new session for %s", update.Message.From.UserName)
-		session = b.sessions.AddSession(
-			chatID,
-			update.Message.From.ID,
-			update.Message.From.UserName,
-		)
+	// the cached session is reused by the cache, the session is taken active so it can't be evicted in the meantime
+	b.log.Debugf("session for %s", update.Message.From.UserName)
+	session = b.sessions.AddSession(
+		chatID,
+		update.Message.From.ID,
+		update.Message.From.UserName,
+	)
+	if session == nil {
+		b.log.Warn("sessions cache is full, rejecting the command")
+		b.sender.Send(tgbotapi.NewMessage(chatID, MessageTooManySessions))
+		return
+	}
+	b.sender.Send(composeBaseReply(command.ID, update.Message))
+
+	sessionCtx := session.setUpActive(ctx, b.log)
+	b.running.Add(1)
+	go func() { //starts pocessing of the session in a different goroutine
+		defer b.running.Done()
+		session.Process(sessionCtx, b.log, command, b.sender, b.service)
+	}()
+}
+
+// rejectUpdate answers the update the bot can't handle because it stops
+func (b *TelegramBot) rejectUpdate(update tgbotapi.Update) {
+	chat := update.FromChat()
+	if chat == nil {
+		b.log.Debugf("update %d dropped", update.UpdateID)
+		return
 	}
+	b.log.Debugf("update %d rejected because the bot stops", update.UpdateID)
+	b.sender.Send(tgbotapi.NewMessage(chat.ID, MessageAbort))
+}
 
-	go session.Process(session.setUpActive(ctx, b.log), b.log, command, b.sender, b.service) //starts pocessing of the session in a different goroutine
+// readDocument downloads the file sent by the user to pass its content as the input,
+// on failure it returns the text of the message for the user
+func (b *TelegramBot) readDocument(ctx context.Context, document *tgbotapi.Document) (string, string) {
+	if document.FileSize > maxDocumentSize {
+		return "", MessageDocumentTooLarge
+	}
+
+	content, err := b.files.Download(ctx, document.FileID)
+	if errors.Is(err, ErrDocumentTooLarge) {
+		return "", MessageDocumentTooLarge
+	}
+	if err != nil {
+		b.log.WithError(err).Error("error on download document")
+		return "", MessageDocumentError
+	}
+	return string(content), ""
 }
 
 // func (b *TelegramBot) displayMap() {
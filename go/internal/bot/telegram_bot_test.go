@@ -2,11 +2,18 @@ package bot
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/golang/mock/gomock"
+	mock_service "github.com/iv-sukhanov/finance_tracker/internal/service/mock"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/require"
 )
@@ -157,14 +164,25 @@ func TestTelegramBot_HandleUpdate(t *testing.T) {
 				sender.EXPECT().Send(msg)
 			},
 			sessionsBehavior: func(sessions *MockSessions) {
-				sessions.EXPECT().GetSession(gomock.Any()).Return(
-					&session{
-						client:        &client{username: "test"},
-						active:        0,
-						expectInput:   0,
-						messageChanel: make(chan string),
-					},
-				)
+				cached := &session{
+					client:        &client{username: "test"},
+					active:        0,
+					expectInput:   0,
+					messageChanel: make(chan string),
+				}
+				sessions.EXPECT().GetSession(gomock.Any()).Return(cached)
+				sessions.EXPECT().AddSession(int64(1), int64(1), "test_username").Return(cached)
+			},
+			update: newUpdateWithMessage(CommandAddCategory),
+		},
+		{
+			name: "Sessions_full",
+			senderBehavior: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageTooManySessions))
+			},
+			sessionsBehavior: func(sessions *MockSessions) {
+				sessions.EXPECT().GetSession(gomock.Any()).Return(nil)
+				sessions.EXPECT().AddSession(int64(1), int64(1), "test_username").Return(nil)
 			},
 			update: newUpdateWithMessage(CommandAddCategory),
 		},
@@ -194,3 +212,308 @@ func TestTelegramBot_HandleUpdate(t *testing.T) {
 		})
 	}
 }
+
+// newFakeTelegram starts a fake Telegram API that answers getUpdates with the update once
+// and with no updates afterwards, every other method succeeds
+func newFakeTelegram(t *testing.T, update string) (*httptest.Server, *tgbotapi.BotAPI) {
+	var polled int32
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			if atomic.CompareAndSwapInt32(&polled, 0, 1) {
+				w.Write([]byte(`{"ok":true,"result":[` + update + `]}`))
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte(`{"ok":true,"result":[]}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", telegram.URL+"/bot%s/%s")
+	require.NoError(t, err)
+	return telegram, api
+}
+
+func TestTelegramBot_Start_shutdown(t *testing.T) {
+
+	const update = `{"update_id":1,"message":{"message_id":7,"from":{"id":1,"is_bot":false,"first_name":"test","username":"test_username"},` +
+		`"chat":{"id":1,"type":"private"},"date":1700000000,"text":"` + CommandShowCategories + `"}}`
+
+	telegram, api := newFakeTelegram(t, update)
+	defer telegram.Close()
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	replied := make(chan struct{})
+	var aborted int32
+
+	mockSender := NewMockSender(controller)
+	mockSender.EXPECT().Run(gomock.Any()).DoAndReturn(func(ctx context.Context) {
+		<-ctx.Done()
+		// the sender has to outlive the sessions to deliver their last messages
+		require.Equal(t, int32(1), atomic.LoadInt32(&aborted), "the sender stopped before the session was aborted")
+	})
+	reply := tgbotapi.NewMessage(1, MessageShowCategories)
+	reply.ReplyToMessageID = 7
+	mockSender.EXPECT().Send(reply).Do(func(tgbotapi.MessageConfig) { close(replied) })
+	mockSender.EXPECT().Send(tgbotapi.NewMessage(1, MessageAbort)).Do(func(tgbotapi.MessageConfig) {
+		atomic.StoreInt32(&aborted, 1)
+	})
+
+	mockSessions := NewMockSessions(controller)
+	mockSessions.EXPECT().GetSession(int64(1)).Return(nil)
+	mockSessions.EXPECT().AddSession(int64(1), int64(1), "test_username").Return(
+		&session{
+			client:        &client{chanID: 1, userID: 1, username: "test_username"},
+			messageChanel: make(chan string),
+		},
+	)
+
+	mockService := mock_service.NewMockServiceInterface(controller)
+	mockService.EXPECT().GetDueRecurring(gomock.Any(), gomock.Any(), schedulerBatch).Return(nil, nil).AnyTimes()
+
+	b := &TelegramBot{
+		sender:   mockSender,
+		sessions: mockSessions,
+		service:  mockService,
+		log:      test_log,
+		api:      api,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-replied:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the update was not handled")
+	}
+	cancel() // the session is waiting for the input now
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownGrace):
+		require.FailNow(t, "the bot did not stop")
+	}
+}
+
+func TestTelegramBot_HandleUpdate_concurrent(t *testing.T) {
+
+	const (
+		chats  = 50
+		rounds = 3
+	)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockSender := NewMockSender(controller)
+	mockSender.EXPECT().Send(gomock.Any()).AnyTimes()
+
+	sessions := NewSessionsCache()
+	sessions.maxSize = chats / 2 // some of the chats don't get a session while the others are busy
+
+	b := &TelegramBot{
+		sender:   mockSender,
+		sessions: sessions,
+		service:  mock_service.NewMockServiceInterface(controller),
+		log:      test_log,
+	}
+
+	newUpdate := func(chatID int64, text string) tgbotapi.Update {
+		message := &tgbotapi.Message{
+			Text: text,
+			Chat: &tgbotapi.Chat{ID: chatID},
+			From: &tgbotapi.User{ID: chatID, UserName: "test_username"},
+		}
+		if strings.HasPrefix(text, "/") {
+			message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(text)}}
+		}
+		return tgbotapi.Update{Message: message}
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for chatID := int64(1); chatID <= chats; chatID++ {
+		wg.Add(1)
+		go func(chatID int64) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				b.HandleUpdate(ctx, newUpdate(chatID, CommandShowCategories))
+				b.HandleUpdate(ctx, newUpdate(chatID, "/abort"))
+
+				// the next command has to find the session closed, otherwise it is the input of the previous one
+				for s := sessions.GetSession(chatID); s != nil && s.isActive(); s = sessions.GetSession(chatID) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(chatID)
+	}
+
+	stopStats := make(chan struct{})
+	statsDone := make(chan struct{})
+	var maxCached int
+	go func() {
+		defer close(statsDone)
+		for {
+			select {
+			case <-stopStats:
+				return
+			default:
+				maxCached = max(maxCached, sessions.Stats().Cached)
+				sessions.EvictIdle()
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(stopStats)
+	<-statsDone
+	require.LessOrEqual(t, maxCached, chats/2)
+
+	require.True(t, b.waitRunning(5*time.Second), "the sessions did not finish")
+	stats := sessions.Stats()
+	require.Zero(t, stats.Active)
+	require.LessOrEqual(t, stats.Cached, chats/2)
+	require.Positive(t, stats.Evicted, "no room was made for the other chats")
+}
+
+func TestTelegramBot_HandleUpdate_document(t *testing.T) {
+
+	newUpdateWithDocument := func(size int) tgbotapi.Update {
+		return tgbotapi.Update{
+			Message: &tgbotapi.Message{
+				Document: &tgbotapi.Document{FileID: "file_id", FileName: "records.csv", FileSize: size},
+				Chat:     &tgbotapi.Chat{ID: 1},
+				From:     &tgbotapi.User{ID: 1, UserName: "test_username"},
+			},
+		}
+	}
+
+	tt := []struct {
+		name           string
+		update         tgbotapi.Update
+		senderBehavior func(*MockSender)
+		filesBehavior  func(*MockDownloader)
+		wantInput      string
+	}{
+		{
+			name:           "Ok",
+			update:         newUpdateWithDocument(16),
+			senderBehavior: func(sender *MockSender) {},
+			filesBehavior: func(files *MockDownloader) {
+				files.EXPECT().Download(gomock.Any(), "file_id").Return([]byte("category,amount\n"), nil)
+			},
+			wantInput: "category,amount\n",
+		},
+		{
+			name:   "Too_large",
+			update: newUpdateWithDocument(maxDocumentSize + 1),
+			senderBehavior: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageDocumentTooLarge))
+			},
+			filesBehavior: func(files *MockDownloader) {},
+		},
+		{
+			name:   "Too_large_unreported",
+			update: newUpdateWithDocument(0),
+			senderBehavior: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageDocumentTooLarge))
+			},
+			filesBehavior: func(files *MockDownloader) {
+				files.EXPECT().Download(gomock.Any(), "file_id").Return(nil, ErrDocumentTooLarge)
+			},
+		},
+		{
+			name:   "Download_error",
+			update: newUpdateWithDocument(16),
+			senderBehavior: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageDocumentError))
+			},
+			filesBehavior: func(files *MockDownloader) {
+				files.EXPECT().Download(gomock.Any(), "file_id").Return(nil, errors.New("error"))
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockSender := NewMockSender(controller)
+			tc.senderBehavior(mockSender)
+			mockFiles := NewMockDownloader(controller)
+			tc.filesBehavior(mockFiles)
+
+			s := &session{
+				client:        &client{username: "test_username"},
+				active:        1,
+				expectInput:   1,
+				messageChanel: make(chan string, 1),
+			}
+			mockSessions := NewMockSessions(controller)
+			mockSessions.EXPECT().GetSession(int64(1)).Return(s)
+
+			b := &TelegramBot{
+				sender:   mockSender,
+				sessions: mockSessions,
+				files:    mockFiles,
+				log:      test_log,
+			}
+			b.HandleUpdate(context.Background(), tc.update)
+
+			if tc.wantInput == "" { // the session keeps waiting for the input it can read
+				require.True(t, s.isExpectingInput())
+				require.Empty(t, s.messageChanel)
+				return
+			}
+			require.False(t, s.isExpectingInput())
+			require.Equal(t, tc.wantInput, <-s.messageChanel)
+		})
+	}
+}
+
+func Test_apiDownloader_Download(t *testing.T) {
+
+	content := []byte("category,amount\nfood,12.34\n")
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/getFile"):
+			path := "documents/" + r.FormValue("file_id")
+			w.Write([]byte(`{"ok":true,"result":{"file_id":"id","file_path":"` + path + `"}}`))
+		case strings.HasSuffix(r.URL.Path, "/documents/small"):
+			w.Write(content)
+		case strings.HasSuffix(r.URL.Path, "/documents/large"):
+			w.Write(make([]byte, maxDocumentSize+1))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer telegram.Close()
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", telegram.URL+"/bot%s/%s")
+	require.NoError(t, err)
+	files := newAPIDownloader(api)
+	files.endpoint = telegram.URL + "/file/bot%s/%s"
+
+	got, err := files.Download(context.Background(), "small")
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+
+	_, err = files.Download(context.Background(), "large")
+	require.ErrorIs(t, err, ErrDocumentTooLarge)
+
+	_, err = files.Download(context.Background(), "missing")
+	require.Error(t, err)
+}
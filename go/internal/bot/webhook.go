@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// header Telegram puts the secret token of the webhook into
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+	// time given to read the headers of a webhook request and to finish the requests in flight when the bot stops
+	webhookTimeout = 10 * time.Second
+
+	// number of the random bytes of the generated secret token, it is sent hex encoded
+	secretTokenBytes = 32
+)
+
+// WebhookConfig is a struct that configures receiving the updates with a webhook instead of long polling
+//
+//   - URL: the public HTTPS address Telegram posts the updates to, its path is the one the server handles
+//
+//   - ListenAddr: the address the embedded HTTP server listens on, like :8080
+//
+//   - SecretToken: the token Telegram sends with every update, the updates without it are rejected,
+//     a random token is generated on start if it is empty
+type WebhookConfig struct {
+	URL         string
+	ListenAddr  string
+	SecretToken string
+}
+
+// listenWebhook registers the webhook and serves the updates posted by Telegram until the context is cancelled,
+// the webhook is unregistered before returning, so the updates can be polled again
+func (b *TelegramBot) listenWebhook(ctx context.Context) {
+
+	link, err := url.Parse(b.webhook.URL)
+	if err != nil {
+		b.log.WithError(err).Error("invalid webhook url")
+		return
+	}
+	path := link.Path
+	if path == "" {
+		path = "/"
+	}
+
+	// the updates are never taken without a secret token, Telegram gets the generated one with the webhook
+	if b.webhook.SecretToken == "" {
+		if b.webhook.SecretToken, err = newSecretToken(); err != nil {
+			b.log.WithError(err).Error("error generating webhook secret token")
+			return
+		}
+		b.log.Info("webhook secret token is not set, a random one is used")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, b.webhookHandler())
+	server := &http.Server{
+		Addr:              b.webhook.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: webhookTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	if err := b.setWebhook(); err != nil {
+		b.log.WithError(err).Error("error setting webhook")
+		server.Close()
+		return
+	}
+	b.log.Infof("listening for webhook updates on %s%s", b.webhook.ListenAddr, path)
+
+	select {
+	case err := <-serverErr:
+		b.log.WithError(err).Error("webhook server stopped")
+	case <-ctx.Done():
+		b.log.Info("context cancelled, stopping webhook server")
+	}
+
+	if err := b.deleteWebhook(); err != nil {
+		b.log.WithError(err).Error("error deleting webhook")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		b.log.WithError(err).Error("error shutting down webhook server")
+	}
+}
+
+// webhookHandler returns the handler of the updates posted by Telegram,
+// every update is passed to the dispatcher, if its queue stays full for webhookDispatchWait,
+// Telegram is asked to deliver the update later
+func (b *TelegramBot) webhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if b.webhook.SecretToken == "" ||
+			subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(b.webhook.SecretToken)) != 1 {
+			b.log.Warn("webhook update with wrong secret token from ", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			b.log.WithError(err).Error("error decoding webhook update")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), webhookDispatchWait)
+		defer cancel()
+		if err := b.updates.dispatch(ctx, update); err != nil {
+			b.log.WithError(err).Warn("webhook update is not queued")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// setWebhook registers the webhook with Telegram, the config of the library lacks the secret token,
+// so the request is made by hand
+func (b *TelegramBot) setWebhook() error {
+	params := make(tgbotapi.Params)
+	params["url"] = b.webhook.URL
+	params["secret_token"] = b.webhook.SecretToken
+
+	resp, err := b.api.MakeRequest("setWebhook", params)
+	if err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	b.log.Debug("webhook set: ", resp.Description)
+	return nil
+}
+
+// deleteWebhook unregisters the webhook, the pending updates are kept for the next start
+func (b *TelegramBot) deleteWebhook() error {
+	resp, err := b.api.Request(tgbotapi.DeleteWebhookConfig{})
+	if err != nil {
+		return fmt.Errorf("deleteWebhook: %w", err)
+	}
+	b.log.Debug("webhook deleted: ", resp.Description)
+	return nil
+}
+
+// newSecretToken generates a random secret token of the webhook
+func newSecretToken() (string, error) {
+	raw := make([]byte, secretTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("newSecretToken: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
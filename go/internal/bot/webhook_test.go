@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webhookHandler(t *testing.T) {
+
+	const (
+		secret = "s3cr3t"
+		start  = `{"update_id":1,"message":{"message_id":1,"from":{"id":1,"is_bot":false,"first_name":"test","username":"test_username"},` +
+			`"chat":{"id":1,"type":"private"},"date":1700000000,"text":"/start","entities":[{"offset":0,"length":6,"type":"bot_command"}]}}`
+		unknown = `{"update_id":2,"message":{"message_id":2,"from":{"id":1,"is_bot":false,"first_name":"test","username":"test_username"},` +
+			`"chat":{"id":1,"type":"private"},"date":1700000000,"text":"/goida","entities":[{"offset":0,"length":6,"type":"bot_command"}]}}`
+	)
+
+	tt := []struct {
+		name           string
+		method         string
+		secret         string
+		body           string
+		senderBehavior func(*MockSender)
+		wantStatus     int
+	}{
+		{
+			name:   "Start",
+			method: http.MethodPost,
+			secret: secret,
+			body:   start,
+			senderBehavior: func(sender *MockSender) {
+				msg := tgbotapi.NewMessage(1, MessageStart)
+				msg.ReplyMarkup = baseKeyboard
+				sender.EXPECT().Send(msg)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Unknown_command",
+			method: http.MethodPost,
+			secret: secret,
+			body:   unknown,
+			senderBehavior: func(sender *MockSender) {
+				sender.EXPECT().Send(tgbotapi.NewMessage(1, MessageUnknownCommand))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:           "Wrong_secret",
+			method:         http.MethodPost,
+			secret:         "guess",
+			body:           start,
+			senderBehavior: func(sender *MockSender) {},
+			wantStatus:     http.StatusUnauthorized,
+		},
+		{
+			name:           "No_secret",
+			method:         http.MethodPost,
+			body:           start,
+			senderBehavior: func(sender *MockSender) {},
+			wantStatus:     http.StatusUnauthorized,
+		},
+		{
+			name:           "Invalid_update",
+			method:         http.MethodPost,
+			secret:         secret,
+			body:           `{"update_id":`,
+			senderBehavior: func(sender *MockSender) {},
+			wantStatus:     http.StatusBadRequest,
+		},
+		{
+			name:           "Wrong_method",
+			method:         http.MethodGet,
+			secret:         secret,
+			senderBehavior: func(sender *MockSender) {},
+			wantStatus:     http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockSender := NewMockSender(controller)
+			tc.senderBehavior(mockSender)
+
+			b := &TelegramBot{
+				sender:   mockSender,
+				sessions: NewMockSessions(controller),
+				log:      test_log,
+				webhook:  &WebhookConfig{SecretToken: secret},
+			}
+			b.updates = newDispatcher(DispatcherConfig{}, b.HandleUpdate, test_log)
+			b.updates.start(context.Background())
+			server := httptest.NewServer(b.webhookHandler())
+			defer server.Close()
+
+			req, err := http.NewRequest(tc.method, server.URL, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			if tc.secret != "" {
+				req.Header.Set(secretTokenHeader, tc.secret)
+			}
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, tc.wantStatus, resp.StatusCode)
+			require.True(t, b.updates.stop(5*time.Second)) // the queued update is handled before the expectations are checked
+		})
+	}
+}
+
+func Test_webhookHandler_emptySecret(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	// no update gets through a config without the secret token, with the header or without it
+	b := &TelegramBot{
+		sender:   NewMockSender(controller),
+		sessions: NewMockSessions(controller),
+		log:      test_log,
+		webhook:  &WebhookConfig{},
+	}
+	b.updates = newDispatcher(DispatcherConfig{}, b.HandleUpdate, test_log)
+	b.updates.start(context.Background())
+	server := httptest.NewServer(b.webhookHandler())
+	defer server.Close()
+
+	for _, header := range []string{"", "guess"} {
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"update_id":1}`))
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(secretTokenHeader, header)
+		}
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+	require.True(t, b.updates.stop(5*time.Second))
+}
+
+func Test_newSecretToken(t *testing.T) {
+
+	first, err := newSecretToken()
+	require.NoError(t, err)
+	second, err := newSecretToken()
+	require.NoError(t, err)
+
+	// Telegram takes up to 256 characters of A-Z, a-z, 0-9, _ and -
+	require.Regexp(t, `^[0-9a-f]{64}$`, first)
+	require.NotEqual(t, first, second)
+}
+
+func TestTelegramBot_setWebhook(t *testing.T) {
+
+	requests := make(map[string]map[string]string)
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		params := make(map[string]string)
+		for key := range r.PostForm {
+			params[key] = r.PostForm.Get(key)
+		}
+		requests[r.URL.Path] = params
+		w.Write([]byte(`{"ok":true,"result":true,"description":"done"}`))
+	}))
+	defer telegram.Close()
+
+	api := &tgbotapi.BotAPI{Token: "token", Client: telegram.Client(), Buffer: 100}
+	api.SetAPIEndpoint(telegram.URL + "/bot%s/%s")
+
+	b := &TelegramBot{
+		api:     api,
+		log:     test_log,
+		webhook: &WebhookConfig{URL: "https://example.com/webhook", SecretToken: "s3cr3t"},
+	}
+
+	require.NoError(t, b.setWebhook())
+	require.Equal(t, map[string]string{"url": "https://example.com/webhook", "secret_token": "s3cr3t"}, requests["/bottoken/setWebhook"])
+
+	require.NoError(t, b.deleteWebhook())
+	require.Contains(t, requests, "/bottoken/deleteWebhook")
+}
@@ -6,35 +6,51 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultCurrency is the currency users and records get if none is specified,
+// it is also the reference currency exchange rates are defined against.
+const DefaultCurrency = "EUR"
+
+// kinds of categories, the records of a category are of the same kind
+const (
+	KindSpending = "spending"
+	KindIncome   = "income"
+)
+
 type (
 	//Represents a user
 	//GUID - unique identifier of the user
 	//Username - telegram username of the user
 	//TelegramID - telegram id of the user
+	//Currency - base currency of the user, all the totals are shown in it
 	//CreatedAt - time when the user was created
 	//UpdatedAt - time when the user was updated last time
 	User struct {
 		GUID       uuid.UUID `json:"guid" db:"guid"`
 		Username   string    `json:"username" db:"username"`
 		TelegramID string    `json:"telegram_id" db:"telegram_id"`
+		Currency   string    `json:"currency" db:"currency"`
 		CreatedAt  time.Time `json:"created_at" db:"created_at"`
 		UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 	}
 
-	//SpendingCategory represents a spending category
+	//SpendingCategory represents a spending or an income category
 	//GUID - unique identifier of the category
 	//UserGUID - unique identifier of the user to whom the category belongs
 	//Category - name of the category
 	//Description - description of the category
-	//Amount - amount of money spent in the category
+	//Kind - either KindSpending or KindIncome, KindSpending if empty
+	//Amount - amount of money spent or earned in the category, in the minor units of the base currency of the user
+	//ParentGUID - unique identifier of the parent category, uuid.Nil for a top level category
 	//CreatedAt - time when the category was created
 	//UpdatedAt - time when the category was updated last time
 	SpendingCategory struct {
 		GUID        uuid.UUID `json:"guid" db:"guid"`
 		UserGUID    uuid.UUID `json:"user_guid" db:"user_guid"`
+		ParentGUID  uuid.UUID `json:"parent_guid" db:"parent_guid"`
 		Category    string    `json:"category" db:"category"`
 		Description string    `json:"description" db:"description"`
-		Amount      uint64    `json:"amount" db:"amount"`
+		Kind        string    `json:"kind" db:"kind"`
+		Amount      Money     `json:"amount" db:"amount"`
 		CreatedAt   time.Time `json:"created_at" db:"created_at"`
 		UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	}
@@ -42,16 +58,179 @@ type (
 	//SpendingRecord represents a spending record
 	//GUID - unique identifier of the record
 	//CategoryGUID - unique identifier of the category to which the record belongs
-	//Amount - amount of money spent in the record
+	//Amount - amount of money spent in the record, in the minor units of the currency of the record
+	//Currency - currency the money was spent in
+	//BaseAmount - amount converted to the minor units of the base currency of the user
 	//Description - description of the record
+	//RecurringGUID - unique identifier of the recurring record the record was made from, uuid.Nil if made by hand
+	//OccurredAt - the occurrence of the recurring record the record was made for
+	//SpentAt - time when the money was spent, the time of creation if not set
+	//Tags - lowercase labels of the record without the leading #, replaced with the given ones on update
+	//TransactionID - id of the bank transaction the record was imported from, empty if made by hand, not loaded with the record
 	//CreatedAt - time when the record was created
 	//UpdatedAt - time when the record was updated last time
 	SpendingRecord struct {
+		GUID          uuid.UUID `json:"guid" db:"guid"`
+		CategoryGUID  uuid.UUID `json:"category_guid" db:"category_guid"`
+		Amount        Money     `json:"amount" db:"amount"`
+		Currency      string    `json:"currency" db:"currency"`
+		BaseAmount    Money     `json:"base_amount" db:"base_amount"`
+		Description   string    `json:"description" db:"description"`
+		RecurringGUID uuid.UUID `json:"recurring_guid" db:"recurring_guid"`
+		OccurredAt    time.Time `json:"occurred_at" db:"occurred_at"`
+		SpentAt       time.Time `json:"spent_at" db:"spent_at"`
+		Tags          []string  `json:"tags" db:"-"`
+		TransactionID string    `json:"transaction_id,omitempty" db:"-"`
+		CreatedAt     time.Time `json:"created_at" db:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	}
+
+	//RecurringRecord represents a spending record repeated on a schedule
+	//GUID - unique identifier of the recurring record
+	//UserGUID - unique identifier of the user who owns the category
+	//CategoryGUID - unique identifier of the category the records are made in
+	//Category - name of the category
+	//Amount - amount of money of every record, in the minor units of the currency of the recurring record
+	//Currency - currency of the records, the base currency of the user if empty on creation
+	//Description - description of every record
+	//Schedule - daily, weekly, monthly or a cron expression, see service.ParseSchedule
+	//StartsAt - the first occurrence, daily, weekly and monthly schedules repeat it
+	//NextRunAt - the next occurrence to make a record for
+	//CreatedAt - time when the recurring record was created
+	//UpdatedAt - time when the recurring record was updated last time
+	RecurringRecord struct {
 		GUID         uuid.UUID `json:"guid" db:"guid"`
+		UserGUID     uuid.UUID `json:"user_guid" db:"user_guid"`
 		CategoryGUID uuid.UUID `json:"category_guid" db:"category_guid"`
-		Amount       uint32    `json:"amount" db:"amount"`
+		Category     string    `json:"category" db:"category"`
+		Amount       Money     `json:"amount" db:"amount"`
+		Currency     string    `json:"currency" db:"currency"`
 		Description  string    `json:"description" db:"description"`
+		Schedule     string    `json:"schedule" db:"schedule"`
+		StartsAt     time.Time `json:"starts_at" db:"starts_at"`
+		NextRunAt    time.Time `json:"next_run_at" db:"next_run_at"`
+		CreatedAt    time.Time `json:"created_at" db:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	}
+
+	//PayeeRule maps the payees of the imported bank transactions to a category
+	//GUID - unique identifier of the rule
+	//UserGUID - unique identifier of the user who owns the category
+	//CategoryGUID - unique identifier of the category the transactions go to
+	//Category - name of the category
+	//Kind - kind of the category, the rule only maps the transactions of the same kind
+	//Pattern - lowercase text the payee of a transaction contains
+	//CreatedAt - time when the rule was created
+	//UpdatedAt - time when the rule was updated last time
+	PayeeRule struct {
+		GUID         uuid.UUID `json:"guid" db:"guid"`
+		UserGUID     uuid.UUID `json:"user_guid" db:"user_guid"`
+		CategoryGUID uuid.UUID `json:"category_guid" db:"category_guid"`
+		Category     string    `json:"category" db:"category"`
+		Kind         string    `json:"kind" db:"kind"`
+		Pattern      string    `json:"pattern" db:"pattern"`
+		CreatedAt    time.Time `json:"created_at" db:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	}
+
+	//BankTransaction represents a transaction of a bank statement waiting to be imported as a record
+	//ID - the id the bank gave the transaction, unique for the user
+	//PostedAt - time when the transaction was booked
+	//Amount - amount of money, in the minor units of the currency of the transaction
+	//Currency - currency of the transaction
+	//Kind - KindSpending for the debits, KindIncome for the credits
+	//Payee - the other party of the transaction
+	//Description - the memo or the remittance information of the transaction
+	//CategoryGUID - unique identifier of the category the transaction goes to, uuid.Nil until it is mapped
+	BankTransaction struct {
+		ID           string    `json:"id"`
+		PostedAt     time.Time `json:"posted_at"`
+		Amount       Money     `json:"amount"`
+		Currency     string    `json:"currency"`
+		Kind         string    `json:"kind"`
+		Payee        string    `json:"payee"`
+		Description  string    `json:"description"`
+		CategoryGUID uuid.UUID `json:"category_guid"`
+	}
+
+	//Budget represents a monthly spending limit of a spending category
+	//GUID - unique identifier of the budget
+	//CategoryGUID - unique identifier of the category the budget is set for
+	//Amount - the limit for a month, in the minor units of the base currency of the user
+	//CreatedAt - time when the budget was created
+	//UpdatedAt - time when the budget was updated last time
+	Budget struct {
+		GUID         uuid.UUID `json:"guid" db:"guid"`
+		CategoryGUID uuid.UUID `json:"category_guid" db:"category_guid"`
+		Amount       Money     `json:"amount" db:"amount"`
 		CreatedAt    time.Time `json:"created_at" db:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 	}
+
+	//BudgetStatus represents a budget together with the money spent against it
+	//Category - name of the category the budget is set for
+	//Spent - amount of money spent in the category over the period, in the base currency of the user
+	BudgetStatus struct {
+		Budget
+		Category string `json:"category" db:"category"`
+		Spent    Money  `json:"spent" db:"spent"`
+	}
+
+	//Balance represents the money flow of a user over a period of time
+	//Income - amount of money earned, in the base currency of the user
+	//Spending - amount of money spent, in the base currency of the user
+	Balance struct {
+		Income   Money `json:"income" db:"income"`
+		Spending Money `json:"spending" db:"spending"`
+	}
+
+	//TagSpending represents the money spent on the records with a tag
+	//Tag - the tag without the leading #
+	//Spent - amount of money spent, in the base currency of the user
+	TagSpending struct {
+		Tag   string `json:"tag" db:"tag"`
+		Spent Money  `json:"spent" db:"spent"`
+	}
+
+	//CategoryTree represents a category together with its subcategories
+	//SpendingCategory - the category, its Amount includes the amounts of all the subcategories
+	//Children - the subcategories of the category
+	CategoryTree struct {
+		SpendingCategory
+		Children []CategoryTree `json:"children"`
+	}
+
+	//Session represents the saved state of a multi-step flow of the bot
+	//ChatID - telegram id of the chat the flow goes on in
+	//UserID - telegram id of the user
+	//Username - telegram username of the user
+	//CommandID - id of the command waiting for the input
+	//BatchKind - kind of the data collected by the previous commands, empty if there is none
+	//Batch - JSON of the data collected by the previous commands
+	//CreatedAt - time when the flow was started
+	//UpdatedAt - time when the flow made the last step
+	Session struct {
+		ChatID    int64     `json:"chat_id" db:"chat_id"`
+		UserID    int64     `json:"user_id" db:"user_id"`
+		Username  string    `json:"username" db:"username"`
+		CommandID int       `json:"command_id" db:"command_id"`
+		BatchKind string    `json:"batch_kind" db:"batch_kind"`
+		Batch     []byte    `json:"batch" db:"batch"`
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+		UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	}
+
+	//ExchangeRate represents an exchange rate of a currency
+	//Currency - ISO 4217 code of the currency
+	//Rate - how much of the currency one unit of DefaultCurrency is worth
+	//Scale - number of the decimal places of the currency, see CurrencyScale
+	//CreatedAt - time when the rate was created
+	//UpdatedAt - time when the rate was updated last time
+	ExchangeRate struct {
+		Currency  string    `json:"currency" db:"currency"`
+		Rate      float64   `json:"rate" db:"rate"`
+		Scale     int       `json:"scale" db:"scale"`
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+		UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	}
 )
@@ -0,0 +1,315 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// versionTable is the table of the applied version, it is the same the migrate CLI uses,
+	// so the databases migrated with it before keep their version
+	versionTable = "schema_migrations"
+
+	// lockKey is the key of the advisory lock held while the migrations run,
+	// so the instances starting at the same time don't apply them twice
+	lockKey int64 = 6_100_420_725
+
+	createVersionTableQuery = `CREATE TABLE IF NOT EXISTS ` + versionTable + ` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+)
+
+var (
+	// ErrDirty is returned when a migration has failed halfway, the schema has to be fixed by hand.
+	ErrDirty = errors.New("database is dirty")
+	// ErrUnknownVersion is returned when the database has a version there is no migration for,
+	// usually it was migrated by a newer binary.
+	ErrUnknownVersion = errors.New("unknown database version")
+
+	filenameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is a step of the database schema
+//
+//   - Version: the version of the schema after the step, the steps are applied in the order of the versions
+//
+//   - Name: the name of the step
+//
+//   - Up: the SQL applying the step
+//
+//   - Down: the SQL reverting the step
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the migrations from the files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// the other files are skipped.
+//
+// Parameters:
+//   - fsys: The file system with the migration files in its root.
+//
+// Returns:
+//   - []Migration: The migrations ordered by the version.
+//   - error: An error if a file can't be read, a version has two names or lacks the up file, or nil if successful.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		matches := filenameRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("Load: invalid version of %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Load: %w", err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("Load: version %d is named both %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("Load: version %d has no up migration", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Migrator applies the migrations to the database
+//
+//   - db: the database
+//
+//   - migrations: the migrations ordered by the version
+//
+//   - log: a logger to log the applied steps
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	log        *logrus.Logger
+}
+
+// New creates a new instance of Migrator.
+//
+// Parameters:
+//   - db: The database to be migrated.
+//   - fsys: The file system with the migration files, see Load.
+//   - log: A logger to log the applied steps.
+//
+// Returns:
+//   - *Migrator: A pointer to the migrator.
+//   - error: An error if the migrations can't be loaded, or nil if successful.
+func New(db *sqlx.DB, fsys fs.FS, log *logrus.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("New: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations, log: log}, nil
+}
+
+// Up applies the migrations newer than the version of the database.
+//
+// Parameters:
+//   - ctx: The context of the migration, the step in progress is rolled back if it is cancelled.
+//
+// Returns:
+//   - int: The number of the applied migrations.
+//   - error: An error if the database is dirty, has an unknown version or a step fails, or nil if successful.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sqlx.Conn, current int) error {
+		for _, migration := range m.migrations[current:] {
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.log.Infof("applied migration %d_%s", migration.Version, migration.Name)
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return applied, fmt.Errorf("Migrator.Up: %w", err)
+	}
+	return applied, nil
+}
+
+// Down reverts the newest applied migrations.
+//
+// Parameters:
+//   - ctx: The context of the migration, the step in progress is rolled back if it is cancelled.
+//   - steps: The number of the migrations to revert, all of them are reverted if it exceeds the applied ones.
+//
+// Returns:
+//   - int: The number of the reverted migrations.
+//   - error: An error if the database is dirty, has an unknown version or a step fails, or nil if successful.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(conn *sqlx.Conn, current int) error {
+		for i := current - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Down == "" {
+				return fmt.Errorf("%d_%s has no down migration", migration.Version, migration.Name)
+			}
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.log.Infof("reverted migration %d_%s", migration.Version, migration.Name)
+			reverted++
+		}
+		return nil
+	})
+	if err != nil {
+		return reverted, fmt.Errorf("Migrator.Down: %w", err)
+	}
+	return reverted, nil
+}
+
+// Version returns the version of the database.
+//
+// Parameters:
+//   - ctx: The context of the query.
+//
+// Returns:
+//   - uint64: The version of the database, zero if no migration is applied.
+//   - bool: True if a migration has failed halfway and the database is dirty.
+//   - error: An error if the query fails, or nil if successful.
+func (m *Migrator) Version(ctx context.Context) (uint64, bool, error) {
+	if _, err := m.db.ExecContext(ctx, createVersionTableQuery); err != nil {
+		return 0, false, fmt.Errorf("Migrator.Version: %w", err)
+	}
+	version, dirty, err := readVersion(ctx, m.db)
+	if err != nil {
+		return 0, false, fmt.Errorf("Migrator.Version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Latest returns the version of the newest migration, zero if there are none
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// locked runs the function holding the advisory lock on a dedicated connection,
+// the function gets the connection and the number of the applied migrations
+func (m *Migrator) locked(ctx context.Context, run func(conn *sqlx.Conn, current int) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+	defer func() {
+		// the lock is released with the session, so the connection is discarded if unlocking fails
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			m.log.WithError(err).Warn("error releasing migration lock, closing the connection")
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createVersionTableQuery); err != nil {
+		return err
+	}
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+
+	current := 0
+	if version != 0 {
+		current = sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+		if current == len(m.migrations) || m.migrations[current].Version != version {
+			return fmt.Errorf("%w %d", ErrUnknownVersion, version)
+		}
+		current++
+	}
+
+	return run(conn, current)
+}
+
+// apply runs the SQL of a step and sets the version within a transaction,
+// so a failed step leaves the database as it was
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, query string, version uint64) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+versionTable); err != nil {
+		return err
+	}
+	if version != 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+versionTable+` (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// readVersion reads the version from the version table, zero if it is empty
+func readVersion(ctx context.Context, q sqlx.QueryerContext) (uint64, bool, error) {
+	var row struct {
+		Version uint64 `db:"version"`
+		Dirty   bool   `db:"dirty"`
+	}
+	err := sqlx.GetContext(ctx, q, &row, `SELECT version, dirty FROM `+versionTable+` LIMIT 1`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return row.Version, row.Dirty, nil
+}
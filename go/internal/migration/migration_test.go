@@ -0,0 +1,238 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/migrations"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const migrationsDir = "../../migrations/"
+
+func Test_Load(t *testing.T) {
+
+	tt := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "Ordered",
+			fsys: fstest.MapFS{
+				"000010_sessions.up.sql":       {Data: []byte("create table sessions ();")},
+				"000010_sessions.down.sql":     {Data: []byte("drop table sessions;")},
+				"000002_currencies.up.sql":     {Data: []byte("create table exchange_rates ();")},
+				"000001_init.up.sql":           {Data: []byte("create table users ();")},
+				"000001_init.down.sql":         {Data: []byte("drop table users;")},
+				"migrations.go":                {Data: []byte("package migrations")},
+				"test_data/000003_data.up.sql": {Data: []byte("insert into users default values;")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "create table users ();", Down: "drop table users;"},
+				{Version: 2, Name: "currencies", Up: "create table exchange_rates ();"},
+				{Version: 10, Name: "sessions", Up: "create table sessions ();", Down: "drop table sessions;"},
+			},
+		},
+		{
+			name: "Empty",
+			fsys: fstest.MapFS{},
+			want: []Migration{},
+		},
+		{
+			name: "No_up",
+			fsys: fstest.MapFS{
+				"000001_init.down.sql": {Data: []byte("drop table users;")},
+			},
+			wantErr: "version 1 has no up migration",
+		},
+		{
+			name: "Two_names",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql":  {Data: []byte("create table users ();")},
+				"000001_users.up.sql": {Data: []byte("create table users ();")},
+			},
+			wantErr: "version 1 is named both",
+		},
+		{
+			name: "Zero_version",
+			fsys: fstest.MapFS{
+				"000000_init.up.sql": {Data: []byte("create table users ();")},
+			},
+			wantErr: "invalid version of 000000_init.up.sql",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Load(tc.fsys)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_Load_embedded(t *testing.T) {
+
+	filenames, err := utils.UpMigrations(migrationsDir)
+	require.NoError(t, err)
+
+	got, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.Len(t, got, len(filenames))
+	for _, migration := range got {
+		require.NotEmpty(t, migration.Down, "migration %d_%s can't be reverted", migration.Version, migration.Name)
+	}
+}
+
+func Test_Migrator(t *testing.T) {
+
+	ctx := context.Background()
+
+	filenames, err := utils.UpMigrations(migrationsDir)
+	require.NoError(t, err)
+
+	// the schema the repository tests run against
+	containerDB, stop, err := utils.NewPGContainer(filenames...)
+	require.NoError(t, err)
+	defer stop()
+	wantSchema := dumpSchema(t, containerDB)
+
+	db, stop, err := utils.NewPGContainer()
+	require.NoError(t, err)
+	defer stop()
+	emptySchema := dumpSchema(t, db)
+
+	migrator, err := New(db, migrations.FS, logrus.New())
+	require.NoError(t, err)
+
+	t.Run("Up", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, len(filenames), applied)
+		require.Equal(t, wantSchema, dumpSchema(t, db))
+
+		version, dirty, err := migrator.Version(ctx)
+		require.NoError(t, err)
+		require.False(t, dirty)
+		require.Equal(t, migrator.Latest(), version)
+
+		applied, err = migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Zero(t, applied)
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		reverted, err := migrator.Down(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, reverted)
+
+		version, _, err := migrator.Version(ctx)
+		require.NoError(t, err)
+		require.Equal(t, migrator.migrations[len(migrator.migrations)-2].Version, version)
+
+		reverted, err = migrator.Down(ctx, len(filenames))
+		require.NoError(t, err)
+		require.Equal(t, len(filenames)-1, reverted)
+		require.Equal(t, emptySchema, dumpSchema(t, db))
+
+		version, _, err = migrator.Version(ctx)
+		require.NoError(t, err)
+		require.Zero(t, version)
+	})
+
+	t.Run("Concurrent_up", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			applied int
+		)
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n, err := migrator.Up(ctx)
+				if err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				applied += n
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, len(filenames), applied)
+		require.Equal(t, wantSchema, dumpSchema(t, db))
+	})
+
+	t.Run("Dirty", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE ` + versionTable + ` SET dirty = true`)
+		require.NoError(t, err)
+		defer db.Exec(`UPDATE ` + versionTable + ` SET dirty = false`)
+
+		_, err = migrator.Up(ctx)
+		require.ErrorIs(t, err, ErrDirty)
+		_, err = migrator.Down(ctx, 1)
+		require.ErrorIs(t, err, ErrDirty)
+	})
+
+	t.Run("Unknown_version", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE `+versionTable+` SET version = $1`, migrator.Latest()+1)
+		require.NoError(t, err)
+		defer db.Exec(`UPDATE `+versionTable+` SET version = $1`, migrator.Latest())
+
+		_, err = migrator.Up(ctx)
+		require.ErrorIs(t, err, ErrUnknownVersion)
+	})
+}
+
+// dumpSchema lists the columns, constraints, indexes, functions and triggers of the public schema,
+// the version table of the migrations and the objects of the extensions are left out
+func dumpSchema(t *testing.T, db *sqlx.DB) string {
+	t.Helper()
+
+	queries := []string{
+		`SELECT table_name || '.' || column_name || ' ' || data_type || ' ' || is_nullable || ' ' || coalesce(column_default, '')
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name <> '` + versionTable + `'
+			ORDER BY table_name, column_name`,
+		`SELECT conrelid::regclass::text || ' ' || conname || ' ' || pg_get_constraintdef(oid)
+			FROM pg_constraint
+			WHERE connamespace = 'public'::regnamespace AND conrelid::regclass::text <> '` + versionTable + `'
+			ORDER BY 1`,
+		`SELECT indexdef
+			FROM pg_indexes
+			WHERE schemaname = 'public' AND tablename <> '` + versionTable + `'
+			ORDER BY 1`,
+		`SELECT p.proname || ' ' || pg_get_functiondef(p.oid)
+			FROM pg_proc p
+			WHERE p.pronamespace = 'public'::regnamespace
+				AND NOT EXISTS (SELECT FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+			ORDER BY 1`,
+		`SELECT event_object_table || ' ' || trigger_name || ' ' || action_timing || ' ' || event_manipulation || ' ' || action_statement
+			FROM information_schema.triggers
+			WHERE trigger_schema = 'public'
+			ORDER BY 1`,
+	}
+
+	var dump strings.Builder
+	for _, query := range queries {
+		var rows []string
+		require.NoError(t, db.Select(&rows, query))
+		dump.WriteString(strings.Join(rows, "\n"))
+		dump.WriteString("\n\n")
+	}
+	return dump.String()
+}
@@ -0,0 +1,136 @@
+package ftracker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidAmount is returned when an amount can't be parsed.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrAmountOverflow is returned when an amount doesn't fit into Money.
+	ErrAmountOverflow = errors.New("amount overflow")
+)
+
+// defaultScale is the number of the decimal places of the currencies that are not in currencyScales
+const defaultScale = 2
+
+// currencyScales holds the ISO 4217 currencies whose minor unit is not a hundredth of the major one,
+// the migration introducing the scales rescales the stored amounts of the same currencies
+var currencyScales = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Money is an amount of money in the minor units of its currency, e.g. cents of EUR or yens of JPY,
+// the currency is kept next to the amount, CurrencyScale tells how many minor units make a major one.
+// The arithmetic methods report overflows instead of wrapping around.
+type Money int64
+
+// CurrencyScale returns the number of the decimal places of the currency, 2 for the unknown ones.
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[currency]; ok {
+		return scale
+	}
+	return defaultScale
+}
+
+// ParseMoney parses the amount entered by a user, e.g. "12", "12.5" or "12,50".
+// Either a point or a comma separates the decimal places, there may be no more of them than the currency has,
+// unless the extra ones are zeros, so "100.00" is a valid amount of JPY.
+//
+// Parameters:
+//   - s: The amount in the major units of the currency.
+//   - currency: The currency of the amount, an empty one stands for DefaultCurrency.
+//
+// Returns:
+//   - Money: The amount in the minor units of the currency.
+//   - error: ErrInvalidAmount if s is not a non-negative decimal number or has too many decimal places,
+//     ErrAmountOverflow if it is too large, or nil if successful.
+func ParseMoney(s, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	scale := CurrencyScale(currency)
+
+	s = strings.TrimSpace(s)
+	units, fraction, separated := s, "", false
+	if i := strings.IndexAny(s, ".,"); i >= 0 {
+		units, fraction, separated = s[:i], s[i+1:], true
+	}
+	if !isDigits(units) || (separated && !isDigits(fraction)) {
+		return 0, fmt.Errorf("ParseMoney: %w: %q", ErrInvalidAmount, s)
+	}
+
+	if len(fraction) > scale {
+		if strings.Trim(fraction[scale:], "0") != "" {
+			return 0, fmt.Errorf("ParseMoney: %w: %s has %d decimal places", ErrInvalidAmount, currency, scale)
+		}
+		fraction = fraction[:scale]
+	}
+	fraction += strings.Repeat("0", scale-len(fraction))
+
+	amount, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ParseMoney: %w: %q", ErrAmountOverflow, s)
+	}
+	return Money(amount), nil
+}
+
+// isDigits checks if the string is a non-empty sequence of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns the sum of the amounts, ErrAmountOverflow if it doesn't fit into Money.
+func (m Money) Add(other Money) (Money, error) {
+	sum := m + other
+	if (other > 0 && sum < m) || (other < 0 && sum > m) {
+		return 0, fmt.Errorf("Money.Add: %w: %d + %d", ErrAmountOverflow, m, other)
+	}
+	return sum, nil
+}
+
+// Sub returns the difference of the amounts, ErrAmountOverflow if it doesn't fit into Money.
+func (m Money) Sub(other Money) (Money, error) {
+	diff := m - other
+	if (other > 0 && diff > m) || (other < 0 && diff < m) {
+		return 0, fmt.Errorf("Money.Sub: %w: %d - %d", ErrAmountOverflow, m, other)
+	}
+	return diff, nil
+}
+
+// Format formats the amount in the major units of the currency with a point before the decimal places,
+// e.g. "1234.50" for EUR and "1234" for JPY, an empty currency stands for DefaultCurrency.
+func (m Money) Format(currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	scale := CurrencyScale(currency)
+
+	sign, abs := "", uint64(m)
+	if m < 0 {
+		sign, abs = "-", -uint64(m) // the two's complement negation is right for the minimal value too
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
@@ -0,0 +1,124 @@
+package ftracker
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseMoney(t *testing.T) {
+
+	tt := []struct {
+		name     string
+		input    string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{name: "Units", input: "12", currency: "EUR", want: 1200},
+		{name: "Point", input: "12.5", currency: "EUR", want: 1250},
+		{name: "Comma", input: "12,05", currency: "EUR", want: 1205},
+		{name: "Default_currency", input: "0.99", want: 99},
+		{name: "Leading_zeros", input: "007.10", currency: "EUR", want: 710},
+		{name: "Zero", input: "0", currency: "EUR", want: 0},
+		{name: "Zero_scale", input: "1500", currency: "JPY", want: 1500},
+		{name: "Zero_scale_zero_decimals", input: "1500.00", currency: "JPY", want: 1500},
+		{name: "Three_decimals", input: "1.255", currency: "KWD", want: 1255},
+		{name: "Extra_zero_decimals", input: "1.2000", currency: "EUR", want: 120},
+		{name: "Max", input: "92233720368547758.07", currency: "EUR", want: math.MaxInt64},
+		{name: "Too_many_decimals", input: "1.255", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "Zero_scale_decimals", input: "1500.5", currency: "JPY", wantErr: ErrInvalidAmount},
+		{name: "Negative", input: "-12", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "Empty", input: "", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "No_units", input: ".5", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "No_decimals", input: "5.", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "Two_separators", input: "1.000,50", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "Letters", input: "12a", currency: "EUR", wantErr: ErrInvalidAmount},
+		{name: "Overflow", input: "92233720368547758.08", currency: "EUR", wantErr: ErrAmountOverflow},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseMoney(tc.input, tc.currency)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_Money_Format(t *testing.T) {
+
+	tt := []struct {
+		name     string
+		amount   Money
+		currency string
+		want     string
+	}{
+		{name: "Cents", amount: 123450, currency: "EUR", want: "1234.50"},
+		{name: "Default_currency", amount: 5, want: "0.05"},
+		{name: "Zero", amount: 0, currency: "EUR", want: "0.00"},
+		{name: "Zero_scale", amount: 1500, currency: "JPY", want: "1500"},
+		{name: "Three_decimals", amount: 1255, currency: "KWD", want: "1.255"},
+		{name: "Four_decimals", amount: 7, currency: "CLF", want: "0.0007"},
+		{name: "Negative", amount: -1205, currency: "EUR", want: "-12.05"},
+		{name: "Max", amount: math.MaxInt64, currency: "EUR", want: "92233720368547758.07"},
+		{name: "Min", amount: math.MinInt64, currency: "EUR", want: "-92233720368547758.08"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.amount.Format(tc.currency))
+		})
+	}
+}
+
+func Test_Money_Add_Sub(t *testing.T) {
+
+	tt := []struct {
+		name    string
+		a, b    Money
+		sum     Money
+		diff    Money
+		sumErr  bool
+		diffErr bool
+	}{
+		{name: "Small", a: 1500, b: 250, sum: 1750, diff: 1250},
+		{name: "Negative", a: -1500, b: 250, sum: -1250, diff: -1750},
+		{name: "Max", a: math.MaxInt64, b: 1, sumErr: true, diff: math.MaxInt64 - 1},
+		{name: "Min", a: math.MinInt64, b: 1, sum: math.MinInt64 + 1, diffErr: true},
+		{name: "Max_minus_negative", a: math.MaxInt64, b: -1, sum: math.MaxInt64 - 1, diffErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sum, err := tc.a.Add(tc.b)
+			if tc.sumErr {
+				require.ErrorIs(t, err, ErrAmountOverflow)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.sum, sum)
+			}
+
+			diff, err := tc.a.Sub(tc.b)
+			if tc.diffErr {
+				require.ErrorIs(t, err, ErrAmountOverflow)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.diff, diff)
+			}
+		})
+	}
+}
+
+func Test_CurrencyScale(t *testing.T) {
+	require.Equal(t, 2, CurrencyScale("EUR"))
+	require.Equal(t, 2, CurrencyScale("XYZ"))
+	require.Equal(t, 0, CurrencyScale("JPY"))
+	require.Equal(t, 3, CurrencyScale("KWD"))
+	require.Equal(t, 4, CurrencyScale("CLF"))
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// BudgetRepo implements the Budget interface.
+	BudgetRepo struct {
+		db      *sqlx.DB
+		timeout time.Duration
+	}
+
+	// BudgetOptions defines the options for retrieving budgets.
+	// The money spent is summed up over the records created between TimeFrom and TimeTo.
+	BudgetOptions struct {
+		CategoryGUIDs []uuid.UUID
+		TimeFrom      time.Time
+		TimeTo        time.Time
+	}
+)
+
+// NewBudgetRepository creates a new instance of BudgetRepo with the provided database connection.
+func NewBudgetRepository(db *sqlx.DB, opts ...Option) *BudgetRepo {
+	return &BudgetRepo{db: db, timeout: newOptions(opts).queryTimeout}
+}
+
+// GetBudgets retrieves the budgets of the user's categories ordered by category name,
+// together with the money spent in each category over the time frame.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the budgets.
+//   - opts: A struct containing filtering options and the time frame.
+//
+// Returns:
+//   - A slice of BudgetStatus objects that match the query criteria.
+//   - An error if the query fails, or nil if successful.
+func (b *BudgetRepo) GetBudgets(ctx context.Context, userGUID uuid.UUID, opts BudgetOptions) ([]ftracker.BudgetStatus, error) {
+
+	ctx, cancel := withTimeout(ctx, b.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(budgetsTable+" b",
+		"b.guid", "b.category_guid", "b.amount", "b.created_at", "b.updated_at", "c.category",
+		"COALESCE(SUM(r.base_amount), 0) AS spent",
+	).
+		Join(fmt.Sprintf("JOIN %s c ON c.guid = b.category_guid", spendingCategoriesTable)).
+		Join(
+			fmt.Sprintf("LEFT JOIN %s r ON r.category_guid = b.category_guid AND r.spent_at >= ? AND r.spent_at < ?", spendingRecordsTable),
+			opts.TimeFrom, opts.TimeTo,
+		).
+		Where(
+			query.Raw("c.user_guid = ?", userGUID),
+			query.In("b.category_guid", opts.CategoryGUIDs),
+		).
+		GroupBy("b.guid", "c.category").
+		Sortable("c.category").
+		OrderBy("c.category", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetBudgets: %w", err)
+	}
+
+	var budgets []ftracker.BudgetStatus
+	err = b.db.SelectContext(ctx, &budgets, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetBudgets: %w", err)
+	}
+
+	return budgets, nil
+}
+
+// SetBudgets sets the monthly limits of the user's spending categories,
+// the existing budget of a category is replaced.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
+//   - budgets: A slice of Budget objects identified by their CategoryGUIDs.
+//
+// Returns:
+//   - An error if the operation fails at any point, e.g. one of the categories does not exist,
+//     belongs to another user or is an income category.
+func (b *BudgetRepo) SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error {
+	ctx, cancel := withTimeout(ctx, b.timeout)
+	defer cancel()
+
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.SetBudgets: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (category_guid, amount) SELECT guid, $1 FROM %s WHERE guid = $2 AND user_guid = $3 AND kind = '%s' "+
+			"ON CONFLICT (category_guid) DO UPDATE SET amount = EXCLUDED.amount",
+		budgetsTable,
+		spendingCategoriesTable,
+		ftracker.KindSpending,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.SetBudgets: %w", err)
+	}
+
+	for _, budget := range budgets {
+		var res sql.Result
+		res, err = stmt.ExecContext(ctx, budget.Amount, budget.CategoryGUID, userGUID)
+		if err == nil {
+			err = expectOneRow(res, budget.CategoryGUID)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.SetBudgets: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.SetBudgets: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBudgets removes the monthly limits of the user's categories.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
+//   - categoryGUIDs: A slice of UUIDs of the categories whose budgets are removed.
+//
+// Returns:
+//   - An error if the operation fails at any point, e.g. one of the categories has no budget
+//     or belongs to another user.
+func (b *BudgetRepo) DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, b.timeout)
+	defer cancel()
+
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteBudgets: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE category_guid IN (SELECT guid FROM %s WHERE guid = $1 AND user_guid = $2)",
+		budgetsTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteBudgets: %w", err)
+	}
+
+	for _, guid := range categoryGUIDs {
+		res, err := stmt.ExecContext(ctx, guid, userGUID)
+		if err == nil {
+			err = expectOneRow(res, guid)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeleteBudgets: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.DeleteBudgets: %w", err)
+	}
+
+	return nil
+}
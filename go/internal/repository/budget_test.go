@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetBudgets(t *testing.T) {
+
+	t.Parallel()
+
+	october := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name      string
+		userGUID  uuid.UUID
+		opts      BudgetOptions
+		wantGUIDs []uuid.UUID
+		wantSpent []uint64
+	}{
+		{
+			name:      "Current_month",
+			userGUID:  userGuids[8],
+			opts:      BudgetOptions{TimeFrom: october, TimeTo: november},
+			wantGUIDs: budgetGuids[1:3],
+			wantSpent: []uint64{3500, 0},
+		},
+		{
+			name:      "Another_month",
+			userGUID:  userGuids[8],
+			opts:      BudgetOptions{TimeFrom: november, TimeTo: november.AddDate(0, 1, 0)},
+			wantGUIDs: budgetGuids[1:3],
+			wantSpent: []uint64{500, 0},
+		},
+		{
+			name:      "By_category",
+			userGUID:  userGuids[8],
+			opts:      BudgetOptions{CategoryGUIDs: categoryGuids[20:21], TimeFrom: october, TimeTo: november},
+			wantGUIDs: budgetGuids[1:2],
+			wantSpent: []uint64{3500},
+		},
+		{
+			name:     "Category_without_budget",
+			userGUID: userGuids[8],
+			opts:     BudgetOptions{CategoryGUIDs: categoryGuids[22:23], TimeFrom: october, TimeTo: november},
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+			opts:     BudgetOptions{CategoryGUIDs: categoryGuids[20:22], TimeFrom: october, TimeTo: november},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			budgets, err := budRepo.GetBudgets(context.Background(), tc.userGUID, tc.opts)
+			require.NoError(t, err)
+			require.Len(t, budgets, len(tc.wantGUIDs))
+			for i, budget := range budgets {
+				require.Equal(t, tc.wantGUIDs[i], budget.GUID)
+				require.Equal(t, tc.wantSpent[i], budget.Spent)
+			}
+		})
+	}
+}
+
+func Test_SetBudgets(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name    string
+		budgets []ftracker.Budget
+		wantErr bool
+	}{
+		{
+			name: "New_budget",
+			budgets: []ftracker.Budget{
+				{CategoryGUID: categoryGuids[23], Amount: 1500},
+			},
+		},
+		{
+			name: "Income_category",
+			budgets: []ftracker.Budget{
+				{CategoryGUID: categoryGuids[25], Amount: 1500},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Another_user",
+			budgets: []ftracker.Budget{
+				{CategoryGUID: categoryGuids[21], Amount: 1500},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Zero_amount",
+			budgets: []ftracker.Budget{
+				{CategoryGUID: categoryGuids[23], Amount: 0},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			err := budRepo.SetBudgets(context.Background(), userGuids[9], tc.budgets)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, budget := range tc.budgets {
+				got, err := budRepo.GetBudgets(context.Background(), userGuids[9], BudgetOptions{CategoryGUIDs: []uuid.UUID{budget.CategoryGUID}})
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, budget.Amount, got[0].Amount)
+			}
+		})
+	}
+}
+
+func Test_DeleteBudgets(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name          string
+		userGUID      uuid.UUID
+		categoryGUIDs []uuid.UUID
+		wantErr       bool
+	}{
+		{
+			name:          "Another_user",
+			userGUID:      userGuids[0],
+			categoryGUIDs: categoryGuids[24:25],
+			wantErr:       true,
+		},
+		{
+			name:          "Ok",
+			userGUID:      userGuids[9],
+			categoryGUIDs: categoryGuids[24:25],
+		},
+		{
+			name:          "No_budget",
+			userGUID:      userGuids[9],
+			categoryGUIDs: categoryGuids[24:25],
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			err := budRepo.DeleteBudgets(context.Background(), tc.userGUID, tc.categoryGUIDs)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := budRepo.GetBudgets(context.Background(), tc.userGUID, BudgetOptions{CategoryGUIDs: tc.categoryGUIDs})
+			require.NoError(t, err)
+			require.Len(t, got, 0)
+		})
+	}
+}
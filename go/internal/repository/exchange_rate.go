@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
+	"github.com/jmoiron/sqlx"
+)
+
+// ExchangeRateRepo implements the ExchangeRate interface.
+type ExchangeRateRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+// NewExchangeRateRepository creates a new instance of ExchangeRateRepo with the provided database connection.
+func NewExchangeRateRepository(db *sqlx.DB, opts ...Option) *ExchangeRateRepo {
+	return &ExchangeRateRepo{db: db, timeout: newOptions(opts).queryTimeout}
+}
+
+// GetExchangeRates retrieves the exchange rates of the given currencies ordered by currency code.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - currencies: Currency codes to look up, if empty, all the rates are returned.
+//
+// Returns:
+//   - A slice of ExchangeRate objects, currencies without a rate are omitted.
+//   - An error if the query fails, or nil if successful.
+func (e *ExchangeRateRepo) GetExchangeRates(ctx context.Context, currencies []string) ([]ftracker.ExchangeRate, error) {
+
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(exchangeRatesTable, "currency", "rate", "scale", "created_at", "updated_at").
+		Where(query.In("currency", currencies)).
+		Sortable("currency").
+		OrderBy("currency", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetExchangeRates: %w", err)
+	}
+
+	var rates []ftracker.ExchangeRate
+	err = e.db.SelectContext(ctx, &rates, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetExchangeRates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// SetExchangeRates inserts the exchange rates or replaces the existing rates of the same currencies.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - rates: A slice of ExchangeRate objects to be stored.
+//
+// Returns:
+//   - An error if the operation fails at any point, nothing is stored in that case.
+func (e *ExchangeRateRepo) SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error {
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.SetExchangeRates: %w", err)
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (currency, rate, scale) VALUES (:currency, :rate, :scale) ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, scale = EXCLUDED.scale",
+		exchangeRatesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.SetExchangeRates: %w", err)
+	}
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate); err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.SetExchangeRates: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.SetExchangeRates: %w", err)
+	}
+
+	return nil
+}
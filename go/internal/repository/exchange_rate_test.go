@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SetExchangeRates(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name    string
+		rates   []ftracker.ExchangeRate
+		want    []ftracker.ExchangeRate
+		wantErr bool
+	}{
+		{
+			name: "New_rates",
+			rates: []ftracker.ExchangeRate{
+				{Currency: "JPY", Rate: 160.5, Scale: 0},
+				{Currency: "CHF", Rate: 0.95, Scale: 2},
+			},
+			want: []ftracker.ExchangeRate{
+				{Currency: "CHF", Rate: 0.95, Scale: 2},
+				{Currency: "JPY", Rate: 160.5, Scale: 0},
+			},
+		},
+		{
+			name: "Replaced_rate",
+			rates: []ftracker.ExchangeRate{
+				{Currency: "PLN", Rate: 4.3, Scale: 2},
+				{Currency: "PLN", Rate: 4.25, Scale: 2},
+			},
+			want: []ftracker.ExchangeRate{
+				{Currency: "PLN", Rate: 4.25, Scale: 2},
+			},
+		},
+		{
+			name: "Errorous",
+			rates: []ftracker.ExchangeRate{
+				{Currency: "CZK", Rate: 25.1},
+				{Currency: "SEK", Rate: -11.5},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			currencies := make([]string, len(tc.rates))
+			for i, rate := range tc.rates {
+				currencies[i] = rate.Currency
+			}
+
+			err := excRepo.SetExchangeRates(context.Background(), tc.rates)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				got, err := excRepo.GetExchangeRates(context.Background(), currencies)
+				require.NoError(t, err)
+				require.Len(t, got, 0)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := excRepo.GetExchangeRates(context.Background(), currencies)
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.want))
+			for i := range tc.want {
+				require.Equal(t, tc.want[i].Currency, got[i].Currency)
+				require.Equal(t, tc.want[i].Rate, got[i].Rate)
+				require.Equal(t, tc.want[i].Scale, got[i].Scale)
+			}
+		})
+	}
+}
+
+func Test_GetExchangeRates(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name       string
+		currencies []string
+		want       []ftracker.ExchangeRate
+	}{
+		{
+			name:       "By_currencies",
+			currencies: []string{"USD", "EUR"},
+			want: []ftracker.ExchangeRate{
+				{Currency: "EUR", Rate: 1},
+				{Currency: "USD", Rate: 2},
+			},
+		},
+		{
+			name:       "Unknown_currency",
+			currencies: []string{"XXX"},
+			want:       []ftracker.ExchangeRate{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			got, err := excRepo.GetExchangeRates(context.Background(), tc.currencies)
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.want))
+			for i := range tc.want {
+				require.Equal(t, tc.want[i].Currency, got[i].Currency)
+				require.Equal(t, tc.want[i].Rate, got[i].Rate)
+			}
+		})
+	}
+}
@@ -24,6 +24,26 @@ var (
 		uuid.MustParse("00000000-0000-0000-0000-000000000004"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000005"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000006"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000007"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000008"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000009"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000010"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000011"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000012"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000013"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000014"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000015"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000016"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000017"),
 	}
 
 	categoryGuids = []uuid.UUID{
@@ -39,6 +59,43 @@ var (
 		uuid.MustParse("00000000-0000-0000-0000-000000000081"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000091"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000101"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000111"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000121"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000131"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000141"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000151"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000161"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000171"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000181"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000191"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000201"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000211"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000221"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000231"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000241"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000251"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000261"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000271"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000281"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000291"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000301"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000311"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000321"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000331"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000341"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000351"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000361"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000371"),
 	}
 
 	recordGuids = []uuid.UUID{
@@ -46,6 +103,57 @@ var (
 		uuid.MustParse("00000000-0000-0000-0000-000000000211"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000311"),
 		uuid.MustParse("00000000-0000-0000-0000-000000000411"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000511"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000611"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000711"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000811"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000911"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001011"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000001111"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001211"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000001311"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001411"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001511"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000001611"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001711"),
+		uuid.MustParse("00000000-0000-0000-0000-000000001811"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000001911"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000002011"),
+		uuid.MustParse("00000000-0000-0000-0000-000000002111"),
+		uuid.MustParse("00000000-0000-0000-0000-000000002211"),
+		uuid.MustParse("00000000-0000-0000-0000-000000002311"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000002411"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000002511"),
+	}
+
+	budgetGuids = []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000182"),
+
+		uuid.MustParse("00000000-0000-0000-0000-000000000212"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000222"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000252"),
+	}
+
+	recurringGuids = []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000272"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000282"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000292"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000302"),
+	}
+
+	payeeRuleGuids = []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000342"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000352"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000362"),
 	}
 
 	timeFrom, _ = time.Parse("2006-01-02", "2024-10-25")
@@ -54,6 +162,11 @@ var (
 	catRepo *CategoryRepo
 	recRepo *RecordRepo
 	usrRepo *UserRepo
+	excRepo *ExchangeRateRepo
+	budRepo *BudgetRepo
+	rcrRepo *RecurringRepo
+	payRepo *PayeeRuleRepo
+	sesRepo *SessionRepo
 )
 
 func TestMain(m *testing.M) {
@@ -62,12 +175,14 @@ func TestMain(m *testing.M) {
 		logrus.Error(err)
 		os.Exit(1)
 	}
-	basePath += "/../../../migrations/"
+	basePath += "/../../migrations/"
+	filenames, err := utils.UpMigrations(basePath)
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
 	var stop func()
-	testContainerDB, stop, err = utils.NewPGContainer(
-		basePath+"000001_init.up.sql",
-		basePath+"test_data/29-10-2024-test-data.sql",
-	)
+	testContainerDB, stop, err = utils.NewPGContainer(append(filenames, basePath+"test_data/29-10-2024-test-data.sql")...)
 	if err != nil {
 		logrus.Error(err)
 		os.Exit(1)
@@ -77,6 +192,11 @@ func TestMain(m *testing.M) {
 	catRepo = NewCategoryRepository(testContainerDB)
 	recRepo = NewRecordRepository(testContainerDB)
 	usrRepo = NewUserRepository(testContainerDB)
+	excRepo = NewExchangeRateRepository(testContainerDB)
+	budRepo = NewBudgetRepository(testContainerDB)
+	rcrRepo = NewRecurringRepository(testContainerDB)
+	payRepo = NewPayeeRuleRepository(testContainerDB)
+	sesRepo = NewSessionRepository(testContainerDB)
 
 	os.Exit(m.Run())
 }
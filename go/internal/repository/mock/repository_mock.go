@@ -5,7 +5,9 @@
 package repositorymock
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -37,33 +39,61 @@ func (m *MockUser) EXPECT() *MockUserMockRecorder {
 }
 
 // AddUsers mocks base method.
-func (m *MockUser) AddUsers(users []ftracker.User) ([]uuid.UUID, error) {
+func (m *MockUser) AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUsers", users)
+	ret := m.ctrl.Call(m, "AddUsers", ctx, users)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddUsers indicates an expected call of AddUsers.
-func (mr *MockUserMockRecorder) AddUsers(users interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) AddUsers(ctx, users interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockUser)(nil).AddUsers), users)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockUser)(nil).AddUsers), ctx, users)
 }
 
 // GetUsers mocks base method.
-func (m *MockUser) GetUsers(opts repository.UserOptions) ([]ftracker.User, error) {
+func (m *MockUser) GetUsers(ctx context.Context, opts repository.UserOptions) ([]ftracker.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", opts)
+	ret := m.ctrl.Call(m, "GetUsers", ctx, opts)
 	ret0, _ := ret[0].([]ftracker.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserMockRecorder) GetUsers(opts interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) GetUsers(ctx, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers), opts)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers), ctx, opts)
+}
+
+// SetUserAPIToken mocks base method.
+func (m *MockUser) SetUserAPIToken(ctx context.Context, userGUID uuid.UUID, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserAPIToken", ctx, userGUID, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserAPIToken indicates an expected call of SetUserAPIToken.
+func (mr *MockUserMockRecorder) SetUserAPIToken(ctx, userGUID, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAPIToken", reflect.TypeOf((*MockUser)(nil).SetUserAPIToken), ctx, userGUID, tokenHash)
+}
+
+// SetUserCurrency mocks base method.
+func (m *MockUser) SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserCurrency", ctx, userGUID, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserCurrency indicates an expected call of SetUserCurrency.
+func (mr *MockUserMockRecorder) SetUserCurrency(ctx, userGUID, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserCurrency", reflect.TypeOf((*MockUser)(nil).SetUserCurrency), ctx, userGUID, currency)
 }
 
 // MockSpendingCategory is a mock of SpendingCategory interface.
@@ -90,33 +120,61 @@ func (m *MockSpendingCategory) EXPECT() *MockSpendingCategoryMockRecorder {
 }
 
 // AddCategories mocks base method.
-func (m *MockSpendingCategory) AddCategories(category []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+func (m *MockSpendingCategory) AddCategories(ctx context.Context, userGUID uuid.UUID, category []ftracker.SpendingCategory) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddCategories", category)
+	ret := m.ctrl.Call(m, "AddCategories", ctx, userGUID, category)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddCategories indicates an expected call of AddCategories.
-func (mr *MockSpendingCategoryMockRecorder) AddCategories(category interface{}) *gomock.Call {
+func (mr *MockSpendingCategoryMockRecorder) AddCategories(ctx, userGUID, category interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockSpendingCategory)(nil).AddCategories), category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockSpendingCategory)(nil).AddCategories), ctx, userGUID, category)
+}
+
+// DeleteCategories mocks base method.
+func (m *MockSpendingCategory) DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategories", ctx, userGUID, guids, moveTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategories indicates an expected call of DeleteCategories.
+func (mr *MockSpendingCategoryMockRecorder) DeleteCategories(ctx, userGUID, guids, moveTo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategories", reflect.TypeOf((*MockSpendingCategory)(nil).DeleteCategories), ctx, userGUID, guids, moveTo)
 }
 
 // GetCategories mocks base method.
-func (m *MockSpendingCategory) GetCategories(opts repository.CategoryOptions) ([]ftracker.SpendingCategory, error) {
+func (m *MockSpendingCategory) GetCategories(ctx context.Context, userGUID uuid.UUID, opts repository.CategoryOptions) ([]ftracker.SpendingCategory, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCategories", opts)
+	ret := m.ctrl.Call(m, "GetCategories", ctx, userGUID, opts)
 	ret0, _ := ret[0].([]ftracker.SpendingCategory)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCategories indicates an expected call of GetCategories.
-func (mr *MockSpendingCategoryMockRecorder) GetCategories(opts interface{}) *gomock.Call {
+func (mr *MockSpendingCategoryMockRecorder) GetCategories(ctx, userGUID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockSpendingCategory)(nil).GetCategories), ctx, userGUID, opts)
+}
+
+// UpdateCategories mocks base method.
+func (m *MockSpendingCategory) UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategories", ctx, userGUID, categories)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategories indicates an expected call of UpdateCategories.
+func (mr *MockSpendingCategoryMockRecorder) UpdateCategories(ctx, userGUID, categories interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockSpendingCategory)(nil).GetCategories), opts)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategories", reflect.TypeOf((*MockSpendingCategory)(nil).UpdateCategories), ctx, userGUID, categories)
 }
 
 // MockSpendingRecord is a mock of SpendingRecord interface.
@@ -143,31 +201,466 @@ func (m *MockSpendingRecord) EXPECT() *MockSpendingRecordMockRecorder {
 }
 
 // AddRecords mocks base method.
-func (m *MockSpendingRecord) AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+func (m *MockSpendingRecord) AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddRecords", records)
+	ret := m.ctrl.Call(m, "AddRecords", ctx, userGUID, records)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddRecords indicates an expected call of AddRecords.
-func (mr *MockSpendingRecordMockRecorder) AddRecords(records interface{}) *gomock.Call {
+func (mr *MockSpendingRecordMockRecorder) AddRecords(ctx, userGUID, records interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockSpendingRecord)(nil).AddRecords), records)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockSpendingRecord)(nil).AddRecords), ctx, userGUID, records)
+}
+
+// DeleteRecords mocks base method.
+func (m *MockSpendingRecord) DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecords", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecords indicates an expected call of DeleteRecords.
+func (mr *MockSpendingRecordMockRecorder) DeleteRecords(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecords", reflect.TypeOf((*MockSpendingRecord)(nil).DeleteRecords), ctx, userGUID, guids)
+}
+
+// GetBalance mocks base method.
+func (m *MockSpendingRecord) GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", ctx, userGUID, from, to)
+	ret0, _ := ret[0].(ftracker.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockSpendingRecordMockRecorder) GetBalance(ctx, userGUID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockSpendingRecord)(nil).GetBalance), ctx, userGUID, from, to)
+}
+
+// GetImportedTransactions mocks base method.
+func (m *MockSpendingRecord) GetImportedTransactions(ctx context.Context, userGUID uuid.UUID, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportedTransactions", ctx, userGUID, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImportedTransactions indicates an expected call of GetImportedTransactions.
+func (mr *MockSpendingRecordMockRecorder) GetImportedTransactions(ctx, userGUID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportedTransactions", reflect.TypeOf((*MockSpendingRecord)(nil).GetImportedTransactions), ctx, userGUID, ids)
 }
 
 // GetRecords mocks base method.
-func (m *MockSpendingRecord) GetRecords(opts repository.RecordOptions) ([]ftracker.SpendingRecord, error) {
+func (m *MockSpendingRecord) GetRecords(ctx context.Context, userGUID uuid.UUID, opts repository.RecordOptions) ([]ftracker.SpendingRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRecords", opts)
+	ret := m.ctrl.Call(m, "GetRecords", ctx, userGUID, opts)
 	ret0, _ := ret[0].([]ftracker.SpendingRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRecords indicates an expected call of GetRecords.
-func (mr *MockSpendingRecordMockRecorder) GetRecords(opts interface{}) *gomock.Call {
+func (mr *MockSpendingRecordMockRecorder) GetRecords(ctx, userGUID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockSpendingRecord)(nil).GetRecords), ctx, userGUID, opts)
+}
+
+// GetTagSpending mocks base method.
+func (m *MockSpendingRecord) GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagSpending", ctx, userGUID, from, to)
+	ret0, _ := ret[0].([]ftracker.TagSpending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagSpending indicates an expected call of GetTagSpending.
+func (mr *MockSpendingRecordMockRecorder) GetTagSpending(ctx, userGUID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagSpending", reflect.TypeOf((*MockSpendingRecord)(nil).GetTagSpending), ctx, userGUID, from, to)
+}
+
+// ImportRecords mocks base method.
+func (m *MockSpendingRecord) ImportRecords(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory, added, updated []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRecords", ctx, userGUID, categories, added, updated)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRecords indicates an expected call of ImportRecords.
+func (mr *MockSpendingRecordMockRecorder) ImportRecords(ctx, userGUID, categories, added, updated interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRecords", reflect.TypeOf((*MockSpendingRecord)(nil).ImportRecords), ctx, userGUID, categories, added, updated)
+}
+
+// UpdateRecords mocks base method.
+func (m *MockSpendingRecord) UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecords", ctx, userGUID, records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecords indicates an expected call of UpdateRecords.
+func (mr *MockSpendingRecordMockRecorder) UpdateRecords(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecords", reflect.TypeOf((*MockSpendingRecord)(nil).UpdateRecords), ctx, userGUID, records)
+}
+
+// MockBudget is a mock of Budget interface.
+type MockBudget struct {
+	ctrl     *gomock.Controller
+	recorder *MockBudgetMockRecorder
+}
+
+// MockBudgetMockRecorder is the mock recorder for MockBudget.
+type MockBudgetMockRecorder struct {
+	mock *MockBudget
+}
+
+// NewMockBudget creates a new mock instance.
+func NewMockBudget(ctrl *gomock.Controller) *MockBudget {
+	mock := &MockBudget{ctrl: ctrl}
+	mock.recorder = &MockBudgetMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBudget) EXPECT() *MockBudgetMockRecorder {
+	return m.recorder
+}
+
+// DeleteBudgets mocks base method.
+func (m *MockBudget) DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBudgets", ctx, userGUID, categoryGUIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBudgets indicates an expected call of DeleteBudgets.
+func (mr *MockBudgetMockRecorder) DeleteBudgets(ctx, userGUID, categoryGUIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBudgets", reflect.TypeOf((*MockBudget)(nil).DeleteBudgets), ctx, userGUID, categoryGUIDs)
+}
+
+// GetBudgets mocks base method.
+func (m *MockBudget) GetBudgets(ctx context.Context, userGUID uuid.UUID, opts repository.BudgetOptions) ([]ftracker.BudgetStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBudgets", ctx, userGUID, opts)
+	ret0, _ := ret[0].([]ftracker.BudgetStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBudgets indicates an expected call of GetBudgets.
+func (mr *MockBudgetMockRecorder) GetBudgets(ctx, userGUID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBudgets", reflect.TypeOf((*MockBudget)(nil).GetBudgets), ctx, userGUID, opts)
+}
+
+// SetBudgets mocks base method.
+func (m *MockBudget) SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBudgets", ctx, userGUID, budgets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBudgets indicates an expected call of SetBudgets.
+func (mr *MockBudgetMockRecorder) SetBudgets(ctx, userGUID, budgets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBudgets", reflect.TypeOf((*MockBudget)(nil).SetBudgets), ctx, userGUID, budgets)
+}
+
+// MockRecurring is a mock of Recurring interface.
+type MockRecurring struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecurringMockRecorder
+}
+
+// MockRecurringMockRecorder is the mock recorder for MockRecurring.
+type MockRecurringMockRecorder struct {
+	mock *MockRecurring
+}
+
+// NewMockRecurring creates a new mock instance.
+func NewMockRecurring(ctrl *gomock.Controller) *MockRecurring {
+	mock := &MockRecurring{ctrl: ctrl}
+	mock.recorder = &MockRecurringMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecurring) EXPECT() *MockRecurringMockRecorder {
+	return m.recorder
+}
+
+// AddRecurring mocks base method.
+func (m *MockRecurring) AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecurring", ctx, userGUID, records)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRecurring indicates an expected call of AddRecurring.
+func (mr *MockRecurringMockRecorder) AddRecurring(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecurring", reflect.TypeOf((*MockRecurring)(nil).AddRecurring), ctx, userGUID, records)
+}
+
+// AdvanceRecurring mocks base method.
+func (m *MockRecurring) AdvanceRecurring(ctx context.Context, guid uuid.UUID, nextRunAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceRecurring", ctx, guid, nextRunAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceRecurring indicates an expected call of AdvanceRecurring.
+func (mr *MockRecurringMockRecorder) AdvanceRecurring(ctx, guid, nextRunAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceRecurring", reflect.TypeOf((*MockRecurring)(nil).AdvanceRecurring), ctx, guid, nextRunAt)
+}
+
+// DeleteRecurring mocks base method.
+func (m *MockRecurring) DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecurring", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecurring indicates an expected call of DeleteRecurring.
+func (mr *MockRecurringMockRecorder) DeleteRecurring(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecurring", reflect.TypeOf((*MockRecurring)(nil).DeleteRecurring), ctx, userGUID, guids)
+}
+
+// GetDueRecurring mocks base method.
+func (m *MockRecurring) GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueRecurring", ctx, now, limit)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueRecurring indicates an expected call of GetDueRecurring.
+func (mr *MockRecurringMockRecorder) GetDueRecurring(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueRecurring", reflect.TypeOf((*MockRecurring)(nil).GetDueRecurring), ctx, now, limit)
+}
+
+// GetRecurring mocks base method.
+func (m *MockRecurring) GetRecurring(ctx context.Context, userGUID uuid.UUID, opts repository.RecurringOptions) ([]ftracker.RecurringRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurring", ctx, userGUID, opts)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecurring indicates an expected call of GetRecurring.
+func (mr *MockRecurringMockRecorder) GetRecurring(ctx, userGUID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurring", reflect.TypeOf((*MockRecurring)(nil).GetRecurring), ctx, userGUID, opts)
+}
+
+// MockPayeeRule is a mock of PayeeRule interface.
+type MockPayeeRule struct {
+	ctrl     *gomock.Controller
+	recorder *MockPayeeRuleMockRecorder
+}
+
+// MockPayeeRuleMockRecorder is the mock recorder for MockPayeeRule.
+type MockPayeeRuleMockRecorder struct {
+	mock *MockPayeeRule
+}
+
+// NewMockPayeeRule creates a new mock instance.
+func NewMockPayeeRule(ctrl *gomock.Controller) *MockPayeeRule {
+	mock := &MockPayeeRule{ctrl: ctrl}
+	mock.recorder = &MockPayeeRuleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPayeeRule) EXPECT() *MockPayeeRuleMockRecorder {
+	return m.recorder
+}
+
+// AddPayeeRules mocks base method.
+func (m *MockPayeeRule) AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPayeeRules", ctx, userGUID, rules)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPayeeRules indicates an expected call of AddPayeeRules.
+func (mr *MockPayeeRuleMockRecorder) AddPayeeRules(ctx, userGUID, rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).AddPayeeRules), ctx, userGUID, rules)
+}
+
+// DeletePayeeRules mocks base method.
+func (m *MockPayeeRule) DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayeeRules", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePayeeRules indicates an expected call of DeletePayeeRules.
+func (mr *MockPayeeRuleMockRecorder) DeletePayeeRules(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).DeletePayeeRules), ctx, userGUID, guids)
+}
+
+// GetPayeeRules mocks base method.
+func (m *MockPayeeRule) GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayeeRules", ctx, userGUID)
+	ret0, _ := ret[0].([]ftracker.PayeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayeeRules indicates an expected call of GetPayeeRules.
+func (mr *MockPayeeRuleMockRecorder) GetPayeeRules(ctx, userGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).GetPayeeRules), ctx, userGUID)
+}
+
+// MockExchangeRate is a mock of ExchangeRate interface.
+type MockExchangeRate struct {
+	ctrl     *gomock.Controller
+	recorder *MockExchangeRateMockRecorder
+}
+
+// MockExchangeRateMockRecorder is the mock recorder for MockExchangeRate.
+type MockExchangeRateMockRecorder struct {
+	mock *MockExchangeRate
+}
+
+// NewMockExchangeRate creates a new mock instance.
+func NewMockExchangeRate(ctrl *gomock.Controller) *MockExchangeRate {
+	mock := &MockExchangeRate{ctrl: ctrl}
+	mock.recorder = &MockExchangeRateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExchangeRate) EXPECT() *MockExchangeRateMockRecorder {
+	return m.recorder
+}
+
+// GetExchangeRates mocks base method.
+func (m *MockExchangeRate) GetExchangeRates(ctx context.Context, currencies []string) ([]ftracker.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRates", ctx, currencies)
+	ret0, _ := ret[0].([]ftracker.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRates indicates an expected call of GetExchangeRates.
+func (mr *MockExchangeRateMockRecorder) GetExchangeRates(ctx, currencies interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockExchangeRate)(nil).GetExchangeRates), ctx, currencies)
+}
+
+// SetExchangeRates mocks base method.
+func (m *MockExchangeRate) SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeRates", ctx, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExchangeRates indicates an expected call of SetExchangeRates.
+func (mr *MockExchangeRateMockRecorder) SetExchangeRates(ctx, rates interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRates", reflect.TypeOf((*MockExchangeRate)(nil).SetExchangeRates), ctx, rates)
+}
+
+// MockSession is a mock of Session interface.
+type MockSession struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionMockRecorder
+}
+
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
+}
+
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
+	return m.recorder
+}
+
+// DeleteSession mocks base method.
+func (m *MockSession) DeleteSession(ctx context.Context, chatID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockSessionMockRecorder) DeleteSession(ctx, chatID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSession)(nil).DeleteSession), ctx, chatID)
+}
+
+// GetSessions mocks base method.
+func (m *MockSession) GetSessions(ctx context.Context) ([]ftracker.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx)
+	ret0, _ := ret[0].([]ftracker.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionMockRecorder) GetSessions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSession)(nil).GetSessions), ctx)
+}
+
+// SaveSession mocks base method.
+func (m *MockSession) SaveSession(ctx context.Context, session ftracker.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MockSessionMockRecorder) SaveSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockSpendingRecord)(nil).GetRecords), opts)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockSession)(nil).SaveSession), ctx, session)
 }
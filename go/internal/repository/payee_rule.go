@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
+	"github.com/jmoiron/sqlx"
+)
+
+// PayeeRuleRepo implements the PayeeRule interface.
+type PayeeRuleRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+// NewPayeeRuleRepository creates a new instance of PayeeRuleRepo with the provided database connection.
+func NewPayeeRuleRepository(db *sqlx.DB, opts ...Option) *PayeeRuleRepo {
+	return &PayeeRuleRepo{db: db, timeout: newOptions(opts).queryTimeout}
+}
+
+// GetPayeeRules retrieves the payee rules of the user ordered by their patterns.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the rules.
+//
+// Returns:
+//   - A slice of PayeeRule objects together with the names and the kinds of their categories.
+//   - An error if the query fails, or nil if successful.
+func (r *PayeeRuleRepo) GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error) {
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(
+		fmt.Sprintf("%s r JOIN %s c ON c.guid = r.category_guid", payeeRulesTable, spendingCategoriesTable),
+		"r.guid", "c.user_guid", "r.category_guid", "c.category", "c.kind", "r.pattern", "r.created_at", "r.updated_at",
+	).
+		Where(query.Raw("c.user_guid = ?", userGUID)).
+		Sortable("r.pattern").
+		OrderBy("r.pattern", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetPayeeRules: %w", err)
+	}
+
+	var rules []ftracker.PayeeRule
+	err = r.db.SelectContext(ctx, &rules, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetPayeeRules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// AddPayeeRules inserts multiple payee rules into the database.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the rules.
+//   - rules: A slice of PayeeRule objects to be added to the database, only their CategoryGUIDs and Patterns are used.
+//
+// Returns:
+//   - A slice of UUIDs representing the GUIDs of the newly inserted rules.
+//   - An error if any issue occurs during the operation, e.g. one of the categories belongs to another user,
+//     a unique constraint violation if the category already has a rule with the pattern.
+func (r *PayeeRuleRepo) AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.AddPayeeRules: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (category_guid, pattern) "+
+			"SELECT guid, $2::text FROM %s WHERE guid = $1::uuid AND user_guid = $3::uuid "+
+			"RETURNING guid",
+		payeeRulesTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.AddPayeeRules: %w", err)
+	}
+
+	guids := make([]uuid.UUID, len(rules))
+	for i, rule := range rules {
+		err := stmt.QueryRowxContext(ctx, rule.CategoryGUID, rule.Pattern, userGUID).Scan(&guids[i])
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("no suitable row with guid %s", rule.CategoryGUID)
+		}
+		if err != nil {
+			rollback(tx)
+			return nil, fmt.Errorf("Repostiory.AddPayeeRules: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("Repostiory.AddPayeeRules: %w", err)
+	}
+
+	return guids, nil
+}
+
+// DeletePayeeRules deletes multiple payee rules of the user,
+// the records already imported with them are kept.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the rules.
+//   - guids: The GUIDs of the rules to be deleted.
+//
+// Returns:
+//   - An error if any issue occurs during the operation, e.g. one of the rules does not exist
+//     or belongs to another user.
+func (r *PayeeRuleRepo) DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeletePayeeRules: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE guid = $1 AND category_guid IN (SELECT guid FROM %s WHERE user_guid = $2)",
+		payeeRulesTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeletePayeeRules: %w", err)
+	}
+
+	for _, guid := range guids {
+		res, err := stmt.ExecContext(ctx, guid, userGUID)
+		if err == nil {
+			err = expectOneRow(res, guid)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeletePayeeRules: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.DeletePayeeRules: %w", err)
+	}
+
+	return nil
+}
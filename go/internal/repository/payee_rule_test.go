@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetPayeeRules(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		userGUID  uuid.UUID
+		wantRules []ftracker.PayeeRule
+	}{
+		{
+			name:     "Ok",
+			userGUID: userGuids[15],
+			wantRules: []ftracker.PayeeRule{
+				{GUID: payeeRuleGuids[1], CategoryGUID: categoryGuids[34], Category: "for_bank2", Kind: ftracker.KindIncome, Pattern: "acme payroll"},
+				{GUID: payeeRuleGuids[0], CategoryGUID: categoryGuids[33], Category: "for_bank1", Kind: ftracker.KindSpending, Pattern: "lidl"},
+			},
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			rules, err := payRepo.GetPayeeRules(context.Background(), tc.userGUID)
+			require.NoError(t, err)
+
+			// the rules added and deleted by the other tests are left out
+			var got []ftracker.PayeeRule
+			for _, rule := range rules {
+				require.Equal(t, tc.userGUID, rule.UserGUID)
+				if rule.GUID == payeeRuleGuids[0] || rule.GUID == payeeRuleGuids[1] {
+					got = append(got, rule)
+				}
+			}
+			require.Len(t, got, len(tc.wantRules))
+			for i := range got {
+				require.Equal(t, tc.wantRules[i].GUID, got[i].GUID)
+				require.Equal(t, tc.wantRules[i].CategoryGUID, got[i].CategoryGUID)
+				require.Equal(t, tc.wantRules[i].Category, got[i].Category)
+				require.Equal(t, tc.wantRules[i].Kind, got[i].Kind)
+				require.Equal(t, tc.wantRules[i].Pattern, got[i].Pattern)
+			}
+		})
+	}
+}
+
+func Test_AddPayeeRules(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name       string
+		userGUID   uuid.UUID
+		rules      []ftracker.PayeeRule
+		wantUnique bool
+		wantErr    bool
+	}{
+		{
+			name:     "Ok",
+			userGUID: userGuids[15],
+			rules:    []ftracker.PayeeRule{{CategoryGUID: categoryGuids[33], Pattern: "aldi"}},
+		},
+		{
+			name:       "Duplicate",
+			userGUID:   userGuids[15],
+			rules:      []ftracker.PayeeRule{{CategoryGUID: categoryGuids[33], Pattern: "lidl"}},
+			wantUnique: true,
+			wantErr:    true,
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+			rules:    []ftracker.PayeeRule{{CategoryGUID: categoryGuids[33], Pattern: "netto"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			guids, err := payRepo.AddPayeeRules(context.Background(), tc.userGUID, tc.rules)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Equal(t, tc.wantUnique, utils.IsUniqueConstrainViolation(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, guids, len(tc.rules))
+
+			rules, err := payRepo.GetPayeeRules(context.Background(), tc.userGUID)
+			require.NoError(t, err)
+			found := false
+			for _, rule := range rules {
+				if rule.GUID == guids[0] {
+					found = true
+					require.Equal(t, tc.rules[0].Pattern, rule.Pattern)
+					require.Equal(t, tc.rules[0].CategoryGUID, rule.CategoryGUID)
+				}
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func Test_DeletePayeeRules(t *testing.T) {
+
+	t.Parallel()
+
+	require.Error(t, payRepo.DeletePayeeRules(context.Background(), userGuids[0], payeeRuleGuids[2:3]))
+	require.NoError(t, payRepo.DeletePayeeRules(context.Background(), userGuids[15], payeeRuleGuids[2:3]))
+
+	rules, err := payRepo.GetPayeeRules(context.Background(), userGuids[15])
+	require.NoError(t, err)
+	for _, rule := range rules {
+		require.NotEqual(t, payeeRuleGuids[2], rule.GUID)
+	}
+}
+
+func Test_ImportedTransactions(t *testing.T) {
+
+	t.Parallel()
+
+	imported, err := recRepo.GetImportedTransactions(context.Background(), userGuids[15], []string{"fitid-1", "fitid-2", "fitid-3"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"fitid-1", "fitid-2"}, imported)
+
+	imported, err = recRepo.GetImportedTransactions(context.Background(), userGuids[0], []string{"fitid-1"})
+	require.NoError(t, err)
+	require.Empty(t, imported)
+
+	record := ftracker.SpendingRecord{CategoryGUID: categoryGuids[33], Amount: 250, Description: "rewe", TransactionID: "fitid-4"}
+	_, err = recRepo.AddRecords(context.Background(), userGuids[15], []ftracker.SpendingRecord{record})
+	require.NoError(t, err)
+
+	imported, err = recRepo.GetImportedTransactions(context.Background(), userGuids[15], []string{"fitid-4"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"fitid-4"}, imported)
+
+	// the transaction is already imported, even though its record is gone
+	record.TransactionID = "fitid-2"
+	_, err = recRepo.AddRecords(context.Background(), userGuids[15], []ftracker.SpendingRecord{record})
+	require.Error(t, err)
+	require.True(t, utils.IsUniqueConstrainViolation(err))
+}
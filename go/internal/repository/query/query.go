@@ -0,0 +1,190 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrUnsortableColumn is returned by Build when the query is ordered by a column
+// that is not in the list of sortable columns.
+var ErrUnsortableColumn = errors.New("column is not sortable")
+
+type (
+	// Cond is a single condition of the WHERE clause.
+	// Its SQL uses ? as a placeholder for every argument, an empty Cond is omitted.
+	Cond struct {
+		sql  string
+		args []any
+	}
+
+	// SelectQuery builds a SELECT statement whose values are passed as arguments
+	// instead of being pasted into the query text.
+	SelectQuery struct {
+		table    string
+		columns  []string
+		joins    []Cond
+		conds    []Cond
+		groupBy  []string
+		sortable map[string]struct{}
+		orderBy  string
+		asc      bool
+		limit    int
+	}
+)
+
+// Raw creates a condition from the given SQL and its arguments.
+// The SQL must be a constant, all the values are passed through ? placeholders.
+func Raw(sql string, args ...any) Cond {
+	return Cond{sql: sql, args: args}
+}
+
+// In creates a condition for a column to be equal to one of the values.
+// It returns an empty condition if no values are provided.
+//
+// Parameters:
+//   - col: The name of the column to be used in the condition.
+//   - values: The values the column is compared with.
+//
+// Returns:
+//   - A condition with a placeholder for every value.
+func In[T any](col string, values []T) Cond {
+	if len(values) == 0 {
+		return Cond{}
+	}
+
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+
+	return Cond{sql: fmt.Sprintf("%s IN (%s)", col, placeholders), args: args}
+}
+
+// TimeFrame creates a condition for a column to be between two timestamps.
+// It returns an empty condition if byTime is false.
+//
+// Parameters:
+//   - col: The name of the column to be used in the condition.
+//   - from: The start time of the range, inclusive.
+//   - to: The end of the range, exclusive.
+//   - byTime: A boolean indicating whether to include the time frame condition.
+//
+// Returns:
+//   - A condition with placeholders for both boundaries.
+func TimeFrame(col string, from, to time.Time, byTime bool) Cond {
+	if !byTime {
+		return Cond{}
+	}
+	return Cond{sql: fmt.Sprintf("%[1]s >= ? AND %[1]s < ?", col), args: []any{from, to}}
+}
+
+// Select creates a new SELECT statement for the given table and columns.
+func Select(table string, columns ...string) *SelectQuery {
+	return &SelectQuery{
+		table:   table,
+		columns: columns,
+	}
+}
+
+// Join adds a JOIN clause with its arguments, e.g. Join("LEFT JOIN t ON t.id = a.id AND t.x > ?", x).
+// The SQL must be a constant, all the values are passed through ? placeholders.
+func (q *SelectQuery) Join(sql string, args ...any) *SelectQuery {
+	q.joins = append(q.joins, Cond{sql: sql, args: args})
+	return q
+}
+
+// Where adds the conditions to the WHERE clause, they are joined with AND.
+func (q *SelectQuery) Where(conds ...Cond) *SelectQuery {
+	q.conds = append(q.conds, conds...)
+	return q
+}
+
+// GroupBy sets the columns of the GROUP BY clause.
+func (q *SelectQuery) GroupBy(columns ...string) *SelectQuery {
+	q.groupBy = columns
+	return q
+}
+
+// Sortable sets the columns the query is allowed to be ordered by.
+func (q *SelectQuery) Sortable(columns ...string) *SelectQuery {
+	q.sortable = make(map[string]struct{}, len(columns))
+	for _, col := range columns {
+		q.sortable[col] = struct{}{}
+	}
+	return q
+}
+
+// OrderBy sets the column and the direction of the ORDER BY clause.
+// An empty column leaves the query unordered.
+func (q *SelectQuery) OrderBy(col string, asc bool) *SelectQuery {
+	q.orderBy = col
+	q.asc = asc
+	return q
+}
+
+// Limit sets the maximum number of rows to return, 0 means no limit.
+func (q *SelectQuery) Limit(limit int) *SelectQuery {
+	q.limit = limit
+	return q
+}
+
+// Build assembles the statement.
+//
+// Returns:
+//   - The SQL with $n placeholders.
+//   - The arguments in the order of the placeholders.
+//   - ErrUnsortableColumn if the query is ordered by a column not set via Sortable.
+func (q *SelectQuery) Build() (string, []any, error) {
+	var (
+		sb   strings.Builder
+		args []any
+	)
+
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", strings.Join(q.columns, ", "), q.table)
+	for _, join := range q.joins {
+		fmt.Fprintf(&sb, " %s", join.sql)
+		args = append(args, join.args...)
+	}
+
+	first := true
+	for _, cond := range q.conds {
+		if cond.sql == "" {
+			continue
+		}
+		if first {
+			sb.WriteString(" WHERE ")
+			first = false
+		} else {
+			sb.WriteString(" AND ")
+		}
+		fmt.Fprintf(&sb, "(%s)", cond.sql)
+		args = append(args, cond.args...)
+	}
+
+	if len(q.groupBy) > 0 {
+		fmt.Fprintf(&sb, " GROUP BY %s", strings.Join(q.groupBy, ", "))
+	}
+
+	if q.orderBy != "" {
+		if _, ok := q.sortable[q.orderBy]; !ok {
+			return "", nil, fmt.Errorf("query.Build: %w: %q", ErrUnsortableColumn, q.orderBy)
+		}
+		direction := "DESC"
+		if q.asc {
+			direction = "ASC"
+		}
+		fmt.Fprintf(&sb, " ORDER BY %s %s", q.orderBy, direction)
+	}
+
+	if q.limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, q.limit)
+	}
+
+	return sqlx.Rebind(sqlx.DOLLAR, sb.String()), args, nil
+}
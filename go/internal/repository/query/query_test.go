@@ -0,0 +1,153 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Build(t *testing.T) {
+
+	guids := []uuid.UUID{
+		uuid.New(),
+		uuid.New(),
+	}
+	timeFrom := time.Date(2024, 10, 25, 0, 0, 0, 0, time.UTC)
+	timeTo := time.Date(2024, 10, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    *SelectQuery
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:    "No_conditions",
+			query:   Select("users", "guid", "username"),
+			wantSQL: "SELECT guid, username FROM users",
+		},
+		{
+			name:    "Empty_conditions",
+			query:   Select("users", "guid").Where(In("guid", []uuid.UUID{}), TimeFrame("updated_at", timeFrom, timeTo, false)),
+			wantSQL: "SELECT guid FROM users",
+		},
+		{
+			name:     "In",
+			query:    Select("users", "guid").Where(In("guid", guids), In("username", []string{"user1"})),
+			wantSQL:  "SELECT guid FROM users WHERE (guid IN ($1, $2)) AND (username IN ($3))",
+			wantArgs: []any{guids[0], guids[1], "user1"},
+		},
+		{
+			name:     "Raw_and_time_frame",
+			query:    Select("spending_records", "guid").Where(Raw("category_guid = ?", guids[0]), TimeFrame("updated_at", timeFrom, timeTo, true)),
+			wantSQL:  "SELECT guid FROM spending_records WHERE (category_guid = $1) AND (updated_at >= $2 AND updated_at < $3)",
+			wantArgs: []any{guids[0], timeFrom, timeTo},
+		},
+		{
+			name:     "Ordered_and_limited",
+			query:    Select("spending_records", "guid").Where(In("guid", guids[:1])).Sortable("amount").OrderBy("amount", true).Limit(5),
+			wantSQL:  "SELECT guid FROM spending_records WHERE (guid IN ($1)) ORDER BY amount ASC LIMIT $2",
+			wantArgs: []any{guids[0], 5},
+		},
+		{
+			name:    "Descending",
+			query:   Select("spending_records", "guid").Sortable("amount", "created_at").OrderBy("created_at", false),
+			wantSQL: "SELECT guid FROM spending_records ORDER BY created_at DESC",
+		},
+		{
+			name:     "Grouped",
+			query:    Select("spending_records", "category_guid", "SUM(amount)").Where(In("guid", guids[:1])).GroupBy("category_guid").Sortable("category_guid").OrderBy("category_guid", true),
+			wantSQL:  "SELECT category_guid, SUM(amount) FROM spending_records WHERE (guid IN ($1)) GROUP BY category_guid ORDER BY category_guid ASC",
+			wantArgs: []any{guids[0]},
+		},
+		{
+			name: "Joined",
+			query: Select("budgets b", "b.guid").
+				Join("LEFT JOIN spending_records r ON r.category_guid = b.category_guid AND r.created_at >= ?", timeFrom).
+				Where(Raw("b.guid = ?", guids[0])),
+			wantSQL:  "SELECT b.guid FROM budgets b LEFT JOIN spending_records r ON r.category_guid = b.category_guid AND r.created_at >= $1 WHERE (b.guid = $2)",
+			wantArgs: []any{timeFrom, guids[0]},
+		},
+		{
+			name:    "Unsortable_column",
+			query:   Select("spending_records", "guid").Sortable("amount").OrderBy("amount; DROP TABLE users", true),
+			wantErr: ErrUnsortableColumn,
+		},
+		{
+			name:    "No_sortable_columns",
+			query:   Select("spending_records", "guid").OrderBy("amount", true),
+			wantErr: ErrUnsortableColumn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			gotSQL, gotArgs, err := tt.query.Build()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, gotSQL)
+			require.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
+
+func Fuzz_In(f *testing.F) {
+
+	f.Add("food")
+	f.Add("'")
+	f.Add("x') OR ('1'='1")
+	f.Add("food'; DROP TABLE spending_categories; --")
+	f.Add("$1 ? $$ \\")
+	f.Add("/* */ --")
+
+	userGUID := uuid.New()
+	build := func(category string) (string, []any, error) {
+		return Select("spending_categories", "guid", "category").
+			Where(
+				Raw("user_guid = ?", userGUID),
+				In("category", []string{category, "gym"}),
+			).
+			Build()
+	}
+
+	wantSQL, _, err := build("food")
+	require.NoError(f, err)
+
+	f.Fuzz(func(t *testing.T, category string) {
+		gotSQL, gotArgs, err := build(category)
+		require.NoError(t, err)
+		require.Equal(t, wantSQL, gotSQL)
+		require.Equal(t, []any{userGUID, category, "gym"}, gotArgs)
+	})
+}
+
+func Fuzz_OrderBy(f *testing.F) {
+
+	f.Add("amount")
+	f.Add("amount; DROP TABLE users")
+	f.Add("(SELECT 1)")
+	f.Add("")
+
+	sortable := []string{"amount", "created_at"}
+
+	f.Fuzz(func(t *testing.T, col string) {
+		gotSQL, _, err := Select("spending_records", "guid").Sortable(sortable...).OrderBy(col, true).Build()
+
+		switch col {
+		case "":
+			require.NoError(t, err)
+			require.Equal(t, "SELECT guid FROM spending_records", gotSQL)
+		case "amount", "created_at":
+			require.NoError(t, err)
+			require.Equal(t, "SELECT guid FROM spending_records ORDER BY "+col+" ASC", gotSQL)
+		default:
+			require.ErrorIs(t, err, ErrUnsortableColumn)
+		}
+	})
+}
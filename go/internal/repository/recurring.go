@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// RecurringRepo implements the Recurring interface.
+	RecurringRepo struct {
+		db      *sqlx.DB
+		timeout time.Duration
+	}
+
+	// RecurringOptions defines the options for retrieving recurring records.
+	RecurringOptions struct {
+		GUIDs         []uuid.UUID
+		CategoryGUIDs []uuid.UUID
+	}
+)
+
+// recurringColumns are the columns of a recurring record joined with its category.
+var recurringColumns = []string{
+	"r.guid", "c.user_guid", "r.category_guid", "c.category", "r.amount", "r.currency", "r.description",
+	"r.schedule", "r.starts_at", "r.next_run_at", "r.created_at", "r.updated_at",
+}
+
+// NewRecurringRepository creates a new instance of RecurringRepo with the provided database connection.
+func NewRecurringRepository(db *sqlx.DB, opts ...Option) *RecurringRepo {
+	return &RecurringRepo{db: db, timeout: newOptions(opts).queryTimeout}
+}
+
+// GetRecurring retrieves the recurring records of the user ordered by their next occurrence.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the recurring records.
+//   - opts: A struct containing filtering options.
+//
+// Returns:
+//   - A slice of RecurringRecord objects that match the query criteria.
+//   - An error if the query fails, or nil if successful.
+func (r *RecurringRepo) GetRecurring(ctx context.Context, userGUID uuid.UUID, opts RecurringOptions) ([]ftracker.RecurringRecord, error) {
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(fmt.Sprintf("%s r JOIN %s c ON c.guid = r.category_guid", recurringRecordsTable, spendingCategoriesTable), recurringColumns...).
+		Where(
+			query.Raw("c.user_guid = ?", userGUID),
+			query.In("r.guid", opts.GUIDs),
+			query.In("r.category_guid", opts.CategoryGUIDs),
+		).
+		Sortable("r.next_run_at").
+		OrderBy("r.next_run_at", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetRecurring: %w", err)
+	}
+
+	var records []ftracker.RecurringRecord
+	err = r.db.SelectContext(ctx, &records, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetRecurring: %w", err)
+	}
+
+	return records, nil
+}
+
+// GetDueRecurring retrieves the recurring records of all the users whose next occurrence is not later than now,
+// ordered by their next occurrence.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - now: The moment the occurrences are due by.
+//   - limit: The maximum number of recurring records to return, 0 means no limit.
+//
+// Returns:
+//   - A slice of due RecurringRecord objects.
+//   - An error if the query fails, or nil if successful.
+func (r *RecurringRepo) GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error) {
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(fmt.Sprintf("%s r JOIN %s c ON c.guid = r.category_guid", recurringRecordsTable, spendingCategoriesTable), recurringColumns...).
+		Where(query.Raw("r.next_run_at <= ?", now)).
+		Sortable("r.next_run_at").
+		OrderBy("r.next_run_at", true).
+		Limit(limit).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetDueRecurring: %w", err)
+	}
+
+	var records []ftracker.RecurringRecord
+	err = r.db.SelectContext(ctx, &records, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetDueRecurring: %w", err)
+	}
+
+	return records, nil
+}
+
+// AddRecurring inserts multiple recurring records into the database,
+// the first occurrence of each is its StartsAt.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the recurring records.
+//   - records: A slice of RecurringRecord objects to be added to the database,
+//     records without a currency are made in the user's currency.
+//
+// Returns:
+//   - A slice of UUIDs representing the GUIDs of the newly inserted recurring records.
+//   - An error if any issue occurs during the operation, e.g. one of the categories belongs to another user,
+//     ErrUnknownCurrency if there is no exchange rate for the currency of one of the records.
+func (r *RecurringRepo) AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.AddRecurring: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"INSERT INTO %[1]s (category_guid, amount, currency, description, schedule, starts_at, next_run_at) "+
+			"SELECT c.guid, $2::numeric, COALESCE(NULLIF($3::text, ''), u.currency), $4::text, $5::text, $6::timestamp, $6::timestamp "+
+			"FROM %[2]s c JOIN %[3]s u ON u.guid = c.user_guid "+
+			"WHERE c.guid = $1::uuid AND c.user_guid = $7::uuid "+
+			"RETURNING guid",
+		recurringRecordsTable,
+		spendingCategoriesTable,
+		usersTable,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.AddRecurring: %w", err)
+	}
+
+	guids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		err := stmt.QueryRowxContext(ctx,
+			record.CategoryGUID, record.Amount, record.Currency, record.Description, record.Schedule, record.StartsAt, userGUID,
+		).Scan(&guids[i])
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("no suitable row with guid %s", record.CategoryGUID)
+		} else if utils.GetSQLErrorCode(utils.GetItitialError(err)) == utils.ErrSQLForeignKeyViolation {
+			err = fmt.Errorf("%w: %q", ErrUnknownCurrency, record.Currency)
+		}
+		if err != nil {
+			rollback(tx)
+			return nil, fmt.Errorf("Repostiory.AddRecurring: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("Repostiory.AddRecurring: %w", err)
+	}
+
+	return guids, nil
+}
+
+// AdvanceRecurring moves the next occurrence of the recurring record forward,
+// it never moves it back, so advancing to an already passed occurrence changes nothing.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - guid: The GUID of the recurring record.
+//   - nextRunAt: The new next occurrence.
+//
+// Returns:
+//   - An error if the query fails, or nil if successful, a deleted recurring record is not an error.
+func (r *RecurringRepo) AdvanceRecurring(ctx context.Context, guid uuid.UUID, nextRunAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET next_run_at = $1 WHERE guid = $2 AND next_run_at < $1", recurringRecordsTable),
+		nextRunAt,
+		guid,
+	)
+	if err != nil {
+		return fmt.Errorf("Repostiory.AdvanceRecurring: %w", err)
+	}
+	return nil
+}
+
+// DeleteRecurring deletes multiple recurring records of the user,
+// the records already made from them are kept.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the recurring records.
+//   - guids: A slice of UUIDs of the recurring records to be deleted.
+//
+// Returns:
+//   - An error if the operation fails at any point, e.g. one of the recurring records
+//     does not exist or belongs to another user.
+func (r *RecurringRepo) DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecurring: %w", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE guid = $1 AND category_guid IN (SELECT guid FROM %s WHERE user_guid = $2)",
+		recurringRecordsTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecurring: %w", err)
+	}
+
+	for _, guid := range guids {
+		res, err := stmt.ExecContext(ctx, guid, userGUID)
+		if err == nil {
+			err = expectOneRow(res, guid)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeleteRecurring: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecurring: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetRecurring(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		userGUID  uuid.UUID
+		opts      RecurringOptions
+		wantGUIDs []uuid.UUID
+	}{
+		{
+			name:      "By_guids",
+			userGUID:  userGuids[10],
+			opts:      RecurringOptions{GUIDs: recurringGuids[:2]},
+			wantGUIDs: recurringGuids[:2],
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+			opts:     RecurringOptions{GUIDs: recurringGuids[:2]},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			records, err := rcrRepo.GetRecurring(context.Background(), tc.userGUID, tc.opts)
+			require.NoError(t, err)
+			require.Len(t, records, len(tc.wantGUIDs))
+			for i, record := range records {
+				require.Equal(t, tc.wantGUIDs[i], record.GUID)
+				require.Equal(t, tc.userGUID, record.UserGUID)
+				require.Equal(t, "for_recurring1", record.Category)
+			}
+		})
+	}
+}
+
+func Test_GetDueRecurring(t *testing.T) {
+
+	t.Parallel()
+
+	records, err := rcrRepo.GetDueRecurring(context.Background(), time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, recurringGuids[0], records[0].GUID)
+	require.Equal(t, userGuids[10], records[0].UserGUID)
+	require.Equal(t, "monthly", records[0].Schedule)
+	require.Equal(t, time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC), records[0].NextRunAt.UTC())
+}
+
+func Test_AddRecurring(t *testing.T) {
+
+	t.Parallel()
+
+	startsAt := time.Date(2031, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name         string
+		userGUID     uuid.UUID
+		records      []ftracker.RecurringRecord
+		wantCurrency string
+		wantErr      error
+	}{
+		{
+			name:     "Base_currency",
+			userGUID: userGuids[10],
+			records: []ftracker.RecurringRecord{
+				{CategoryGUID: categoryGuids[26], Amount: 1500, Description: "internet", Schedule: "monthly", StartsAt: startsAt},
+			},
+			wantCurrency: ftracker.DefaultCurrency,
+		},
+		{
+			name:     "Other_currency",
+			userGUID: userGuids[10],
+			records: []ftracker.RecurringRecord{
+				{CategoryGUID: categoryGuids[26], Amount: 1500, Currency: "USD", Description: "cloud", Schedule: "0 9 * * 1", StartsAt: startsAt},
+			},
+			wantCurrency: "USD",
+		},
+		{
+			name:     "Unknown_currency",
+			userGUID: userGuids[10],
+			records: []ftracker.RecurringRecord{
+				{CategoryGUID: categoryGuids[26], Amount: 1500, Currency: "XXX", Schedule: "daily", StartsAt: startsAt},
+			},
+			wantErr: ErrUnknownCurrency,
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+			records: []ftracker.RecurringRecord{
+				{CategoryGUID: categoryGuids[26], Amount: 1500, Schedule: "daily", StartsAt: startsAt},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			guids, err := rcrRepo.AddRecurring(context.Background(), tc.userGUID, tc.records)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			if tc.wantCurrency == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := rcrRepo.GetRecurring(context.Background(), tc.userGUID, RecurringOptions{GUIDs: guids})
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.records))
+			for i := range got {
+				require.Equal(t, tc.wantCurrency, got[i].Currency)
+				require.Equal(t, tc.records[i].Schedule, got[i].Schedule)
+				require.Equal(t, startsAt, got[i].NextRunAt.UTC())
+			}
+		})
+	}
+}
+
+func Test_AdvanceRecurring(t *testing.T) {
+
+	t.Parallel()
+
+	next := time.Date(2030, 2, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, rcrRepo.AdvanceRecurring(context.Background(), recurringGuids[2], next))
+	// moving back is ignored
+	require.NoError(t, rcrRepo.AdvanceRecurring(context.Background(), recurringGuids[2], next.AddDate(0, -1, 0)))
+
+	got, err := rcrRepo.GetRecurring(context.Background(), userGuids[10], RecurringOptions{GUIDs: recurringGuids[2:3]})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, next, got[0].NextRunAt.UTC())
+}
+
+func Test_DeleteRecurring(t *testing.T) {
+
+	t.Parallel()
+
+	require.Error(t, rcrRepo.DeleteRecurring(context.Background(), userGuids[0], recurringGuids[3:4]))
+	require.NoError(t, rcrRepo.DeleteRecurring(context.Background(), userGuids[10], recurringGuids[3:4]))
+
+	got, err := rcrRepo.GetRecurring(context.Background(), userGuids[10], RecurringOptions{GUIDs: recurringGuids[3:4]})
+	require.NoError(t, err)
+	require.Len(t, got, 0)
+}
+
+func Test_AddRecords_occurrence(t *testing.T) {
+
+	t.Parallel()
+
+	record := ftracker.SpendingRecord{
+		CategoryGUID:  categoryGuids[26],
+		Amount:        1000,
+		Description:   "rent",
+		RecurringGUID: recurringGuids[0],
+		OccurredAt:    time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	// the occurrence is already recorded
+	_, err := recRepo.AddRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{record})
+	require.Error(t, err)
+	require.True(t, utils.IsUniqueConstrainViolation(err))
+
+	record.OccurredAt = record.OccurredAt.AddDate(0, 1, 0)
+	_, err = recRepo.AddRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{record})
+	require.NoError(t, err)
+}
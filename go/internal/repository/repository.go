@@ -1,6 +1,12 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
 	"github.com/jmoiron/sqlx"
@@ -10,38 +16,212 @@ const (
 	usersTable              = "users"
 	spendingCategoriesTable = "spending_categories"
 	spendingRecordsTable    = "spending_records"
+	exchangeRatesTable      = "exchange_rates"
+	budgetsTable            = "budgets"
+	recurringRecordsTable   = "recurring_records"
+	tagsTable               = "tags"
+	recordTagsTable         = "record_tags"
+	sessionsTable           = "sessions"
+	bankTransactionsTable   = "bank_transactions"
+	payeeRulesTable         = "payee_rules"
+
+	// DefaultQueryTimeout is the deadline of every repository call unless WithQueryTimeout sets another one
+	DefaultQueryTimeout = 10 * time.Second
 )
 
+// ErrUnknownCurrency is returned when there is no exchange rate for the currency.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
 // User defines the interface for user repository.
 type User interface {
-	AddUsers(users []ftracker.User) ([]uuid.UUID, error)
-	GetUsers(opts UserOptions) ([]ftracker.User, error)
+	AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error)
+	GetUsers(ctx context.Context, opts UserOptions) ([]ftracker.User, error)
+	SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error
+	SetUserAPIToken(ctx context.Context, userGUID uuid.UUID, tokenHash string) error
 }
 
 // SpendingCategory defines the interface for spending category repository.
+// Every method is scoped to the categories of the user identified by userGUID.
 type SpendingCategory interface {
-	AddCategories(category []ftracker.SpendingCategory) ([]uuid.UUID, error)
-	GetCategories(opts CategoryOptions) ([]ftracker.SpendingCategory, error)
+	AddCategories(ctx context.Context, userGUID uuid.UUID, category []ftracker.SpendingCategory) ([]uuid.UUID, error)
+	GetCategories(ctx context.Context, userGUID uuid.UUID, opts CategoryOptions) ([]ftracker.SpendingCategory, error)
+	UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error
+	DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error
 }
 
 // SpendingRecord defines the interface for spending record repository.
+// Every method is scoped to the records in the categories of the user identified by userGUID.
 type SpendingRecord interface {
-	AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error)
-	GetRecords(opts RecordOptions) ([]ftracker.SpendingRecord, error)
+	AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error)
+	GetRecords(ctx context.Context, userGUID uuid.UUID, opts RecordOptions) ([]ftracker.SpendingRecord, error)
+	GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error)
+	GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error)
+	GetImportedTransactions(ctx context.Context, userGUID uuid.UUID, ids []string) ([]string, error)
+	UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error
+	ImportRecords(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory, added, updated []ftracker.SpendingRecord) ([]uuid.UUID, error)
+	DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
+}
+
+// Budget defines the interface for budget repository.
+// Every method is scoped to the budgets of the spending categories of the user identified by userGUID.
+type Budget interface {
+	SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error
+	GetBudgets(ctx context.Context, userGUID uuid.UUID, opts BudgetOptions) ([]ftracker.BudgetStatus, error)
+	DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error
+}
+
+// Recurring defines the interface for recurring record repository.
+// Every method but GetDueRecurring and AdvanceRecurring is scoped to the recurring records
+// in the categories of the user identified by userGUID, those two serve the scheduler of all the users.
+type Recurring interface {
+	AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error)
+	GetRecurring(ctx context.Context, userGUID uuid.UUID, opts RecurringOptions) ([]ftracker.RecurringRecord, error)
+	DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
+	GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error)
+	AdvanceRecurring(ctx context.Context, guid uuid.UUID, nextRunAt time.Time) error
+}
+
+// PayeeRule defines the interface for payee rule repository.
+// Every method is scoped to the rules of the categories of the user identified by userGUID.
+type PayeeRule interface {
+	AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error)
+	GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error)
+	DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
+}
+
+// ExchangeRate defines the interface for exchange rate repository.
+type ExchangeRate interface {
+	SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error
+	GetExchangeRates(ctx context.Context, currencies []string) ([]ftracker.ExchangeRate, error)
 }
 
-// Repository implements the interfaces for user, spending category, and spending record repositories.
+// Session defines the interface for the repository of the saved bot sessions.
+type Session interface {
+	SaveSession(ctx context.Context, session ftracker.Session) error
+	GetSessions(ctx context.Context) ([]ftracker.Session, error)
+	DeleteSession(ctx context.Context, chatID int64) error
+}
+
+// Repository implements the interfaces for user, spending category, spending record, budget,
+// recurring record, payee rule, exchange rate and session repositories.
 type Repostitory struct {
 	User
 	SpendingCategory
 	SpendingRecord
+	Budget
+	Recurring
+	PayeeRule
+	ExchangeRate
+	Session
+}
+
+// Option is a function to configure the repositories.
+type Option func(*options)
+
+// options holds the configuration of the repositories
+//
+//   - queryTimeout: the deadline of every call, the calls within a transaction share it, zero means no deadline
+type options struct {
+	queryTimeout time.Duration
+}
+
+// WithQueryTimeout is a function that sets the deadline of every repository call,
+// the context passed to the call may still end it earlier, zero disables the deadline.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.queryTimeout = timeout
+	}
+}
+
+// newOptions applies the options to the defaults
+func newOptions(opts []Option) options {
+	o := options{queryTimeout: DefaultQueryTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // NewUserRepository creates a new instance of User repository.
-func New(db *sqlx.DB) *Repostitory {
+func New(db *sqlx.DB, opts ...Option) *Repostitory {
 	return &Repostitory{
-		User:             NewUserRepository(db),
-		SpendingCategory: NewCategoryRepository(db),
-		SpendingRecord:   NewRecordRepository(db),
+		User:             NewUserRepository(db, opts...),
+		SpendingCategory: NewCategoryRepository(db, opts...),
+		SpendingRecord:   NewRecordRepository(db, opts...),
+		Budget:           NewBudgetRepository(db, opts...),
+		Recurring:        NewRecurringRepository(db, opts...),
+		PayeeRule:        NewPayeeRuleRepository(db, opts...),
+		ExchangeRate:     NewExchangeRateRepository(db, opts...),
+		Session:          NewSessionRepository(db, opts...),
+	}
+}
+
+// withTimeout bounds the context of a call with the timeout, the context is only made cancellable for a zero timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// rollback rolls the transaction back. The transaction whose context is done is already rolled back,
+// any other failure means the connection is broken, so it panics.
+func rollback(tx *sqlx.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		panic(err)
+	}
+}
+
+// commit commits the transaction. The transaction whose context is done is rolled back instead
+// and the error of the context is returned, any other failure means the connection is broken, so it panics.
+func commit(ctx context.Context, tx *sqlx.Tx) error {
+	err := tx.Commit()
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	panic(err)
+}
+
+// convertAmount returns the SQL expression converting the amount between the currencies of two exchange rates,
+// the rates are the amounts worth one unit of ftracker.DefaultCurrency, the scales shift the minor units,
+// so 100 cents of EUR become 160 yens rather than 16000.
+//
+// Parameters:
+//   - amount: The SQL expression of the amount in the minor units of the currency it is converted from.
+//   - from: The alias of the exchange rate of the currency the amount is in.
+//   - to: The alias of the exchange rate of the currency the amount is converted into.
+//
+// Returns:
+//   - The SQL expression of the amount in the minor units of the other currency, rounded to the nearest one.
+func convertAmount(amount, from, to string) string {
+	return fmt.Sprintf("ROUND(%[1]s * %[3]s.rate / %[2]s.rate * power(10::numeric, %[3]s.scale - %[2]s.scale))", amount, from, to)
+}
+
+// expectOneRow checks that the statement affected exactly one row,
+// otherwise the row identified by guid is considered missing.
+func expectOneRow(res sql.Result, guid uuid.UUID) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return fmt.Errorf("no suitable row with guid %s", guid)
+	}
+	return nil
+}
+
+// checkOwner makes sure the entity with the given owner belongs to the user,
+// an empty owner is populated with the user's GUID.
+func checkOwner(userGUID uuid.UUID, owner *uuid.UUID) error {
+	if *owner == uuid.Nil {
+		*owner = userGUID
+		return nil
+	}
+	if *owner != userGUID {
+		return fmt.Errorf("user %s is not the owner", userGUID)
 	}
+	return nil
 }
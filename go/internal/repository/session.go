@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
+	"github.com/jmoiron/sqlx"
+)
+
+// SessionRepo implements the Session interface.
+type SessionRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+// NewSessionRepository creates a new instance of SessionRepo with the provided database connection.
+func NewSessionRepository(db *sqlx.DB, opts ...Option) *SessionRepo {
+	return &SessionRepo{db: db, timeout: newOptions(opts).queryTimeout}
+}
+
+// SaveSession inserts the session or replaces the saved session of the same chat.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - session: The session to be saved, its CreatedAt and UpdatedAt are set by the database.
+//
+// Returns:
+//   - An error if the operation fails, or nil if successful.
+func (s *SessionRepo) SaveSession(ctx context.Context, session ftracker.Session) error {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.db.NamedExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (chat_id, user_id, username, command_id, batch_kind, batch) "+
+			"VALUES (:chat_id, :user_id, :username, :command_id, :batch_kind, :batch) "+
+			"ON CONFLICT (chat_id) DO UPDATE SET command_id = EXCLUDED.command_id, batch_kind = EXCLUDED.batch_kind, batch = EXCLUDED.batch",
+		sessionsTable,
+	), session)
+	if err != nil {
+		return fmt.Errorf("Repostiory.SaveSession: %w", err)
+	}
+	return nil
+}
+
+// GetSessions retrieves all the saved sessions, the least recently updated first.
+//
+// Returns:
+//   - A slice of the saved sessions.
+//   - An error if the query fails, or nil if successful.
+func (s *SessionRepo) GetSessions(ctx context.Context) ([]ftracker.Session, error) {
+
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(sessionsTable, "chat_id", "user_id", "username", "command_id", "batch_kind", "batch", "created_at", "updated_at").
+		Sortable("updated_at").
+		OrderBy("updated_at", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetSessions: %w", err)
+	}
+
+	var sessions []ftracker.Session
+	err = s.db.SelectContext(ctx, &sessions, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetSessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession deletes the saved session of the chat, it is not an error if there is none.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - chatID: The telegram id of the chat.
+//
+// Returns:
+//   - An error if the operation fails, or nil if successful.
+func (s *SessionRepo) DeleteSession(ctx context.Context, chatID int64) error {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE chat_id = $1", sessionsTable), chatID)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteSession: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sessions(t *testing.T) {
+
+	first := ftracker.Session{
+		ChatID:    1001,
+		UserID:    1001,
+		Username:  "for_sessions",
+		CommandID: 2,
+		BatchKind: "category",
+		Batch:     []byte(`{"category": "food"}`),
+	}
+	second := ftracker.Session{
+		ChatID:    1002,
+		UserID:    1002,
+		Username:  "for_sessions_no_batch",
+		CommandID: 1,
+	}
+
+	require.NoError(t, sesRepo.SaveSession(context.Background(), first))
+	require.NoError(t, sesRepo.SaveSession(context.Background(), second))
+
+	got, err := sesRepo.GetSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, first.ChatID, got[0].ChatID)
+	require.Equal(t, first.Username, got[0].Username)
+	require.Equal(t, first.CommandID, got[0].CommandID)
+	require.Equal(t, first.BatchKind, got[0].BatchKind)
+	require.JSONEq(t, string(first.Batch), string(got[0].Batch))
+	require.False(t, got[0].UpdatedAt.IsZero())
+	require.Equal(t, second.ChatID, got[1].ChatID)
+	require.Nil(t, got[1].Batch)
+
+	// the next step replaces the saved one and moves the session to the end
+	first.CommandID = 3
+	first.Batch = []byte(`{"category": "drinks"}`)
+	require.NoError(t, sesRepo.SaveSession(context.Background(), first))
+
+	got, err = sesRepo.GetSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, second.ChatID, got[0].ChatID)
+	require.Equal(t, first.ChatID, got[1].ChatID)
+	require.Equal(t, 3, got[1].CommandID)
+	require.JSONEq(t, string(first.Batch), string(got[1].Batch))
+
+	require.NoError(t, sesRepo.DeleteSession(context.Background(), first.ChatID))
+	require.NoError(t, sesRepo.DeleteSession(context.Background(), first.ChatID))
+
+	got, err = sesRepo.GetSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, second.ChatID, got[0].ChatID)
+
+	require.NoError(t, sesRepo.DeleteSession(context.Background(), second.ChatID))
+}
@@ -1,26 +1,31 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
 	"github.com/jmoiron/sqlx"
 )
 
 type (
 	// CategoryRepo implements the SpendingCategory interface.
 	CategoryRepo struct {
-		db *sqlx.DB
+		db      *sqlx.DB
+		timeout time.Duration
 	}
 
 	// CategoryOptions defines the options for retrieving spending categories.
 	CategoryOptions struct {
 		Limit      int
 		GUIDs      []uuid.UUID
-		UserGUIDs  []uuid.UUID
 		Categories []string
+		Kinds      []string
 		Order      CategoryOrder
 	}
 
@@ -31,36 +36,46 @@ type (
 	}
 )
 
+// categorySortableColumns are the columns categories can be ordered by.
+var categorySortableColumns = []string{"category", "amount", "created_at", "updated_at"}
+
 // NewCategoryRepository creates a new instance of CategoryRepo with the provided database connection.
-func NewCategoryRepository(db *sqlx.DB) *CategoryRepo {
-	return &CategoryRepo{db: db}
+func NewCategoryRepository(db *sqlx.DB, opts ...Option) *CategoryRepo {
+	return &CategoryRepo{db: db, timeout: newOptions(opts).queryTimeout}
 }
 
-// GetCategories retrieves a list of spending categories from the database based on the provided options.
+// GetCategories retrieves a list of spending categories of the user from the database based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
 //   - opts: A struct containing filtering, ordering, and limiting options for the query.
 //
 // Returns:
 //   - A slice of SpendingCategory objects that match the query criteria.
 //   - An error if the query fails, or nil if successful.
-func (c *CategoryRepo) GetCategories(opts CategoryOptions) ([]ftracker.SpendingCategory, error) {
+func (c *CategoryRepo) GetCategories(ctx context.Context, userGUID uuid.UUID, opts CategoryOptions) ([]ftracker.SpendingCategory, error) {
 
-	whereClause := utils.BindWithOp("AND", true,
-		utils.MakeIn("guid", utils.UUIDsToStrings(opts.GUIDs)...),
-		utils.MakeIn("user_guid", utils.UUIDsToStrings(opts.UserGUIDs)...),
-		utils.MakeIn("category", opts.Categories...),
-	)
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
 
-	query := fmt.Sprintf("SELECT guid, user_guid, category, description, amount, created_at, updated_at FROM %s %s %s %s",
-		spendingCategoriesTable,
-		whereClause,
-		utils.MakeOrderBy(opts.Order.Column, opts.Order.Asc),
-		utils.MakeLimit(opts.Limit),
-	)
+	q, args, err := query.Select(spendingCategoriesTable, "guid", "user_guid", "parent_guid", "category", "description", "kind", "amount", "created_at", "updated_at").
+		Where(
+			query.Raw("user_guid = ?", userGUID),
+			query.In("guid", opts.GUIDs),
+			query.In("category", opts.Categories),
+			query.In("kind", opts.Kinds),
+		).
+		Sortable(categorySortableColumns...).
+		OrderBy(opts.Order.Column, opts.Order.Asc).
+		Limit(opts.Limit).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetCategories: %w", err)
+	}
 
 	var categories []ftracker.SpendingCategory
-	err := c.db.Select(&categories, query)
+	err = c.db.SelectContext(ctx, &categories, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Repostiory.GetCategories: %w", err)
 	}
@@ -68,40 +83,228 @@ func (c *CategoryRepo) GetCategories(opts CategoryOptions) ([]ftracker.SpendingC
 	return categories, nil
 }
 
-// AddCategories inserts multiple spending categories into the database and returns their generated UUIDs.
+// AddCategories inserts multiple spending categories of the user into the database and returns their generated UUIDs.
 //
 // Parameters:
-//   - categories: A slice of SpendingCategory objects to be added to the database.
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
+//   - categories: A slice of SpendingCategory objects to be added to the database, a GUID is generated
+//     for those without one, their UserGUID is either empty or equal to userGUID, an empty Kind stands for ftracker.KindSpending.
+//     A non-empty ParentGUID has to identify an existing category of the same user and kind,
+//     it may be one of the categories inserted earlier in the same slice.
 //
 // Returns:
 //   - A slice of UUIDs corresponding to the inserted categories.
 //   - An error if the operation fails at any point.
-func (c *CategoryRepo) AddCategories(categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
-	tx, err := c.db.Beginx()
+func (c *CategoryRepo) AddCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	tx, err := c.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Repostiory.AddCategory: %w", err)
 	}
 
-	stmt, err := tx.PrepareNamed(fmt.Sprintf("INSERT INTO %s (user_guid, category, description, amount) VALUES (:user_guid, :category, :description, :amount) RETURNING guid", spendingCategoriesTable))
+	guids, err := addCategories(ctx, tx, userGUID, categories)
 	if err != nil {
+		rollback(tx)
 		return nil, fmt.Errorf("Repostiory.AddCategory: %w", err)
 	}
 
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("Repostiory.AddCategories: %w", err)
+	}
+
+	return guids, nil
+}
+
+// addCategories inserts the categories in the transaction, see AddCategories, the transaction is left to the caller
+func addCategories(ctx context.Context, tx *sqlx.Tx, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+	stmt, err := tx.PrepareNamedContext(ctx, fmt.Sprintf(
+		"INSERT INTO %[1]s (guid, user_guid, parent_guid, category, description, kind, amount) "+
+			"VALUES (COALESCE(NULLIF(:guid, CAST('%[2]s' AS UUID)), uuid_generate_v4()), :user_guid, NULLIF(:parent_guid, CAST('%[2]s' AS UUID)), :category, :description, :kind, :amount) "+
+			"RETURNING guid",
+		spendingCategoriesTable, uuid.Nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+	stmtParent, err := tx.PreparexContext(ctx, fmt.Sprintf("SELECT kind FROM %s WHERE guid = $1 AND user_guid = $2", spendingCategoriesTable))
+	if err != nil {
+		return nil, err
+	}
+
 	guids := make([]uuid.UUID, len(categories))
 	for i, category := range categories {
-		if err := stmt.Get(&guids[i], category); err != nil {
-			_err := tx.Rollback()
-			if _err != nil {
-				panic(_err)
-			}
-			return nil, fmt.Errorf("Repostiory.AddCategory: %w", err)
+		if category.Kind == "" {
+			category.Kind = ftracker.KindSpending
+		}
+		err := checkOwner(userGUID, &category.UserGUID)
+		if err == nil && category.ParentGUID != uuid.Nil {
+			err = checkParent(ctx, stmtParent, category)
+		}
+		if err == nil {
+			err = stmt.GetContext(ctx, &guids[i], category)
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	err = tx.Commit()
+	return guids, nil
+}
+
+// UpdateCategories updates the name and description of multiple spending categories of the user.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
+//   - categories: A slice of SpendingCategory objects identified by their GUIDs, containing the new values.
+//
+// Returns:
+//   - An error if the operation fails at any point, e.g. one of the categories does not exist, belongs
+//     to another user or the new name is already taken by another category of the same user.
+func (c *CategoryRepo) UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	tx, err := c.db.BeginTxx(ctx, nil)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("Repostiory.UpdateCategories: %w", err)
 	}
 
-	return guids, nil
+	stmt, err := tx.PrepareNamedContext(ctx, fmt.Sprintf("UPDATE %s SET category = :category, description = :description WHERE guid = :guid AND user_guid = :user_guid", spendingCategoriesTable))
+	if err != nil {
+		return fmt.Errorf("Repostiory.UpdateCategories: %w", err)
+	}
+
+	for _, category := range categories {
+		var res sql.Result
+		err := checkOwner(userGUID, &category.UserGUID)
+		if err == nil {
+			res, err = stmt.ExecContext(ctx, category)
+		}
+		if err == nil {
+			err = expectOneRow(res, category.GUID)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.UpdateCategories: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.UpdateCategories: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCategories deletes multiple spending categories of the user from the database.
+// The records of the deleted categories are either deleted as well or moved to another category
+// of the same user and kind, in which case the amount of that category is increased accordingly.
+// The subcategories of the deleted categories are moved up to the parents of the deleted ones.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories.
+//   - guids: A slice of UUIDs of the categories to be deleted.
+//   - moveTo: The UUID of the category the records should be moved to,
+//     if it is uuid.Nil, the records are deleted together with the categories.
+//
+// Returns:
+//   - An error if the operation fails at any point, e.g. one of the categories does not exist,
+//     belongs to another user or is of another kind than the moveTo category.
+func (c *CategoryRepo) DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+
+	stmtMoveAmount, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"UPDATE %[1]s SET amount = %[1]s.amount + src.amount FROM %[1]s src WHERE %[1]s.guid = $1 AND src.guid = $2 AND %[1]s.user_guid = $3 AND src.user_guid = $3 AND src.kind = %[1]s.kind AND src.guid <> $1",
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+	stmtMoveRecords, err := tx.PreparexContext(ctx, fmt.Sprintf("UPDATE %s SET category_guid = $1 WHERE category_guid = $2", spendingRecordsTable))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+	stmtDelRecords, err := tx.PreparexContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE category_guid IN (SELECT guid FROM %s WHERE guid = $1 AND user_guid = $2)", spendingRecordsTable, spendingCategoriesTable))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+	stmtReparent, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"UPDATE %[1]s SET parent_guid = src.parent_guid FROM %[1]s src WHERE %[1]s.parent_guid = src.guid AND src.guid = $1 AND src.user_guid = $2",
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+	stmtDelCategory, err := tx.PreparexContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE guid = $1 AND user_guid = $2", spendingCategoriesTable))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+
+	for _, guid := range guids {
+
+		if moveTo != uuid.Nil {
+			res, err := stmtMoveAmount.ExecContext(ctx, moveTo, guid, userGUID)
+			if err == nil {
+				err = expectOneRow(res, moveTo)
+			}
+			if err == nil {
+				_, err = stmtMoveRecords.ExecContext(ctx, moveTo, guid)
+			}
+			if err != nil {
+				rollback(tx)
+				return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+			}
+		} else {
+			if _, err := stmtDelRecords.ExecContext(ctx, guid, userGUID); err != nil {
+				rollback(tx)
+				return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+			}
+		}
+
+		_, err := stmtReparent.ExecContext(ctx, guid, userGUID)
+		var res sql.Result
+		if err == nil {
+			res, err = stmtDelCategory.ExecContext(ctx, guid, userGUID)
+		}
+		if err == nil {
+			err = expectOneRow(res, guid)
+		}
+		if err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.DeleteCategories: %w", err)
+	}
+
+	return nil
+}
+
+// checkParent makes sure the parent of the category belongs to the same user and is of the same kind.
+func checkParent(ctx context.Context, stmt *sqlx.Stmt, category ftracker.SpendingCategory) error {
+	var kind string
+	err := stmt.GetContext(ctx, &kind, category.ParentGUID, category.UserGUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no suitable row with guid %s", category.ParentGUID)
+	}
+	if err != nil {
+		return err
+	}
+	if kind != category.Kind {
+		return fmt.Errorf("parent category %s is of kind %s", category.ParentGUID, kind)
+	}
+	return nil
 }
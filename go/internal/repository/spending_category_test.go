@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,13 +15,15 @@ func TestCategoryRepo_AddCategories(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		args    []ftracker.SpendingCategory
-		want    []ftracker.SpendingCategory
-		wantErr bool
+		name     string
+		userGUID uuid.UUID
+		args     []ftracker.SpendingCategory
+		want     []ftracker.SpendingCategory
+		wantErr  bool
 	}{
 		{
-			name: "Single_category",
+			name:     "Single_category",
+			userGUID: userGuids[0],
 			args: []ftracker.SpendingCategory{
 				{UserGUID: userGuids[0], Category: "Beer", Description: "This category is for money spent on beer or something related to it", Amount: 0},
 			},
@@ -28,7 +32,8 @@ func TestCategoryRepo_AddCategories(t *testing.T) {
 			},
 		},
 		{
-			name: "Multible_categories",
+			name:     "Multible_categories",
+			userGUID: userGuids[0],
 			args: []ftracker.SpendingCategory{
 				{UserGUID: userGuids[0], Category: "Food", Description: "This category is for money spent on products", Amount: 0},
 				{UserGUID: userGuids[0], Category: "Restaurants", Description: "This category is for money spent in restaurants", Amount: 0},
@@ -41,10 +46,72 @@ func TestCategoryRepo_AddCategories(t *testing.T) {
 			},
 		},
 		{
-			name: "Errorous",
+			name:     "Without_user_guid",
+			userGUID: userGuids[1],
 			args: []ftracker.SpendingCategory{
-				{UserGUID: userGuids[0], Category: "Food", Description: "This category is for money spent on products", Amount: 0},
-				{UserGUID: uuid.MustParse("00000000-0000-0000-0000-100000000001"), Category: "Mental Helth", Description: "This category is for money spent to improve mental health", Amount: 0},
+				{Category: "Gym", Description: "This category is for money spent on sport", Amount: 0},
+			},
+			want: []ftracker.SpendingCategory{
+				{UserGUID: userGuids[1], Category: "Gym", Description: "This category is for money spent on sport", Amount: 0},
+			},
+		},
+		{
+			name:     "Income_category",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingCategory{
+				{Category: "Salary", Description: "This category is for money earned at work", Kind: ftracker.KindIncome},
+			},
+			want: []ftracker.SpendingCategory{
+				{UserGUID: userGuids[1], Category: "Salary", Description: "This category is for money earned at work", Kind: ftracker.KindIncome},
+			},
+		},
+		{
+			name:     "Subcategory",
+			userGUID: userGuids[12],
+			args: []ftracker.SpendingCategory{
+				{ParentGUID: categoryGuids[29], Category: "Bakery", Description: "This category is for money spent on bread"},
+			},
+			want: []ftracker.SpendingCategory{
+				{UserGUID: userGuids[12], ParentGUID: categoryGuids[29], Category: "Bakery", Description: "This category is for money spent on bread"},
+			},
+		},
+		{
+			name:     "Parent_of_another_kind",
+			userGUID: userGuids[12],
+			args: []ftracker.SpendingCategory{
+				{ParentGUID: categoryGuids[32], Category: "Groceries", Description: "This category is for money spent on groceries"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Parent_of_another_user",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingCategory{
+				{ParentGUID: categoryGuids[29], Category: "Snacks", Description: "This category is for money spent on snacks"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Unknown_kind",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingCategory{
+				{Category: "Lottery", Description: "This category is for money won in the lottery", Kind: "gamble"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Errorous",
+			userGUID: uuid.MustParse("00000000-0000-0000-0000-100000000001"),
+			args: []ftracker.SpendingCategory{
+				{Category: "Mental Helth", Description: "This category is for money spent to improve mental health", Amount: 0},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingCategory{
+				{UserGUID: userGuids[0], Category: "Taxes", Description: "This category is for money spent on taxes", Amount: 0},
 			},
 			wantErr: true,
 		},
@@ -54,7 +121,7 @@ func TestCategoryRepo_AddCategories(t *testing.T) {
 
 			t.Parallel()
 
-			got, err := catRepo.AddCategories(tt.args)
+			got, err := catRepo.AddCategories(context.Background(), tt.userGUID, tt.args)
 			if !tt.wantErr {
 				require.NoError(t, err)
 			} else {
@@ -63,15 +130,20 @@ func TestCategoryRepo_AddCategories(t *testing.T) {
 				return
 			}
 
-			res, err := catRepo.GetCategories(CategoryOptions{GUIDs: got})
+			res, err := catRepo.GetCategories(context.Background(), tt.userGUID, CategoryOptions{GUIDs: got})
 			require.NoError(t, err)
 
 			require.Len(t, res, len(tt.want))
 			for i, category := range tt.want {
 				require.Equal(t, category.UserGUID, res[i].UserGUID)
+				require.Equal(t, category.ParentGUID, res[i].ParentGUID)
 				require.Equal(t, category.Category, res[i].Category)
 				require.Equal(t, category.Description, res[i].Description)
 				require.Equal(t, category.Amount, res[i].Amount)
+				if category.Kind == "" {
+					category.Kind = ftracker.KindSpending
+				}
+				require.Equal(t, category.Kind, res[i].Kind)
 			}
 		})
 	}
@@ -82,16 +154,17 @@ func TestCategoryRepo_GetCategories(t *testing.T) {
 	t.Parallel()
 
 	tt := []struct {
-		name    string
-		options CategoryOptions
-		want    []ftracker.SpendingCategory
-		wantErr bool
+		name     string
+		userGUID uuid.UUID
+		options  CategoryOptions
+		want     []ftracker.SpendingCategory
+		wantErr  bool
 	}{
 		{
-			name: "By_guids",
+			name:     "By_guids",
+			userGUID: userGuids[1],
 			options: CategoryOptions{
-				GUIDs:     categoryGuids[6:10],
-				UserGUIDs: []uuid.UUID{userGuids[1]},
+				GUIDs: categoryGuids[6:10],
 			},
 			want: []ftracker.SpendingCategory{
 				{GUID: categoryGuids[7], UserGUID: userGuids[1], Category: "for_get_categories2", Description: "bla bla bla", Amount: 0},
@@ -99,21 +172,21 @@ func TestCategoryRepo_GetCategories(t *testing.T) {
 			},
 		},
 		{
-			name: "Limited",
+			name:     "Limited",
+			userGUID: userGuids[0],
 			options: CategoryOptions{
-				GUIDs:     categoryGuids[6:10],
-				UserGUIDs: []uuid.UUID{userGuids[0]},
-				Limit:     1,
+				GUIDs: categoryGuids[6:10],
+				Limit: 1,
 			},
 			want: []ftracker.SpendingCategory{
 				{GUID: categoryGuids[6], UserGUID: userGuids[0], Category: "for_get_categories1", Description: "bla bla bla", Amount: 0},
 			},
 		},
 		{
-			name: "By_category",
+			name:     "By_category",
+			userGUID: userGuids[1],
 			options: CategoryOptions{
 				GUIDs:      categoryGuids[6:10],
-				UserGUIDs:  []uuid.UUID{userGuids[1]},
 				Categories: []string{"for_get_categories2"},
 			},
 			want: []ftracker.SpendingCategory{
@@ -121,17 +194,51 @@ func TestCategoryRepo_GetCategories(t *testing.T) {
 			},
 		},
 		{
-			name: "Ordered",
+			name:     "Ordered",
+			userGUID: userGuids[1],
 			options: CategoryOptions{
 				GUIDs: categoryGuids[6:10],
-				Order: CategoryOrder{Column: "category", Asc: true},
+				Order: CategoryOrder{Column: "category", Asc: false},
 			},
 			want: []ftracker.SpendingCategory{
-				{GUID: categoryGuids[6], UserGUID: userGuids[0], Category: "for_get_categories1", Description: "bla bla bla", Amount: 0},
-				{GUID: categoryGuids[7], UserGUID: userGuids[1], Category: "for_get_categories2", Description: "bla bla bla", Amount: 0},
-				{GUID: categoryGuids[8], UserGUID: userGuids[0], Category: "for_get_categories3", Description: "bla bla bla", Amount: 0},
 				{GUID: categoryGuids[9], UserGUID: userGuids[1], Category: "for_get_categories4", Description: "bla bla bla", Amount: 0},
+				{GUID: categoryGuids[7], UserGUID: userGuids[1], Category: "for_get_categories2", Description: "bla bla bla", Amount: 0},
+			},
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[0],
+			options: CategoryOptions{
+				GUIDs:      categoryGuids[6:10],
+				Categories: []string{"for_get_categories2", "for_get_categories4"},
+			},
+			want: []ftracker.SpendingCategory{},
+		},
+		{
+			name:     "By_kind",
+			userGUID: userGuids[7],
+			options: CategoryOptions{
+				Kinds: []string{ftracker.KindIncome},
+			},
+			want: []ftracker.SpendingCategory{
+				{GUID: categoryGuids[19], UserGUID: userGuids[7], Category: "for_income2", Description: "bla bla bla", Kind: ftracker.KindIncome, Amount: 7000},
+			},
+		},
+		{
+			name:     "Hostile_category",
+			userGUID: userGuids[1],
+			options: CategoryOptions{
+				Categories: []string{"x') OR ('1'='1"},
+			},
+			want: []ftracker.SpendingCategory{},
+		},
+		{
+			name:     "Unsortable_column",
+			userGUID: userGuids[1],
+			options: CategoryOptions{
+				Order: CategoryOrder{Column: "category; DROP TABLE users", Asc: true},
 			},
+			wantErr: true,
 		},
 	}
 
@@ -140,7 +247,7 @@ func TestCategoryRepo_GetCategories(t *testing.T) {
 
 			t.Parallel()
 
-			res, err := catRepo.GetCategories(tc.options)
+			res, err := catRepo.GetCategories(context.Background(), tc.userGUID, tc.options)
 			if tc.wantErr {
 				require.Error(t, err)
 				return
@@ -150,10 +257,215 @@ func TestCategoryRepo_GetCategories(t *testing.T) {
 			for i, category := range tc.want {
 				require.Equal(t, category.GUID, res[i].GUID)
 				require.Equal(t, category.UserGUID, res[i].UserGUID)
+				require.Equal(t, category.ParentGUID, res[i].ParentGUID)
 				require.Equal(t, category.Category, res[i].Category)
 				require.Equal(t, category.Description, res[i].Description)
 				require.Equal(t, category.Amount, res[i].Amount)
+				if category.Kind == "" {
+					category.Kind = ftracker.KindSpending
+				}
+				require.Equal(t, category.Kind, res[i].Kind)
+			}
+		})
+	}
+}
+
+func TestCategoryRepo_UpdateCategories(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		args      []ftracker.SpendingCategory
+		wantErr   bool
+		errorCode string
+	}{
+		{
+			name: "Rename_and_describe",
+			args: []ftracker.SpendingCategory{
+				{GUID: categoryGuids[12], Category: "for_update_categories_renamed", Description: "new description"},
+			},
+		},
+		{
+			name: "Duplicate_name",
+			args: []ftracker.SpendingCategory{
+				{GUID: categoryGuids[13], Category: "for_get_categories2", Description: "bla bla bla"},
+			},
+			wantErr:   true,
+			errorCode: utils.ErrSQLUniqueViolation,
+		},
+		{
+			name: "Errorous",
+			args: []ftracker.SpendingCategory{
+				{GUID: uuid.MustParse("00000000-0000-0000-0000-100000000001"), Category: "Mental Helth"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Another_user",
+			args: []ftracker.SpendingCategory{
+				{GUID: categoryGuids[6], Category: "stolen", Description: "bla bla bla"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			err := catRepo.UpdateCategories(context.Background(), userGuids[1], tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.errorCode != "" {
+					require.Equal(t, tt.errorCode, utils.GetSQLErrorCode(utils.GetItitialError(err)))
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			guids := make([]uuid.UUID, len(tt.args))
+			for i, category := range tt.args {
+				guids[i] = category.GUID
+			}
+			res, err := catRepo.GetCategories(context.Background(), userGuids[1], CategoryOptions{GUIDs: guids})
+			require.NoError(t, err)
+
+			require.Len(t, res, len(tt.args))
+			for i, category := range tt.args {
+				require.Equal(t, category.Category, res[i].Category)
+				require.Equal(t, category.Description, res[i].Description)
+			}
+		})
+	}
+}
+
+func TestCategoryRepo_DeleteCategories(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		userGUID    uuid.UUID
+		guids       []uuid.UUID
+		moveTo      uuid.UUID
+		recordGUIDs []uuid.UUID
+		wantAmount  uint64
+		wantErr     bool
+	}{
+		{
+			name:        "With_records",
+			guids:       []uuid.UUID{categoryGuids[14]},
+			recordGUIDs: []uuid.UUID{recordGuids[7]},
+		},
+		{
+			name:        "Move_records",
+			guids:       []uuid.UUID{categoryGuids[15]},
+			moveTo:      categoryGuids[16],
+			recordGUIDs: []uuid.UUID{recordGuids[8]},
+			wantAmount:  3000,
+		},
+		{
+			name:    "Move_to_another_user",
+			guids:   []uuid.UUID{categoryGuids[13]},
+			moveTo:  categoryGuids[0],
+			wantErr: true,
+		},
+		{
+			name:     "Move_to_another_kind",
+			userGUID: userGuids[7],
+			guids:    []uuid.UUID{categoryGuids[18]},
+			moveTo:   categoryGuids[19],
+			wantErr:  true,
+		},
+		{
+			name:    "Another_user",
+			guids:   []uuid.UUID{categoryGuids[6]},
+			wantErr: true,
+		},
+		{
+			name:    "Errorous",
+			guids:   []uuid.UUID{uuid.MustParse("00000000-0000-0000-0000-100000000001")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			if tt.userGUID == uuid.Nil {
+				tt.userGUID = userGuids[1]
+			}
+
+			err := catRepo.DeleteCategories(context.Background(), tt.userGUID, tt.guids, tt.moveTo)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := catRepo.GetCategories(context.Background(), tt.userGUID, CategoryOptions{GUIDs: tt.guids})
+			require.NoError(t, err)
+			require.Len(t, res, 0)
+
+			records, err := recRepo.GetRecords(context.Background(), tt.userGUID, RecordOptions{GUIDs: tt.recordGUIDs})
+			require.NoError(t, err)
+			if tt.moveTo == uuid.Nil {
+				require.Len(t, records, 0)
+				return
 			}
+
+			require.Len(t, records, len(tt.recordGUIDs))
+			for _, record := range records {
+				require.Equal(t, tt.moveTo, record.CategoryGUID)
+			}
+			target, err := catRepo.GetCategories(context.Background(), tt.userGUID, CategoryOptions{GUIDs: []uuid.UUID{tt.moveTo}})
+			require.NoError(t, err)
+			require.Len(t, target, 1)
+			require.Equal(t, tt.wantAmount, target[0].Amount)
 		})
 	}
 }
+
+func TestCategoryRepo_DeleteCategories_subcategories(t *testing.T) {
+
+	t.Parallel()
+
+	err := catRepo.DeleteCategories(context.Background(), userGuids[12], []uuid.UUID{categoryGuids[30]}, uuid.Nil)
+	require.NoError(t, err)
+
+	res, err := catRepo.GetCategories(context.Background(), userGuids[12], CategoryOptions{GUIDs: []uuid.UUID{categoryGuids[31]}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, categoryGuids[29], res[0].ParentGUID)
+}
+
+func TestCategoryRepo_AddCategories_sameName(t *testing.T) {
+
+	t.Parallel()
+
+	parents, err := catRepo.AddCategories(context.Background(), userGuids[12], []ftracker.SpendingCategory{
+		{Category: "for_same_name", Description: "bla bla bla"},
+	})
+	require.NoError(t, err)
+
+	// the same name is taken under another parent, but not under the same one or among the roots
+	_, err = catRepo.AddCategories(context.Background(), userGuids[12], []ftracker.SpendingCategory{
+		{ParentGUID: categoryGuids[29], Category: "Other", Description: "bla bla bla"},
+		{ParentGUID: parents[0], Category: "Other", Description: "bla bla bla"},
+	})
+	require.NoError(t, err)
+	_, err = catRepo.AddCategories(context.Background(), userGuids[12], []ftracker.SpendingCategory{
+		{ParentGUID: parents[0], Category: "Other", Description: "bla bla bla"},
+	})
+	require.True(t, utils.IsUniqueConstrainViolation(err))
+	_, err = catRepo.AddCategories(context.Background(), userGuids[12], []ftracker.SpendingCategory{
+		{Category: "for_same_name", Description: "bla bla bla"},
+	})
+	require.True(t, utils.IsUniqueConstrainViolation(err))
+
+	res, err := catRepo.GetCategories(context.Background(), userGuids[12], CategoryOptions{Categories: []string{"Other"}})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+}
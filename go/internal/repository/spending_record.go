@@ -1,19 +1,24 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
 	"github.com/jmoiron/sqlx"
 )
 
 type (
 	// RecordRepo implements the SpendingRecord interface.
 	RecordRepo struct {
-		db *sqlx.DB
+		db      *sqlx.DB
+		timeout time.Duration
 	}
 
 	// RecordOptions defines the options for retrieving spending records.
@@ -24,6 +29,8 @@ type (
 		ByTime        bool
 		GUIDs         []uuid.UUID
 		CategoryGUIDs []uuid.UUID
+		Tags          []string
+		AllTags       bool
 		Order         RecordOrder
 	}
 
@@ -37,92 +44,606 @@ type (
 	}
 )
 
+// recordSortableColumns are the columns records can be ordered by.
+var recordSortableColumns = []string{"amount", "spent_at", "created_at", "updated_at"}
+
 // NewRecordRepository creates a new instance of RecordRepo with the provided database connection.
-func NewRecordRepository(db *sqlx.DB) *RecordRepo {
-	return &RecordRepo{db: db}
+func NewRecordRepository(db *sqlx.DB, opts ...Option) *RecordRepo {
+	return &RecordRepo{db: db, timeout: newOptions(opts).queryTimeout}
 }
 
-// GetRecords retrieves a list of spending records from the database based on the provided options.
+// GetRecords retrieves a list of spending records of the user from the database based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
 //   - opts: A struct containing options.
 //
 // Returns:
-//   - A slice of SpendingRecord structs matching the query.
+//   - A slice of SpendingRecord structs matching the query, together with their tags ordered by name.
 //   - An error if the query fails, or nil if successful.
-func (r *RecordRepo) GetRecords(opts RecordOptions) ([]ftracker.SpendingRecord, error) {
+func (r *RecordRepo) GetRecords(ctx context.Context, userGUID uuid.UUID, opts RecordOptions) ([]ftracker.SpendingRecord, error) {
 
-	whereClause := utils.BindWithOp("AND", true,
-		utils.MakeIn("guid", utils.UUIDsToStrings(opts.GUIDs)...),
-		utils.MakeIn("category_guid", utils.UUIDsToStrings(opts.CategoryGUIDs)...),
-		utils.MakeTimeFrame("updated_at", opts.TimeFrom, opts.TimeTo, opts.ByTime),
-	)
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-	query := fmt.Sprintf(
-		"SELECT guid, category_guid, amount, description, created_at, updated_at FROM %s %s %s %s",
-		spendingRecordsTable,
-		whereClause,
-		utils.MakeOrderBy(opts.Order.Column, opts.Order.Asc),
-		utils.MakeLimit(opts.Limit),
-	)
+	q, args, err := query.Select(spendingRecordsTable, "guid", "category_guid", "amount", "currency", "base_amount", "description", "spent_at", "created_at", "updated_at").
+		Where(
+			query.Raw(fmt.Sprintf("category_guid IN (SELECT guid FROM %s WHERE user_guid = ?)", spendingCategoriesTable), userGUID),
+			query.In("guid", opts.GUIDs),
+			query.In("category_guid", opts.CategoryGUIDs),
+			query.TimeFrame("spent_at", opts.TimeFrom, opts.TimeTo, opts.ByTime),
+			tagsCond(opts.Tags, opts.AllTags),
+		).
+		Sortable(recordSortableColumns...).
+		OrderBy(opts.Order.Column, opts.Order.Asc).
+		Limit(opts.Limit).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetRecords: %w", err)
+	}
 
 	var records []ftracker.SpendingRecord
-	err := r.db.Select(&records, query)
+	err = r.db.SelectContext(ctx, &records, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Repostiory.GetRecords: %w", err)
 	}
 
+	if err := r.loadTags(ctx, records); err != nil {
+		return nil, fmt.Errorf("Repostiory.GetRecords: %w", err)
+	}
+
 	return records, nil
 }
 
+// loadTags sets the tags of the records ordered by name, the records without tags keep nil tags
+func (r *RecordRepo) loadTags(ctx context.Context, records []ftracker.SpendingRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	indexes := make(map[uuid.UUID]int, len(records))
+	guids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		indexes[record.GUID] = i
+		guids[i] = record.GUID
+	}
+
+	q, args, err := query.Select(recordTagsTable+" rt", "rt.record_guid", "t.tag").
+		Join(fmt.Sprintf("JOIN %s t ON t.guid = rt.tag_guid", tagsTable)).
+		Where(query.In("rt.record_guid", guids)).
+		Sortable("t.tag").
+		OrderBy("t.tag", true).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	var links []struct {
+		RecordGUID uuid.UUID `db:"record_guid"`
+		Tag        string    `db:"tag"`
+	}
+	if err := r.db.SelectContext(ctx, &links, q, args...); err != nil {
+		return err
+	}
+	for _, link := range links {
+		i := indexes[link.RecordGUID]
+		records[i].Tags = append(records[i].Tags, link.Tag)
+	}
+	return nil
+}
+
+// GetBalance sums up the records of the user's income and spending categories spent in the time frame.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
+//   - from: The start of the time frame, inclusive.
+//   - to: The end of the time frame, exclusive.
+//
+// Returns:
+//   - A Balance with the totals in the user's currency.
+//   - An error if the query fails, or nil if successful.
+func (r *RecordRepo) GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(
+		fmt.Sprintf("%s r JOIN %s c ON c.guid = r.category_guid", spendingRecordsTable, spendingCategoriesTable),
+		fmt.Sprintf("COALESCE(SUM(r.base_amount) FILTER (WHERE c.kind = '%s'), 0) AS income", ftracker.KindIncome),
+		fmt.Sprintf("COALESCE(SUM(r.base_amount) FILTER (WHERE c.kind = '%s'), 0) AS spending", ftracker.KindSpending),
+	).
+		Where(
+			query.Raw("c.user_guid = ?", userGUID),
+			query.TimeFrame("r.spent_at", from, to, true),
+		).
+		Build()
+	if err != nil {
+		return ftracker.Balance{}, fmt.Errorf("Repostiory.GetBalance: %w", err)
+	}
+
+	var balance ftracker.Balance
+	err = r.db.GetContext(ctx, &balance, q, args...)
+	if err != nil {
+		return ftracker.Balance{}, fmt.Errorf("Repostiory.GetBalance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// GetTagSpending sums up the records of the user's spending categories spent in the time frame by their tags,
+// a record with several tags counts towards each of them.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the tags.
+//   - from: The start of the time frame, inclusive.
+//   - to: The end of the time frame, exclusive.
+//
+// Returns:
+//   - A slice of TagSpending with the totals in the user's currency, the biggest first.
+//   - An error if the query fails, or nil if successful.
+func (r *RecordRepo) GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error) {
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(tagsTable+" t", "t.tag", "SUM(r.base_amount) AS spent").
+		Join(fmt.Sprintf("JOIN %s rt ON rt.tag_guid = t.guid", recordTagsTable)).
+		Join(fmt.Sprintf("JOIN %s r ON r.guid = rt.record_guid", spendingRecordsTable)).
+		Join(fmt.Sprintf("JOIN %s c ON c.guid = r.category_guid", spendingCategoriesTable)).
+		Where(
+			query.Raw("t.user_guid = ?", userGUID),
+			query.Raw("c.kind = ?", ftracker.KindSpending),
+			query.TimeFrame("r.spent_at", from, to, true),
+		).
+		GroupBy("t.tag").
+		Sortable("spent").
+		OrderBy("spent", false).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetTagSpending: %w", err)
+	}
+
+	var spending []ftracker.TagSpending
+	err = r.db.SelectContext(ctx, &spending, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetTagSpending: %w", err)
+	}
+
+	return spending, nil
+}
+
+// GetImportedTransactions retrieves the ids of the bank transactions the user has already imported among the given ones.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who imported the transactions.
+//   - ids: The ids of the bank transactions to look for.
+//
+// Returns:
+//   - A slice of the ids that are already imported, the records of some of them may be deleted since.
+//   - An error if the query fails, or nil if successful.
+func (r *RecordRepo) GetImportedTransactions(ctx context.Context, userGUID uuid.UUID, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	q, args, err := query.Select(bankTransactionsTable, "transaction_id").
+		Where(
+			query.Raw("user_guid = ?", userGUID),
+			query.In("transaction_id", ids),
+		).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetImportedTransactions: %w", err)
+	}
+
+	var imported []string
+	err = r.db.SelectContext(ctx, &imported, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repostiory.GetImportedTransactions: %w", err)
+	}
+
+	return imported, nil
+}
+
 // AddRecords inserts multiple spending records into the database and updates the corresponding
-// spending categories' amounts.
+// spending categories' amounts by the records' amounts converted into the user's currency.
 //
 // Parameters:
-//   - records: A slice of SpendingRecord objects to be added to the database.
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
+//   - records: A slice of SpendingRecord objects to be added to the database,
+//     records without a currency are made in the user's currency, records without SpentAt are spent now,
+//     records with a RecurringGUID are stored together with their OccurredAt,
+//     the records are linked to their Tags, the tags the user doesn't have yet are created,
+//     the TransactionIDs of the records imported from the bank statements are marked as imported.
 //
 // Returns:
 //   - A slice of UUIDs representing the GUIDs of the newly inserted spending records.
-//   - An error if any issue occurs during the operation.
-func (r *RecordRepo) AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
-	tx, err := r.db.Beginx()
+//   - An error if any issue occurs during the operation, e.g. one of the categories belongs to another user,
+//     ErrUnknownCurrency if there is no exchange rate for the currency of one of the records,
+//     a unique constraint violation if the occurrence of the recurring record is already recorded
+//     or the bank transaction is already imported.
+func (r *RecordRepo) AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Repostiory.AddRecords: %w", err)
 	}
 
-	stmtIn, err := tx.PrepareNamed(fmt.Sprintf("INSERT INTO %s (category_guid, amount, description) VALUES (:category_guid, :amount, :description) RETURNING guid", spendingRecordsTable))
+	guids, err := addRecords(ctx, tx, userGUID, records)
 	if err != nil {
+		rollback(tx)
 		return nil, fmt.Errorf("Repostiory.AddRecords: %w", err)
 	}
-	stmtUpd, err := tx.PrepareNamed(fmt.Sprintf("UPDATE %s SET amount = amount + :amount WHERE guid = :category_guid", spendingCategoriesTable))
-	if err != nil {
+
+	if err := commit(ctx, tx); err != nil {
 		return nil, fmt.Errorf("Repostiory.AddRecords: %w", err)
 	}
 
+	return guids, nil
+}
+
+// addRecords inserts the records in the transaction, see AddRecords, the transaction is left to the caller
+func addRecords(ctx context.Context, tx *sqlx.Tx, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	// the base amount is calculated from the exchange rates of the record's currency
+	// and the user's currency, a record without a currency is made in the user's currency
+	stmtIn, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"INSERT INTO %[1]s (category_guid, amount, currency, base_amount, description, recurring_guid, occurred_at, spent_at) "+
+			"SELECT $1::uuid, $2::bigint, fr.currency, %[4]s, $3::text, $6::uuid, $7::timestamp, COALESCE($8::timestamp, now()) "+
+			"FROM %[2]s u "+
+			"JOIN %[3]s fr ON fr.currency = COALESCE(NULLIF($4::text, ''), u.currency) "+
+			"JOIN %[3]s tr ON tr.currency = u.currency "+
+			"WHERE u.guid = $5::uuid "+
+			"RETURNING guid, base_amount",
+		spendingRecordsTable,
+		usersTable,
+		exchangeRatesTable,
+		convertAmount("$2::bigint", "fr", "tr"),
+	))
+	if err != nil {
+		return nil, err
+	}
+	stmtUpd, err := tx.PreparexContext(ctx, fmt.Sprintf("UPDATE %s SET amount = amount + $1 WHERE guid = $2 AND user_guid = $3", spendingCategoriesTable))
+	if err != nil {
+		return nil, err
+	}
+	stmtTag, stmtLink, err := prepareTagging(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	stmtBank, err := tx.PreparexContext(ctx, fmt.Sprintf("INSERT INTO %s (user_guid, transaction_id, record_guid) VALUES ($1, $2, $3)", bankTransactionsTable))
+	if err != nil {
+		return nil, err
+	}
+
 	guids := make([]uuid.UUID, len(records))
 	for i, record := range records {
 
-		if _, err := stmtUpd.Exec(record); err != nil {
-			_err := tx.Rollback()
-			if _err != nil {
-				panic(_err)
+		// only the records made from a recurring record have an occurrence
+		var recurringGUID, occurredAt any
+		if record.RecurringGUID != uuid.Nil {
+			recurringGUID, occurredAt = record.RecurringGUID, record.OccurredAt
+		}
+
+		var spentAt any
+		if !record.SpentAt.IsZero() {
+			spentAt = record.SpentAt
+		}
+
+		var baseAmount ftracker.Money
+		err := stmtIn.QueryRowxContext(ctx, record.CategoryGUID, record.Amount, record.Description, record.Currency, userGUID, recurringGUID, occurredAt, spentAt).Scan(&guids[i], &baseAmount)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: %q", ErrUnknownCurrency, record.Currency)
+		}
+		if err == nil {
+			var res sql.Result
+			res, err = stmtUpd.ExecContext(ctx, baseAmount, record.CategoryGUID, userGUID)
+			if err == nil {
+				err = expectOneRow(res, record.CategoryGUID)
 			}
-			return nil, fmt.Errorf("Repostiory.AddRecords: %w", err)
+		}
+		if err == nil {
+			err = tagRecord(ctx, stmtTag, stmtLink, userGUID, guids[i], record.Tags)
+		}
+		if err == nil && record.TransactionID != "" {
+			_, err = stmtBank.ExecContext(ctx, userGUID, record.TransactionID, guids[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return guids, nil
+}
+
+// UpdateRecords updates the amount, description and tags of multiple spending records and corrects
+// the corresponding spending categories' amounts by the difference between the new and the old base amount.
+// The category, the currency and the time of a record are changed only if they are set, a record moved
+// to another category takes its base amount from the old category to the new one.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
+//   - records: A slice of SpendingRecord objects identified by their GUIDs, containing the new values,
+//     the Tags replace the tags of the record, the records got with GetRecords come with their tags,
+//     so they keep them unless they are changed.
+//
+// Returns:
+//   - An error if any issue occurs during the operation, e.g. one of the records does not exist
+//     or belongs to another user, the new category belongs to another user or the new currency is unknown.
+func (r *RecordRepo) UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.UpdateRecords: %w", err)
+	}
+
+	if err := updateRecords(ctx, tx, userGUID, records); err != nil {
+		rollback(tx)
+		return fmt.Errorf("Repostiory.UpdateRecords: %w", err)
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.UpdateRecords: %w", err)
+	}
+
+	return nil
+}
+
+// updateRecords updates the records in the transaction, see UpdateRecords, the transaction is left to the caller
+func updateRecords(ctx context.Context, tx *sqlx.Tx, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	stmtSel, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"SELECT category_guid, base_amount FROM %s WHERE guid = $1 AND category_guid IN (SELECT guid FROM %s WHERE user_guid = $2) FOR UPDATE",
+		spendingRecordsTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return err
+	}
+	stmtUpdCat, err := tx.PreparexContext(ctx, fmt.Sprintf("UPDATE %s SET amount = amount + $1 WHERE guid = $2", spendingCategoriesTable))
+	if err != nil {
+		return err
+	}
+	stmtUpdRec, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"UPDATE %[1]s SET amount = $1::bigint, description = $2, base_amount = %[5]s, "+
+			"currency = fr.currency, category_guid = c.guid, spent_at = COALESCE($7::timestamp, %[1]s.spent_at) "+
+			"FROM %[2]s u, %[3]s fr, %[3]s tr, %[4]s c "+
+			"WHERE %[1]s.guid = $3 AND u.guid = $4 AND tr.currency = u.currency "+
+			"AND fr.currency = COALESCE(NULLIF($5::text, ''), %[1]s.currency) "+
+			"AND c.guid = COALESCE($6::uuid, %[1]s.category_guid) AND c.user_guid = u.guid "+
+			"RETURNING %[1]s.category_guid, %[1]s.base_amount",
+		spendingRecordsTable,
+		usersTable,
+		exchangeRatesTable,
+		spendingCategoriesTable,
+		convertAmount("$1::bigint", "fr", "tr"),
+	))
+	if err != nil {
+		return err
+	}
+	stmtUnlink, err := tx.PreparexContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE record_guid = $1", recordTagsTable))
+	if err != nil {
+		return err
+	}
+	stmtTag, stmtLink, err := prepareTagging(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+
+		var old ftracker.SpendingRecord
+		if err := stmtSel.GetContext(ctx, &old, record.GUID, userGUID); err != nil {
+			return err
+		}
+
+		var categoryGUID, spentAt any
+		if record.CategoryGUID != uuid.Nil {
+			categoryGUID = record.CategoryGUID
+		}
+		if !record.SpentAt.IsZero() {
+			spentAt = record.SpentAt
+		}
+
+		var updated ftracker.SpendingRecord
+		err := stmtUpdRec.GetContext(ctx, &updated, record.Amount, record.Description, record.GUID, userGUID, record.Currency, categoryGUID, spentAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("no suitable currency %q or category %s for the record %s", record.Currency, record.CategoryGUID, record.GUID)
+		}
+		if err != nil {
+			return err
 		}
 
-		if err := stmtIn.Get(&guids[i], record); err != nil {
-			_err := tx.Rollback()
-			if _err != nil {
-				panic(_err)
+		if updated.CategoryGUID == old.CategoryGUID {
+			_, err = stmtUpdCat.ExecContext(ctx, updated.BaseAmount-old.BaseAmount, old.CategoryGUID)
+		} else {
+			_, err = stmtUpdCat.ExecContext(ctx, -old.BaseAmount, old.CategoryGUID)
+			if err == nil {
+				_, err = stmtUpdCat.ExecContext(ctx, updated.BaseAmount, updated.CategoryGUID)
 			}
-			return nil, fmt.Errorf("Repostiory.AddRecords: %w", err)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := stmtUnlink.ExecContext(ctx, record.GUID); err != nil {
+			return err
+		}
+		if err := tagRecord(ctx, stmtTag, stmtLink, userGUID, record.GUID, record.Tags); err != nil {
+			return err
 		}
 	}
 
-	err = tx.Commit()
+	return nil
+}
+
+// ImportRecords adds the categories, adds the new records and updates the changed ones in one transaction,
+// so an import is written either whole or not at all.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
+//   - categories: The categories to be added before the records, see AddCategories,
+//     the records of the new categories refer to the GUIDs set in them.
+//   - added: The records to be added, see AddRecords.
+//   - updated: The records to be updated, see UpdateRecords.
+//
+// Returns:
+//   - A slice of UUIDs representing the GUIDs of the added records.
+//   - An error if any of the steps fails, nothing is written then.
+func (r *RecordRepo) ImportRecords(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory, added, updated []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("Repostiory.ImportRecords: %w", err)
+	}
+
+	var guids []uuid.UUID
+	_, err = addCategories(ctx, tx, userGUID, categories)
+	if err == nil {
+		guids, err = addRecords(ctx, tx, userGUID, added)
+	}
+	if err == nil {
+		err = updateRecords(ctx, tx, userGUID, updated)
+	}
+	if err != nil {
+		rollback(tx)
+		return nil, fmt.Errorf("Repostiory.ImportRecords: %w", err)
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("Repostiory.ImportRecords: %w", err)
 	}
 
 	return guids, nil
 }
+
+// DeleteRecords deletes multiple spending records from the database and subtracts their base amounts
+// from the corresponding spending categories' amounts.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user who owns the categories of the records.
+//   - guids: A slice of UUIDs of the records to be deleted.
+//
+// Returns:
+//   - An error if any issue occurs during the operation, e.g. one of the records does not exist
+//     or belongs to another user.
+func (r *RecordRepo) DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+	}
+
+	stmtDel, err := tx.PreparexContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE guid = $1 AND category_guid IN (SELECT guid FROM %s WHERE user_guid = $2) RETURNING category_guid, base_amount",
+		spendingRecordsTable,
+		spendingCategoriesTable,
+	))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+	}
+	stmtUpd, err := tx.PreparexContext(ctx, fmt.Sprintf("UPDATE %s SET amount = amount - $1 WHERE guid = $2", spendingCategoriesTable))
+	if err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+	}
+
+	for _, guid := range guids {
+
+		var deleted ftracker.SpendingRecord
+		if err := stmtDel.GetContext(ctx, &deleted, guid, userGUID); err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+		}
+
+		if _, err := stmtUpd.ExecContext(ctx, deleted.BaseAmount, deleted.CategoryGUID); err != nil {
+			rollback(tx)
+			return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repostiory.DeleteRecords: %w", err)
+	}
+
+	return nil
+}
+
+// tagsCond creates a condition for the records to have any or, if all is set, every one of the tags.
+// It returns an empty condition if no tags are provided.
+func tagsCond(tags []string, all bool) query.Cond {
+	if len(tags) == 0 {
+		return query.Cond{}
+	}
+
+	args := make([]any, 0, len(tags)+1)
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if _, ok := seen[tag]; !ok {
+			seen[tag] = struct{}{}
+			args = append(args, tag)
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+
+	having := ""
+	if all {
+		having = " GROUP BY rt.record_guid HAVING COUNT(*) = ?"
+		args = append(args, len(seen))
+	}
+
+	return query.Raw(fmt.Sprintf(
+		"guid IN (SELECT rt.record_guid FROM %s rt JOIN %s t ON t.guid = rt.tag_guid WHERE t.tag IN (%s)%s)",
+		recordTagsTable,
+		tagsTable,
+		placeholders,
+		having,
+	), args...)
+}
+
+// prepareTagging prepares the statements of tagRecord in the transaction.
+func prepareTagging(ctx context.Context, tx *sqlx.Tx) (stmtTag, stmtLink *sqlx.Stmt, err error) {
+	// updating the existing tag makes it return its guid too
+	stmtTag, err = tx.PreparexContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (user_guid, tag) VALUES ($1, $2) ON CONFLICT (user_guid, tag) DO UPDATE SET tag = EXCLUDED.tag RETURNING guid",
+		tagsTable,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+	stmtLink, err = tx.PreparexContext(ctx, fmt.Sprintf("INSERT INTO %s (record_guid, tag_guid) VALUES ($1, $2) ON CONFLICT DO NOTHING", recordTagsTable))
+	if err != nil {
+		return nil, nil, err
+	}
+	return stmtTag, stmtLink, nil
+}
+
+// tagRecord links the record to the user's tags, the tags the user doesn't have yet are created.
+func tagRecord(ctx context.Context, stmtTag, stmtLink *sqlx.Stmt, userGUID, recordGUID uuid.UUID, tags []string) error {
+	for _, tag := range tags {
+		var tagGUID uuid.UUID
+		if err := stmtTag.GetContext(ctx, &tagGUID, userGUID, tag); err != nil {
+			return err
+		}
+		if _, err := stmtLink.ExecContext(ctx, recordGUID, tagGUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
@@ -15,6 +17,7 @@ func Test_AddRecords(t *testing.T) {
 
 	tests := []struct {
 		name          string
+		userGUID      uuid.UUID
 		args          []ftracker.SpendingRecord
 		categoryGUIDs []uuid.UUID
 		want          []ftracker.SpendingRecord
@@ -47,6 +50,24 @@ func Test_AddRecords(t *testing.T) {
 			},
 			wantAmount: []uint64{7950, 820},
 		},
+		{
+			name: "Other_currency",
+			args: []ftracker.SpendingRecord{
+				{CategoryGUID: categoryGuids[3], Amount: 1000, Currency: "USD", Description: "Bought a burger at the airport"},
+			},
+			categoryGUIDs: []uuid.UUID{categoryGuids[3]},
+			want: []ftracker.SpendingRecord{
+				{CategoryGUID: categoryGuids[3], Amount: 1000, Currency: "USD", BaseAmount: 500, Description: "Bought a burger at the airport"},
+			},
+			wantAmount: []uint64{500},
+		},
+		{
+			name: "Unknown_currency",
+			args: []ftracker.SpendingRecord{
+				{CategoryGUID: categoryGuids[3], Amount: 1000, Currency: "XXX", Description: "Bought something strange"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Errorous",
 			args: []ftracker.SpendingRecord{
@@ -55,13 +76,26 @@ func Test_AddRecords(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingRecord{
+				{CategoryGUID: categoryGuids[3], Amount: 490, Description: "Bought pita pita for lunch"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			t.Parallel()
 
-			got, err := recRepo.AddRecords(tt.args)
+			userGUID := tt.userGUID
+			if userGUID == uuid.Nil {
+				userGUID = userGuids[0]
+			}
+
+			got, err := recRepo.AddRecords(context.Background(), userGUID, tt.args)
 			if !tt.wantErr {
 				require.NoError(t, err)
 			} else {
@@ -70,10 +104,10 @@ func Test_AddRecords(t *testing.T) {
 				return
 			}
 
-			res, err := recRepo.GetRecords(RecordOptions{GUIDs: got})
+			res, err := recRepo.GetRecords(context.Background(), userGUID, RecordOptions{GUIDs: got})
 			require.NoError(t, err)
 
-			totalAmounts, err := catRepo.GetCategories(CategoryOptions{GUIDs: tt.categoryGUIDs})
+			totalAmounts, err := catRepo.GetCategories(context.Background(), userGUID, CategoryOptions{GUIDs: tt.categoryGUIDs})
 			require.NoError(t, err)
 			sort.Slice(totalAmounts, func(i, j int) bool {
 				return totalAmounts[i].Category < totalAmounts[j].Category
@@ -81,8 +115,14 @@ func Test_AddRecords(t *testing.T) {
 
 			require.Len(t, res, len(tt.want))
 			for i, record := range tt.want {
+				wantCurrency, wantBaseAmount := record.Currency, record.BaseAmount
+				if wantCurrency == "" {
+					wantCurrency, wantBaseAmount = ftracker.DefaultCurrency, record.Amount
+				}
 				require.Equal(t, record.CategoryGUID, res[i].CategoryGUID)
 				require.Equal(t, record.Amount, res[i].Amount)
+				require.Equal(t, wantCurrency, res[i].Currency)
+				require.Equal(t, wantBaseAmount, res[i].BaseAmount)
 				require.Equal(t, record.Description, res[i].Description)
 			}
 			for i := 0; i < len(totalAmounts); i++ {
@@ -97,10 +137,11 @@ func Test_GetRecords(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		options RecordOptions
-		want    []ftracker.SpendingRecord
-		wantErr bool
+		name     string
+		userGUID uuid.UUID
+		options  RecordOptions
+		want     []ftracker.SpendingRecord
+		wantErr  bool
 	}{
 		{
 			name: "By_guids",
@@ -149,13 +190,26 @@ func Test_GetRecords(t *testing.T) {
 				{GUID: recordGuids[2], CategoryGUID: categoryGuids[4], Amount: 2710, Description: "bla bla bla"},
 			},
 		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[1],
+			options: RecordOptions{
+				GUIDs: recordGuids[:4],
+			},
+			want: []ftracker.SpendingRecord{},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 
 			t.Parallel()
 
-			got, err := recRepo.GetRecords(tc.options)
+			userGUID := tc.userGUID
+			if userGUID == uuid.Nil {
+				userGUID = userGuids[0]
+			}
+
+			got, err := recRepo.GetRecords(context.Background(), userGUID, tc.options)
 			if tc.wantErr {
 				require.Error(t, err)
 				return
@@ -171,3 +225,445 @@ func Test_GetRecords(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetBalance(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want ftracker.Balance
+	}{
+		{
+			name: "Both_kinds",
+			from: timeFrom,
+			to:   timeTo,
+			want: ftracker.Balance{Income: 5000, Spending: 1500},
+		},
+		{
+			name: "Wide_frame",
+			from: timeFrom,
+			to:   timeFrom.AddDate(0, 1, 0),
+			want: ftracker.Balance{Income: 7000, Spending: 1500},
+		},
+		{
+			name: "Empty_frame",
+			from: timeFrom.AddDate(-1, 0, 0),
+			to:   timeFrom.AddDate(0, -1, 0),
+			want: ftracker.Balance{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			got, err := recRepo.GetBalance(context.Background(), userGuids[7], tc.from, tc.to)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_UpdateRecords(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		userGUID     uuid.UUID
+		args         []ftracker.SpendingRecord
+		categoryGUID uuid.UUID
+		wantAmount   uint64
+		wantErr      bool
+	}{
+		{
+			name: "Single_record",
+			args: []ftracker.SpendingRecord{
+				{GUID: recordGuids[4], Amount: 2500, Description: "corrected amount"},
+			},
+			categoryGUID: categoryGuids[10],
+			wantAmount:   2500,
+		},
+		{
+			name: "Errorous",
+			args: []ftracker.SpendingRecord{
+				{GUID: uuid.MustParse("00000000-0000-0000-0000-100000000001"), Amount: 500, Description: "jelly candies"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[1],
+			args: []ftracker.SpendingRecord{
+				{GUID: recordGuids[6], Amount: 1, Description: "stolen"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			userGUID := tt.userGUID
+			if userGUID == uuid.Nil {
+				userGUID = userGuids[0]
+			}
+
+			err := recRepo.UpdateRecords(context.Background(), userGUID, tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			guids := make([]uuid.UUID, len(tt.args))
+			for i, record := range tt.args {
+				guids[i] = record.GUID
+			}
+			res, err := recRepo.GetRecords(context.Background(), userGUID, RecordOptions{GUIDs: guids})
+			require.NoError(t, err)
+
+			require.Len(t, res, len(tt.args))
+			for i, record := range tt.args {
+				require.Equal(t, record.Amount, res[i].Amount)
+				require.Equal(t, record.Description, res[i].Description)
+			}
+
+			categories, err := catRepo.GetCategories(context.Background(), userGUID, CategoryOptions{GUIDs: []uuid.UUID{tt.categoryGUID}})
+			require.NoError(t, err)
+			require.Len(t, categories, 1)
+			require.Equal(t, tt.wantAmount, categories[0].Amount)
+		})
+	}
+}
+
+func Test_UpdateRecords_move(t *testing.T) {
+
+	t.Parallel()
+
+	spentAt := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	// the record goes to another category with another time, its amount is taken from the old category
+	err := recRepo.UpdateRecords(context.Background(), userGuids[16], []ftracker.SpendingRecord{
+		{GUID: recordGuids[24], CategoryGUID: categoryGuids[36], Amount: 1200, Description: "theatre", SpentAt: spentAt},
+	})
+	require.NoError(t, err)
+
+	got, err := recRepo.GetRecords(context.Background(), userGuids[16], RecordOptions{GUIDs: []uuid.UUID{recordGuids[24]}})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, categoryGuids[36], got[0].CategoryGUID)
+	require.Equal(t, ftracker.Money(1200), got[0].Amount)
+	require.True(t, spentAt.Equal(got[0].SpentAt))
+
+	categories, err := catRepo.GetCategories(context.Background(), userGuids[16], CategoryOptions{GUIDs: categoryGuids[35:37]})
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	amounts := map[uuid.UUID]ftracker.Money{categories[0].GUID: categories[0].Amount, categories[1].GUID: categories[1].Amount}
+	require.Equal(t, ftracker.Money(0), amounts[categoryGuids[35]])
+	require.Equal(t, ftracker.Money(1200), amounts[categoryGuids[36]])
+
+	// the categories of another user are not used
+	err = recRepo.UpdateRecords(context.Background(), userGuids[16], []ftracker.SpendingRecord{
+		{GUID: recordGuids[24], CategoryGUID: categoryGuids[33], Amount: 1200, Description: "theatre"},
+	})
+	require.Error(t, err)
+
+	// neither are the unknown currencies
+	err = recRepo.UpdateRecords(context.Background(), userGuids[16], []ftracker.SpendingRecord{
+		{GUID: recordGuids[24], Amount: 1200, Currency: "XXX", Description: "theatre"},
+	})
+	require.Error(t, err)
+}
+
+func Test_DeleteRecords(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		userGUID     uuid.UUID
+		args         []uuid.UUID
+		categoryGUID uuid.UUID
+		wantAmount   uint64
+		wantErr      bool
+	}{
+		{
+			name:         "Single_record",
+			args:         []uuid.UUID{recordGuids[5]},
+			categoryGUID: categoryGuids[11],
+			wantAmount:   1000,
+		},
+		{
+			name:     "Another_user",
+			userGUID: userGuids[1],
+			args:     []uuid.UUID{recordGuids[6]},
+			wantErr:  true,
+		},
+		{
+			name:    "Errorous",
+			args:    []uuid.UUID{uuid.MustParse("00000000-0000-0000-0000-100000000001")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			userGUID := tt.userGUID
+			if userGUID == uuid.Nil {
+				userGUID = userGuids[0]
+			}
+
+			err := recRepo.DeleteRecords(context.Background(), userGUID, tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := recRepo.GetRecords(context.Background(), userGUID, RecordOptions{GUIDs: tt.args})
+			require.NoError(t, err)
+			require.Len(t, res, 0)
+
+			categories, err := catRepo.GetCategories(context.Background(), userGUID, CategoryOptions{GUIDs: []uuid.UUID{tt.categoryGUID}})
+			require.NoError(t, err)
+			require.Len(t, categories, 1)
+			require.Equal(t, tt.wantAmount, categories[0].Amount)
+		})
+	}
+}
+
+func Test_AddRecords_spentAt(t *testing.T) {
+
+	t.Parallel()
+
+	spentAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	guids, err := recRepo.AddRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{
+		{CategoryGUID: categoryGuids[26], Amount: 1250, Description: "dinner", SpentAt: spentAt},
+	})
+	require.NoError(t, err)
+
+	// the record is filed under the day it was spent, not the day it was added
+	got, err := recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{
+		CategoryGUIDs: categoryGuids[26:27],
+		TimeFrom:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		TimeTo:        time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		ByTime:        true,
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, guids[0], got[0].GUID)
+	require.Equal(t, spentAt, got[0].SpentAt.UTC())
+}
+
+func Test_GetRecords_tags(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		tags      []string
+		all       bool
+		wantGUIDs []uuid.UUID
+	}{
+		{
+			name:      "Any_single",
+			tags:      []string{"vacation"},
+			wantGUIDs: []uuid.UUID{recordGuids[19], recordGuids[20], recordGuids[22]},
+		},
+		{
+			name:      "Any_several",
+			tags:      []string{"food", "work"},
+			wantGUIDs: []uuid.UUID{recordGuids[19], recordGuids[21]},
+		},
+		{
+			name:      "All",
+			tags:      []string{"vacation", "food"},
+			all:       true,
+			wantGUIDs: []uuid.UUID{recordGuids[19]},
+		},
+		{
+			name:      "All_duplicated",
+			tags:      []string{"vacation", "vacation"},
+			all:       true,
+			wantGUIDs: []uuid.UUID{recordGuids[19], recordGuids[20], recordGuids[22]},
+		},
+		{
+			name: "Unknown_tag",
+			tags: []string{"nope"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			got, err := recRepo.GetRecords(context.Background(), userGuids[11], RecordOptions{Tags: tc.tags, AllTags: tc.all})
+			require.NoError(t, err)
+			gotGUIDs := make([]uuid.UUID, len(got))
+			for i, record := range got {
+				gotGUIDs[i] = record.GUID
+			}
+			require.ElementsMatch(t, tc.wantGUIDs, gotGUIDs)
+		})
+	}
+}
+
+func Test_GetRecords_loadTags(t *testing.T) {
+
+	t.Parallel()
+
+	got, err := recRepo.GetRecords(context.Background(), userGuids[11], RecordOptions{GUIDs: recordGuids[19:23]})
+	require.NoError(t, err)
+
+	tags := make(map[uuid.UUID][]string, len(got))
+	for _, record := range got {
+		tags[record.GUID] = record.Tags
+	}
+	require.Equal(t, map[uuid.UUID][]string{
+		recordGuids[19]: {"food", "vacation"},
+		recordGuids[20]: {"vacation"},
+		recordGuids[21]: {"food"},
+		recordGuids[22]: {"vacation"},
+	}, tags)
+}
+
+func Test_GetTagSpending(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want []ftracker.TagSpending
+	}{
+		{
+			name: "October",
+			from: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			want: []ftracker.TagSpending{{Tag: "vacation", Spent: 1500}, {Tag: "food", Spent: 1000}},
+		},
+		{
+			name: "November",
+			from: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			want: []ftracker.TagSpending{{Tag: "food", Spent: 300}},
+		},
+		{
+			name: "Empty",
+			from: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			got, err := recRepo.GetTagSpending(context.Background(), userGuids[11], tc.from, tc.to)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_AddRecords_tags(t *testing.T) {
+
+	t.Parallel()
+
+	guids, err := recRepo.AddRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{
+		{CategoryGUID: categoryGuids[26], Amount: 3000, Description: "membership #gym #health", Tags: []string{"gym", "health"}},
+	})
+	require.NoError(t, err)
+
+	got, err := recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{Tags: []string{"gym", "health"}, AllTags: true})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, guids[0], got[0].GUID)
+
+	// the tags of the record are replaced on update
+	err = recRepo.UpdateRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{
+		{GUID: guids[0], Amount: 3000, Description: "membership #sport", Tags: []string{"sport"}},
+	})
+	require.NoError(t, err)
+
+	got, err = recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{Tags: []string{"gym", "health"}})
+	require.NoError(t, err)
+	require.Len(t, got, 0)
+	got, err = recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{Tags: []string{"sport"}})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, []string{"sport"}, got[0].Tags)
+
+	// the record got with its tags keeps them when only its amount changes
+	got[0].Amount = 3500
+	require.NoError(t, recRepo.UpdateRecords(context.Background(), userGuids[10], got))
+	got, err = recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{GUIDs: guids})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, []string{"sport"}, got[0].Tags)
+
+	// the tags of another user are not used
+	got, err = recRepo.GetRecords(context.Background(), userGuids[11], RecordOptions{Tags: []string{"sport"}})
+	require.NoError(t, err)
+	require.Len(t, got, 0)
+}
+
+func Test_ImportRecords(t *testing.T) {
+
+	t.Parallel()
+
+	category := ftracker.SpendingCategory{GUID: uuid.New(), Category: "imported", Description: "created by an import"}
+	added := []ftracker.SpendingRecord{{CategoryGUID: category.GUID, Amount: 700, Description: "ticket #trip"}}
+
+	// a failed update rolls back the category and the records added before it
+	_, err := recRepo.ImportRecords(context.Background(), userGuids[10], []ftracker.SpendingCategory{category}, added, []ftracker.SpendingRecord{
+		{GUID: uuid.New(), Amount: 100},
+	})
+	require.Error(t, err)
+	categories, err := catRepo.GetCategories(context.Background(), userGuids[10], CategoryOptions{GUIDs: []uuid.UUID{category.GUID}})
+	require.NoError(t, err)
+	require.Len(t, categories, 0)
+
+	guids, err := recRepo.ImportRecords(context.Background(), userGuids[10], []ftracker.SpendingCategory{category}, added, nil)
+	require.NoError(t, err)
+	require.Len(t, guids, 1)
+	categories, err = catRepo.GetCategories(context.Background(), userGuids[10], CategoryOptions{GUIDs: []uuid.UUID{category.GUID}})
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	require.Equal(t, "imported", categories[0].Category)
+	got, err := recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{GUIDs: guids})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, category.GUID, got[0].CategoryGUID)
+}
+
+func Test_AddRecords_context(t *testing.T) {
+
+	t.Parallel()
+
+	record := ftracker.SpendingRecord{CategoryGUID: categoryGuids[26], Amount: 4200, Description: "cancelled"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := recRepo.AddRecords(ctx, userGuids[10], []ftracker.SpendingRecord{record})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the deadline of the repository ends the transaction too, it is rolled back without a panic
+	shortRepo := NewRecordRepository(testContainerDB, WithQueryTimeout(time.Nanosecond))
+	_, err = shortRepo.AddRecords(context.Background(), userGuids[10], []ftracker.SpendingRecord{record})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	got, err := recRepo.GetRecords(context.Background(), userGuids[10], RecordOptions{CategoryGUIDs: []uuid.UUID{categoryGuids[26]}})
+	require.NoError(t, err)
+	for _, r := range got {
+		require.NotEqual(t, "cancelled", r.Description)
+	}
+}
@@ -1,59 +1,67 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository/query"
 	"github.com/jmoiron/sqlx"
 )
 
 type (
 	// UserRepo implements the User interface.
 	UserRepo struct {
-		db *sqlx.DB
+		db      *sqlx.DB
+		timeout time.Duration
 	}
 
 	// UserOptions defines the options for retrieving users.
 	UserOptions struct {
-		Limit       int
-		GUIDs       []uuid.UUID
-		Usernames   []string
-		TelegramIDs []string
+		Limit          int
+		GUIDs          []uuid.UUID
+		Usernames      []string
+		TelegramIDs    []string
+		APITokenHashes []string
 	}
 )
 
 // NewUserRepository creates a new instance of UserRepo with the provided database connection.
-func NewUserRepository(db *sqlx.DB) *UserRepo {
-	return &UserRepo{db: db}
+func NewUserRepository(db *sqlx.DB, opts ...Option) *UserRepo {
+	return &UserRepo{db: db, timeout: newOptions(opts).queryTimeout}
 }
 
 // GetUsers retrieves a list of users from the database based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
 //   - opts: A struct containing filtering options
 //
 // Returns:
 //   - A slice of User objects matching the specified criteria.
 //   - An error if the query fails or any other issue occurs.
-func (s *UserRepo) GetUsers(opts UserOptions) ([]ftracker.User, error) {
+func (s *UserRepo) GetUsers(ctx context.Context, opts UserOptions) ([]ftracker.User, error) {
 
-	whereClause := utils.BindWithOp("AND", true,
-		utils.MakeIn("guid", utils.UUIDsToStrings(opts.GUIDs)...),
-		utils.MakeIn("username", opts.Usernames...),
-		utils.MakeIn("telegram_id", opts.TelegramIDs...),
-	)
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
 
-	query := fmt.Sprintf(
-		"SELECT guid, username, telegram_id, created_at, updated_at FROM %s %s %s",
-		usersTable,
-		whereClause,
-		utils.MakeLimit(opts.Limit),
-	)
+	q, args, err := query.Select(usersTable, "guid", "username", "telegram_id", "currency", "created_at", "updated_at").
+		Where(
+			query.In("guid", opts.GUIDs),
+			query.In("username", opts.Usernames),
+			query.In("telegram_id", opts.TelegramIDs),
+			query.In("api_token_hash", opts.APITokenHashes),
+		).
+		Limit(opts.Limit).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.GetUsers: %w", err)
+	}
 
 	var users []ftracker.User
-	err := s.db.Select(&users, query)
+	err = s.db.SelectContext(ctx, &users, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Repository.GetUsers: %w", err)
 	}
@@ -64,37 +72,145 @@ func (s *UserRepo) GetUsers(opts UserOptions) ([]ftracker.User, error) {
 // AddUsers inserts multiple users into the database and returns their generated UUIDs.
 //
 // Parameters:
-//   - users: A slice of ftracker.User objects to be inserted into the database.
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - users: A slice of ftracker.User objects to be inserted into the database,
+//     users without a currency get ftracker.DefaultCurrency.
 //
 // Returns:
 //   - A slice of uuid.UUID representing the generated UUIDs for the inserted users.
 //   - An error if any issue occurs during the operation.
-func (s *UserRepo) AddUsers(users []ftracker.User) ([]uuid.UUID, error) {
+func (s *UserRepo) AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error) {
+
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
 
-	tx, err := s.db.Beginx()
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Repository.AddUsers: %w", err)
 	}
 
-	stmt, err := s.db.PrepareNamed(fmt.Sprintf("INSERT INTO %s (username, telegram_id) VALUES (:username, :telegram_id) RETURNING guid", usersTable))
+	stmt, err := s.db.PrepareNamedContext(ctx, fmt.Sprintf("INSERT INTO %s (username, telegram_id, currency) VALUES (:username, :telegram_id, :currency) RETURNING guid", usersTable))
 	if err != nil {
 		return nil, fmt.Errorf("Repository.AddUsers: %w", err)
 	}
 
 	guids := make([]uuid.UUID, len(users))
 	for i, u := range users {
-		if err := stmt.Get(&guids[i], u); err != nil {
-			_err := tx.Rollback()
-			if _err != nil {
-				panic(_err)
-			}
+		if u.Currency == "" {
+			u.Currency = ftracker.DefaultCurrency
+		}
+		if err := stmt.GetContext(ctx, &guids[i], u); err != nil {
+			rollback(tx)
 			return nil, fmt.Errorf("Repository.AddUsers: %w", err)
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		panic(err)
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("Repository.AddUsers: %w", err)
 	}
 	return guids, nil
 }
+
+// SetUserAPIToken replaces the API token of the user, only the hash of the token is stored.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user.
+//   - tokenHash: The hash of the new token, the previous token stops working.
+//
+// Returns:
+//   - An error if the operation fails, e.g. the user does not exist.
+func (s *UserRepo) SetUserAPIToken(ctx context.Context, userGUID uuid.UUID, tokenHash string) error {
+
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET api_token_hash = $1 WHERE guid = $2", usersTable), tokenHash, userGUID)
+	if err == nil {
+		err = expectOneRow(res, userGUID)
+	}
+	if err != nil {
+		return fmt.Errorf("Repository.SetUserAPIToken: %w", err)
+	}
+	return nil
+}
+
+// SetUserCurrency changes the base currency of the user. The base amounts of all the user's records
+// are converted into the new currency with the current exchange rates and the amounts of
+// the user's categories are recalculated from them, the budgets are converted as well.
+//
+// Parameters:
+//   - ctx: The context of the query, the query is cancelled with it or after the timeout of the repository.
+//   - userGUID: The GUID of the user.
+//   - currency: The code of the new base currency.
+//
+// Returns:
+//   - An error if the operation fails at any point, ErrUnknownCurrency if there is no rate for the currency.
+func (s *UserRepo) SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error {
+
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Repository.SetUserCurrency: %w", err)
+	}
+
+	var known bool
+	err = tx.GetContext(ctx, &known, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE currency = $1)", exchangeRatesTable), currency)
+	if err == nil && !known {
+		err = ErrUnknownCurrency
+	}
+	if err != nil {
+		rollback(tx)
+		return fmt.Errorf("Repository.SetUserCurrency: %w", err)
+	}
+
+	// budgets are converted from the currency the user has before the update
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %[1]s SET amount = GREATEST(%[5]s, 1) "+
+			"FROM %[2]s c, %[3]s u, %[4]s fr, %[4]s tr "+
+			"WHERE c.guid = %[1]s.category_guid AND c.user_guid = $1 AND u.guid = $1 AND fr.currency = u.currency AND tr.currency = $2",
+		budgetsTable,
+		spendingCategoriesTable,
+		usersTable,
+		exchangeRatesTable,
+		convertAmount(budgetsTable+".amount", "fr", "tr"),
+	), userGUID, currency)
+	if err != nil {
+		rollback(tx)
+		return fmt.Errorf("Repository.SetUserCurrency: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET currency = $1 WHERE guid = $2", usersTable), currency, userGUID)
+	if err == nil {
+		err = expectOneRow(res, userGUID)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(
+			"UPDATE %[1]s SET base_amount = %[4]s "+
+				"FROM %[2]s c, %[3]s fr, %[3]s tr "+
+				"WHERE c.guid = %[1]s.category_guid AND c.user_guid = $1 AND fr.currency = %[1]s.currency AND tr.currency = $2",
+			spendingRecordsTable,
+			spendingCategoriesTable,
+			exchangeRatesTable,
+			convertAmount(spendingRecordsTable+".amount", "fr", "tr"),
+		), userGUID, currency)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(
+			"UPDATE %[1]s SET amount = COALESCE((SELECT SUM(base_amount) FROM %[2]s WHERE category_guid = %[1]s.guid), 0) WHERE user_guid = $1",
+			spendingCategoriesTable,
+			spendingRecordsTable,
+		), userGUID)
+	}
+	if err != nil {
+		rollback(tx)
+		return fmt.Errorf("Repository.SetUserCurrency: %w", err)
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return fmt.Errorf("Repository.SetUserCurrency: %w", err)
+	}
+	return nil
+}
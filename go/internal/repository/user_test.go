@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"testing"
 
+	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
 	"github.com/iv-sukhanov/finance_tracker/internal/utils"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -63,7 +65,7 @@ func Test_AddUsers(t *testing.T) {
 
 			t.Parallel()
 
-			got, err := usrRepo.AddUsers(tc.users)
+			got, err := usrRepo.AddUsers(context.Background(), tc.users)
 			if tc.wantError {
 				require.Error(t, err)
 				err = utils.GetItitialError(err)
@@ -72,12 +74,13 @@ func Test_AddUsers(t *testing.T) {
 			}
 			require.NoError(t, err)
 
-			res, err := usrRepo.GetUsers(UserOptions{GUIDs: got})
+			res, err := usrRepo.GetUsers(context.Background(), UserOptions{GUIDs: got})
 			require.NoError(t, err)
 
 			for i, u := range res {
 				require.Equal(t, tc.want[i].Username, u.Username)
 				require.Equal(t, tc.want[i].TelegramID, u.TelegramID)
+				require.Equal(t, ftracker.DefaultCurrency, u.Currency)
 			}
 		})
 	}
@@ -118,6 +121,13 @@ func Test_GetUsers(t *testing.T) {
 				{GUID: userGuids[3], Username: "for_get_users2", TelegramID: "00000004"},
 			},
 		},
+		{
+			name:    "By_api_token_hash",
+			options: UserOptions{APITokenHashes: []string{"existing_hash"}},
+			want: []ftracker.User{
+				{GUID: userGuids[13], Username: "for_api_tokens1", TelegramID: "00000014"},
+			},
+		},
 		{
 			name:    "With_limit",
 			options: UserOptions{GUIDs: userGuids[2:6], Limit: 2},
@@ -133,7 +143,7 @@ func Test_GetUsers(t *testing.T) {
 
 			t.Parallel()
 
-			got, err := usrRepo.GetUsers(tc.options)
+			got, err := usrRepo.GetUsers(context.Background(), tc.options)
 			if tc.wantError {
 				require.Error(t, err)
 				return
@@ -149,3 +159,127 @@ func Test_GetUsers(t *testing.T) {
 		})
 	}
 }
+
+func Test_SetUserAPIToken(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		userGUID  uuid.UUID
+		hash      string
+		wantError bool
+	}{
+		{
+			name:     "New_token",
+			userGUID: userGuids[14],
+			hash:     "new_hash",
+		},
+		{
+			name:      "Taken_hash",
+			userGUID:  userGuids[14],
+			hash:      "existing_hash",
+			wantError: true,
+		},
+		{
+			name:      "Unknown_user",
+			userGUID:  uuid.MustParse("00000000-0000-0000-0000-100000000001"),
+			hash:      "unknown_hash",
+			wantError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			err := usrRepo.SetUserAPIToken(context.Background(), tc.userGUID, tc.hash)
+			if tc.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := usrRepo.GetUsers(context.Background(), UserOptions{APITokenHashes: []string{tc.hash}})
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			require.Equal(t, tc.userGUID, got[0].GUID)
+		})
+	}
+}
+
+func Test_SetUserCurrency(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name            string
+		userGUID        uuid.UUID
+		currency        string
+		wantBaseAmounts []uint32
+		wantAmount      uint64
+		wantBudget      uint64
+		wantErr         error
+	}{
+		{
+			name:     "Unknown_currency",
+			userGUID: userGuids[6],
+			currency: "XXX",
+			wantErr:  ErrUnknownCurrency,
+		},
+		{
+			name:     "Unknown_user",
+			userGUID: uuid.MustParse("00000000-0000-0000-0000-100000000001"),
+			currency: "USD",
+		},
+		{
+			name:            "Ok",
+			userGUID:        userGuids[6],
+			currency:        "USD",
+			wantBaseAmounts: []uint32{2000, 4000},
+			wantAmount:      6000,
+			wantBudget:      6000,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			before, err := recRepo.GetRecords(context.Background(), tc.userGUID, RecordOptions{GUIDs: recordGuids[10:12]})
+			require.NoError(t, err)
+
+			err = usrRepo.SetUserCurrency(context.Background(), tc.userGUID, tc.currency)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			if tc.wantBaseAmounts == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			users, err := usrRepo.GetUsers(context.Background(), UserOptions{GUIDs: []uuid.UUID{tc.userGUID}})
+			require.NoError(t, err)
+			require.Len(t, users, 1)
+			require.Equal(t, tc.currency, users[0].Currency)
+
+			after, err := recRepo.GetRecords(context.Background(), tc.userGUID, RecordOptions{GUIDs: recordGuids[10:12]})
+			require.NoError(t, err)
+			require.Len(t, after, len(tc.wantBaseAmounts))
+			for i := range after {
+				require.Equal(t, tc.wantBaseAmounts[i], after[i].BaseAmount)
+				require.Equal(t, before[i].UpdatedAt, after[i].UpdatedAt)
+			}
+
+			categories, err := catRepo.GetCategories(context.Background(), tc.userGUID, CategoryOptions{GUIDs: categoryGuids[17:18]})
+			require.NoError(t, err)
+			require.Len(t, categories, 1)
+			require.Equal(t, tc.wantAmount, categories[0].Amount)
+
+			budgets, err := budRepo.GetBudgets(context.Background(), tc.userGUID, BudgetOptions{})
+			require.NoError(t, err)
+			require.Len(t, budgets, 1)
+			require.Equal(t, tc.wantBudget, budgets[0].Amount)
+		})
+	}
+}
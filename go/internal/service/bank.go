@@ -0,0 +1,618 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+)
+
+// maxTransactionIDLen is the length of the transaction_id column
+const maxTransactionIDLen = 255
+
+var (
+	// ErrUnknownStatement is returned when the imported file is neither an OFX nor a CAMT.053 statement.
+	ErrUnknownStatement = errors.New("unknown bank statement format")
+	// ErrInvalidStatement is returned when the imported statement can't be read.
+	ErrInvalidStatement = errors.New("invalid bank statement")
+)
+
+type (
+	// BankStatement is a parsed bank statement
+	//
+	//  - Transactions: the transactions not imported yet in the order of the statement,
+	//   the ones matched by the payee rules of the user have their CategoryGUIDs set
+	//
+	//  - Duplicates: the number of the transactions the user has already imported
+	//
+	//  - Rejected: the transactions that can't be imported, ordered by their lines
+	BankStatement struct {
+		Transactions []ftracker.BankTransaction
+		Duplicates   int
+		Rejected     []RejectedRow
+	}
+
+	// statementEntry is a transaction of the statement together with the line it starts on
+	statementEntry struct {
+		line        int
+		transaction ftracker.BankTransaction
+	}
+
+	// camtEntry is the Ntry element of a CAMT.053 statement, only the used fields are decoded
+	camtEntry struct {
+		Amount struct {
+			Value    string `xml:",chardata"`
+			Currency string `xml:"Ccy,attr"`
+		} `xml:"Amt"`
+		Indicator string `xml:"CdtDbtInd"`
+		Status    struct {
+			Value string `xml:",chardata"`
+			Code  string `xml:"Cd"`
+		} `xml:"Sts"`
+		BookingDate struct {
+			Date     string `xml:"Dt"`
+			DateTime string `xml:"DtTm"`
+		} `xml:"BookgDt"`
+		Reference      string        `xml:"AcctSvcrRef"`
+		EntryReference string        `xml:"NtryRef"`
+		Info           string        `xml:"AddtlNtryInf"`
+		Details        []camtDetails `xml:"NtryDtls>TxDtls"`
+	}
+
+	// camtDetails is the TxDtls element of a CAMT.053 entry
+	camtDetails struct {
+		Refs struct {
+			Reference     string `xml:"AcctSvcrRef"`
+			TransactionID string `xml:"TxId"`
+			EndToEndID    string `xml:"EndToEndId"`
+		} `xml:"Refs"`
+		Creditor     camtParty `xml:"RltdPties>Cdtr"`
+		Debtor       camtParty `xml:"RltdPties>Dbtr"`
+		Unstructured []string  `xml:"RmtInf>Ustrd"`
+	}
+
+	// camtParty is a party of a CAMT.053 transaction, the name is in the Pty element since the version 08
+	camtParty struct {
+		Name      string `xml:"Nm"`
+		PartyName string `xml:"Pty>Nm"`
+	}
+)
+
+// ParseBankStatement parses an OFX (SGML or XML) or a CAMT.053 bank statement.
+// The transactions the user has already imported are skipped by the ids the bank gave them,
+// the rest are mapped to the categories by the payee rules of the user.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repositories.
+//   - userGUID: The GUID of the user the statement is imported for.
+//   - r: The content of the statement.
+//   - currency: The currency of the transactions if the statement doesn't name one, usually the base currency of the user.
+//
+// Returns:
+//   - BankStatement: The new transactions, the number of the duplicates and the rejected transactions.
+//   - error: ErrUnknownStatement if the format is not recognized, ErrInvalidStatement if the statement can't be read,
+//     ErrTooManyRows if it has more than MaxImportRows transactions, an error if the repositories fail, or nil if successful.
+func (s *ImportService) ParseBankStatement(ctx context.Context, userGUID uuid.UUID, r io.Reader, currency string) (BankStatement, error) {
+	if userGUID == uuid.Nil {
+		return BankStatement{}, ErrOwnerRequired
+	}
+	currency = cmp.Or(currency, ftracker.DefaultCurrency)
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w", err)
+	}
+
+	var (
+		entries []statementEntry
+		report  ImportReport
+	)
+	switch {
+	case bytes.Contains(content, []byte("BkToCstmrStmt")):
+		entries, report.Rejected, err = parseCAMT(content, currency)
+	case isOFX(content):
+		entries, report.Rejected = parseOFX(content, currency)
+	default:
+		err = ErrUnknownStatement
+	}
+	if err != nil {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w", err)
+	}
+	if len(entries)+len(report.Rejected) > MaxImportRows {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w: more than %d", ErrTooManyRows, MaxImportRows)
+	}
+
+	// the ids repeated in the statement are rejected, the currencies are checked for the rates as the records need them
+	ids := make([]string, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	valid := entries[:0]
+	for _, entry := range entries {
+		if seen[entry.transaction.ID] {
+			report.reject(entry.line, "duplicate transaction id %s", entry.transaction.ID)
+			continue
+		}
+		seen[entry.transaction.ID] = true
+		ids = append(ids, entry.transaction.ID)
+		valid = append(valid, entry)
+	}
+
+	valid, err = s.rejectUnknownTransactionCurrencies(ctx, valid, &report)
+	if err != nil {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w", err)
+	}
+
+	imported, err := s.records.GetImportedTransactions(ctx, userGUID, ids)
+	if err != nil {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w", err)
+	}
+	isImported := make(map[string]bool, len(imported))
+	for _, id := range imported {
+		isImported[id] = true
+	}
+
+	rules, err := s.rules.GetPayeeRules(ctx, userGUID)
+	if err != nil {
+		return BankStatement{}, fmt.Errorf("ParseBankStatement: %w", err)
+	}
+
+	statement := BankStatement{}
+	for _, entry := range valid {
+		if isImported[entry.transaction.ID] {
+			statement.Duplicates++
+			continue
+		}
+		if rule, ok := matchPayeeRule(rules, entry.transaction); ok {
+			entry.transaction.CategoryGUID = rule.CategoryGUID
+		}
+		statement.Transactions = append(statement.Transactions, entry.transaction)
+	}
+
+	sort.SliceStable(report.Rejected, func(i, j int) bool { return report.Rejected[i].Line < report.Rejected[j].Line })
+	statement.Rejected = report.Rejected
+	return statement, nil
+}
+
+// ImportBankTransactions imports the bank transactions mapped to the categories as records,
+// the transactions without a category are skipped. The transactions imported since the statement was parsed
+// are skipped as well, the rest are added with one call to AddRecords, so either all of them are added or none.
+// The descriptions are written by the bank, so their hashtags are not taken as the tags of the records.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repositories.
+//   - userGUID: The GUID of the user the records belong to.
+//   - transactions: The transactions returned by ParseBankStatement with the categories assigned by the user.
+//
+// Returns:
+//   - int: The number of the imported records.
+//   - error: An error if the repositories fail, or nil if successful.
+func (s *ImportService) ImportBankTransactions(ctx context.Context, userGUID uuid.UUID, transactions []ftracker.BankTransaction) (int, error) {
+	if userGUID == uuid.Nil {
+		return 0, ErrOwnerRequired
+	}
+
+	var ids []string
+	for _, transaction := range transactions {
+		if transaction.CategoryGUID != uuid.Nil {
+			ids = append(ids, transaction.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	imported, err := s.records.GetImportedTransactions(ctx, userGUID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("ImportBankTransactions: %w", err)
+	}
+	isImported := make(map[string]bool, len(imported))
+	for _, id := range imported {
+		isImported[id] = true
+	}
+
+	var records []ftracker.SpendingRecord
+	for _, transaction := range transactions {
+		if transaction.CategoryGUID == uuid.Nil || isImported[transaction.ID] {
+			continue
+		}
+		isImported[transaction.ID] = true
+		records = append(records, ftracker.SpendingRecord{
+			CategoryGUID:  transaction.CategoryGUID,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			Description:   transactionDescription(transaction),
+			SpentAt:       transaction.PostedAt,
+			TransactionID: transaction.ID,
+		})
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.records.AddRecords(ctx, userGUID, records); err != nil {
+		return 0, fmt.Errorf("ImportBankTransactions: %w", err)
+	}
+	return len(records), nil
+}
+
+// rejectUnknownTransactionCurrencies rejects the transactions in the currencies without an exchange rate,
+// it returns the transactions left
+func (s *ImportService) rejectUnknownTransactionCurrencies(ctx context.Context, entries []statementEntry, report *ImportReport) ([]statementEntry, error) {
+	rows := make([]importRow, len(entries))
+	for i, entry := range entries {
+		rows[i] = importRow{line: entry.line, record: ftracker.SpendingRecord{Currency: entry.transaction.Currency}}
+	}
+
+	rows, err := s.rejectUnknownCurrencies(ctx, rows, report)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		known[row.record.Currency] = true
+	}
+
+	left := entries[:0]
+	for _, entry := range entries {
+		if known[entry.transaction.Currency] {
+			left = append(left, entry)
+		}
+	}
+	return left, nil
+}
+
+// transactionDescription makes the description of the record imported from the transaction,
+// the payee comes first as the users look for it, the memo follows unless it repeats the payee
+func transactionDescription(transaction ftracker.BankTransaction) string {
+	payee := strings.TrimSpace(transaction.Payee)
+	memo := strings.TrimSpace(transaction.Description)
+	switch {
+	case payee == "":
+		return memo
+	case memo == "" || strings.EqualFold(payee, memo):
+		return payee
+	default:
+		return payee + " - " + memo
+	}
+}
+
+// isOFX checks if the content is an OFX statement, the SGML ones start with their headers,
+// the XML ones have an OFX root element
+func isOFX(content []byte) bool {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF")))
+	return bytes.HasPrefix(trimmed, []byte("OFXHEADER")) || bytes.Contains(bytes.ToUpper(content), []byte("<OFX>"))
+}
+
+// parseOFX parses the STMTTRN aggregates of an OFX statement. The SGML statements don't close the elements
+// with values, so the content is scanned tag by tag instead of decoded, which reads the XML ones as well.
+// It returns the valid transactions and the rejected ones.
+func parseOFX(content []byte, currency string) ([]statementEntry, []RejectedRow) {
+	var (
+		entries []statementEntry
+		report  ImportReport
+
+		// the aggregates the current tag is in, the elements with values are not pushed
+		stack []string
+		// the values of the current transaction, nil outside of the STMTTRN aggregates
+		fields    map[string]string
+		entryLine int
+		curdef    = currency
+	)
+
+	text := string(content)
+	line := 1
+	for pos := 0; ; {
+		start := strings.IndexByte(text[pos:], '<')
+		if start < 0 {
+			break
+		}
+		line += strings.Count(text[pos:pos+start], "\n")
+		pos += start
+
+		end := strings.IndexByte(text[pos:], '>')
+		if end < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(text[pos+1 : pos+end]))
+		tagLine := line
+		line += strings.Count(text[pos:pos+end], "\n")
+		pos += end + 1
+
+		// the value runs up to the next tag
+		next := strings.IndexByte(text[pos:], '<')
+		if next < 0 {
+			next = len(text) - pos
+		}
+		value := html.UnescapeString(strings.TrimSpace(text[pos : pos+next]))
+
+		switch {
+		case tag == "" || tag[0] == '?' || tag[0] == '!':
+			continue
+
+		case tag[0] == '/':
+			name := tag[1:]
+			if name == "STMTTRN" && fields != nil {
+				entry, reason := parseOFXTransaction(fields, curdef)
+				if reason != "" {
+					report.reject(entryLine, "%s", reason)
+				} else {
+					entry.line = entryLine
+					entries = append(entries, entry)
+				}
+				fields = nil
+			}
+			// the aggregates left open by a sloppy SGML statement are closed with their parent
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == name {
+					stack = stack[:i]
+					break
+				}
+			}
+
+		case value == "":
+			if tag == "STMTTRN" {
+				fields, entryLine = make(map[string]string), tagLine
+			}
+			stack = append(stack, tag)
+
+		default:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			switch {
+			case tag == "CURDEF":
+				curdef = strings.ToUpper(value)
+			case fields == nil:
+			case tag == "CURSYM" && parent == "CURRENCY":
+				fields["CURRENCY"] = strings.ToUpper(value)
+			case tag == "CURSYM":
+				// the amount of a transaction with ORIGCURRENCY is already converted into the currency of the statement
+			default:
+				fields[tag] = value
+			}
+		}
+	}
+
+	return entries, report.Rejected
+}
+
+// parseOFXTransaction validates the values of an STMTTRN aggregate, it returns the reason the transaction is rejected for, if any
+func parseOFXTransaction(fields map[string]string, currency string) (statementEntry, string) {
+	transaction := ftracker.BankTransaction{
+		ID:          fields["FITID"],
+		Currency:    cmp.Or(fields["CURRENCY"], currency),
+		Payee:       fields["NAME"],
+		Description: fields["MEMO"],
+	}
+	if reason := validateTransactionID(transaction.ID); reason != "" {
+		return statementEntry{}, reason
+	}
+	if !currencyRgx.MatchString(transaction.Currency) {
+		return statementEntry{}, fmt.Sprintf("invalid currency %s", transaction.Currency)
+	}
+
+	// the debits are negative, the credits are positive
+	amount := fields["TRNAMT"]
+	transaction.Kind = ftracker.KindIncome
+	if strings.HasPrefix(amount, "-") {
+		transaction.Kind = ftracker.KindSpending
+	}
+	var reason string
+	transaction.Amount, reason = parseTransactionAmount(strings.TrimLeft(amount, "+-"), transaction.Currency)
+	if reason != "" {
+		return statementEntry{}, reason
+	}
+
+	var err error
+	transaction.PostedAt, err = parseOFXTime(fields["DTPOSTED"])
+	if err != nil {
+		return statementEntry{}, fmt.Sprintf("invalid time %s", fields["DTPOSTED"])
+	}
+
+	return statementEntry{transaction: transaction}, ""
+}
+
+// parseOFXTime parses the OFX time YYYYMMDD[HHMMSS[.XXX]][[offset:zone]], the times without an offset are in UTC
+func parseOFXTime(value string) (time.Time, error) {
+	offset := 0
+	if i := strings.IndexByte(value, '['); i >= 0 {
+		zone := strings.TrimSuffix(value[i+1:], "]")
+		zone, _, _ = strings.Cut(zone, ":")
+		hours, err := strconv.ParseFloat(zone, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		offset = int(hours * 3600)
+		value = value[:i]
+	}
+	value, _, _ = strings.Cut(value, ".")
+
+	layout := "20060102150405"
+	switch len(value) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	}
+	t, err := time.ParseInLocation(layout, value, time.FixedZone("", offset))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// parseCAMT parses the Ntry elements of a CAMT.053 statement of any version,
+// it returns the valid transactions and the rejected ones
+func parseCAMT(content []byte, currency string) ([]statementEntry, []RejectedRow, error) {
+	var (
+		entries []statementEntry
+		report  ImportReport
+		// the number of the entries without a reference with the same fields, see camtFallbackID
+		occurrences = make(map[string]int)
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidStatement, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Ntry" {
+			continue
+		}
+		line, _ := decoder.InputPos()
+
+		var entry camtEntry
+		if err := decoder.DecodeElement(&entry, &start); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidStatement, err)
+		}
+
+		parsed, reason := parseCAMTEntry(entry, currency, occurrences)
+		if reason != "" {
+			report.reject(line, "%s", reason)
+			continue
+		}
+		parsed.line = line
+		entries = append(entries, parsed)
+	}
+
+	return entries, report.Rejected, nil
+}
+
+// parseCAMTEntry validates an entry of a CAMT.053 statement, it returns the reason the entry is rejected for, if any
+func parseCAMTEntry(entry camtEntry, currency string, occurrences map[string]int) (statementEntry, string) {
+	// the pending entries may still change, they are imported once booked
+	if status := strings.TrimSpace(cmp.Or(entry.Status.Code, entry.Status.Value)); status != "" && status != "BOOK" {
+		return statementEntry{}, fmt.Sprintf("entry is not booked, its status is %s", status)
+	}
+
+	transaction := ftracker.BankTransaction{
+		Currency: strings.ToUpper(cmp.Or(strings.TrimSpace(entry.Amount.Currency), currency)),
+	}
+	if !currencyRgx.MatchString(transaction.Currency) {
+		return statementEntry{}, fmt.Sprintf("invalid currency %s", transaction.Currency)
+	}
+
+	var details camtDetails
+	if len(entry.Details) > 0 {
+		details = entry.Details[0]
+	}
+
+	// the other party of a debit is the creditor, of a credit the debtor
+	switch indicator := strings.TrimSpace(entry.Indicator); indicator {
+	case "DBIT":
+		transaction.Kind = ftracker.KindSpending
+		transaction.Payee = cmp.Or(details.Creditor.Name, details.Creditor.PartyName)
+	case "CRDT":
+		transaction.Kind = ftracker.KindIncome
+		transaction.Payee = cmp.Or(details.Debtor.Name, details.Debtor.PartyName)
+	default:
+		return statementEntry{}, fmt.Sprintf("invalid credit debit indicator %s", indicator)
+	}
+	transaction.Payee = strings.TrimSpace(transaction.Payee)
+	transaction.Description = strings.TrimSpace(cmp.Or(strings.Join(details.Unstructured, " "), entry.Info))
+
+	var reason string
+	transaction.Amount, reason = parseTransactionAmount(strings.TrimSpace(entry.Amount.Value), transaction.Currency)
+	if reason != "" {
+		return statementEntry{}, reason
+	}
+
+	var err error
+	switch {
+	case entry.BookingDate.DateTime != "":
+		transaction.PostedAt, err = time.Parse(time.RFC3339, strings.TrimSpace(entry.BookingDate.DateTime))
+	case entry.BookingDate.Date != "":
+		transaction.PostedAt, err = time.Parse(time.DateOnly, strings.TrimSpace(entry.BookingDate.Date))
+	default:
+		return statementEntry{}, "no booking date"
+	}
+	if err != nil {
+		return statementEntry{}, fmt.Sprintf("invalid booking date %s", cmp.Or(entry.BookingDate.DateTime, entry.BookingDate.Date))
+	}
+	transaction.PostedAt = transaction.PostedAt.UTC()
+
+	// the reference of the bank is the most stable id, the references of the payer are the next best
+	endToEnd := details.Refs.EndToEndID
+	if endToEnd == "NOTPROVIDED" {
+		endToEnd = ""
+	}
+	transaction.ID = strings.TrimSpace(cmp.Or(
+		entry.Reference,
+		details.Refs.Reference,
+		details.Refs.TransactionID,
+		endToEnd,
+		entry.EntryReference,
+	))
+	if transaction.ID == "" {
+		transaction.ID = camtFallbackID(transaction, occurrences)
+	}
+	if reason := validateTransactionID(transaction.ID); reason != "" {
+		return statementEntry{}, reason
+	}
+
+	return statementEntry{transaction: transaction}, ""
+}
+
+// camtFallbackID makes the id of an entry without references from its fields, the same entries of a statement
+// are told apart by their occurrence, so the statement imported again gives the same ids
+func camtFallbackID(transaction ftracker.BankTransaction, occurrences map[string]int) string {
+	key := strings.Join([]string{
+		transaction.PostedAt.Format(time.DateOnly),
+		transaction.Kind,
+		strconv.FormatInt(int64(transaction.Amount), 10),
+		transaction.Currency,
+		transaction.Payee,
+		transaction.Description,
+	}, "|")
+	occurrences[key]++
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+	return "sha256:" + hex.EncodeToString(sum[:16])
+}
+
+// parseTransactionAmount parses the unsigned amount of a transaction, it returns the reason the amount is rejected for, if any
+func parseTransactionAmount(value, currency string) (ftracker.Money, string) {
+	if strings.HasPrefix(value, ".") || strings.HasPrefix(value, ",") {
+		value = "0" + value
+	}
+	amount, err := ftracker.ParseMoney(value, currency)
+	switch {
+	case errors.Is(err, ftracker.ErrAmountOverflow):
+		return 0, "amount is too large"
+	case err != nil:
+		return 0, fmt.Sprintf("invalid amount %s", value)
+	case amount == 0:
+		return 0, "zero amount"
+	}
+	return amount, ""
+}
+
+// validateTransactionID returns the reason the id of a transaction is rejected for, if any
+func validateTransactionID(id string) string {
+	switch {
+	case id == "":
+		return "no transaction id"
+	case len(id) > maxTransactionIDLen:
+		return "transaction id is too long"
+	}
+	return ""
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	repositorymock "github.com/iv-sukhanov/finance_tracker/internal/repository/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseOFX(t *testing.T) {
+
+	tt := []struct {
+		name         string
+		file         string
+		want         []statementEntry
+		wantRejected []RejectedRow
+	}{
+		{
+			name: "SGML",
+			file: "test_data/statement_sgml.ofx",
+			want: []statementEntry{
+				{line: 39, transaction: ftracker.BankTransaction{
+					ID: "20241101-001", PostedAt: time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC), Amount: 2345, Currency: "EUR",
+					Kind: ftracker.KindSpending, Payee: "LIDL SAGT DANKE", Description: "Card payment",
+				}},
+				{line: 47, transaction: ftracker.BankTransaction{
+					ID: "20241102-001", PostedAt: time.Date(2024, 11, 2, 0, 0, 0, 0, time.UTC), Amount: 250000, Currency: "EUR",
+					Kind: ftracker.KindIncome, Payee: "ACME Payroll", Description: "Salary October",
+				}},
+				{line: 55, transaction: ftracker.BankTransaction{
+					ID: "20241103-001", PostedAt: time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC), Amount: 420, Currency: "EUR",
+					Kind: ftracker.KindSpending, Payee: "Café Central",
+				}},
+				{line: 62, transaction: ftracker.BankTransaction{
+					ID: "20241104-001", PostedAt: time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC), Amount: 1500, Currency: "USD",
+					Kind: ftracker.KindSpending, Payee: "AMAZON US",
+				}},
+			},
+			wantRejected: []RejectedRow{{Line: 73, Reason: "zero amount"}},
+		},
+		{
+			name: "XML",
+			file: "test_data/statement.ofx",
+			want: []statementEntry{
+				{line: 17, transaction: ftracker.BankTransaction{
+					ID: "A-1", PostedAt: time.Date(2024, 11, 2, 1, 0, 0, 0, time.UTC), Amount: 99, Currency: "USD",
+					Kind: ftracker.KindSpending, Payee: "Apple Services", Description: "iCloud & storage",
+				}},
+				{line: 34, transaction: ftracker.BankTransaction{
+					ID: "A-1", PostedAt: time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC), Amount: 10000, Currency: "USD",
+					Kind: ftracker.KindIncome, Payee: "Refund",
+				}},
+			},
+			wantRejected: []RejectedRow{{Line: 28, Reason: "no transaction id"}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := os.ReadFile(tc.file)
+			require.NoError(t, err)
+
+			got, rejected := parseOFX(content, "EUR")
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.wantRejected, rejected)
+		})
+	}
+}
+
+func Test_parseCAMT(t *testing.T) {
+
+	content, err := os.ReadFile("test_data/camt053.xml")
+	require.NoError(t, err)
+
+	got, rejected, err := parseCAMT(content, "USD")
+	require.NoError(t, err)
+	require.Equal(t, []RejectedRow{{Line: 48, Reason: "entry is not booked, its status is PDNG"}}, rejected)
+
+	require.Len(t, got, 4)
+	require.Equal(t, statementEntry{line: 14, transaction: ftracker.BankTransaction{
+		ID: "REF-001", PostedAt: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), Amount: 2345, Currency: "EUR",
+		Kind: ftracker.KindSpending, Payee: "LIDL Berlin", Description: "Card payment",
+	}}, got[0])
+	require.Equal(t, statementEntry{line: 29, transaction: ftracker.BankTransaction{
+		ID: "TX-002", PostedAt: time.Date(2024, 11, 2, 8, 15, 0, 0, time.UTC), Amount: 250000, Currency: "EUR",
+		Kind: ftracker.KindIncome, Payee: "ACME Payroll GmbH", Description: "Salary October",
+	}}, got[1])
+
+	// the same entries without references get different ids, which are the same on every import
+	require.Equal(t, "Account fee", got[2].transaction.Description)
+	require.NotEqual(t, got[2].transaction.ID, got[3].transaction.ID)
+	again, _, err := parseCAMT(content, "USD")
+	require.NoError(t, err)
+	require.Equal(t, got, again)
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := parseCAMT([]byte("<Document><BkToCstmrStmt><Ntry>"), "EUR")
+		require.ErrorIs(t, err, ErrInvalidStatement)
+	})
+}
+
+func Test_parseOFXTime(t *testing.T) {
+
+	tt := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "20241101", want: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "202411011230", want: time.Date(2024, 11, 1, 12, 30, 0, 0, time.UTC)},
+		{input: "20241101123045.123", want: time.Date(2024, 11, 1, 12, 30, 45, 0, time.UTC)},
+		{input: "20241101123045[-5:EST]", want: time.Date(2024, 11, 1, 17, 30, 45, 0, time.UTC)},
+		{input: "20241101000000[+5.5]", want: time.Date(2024, 10, 31, 18, 30, 0, 0, time.UTC)},
+		{input: "2024-11-01", wantErr: true},
+		{input: "20241101[EST]", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseOFXTime(tc.input)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_matchPayeeRule(t *testing.T) {
+
+	groceries := ftracker.PayeeRule{CategoryGUID: uuid.New(), Kind: ftracker.KindSpending, Pattern: "lidl"}
+	phone := ftracker.PayeeRule{CategoryGUID: uuid.New(), Kind: ftracker.KindSpending, Pattern: "lidl mobile"}
+	refunds := ftracker.PayeeRule{CategoryGUID: uuid.New(), Kind: ftracker.KindIncome, Pattern: "lidl"}
+	rules := []ftracker.PayeeRule{groceries, phone, refunds}
+
+	tt := []struct {
+		name        string
+		transaction ftracker.BankTransaction
+		want        ftracker.PayeeRule
+		wantFound   bool
+	}{
+		{
+			name:        "Contains",
+			transaction: ftracker.BankTransaction{Kind: ftracker.KindSpending, Payee: "LIDL SAGT DANKE"},
+			want:        groceries,
+			wantFound:   true,
+		},
+		{
+			name:        "Longest",
+			transaction: ftracker.BankTransaction{Kind: ftracker.KindSpending, Payee: "Lidl  Mobile GmbH"},
+			want:        phone,
+			wantFound:   true,
+		},
+		{
+			name:        "Kind",
+			transaction: ftracker.BankTransaction{Kind: ftracker.KindIncome, Payee: "Lidl Mobile"},
+			want:        refunds,
+			wantFound:   true,
+		},
+		{
+			name:        "Description",
+			transaction: ftracker.BankTransaction{Kind: ftracker.KindSpending, Description: "card payment lidl"},
+			want:        groceries,
+			wantFound:   true,
+		},
+		{
+			name:        "No_match",
+			transaction: ftracker.BankTransaction{Kind: ftracker.KindSpending, Payee: "REWE"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found := matchPayeeRule(rules, tc.transaction)
+			require.Equal(t, tc.wantFound, found)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_ParseBankStatement(t *testing.T) {
+
+	userGUID := uuid.New()
+	groceries := uuid.New()
+	errRepo := errors.New("repository error")
+
+	type mocks struct {
+		records *repositorymock.MockSpendingRecord
+		rates   *repositorymock.MockExchangeRate
+		rules   *repositorymock.MockPayeeRule
+	}
+
+	tt := []struct {
+		name     string
+		file     string
+		input    string
+		behavior func(m mocks)
+		want     BankStatement
+		wantErr  error
+	}{
+		{
+			name: "OFX",
+			file: "test_data/statement_sgml.ofx",
+			behavior: func(m mocks) {
+				m.rates.EXPECT().GetExchangeRates(gomock.Any(), []string{"EUR", "USD"}).
+					Return([]ftracker.ExchangeRate{{Currency: "EUR"}}, nil)
+				m.records.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, []string{"20241101-001", "20241102-001", "20241103-001", "20241104-001"}).
+					Return([]string{"20241102-001"}, nil)
+				m.rules.EXPECT().GetPayeeRules(gomock.Any(), userGUID).
+					Return([]ftracker.PayeeRule{{CategoryGUID: groceries, Kind: ftracker.KindSpending, Pattern: "lidl"}}, nil)
+			},
+			want: BankStatement{
+				Transactions: []ftracker.BankTransaction{
+					{
+						ID: "20241101-001", PostedAt: time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC), Amount: 2345, Currency: "EUR",
+						Kind: ftracker.KindSpending, Payee: "LIDL SAGT DANKE", Description: "Card payment", CategoryGUID: groceries,
+					},
+					{
+						ID: "20241103-001", PostedAt: time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC), Amount: 420, Currency: "EUR",
+						Kind: ftracker.KindSpending, Payee: "Café Central",
+					},
+				},
+				Duplicates: 1,
+				Rejected: []RejectedRow{
+					{Line: 62, Reason: "unknown currency USD"},
+					{Line: 73, Reason: "zero amount"},
+				},
+			},
+		},
+		{
+			name: "Duplicate_id",
+			file: "test_data/statement.ofx",
+			behavior: func(m mocks) {
+				m.rates.EXPECT().GetExchangeRates(gomock.Any(), []string{"USD"}).Return([]ftracker.ExchangeRate{{Currency: "USD"}}, nil)
+				m.records.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, []string{"A-1"}).Return(nil, nil)
+				m.rules.EXPECT().GetPayeeRules(gomock.Any(), userGUID).Return(nil, nil)
+			},
+			want: BankStatement{
+				Transactions: []ftracker.BankTransaction{{
+					ID: "A-1", PostedAt: time.Date(2024, 11, 2, 1, 0, 0, 0, time.UTC), Amount: 99, Currency: "USD",
+					Kind: ftracker.KindSpending, Payee: "Apple Services", Description: "iCloud & storage",
+				}},
+				Rejected: []RejectedRow{
+					{Line: 28, Reason: "no transaction id"},
+					{Line: 34, Reason: "duplicate transaction id A-1"},
+				},
+			},
+		},
+		{
+			name: "CAMT",
+			file: "test_data/camt053.xml",
+			behavior: func(m mocks) {
+				m.rates.EXPECT().GetExchangeRates(gomock.Any(), []string{"EUR"}).Return([]ftracker.ExchangeRate{{Currency: "EUR"}}, nil)
+				m.records.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, gomock.Len(4)).Return([]string{"REF-001", "TX-002"}, nil)
+				m.rules.EXPECT().GetPayeeRules(gomock.Any(), userGUID).Return(nil, nil)
+			},
+			want: BankStatement{Duplicates: 2, Rejected: []RejectedRow{{Line: 48, Reason: "entry is not booked, its status is PDNG"}}},
+		},
+		{
+			name:     "Unknown_format",
+			input:    "category,amount\nfood,5\n",
+			behavior: func(m mocks) {},
+			wantErr:  ErrUnknownStatement,
+		},
+		{
+			name:     "Too_many_transactions",
+			input:    "<OFX>" + strings.Repeat("<STMTTRN><FITID>1</STMTTRN>", MaxImportRows+1),
+			behavior: func(m mocks) {},
+			wantErr:  ErrTooManyRows,
+		},
+		{
+			name: "Repository_error",
+			file: "test_data/statement.ofx",
+			behavior: func(m mocks) {
+				m.rates.EXPECT().GetExchangeRates(gomock.Any(), gomock.Any()).Return([]ftracker.ExchangeRate{{Currency: "USD"}}, nil)
+				m.records.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, gomock.Any()).Return(nil, errRepo)
+			},
+			wantErr: errRepo,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			m := mocks{
+				records: repositorymock.NewMockSpendingRecord(cntr),
+				rates:   repositorymock.NewMockExchangeRate(cntr),
+				rules:   repositorymock.NewMockPayeeRule(cntr),
+			}
+			tc.behavior(m)
+
+			content := []byte(tc.input)
+			if tc.file != "" {
+				var err error
+				content, err = os.ReadFile(tc.file)
+				require.NoError(t, err)
+			}
+
+			got, err := NewImportService(nil, m.records, m.rates, m.rules).
+				ParseBankStatement(context.Background(), userGUID, bytes.NewReader(content), "EUR")
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.want.Transactions == nil {
+				// the ids of the entries without references are not checked here
+				require.Len(t, got.Transactions, 2)
+				got.Transactions = nil
+			}
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_ImportBankTransactions(t *testing.T) {
+
+	userGUID := uuid.New()
+	groceries := uuid.New()
+	postedAt := time.Date(2024, 11, 1, 7, 30, 0, 0, time.UTC)
+	transactions := []ftracker.BankTransaction{
+		{ID: "1", PostedAt: postedAt, Amount: 2345, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "LIDL", Description: "Card payment #food", CategoryGUID: groceries},
+		{ID: "2", PostedAt: postedAt, Amount: 420, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "Café"},
+		{ID: "3", PostedAt: postedAt, Amount: 100, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "LIDL", Description: "lidl", CategoryGUID: groceries},
+		{ID: "4", PostedAt: postedAt, Amount: 100, Currency: "EUR", Kind: ftracker.KindSpending, Payee: "LIDL", CategoryGUID: groceries},
+	}
+
+	tt := []struct {
+		name     string
+		input    []ftracker.BankTransaction
+		behavior func(m *repositorymock.MockSpendingRecord)
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:  "Ok",
+			input: transactions,
+			behavior: func(m *repositorymock.MockSpendingRecord) {
+				m.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, []string{"1", "3", "4"}).Return([]string{"4"}, nil)
+				m.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{
+					{
+						// the hashtag of the bank is not a tag of the user
+						CategoryGUID: groceries, Amount: 2345, Currency: "EUR", Description: "LIDL - Card payment #food",
+						SpentAt: postedAt, TransactionID: "1",
+					},
+					{
+						CategoryGUID: groceries, Amount: 100, Currency: "EUR", Description: "LIDL",
+						SpentAt: postedAt, TransactionID: "3",
+					},
+				}).Return([]uuid.UUID{uuid.New(), uuid.New()}, nil)
+			},
+			want: 2,
+		},
+		{
+			name:     "Nothing_mapped",
+			input:    transactions[1:2],
+			behavior: func(m *repositorymock.MockSpendingRecord) {},
+		},
+		{
+			name:  "All_imported",
+			input: transactions[3:],
+			behavior: func(m *repositorymock.MockSpendingRecord) {
+				m.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, []string{"4"}).Return([]string{"4"}, nil)
+			},
+		},
+		{
+			name:  "Repository_error",
+			input: transactions,
+			behavior: func(m *repositorymock.MockSpendingRecord) {
+				m.EXPECT().GetImportedTransactions(gomock.Any(), userGUID, gomock.Any()).Return(nil, nil)
+				m.EXPECT().AddRecords(gomock.Any(), userGUID, gomock.Len(3)).Return(nil, errors.New("repository error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			records := repositorymock.NewMockSpendingRecord(cntr)
+			tc.behavior(records)
+
+			got, err := NewImportService(nil, records, nil, nil).ImportBankTransactions(context.Background(), userGUID, tc.input)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+type (
+	// BudgetService implements the Budget interface.
+	BudgetService struct {
+		repo    repository.Budget
+		records repository.SpendingRecord
+	}
+
+	// BudgetAlert defines how close the spending of a category is to its monthly limit
+	// It is some sort of enum for the budget alerts.
+	BudgetAlert int
+)
+
+const (
+	BudgetAlertNone     BudgetAlert = iota // the spending is below the warning threshold
+	BudgetAlertWarning                     // the spending reached BudgetWarningPercent of the limit
+	BudgetAlertExceeded                    // the spending reached the limit
+)
+
+// BudgetWarningPercent is the share of the monthly limit, in percents, after which the user is warned.
+const BudgetWarningPercent = 80
+
+// NewBudgetService creates a new instance of BudgetService with the provided repositories,
+// the records repository is used to look up the records being evaluated.
+func NewBudgetService(repo repository.Budget, records repository.SpendingRecord) *BudgetService {
+	return &BudgetService{
+		repo:    repo,
+		records: records,
+	}
+}
+
+// MonthBoundaries returns the start of the month the moment belongs to and the start of the next one.
+func MonthBoundaries(moment time.Time) (time.Time, time.Time) {
+	from := time.Date(moment.Year(), moment.Month(), 1, 0, 0, 0, 0, moment.Location())
+	return from, from.AddDate(0, 1, 0)
+}
+
+// SetBudgets sets the monthly limits of the user's spending categories.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - budgets: A slice of Budget objects identified by their CategoryGUIDs.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *BudgetService) SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.SetBudgets(ctx, userGUID, budgets)
+}
+
+// DeleteBudgets removes the monthly limits of the user's categories.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - categoryGUIDs: A slice of UUIDs of the categories whose budgets are removed.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *BudgetService) DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.DeleteBudgets(ctx, userGUID, categoryGUIDs)
+}
+
+// GetBudgets retrieves the budgets of the user's categories with the money spent in the given month.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - month: Any moment of the month the spending is summed up for.
+//   - categoryGUIDs: UUIDs of the categories to look up, if empty, all the budgets are returned.
+//
+// Returns:
+//   - []ftracker.BudgetStatus: The budgets ordered by category name.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *BudgetService) GetBudgets(ctx context.Context, userGUID uuid.UUID, month time.Time, categoryGUIDs ...uuid.UUID) ([]ftracker.BudgetStatus, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
+	from, to := MonthBoundaries(month)
+	return s.repo.GetBudgets(ctx, userGUID, repository.BudgetOptions{
+		CategoryGUIDs: categoryGUIDs,
+		TimeFrom:      from,
+		TimeTo:        to,
+	})
+}
+
+// EvaluateBudget checks whether a freshly added record made the spending of its category
+// cross the warning threshold or the limit of the category's budget for the month of the record.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the record belongs to.
+//   - recordGUID: The GUID of the record that has been added.
+//
+// Returns:
+//   - BudgetAlert: The threshold crossed by the record, BudgetAlertNone if no threshold
+//     was crossed or the category has no budget.
+//   - ftracker.BudgetStatus: The budget of the category, empty if there is none.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *BudgetService) EvaluateBudget(ctx context.Context, userGUID, recordGUID uuid.UUID) (BudgetAlert, ftracker.BudgetStatus, error) {
+	if userGUID == uuid.Nil {
+		return BudgetAlertNone, ftracker.BudgetStatus{}, ErrOwnerRequired
+	}
+
+	records, err := s.records.GetRecords(ctx, userGUID, repository.RecordOptions{GUIDs: []uuid.UUID{recordGUID}})
+	if err != nil {
+		return BudgetAlertNone, ftracker.BudgetStatus{}, fmt.Errorf("EvaluateBudget: %w", err)
+	}
+	if len(records) != 1 {
+		return BudgetAlertNone, ftracker.BudgetStatus{}, fmt.Errorf("EvaluateBudget: no record with guid %s", recordGUID)
+	}
+	record := records[0]
+
+	budgets, err := s.GetBudgets(ctx, userGUID, record.SpentAt, record.CategoryGUID)
+	if err != nil {
+		return BudgetAlertNone, ftracker.BudgetStatus{}, fmt.Errorf("EvaluateBudget: %w", err)
+	}
+	if len(budgets) != 1 {
+		return BudgetAlertNone, ftracker.BudgetStatus{}, nil
+	}
+	budget := budgets[0]
+
+	spentBefore := max(budget.Spent-record.BaseAmount, 0)
+	alert := budgetAlert(budget.Spent, budget.Amount)
+	if alert == budgetAlert(spentBefore, budget.Amount) {
+		alert = BudgetAlertNone
+	}
+
+	return alert, budget, nil
+}
+
+// budgetAlert returns the alert level of the spending against the limit.
+// The warning threshold is rounded up and computed without multiplying the amounts, so the large ones don't overflow.
+func budgetAlert(spent, limit ftracker.Money) BudgetAlert {
+	warning := limit/100*BudgetWarningPercent + (limit%100*BudgetWarningPercent+99)/100
+	switch {
+	case spent >= limit:
+		return BudgetAlertExceeded
+	case spent >= warning:
+		return BudgetAlertWarning
+	default:
+		return BudgetAlertNone
+	}
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+const (
+	// csvTimeLayout is the layout of the times in the exported files
+	csvTimeLayout = "2006-01-02 15:04:05"
+
+	// MaxImportRows is the maximum number of the rows of an imported file
+	MaxImportRows = 5000
+
+	// maxCategoryLen is the length of the category column
+	maxCategoryLen = 255
+)
+
+var (
+	// ErrInvalidCSV is returned when the imported file is not a CSV file with a header naming its columns.
+	ErrInvalidCSV = errors.New("invalid csv")
+	// ErrTooManyRows is returned when the imported file has more than MaxImportRows rows.
+	ErrTooManyRows = errors.New("too many rows")
+
+	// the columns of the exported files, the imported records need the category and the amount columns,
+	// the tags column is only imported, the tags are in the hashtags of the descriptions anyway
+	recordsCSVHeader    = []string{"spent_at", "category", "kind", "amount", "currency", "description"}
+	categoriesCSVHeader = []string{"category", "parent", "kind", "description", "amount"}
+
+	// the layouts the times of the imported records may be in, the dates without a time are taken at midnight
+	csvTimeLayouts = []string{csvTimeLayout, time.RFC3339, "2006-01-02 15:04", "2006-01-02", "02.01.2006"}
+)
+
+type (
+	// ImportService implements the Import interface.
+	ImportService struct {
+		categories repository.SpendingCategory
+		records    repository.SpendingRecord
+		rates      repository.ExchangeRate
+		rules      repository.PayeeRule
+	}
+
+	// ImportOptions configures an import
+	//
+	//  - Currency: the currency of the rows without one, usually the base currency of the user
+	//
+	//  - CreateCategories: the categories the user doesn't have are created, otherwise their rows are rejected
+	//
+	//  - DryRun: the rows are only validated, nothing is written
+	ImportOptions struct {
+		Currency         string
+		CreateCategories bool
+		DryRun           bool
+	}
+
+	// ImportReport is the outcome of an import
+	//
+	//  - Imported: the number of the imported records, or of the valid ones on a dry run
+	//
+	//  - Updated: the number of the updated records, or of the changed ones on a dry run,
+	//   only the workbooks update the records
+	//
+	//  - Unchanged: the number of the rows of the records left as they are
+	//
+	//  - NewCategories: the categories the user didn't have, in the order they first appear,
+	//   they are created if the import creates the categories, otherwise their rows are rejected
+	//
+	//  - Changes: the rows adding or changing the records with their differences, ordered by their lines,
+	//   only the workbook imports list them
+	//
+	//  - Rejected: the rows that were not imported, ordered by their lines
+	ImportReport struct {
+		Imported      int
+		Updated       int
+		Unchanged     int
+		NewCategories []string
+		Changes       []RowChange
+		Rejected      []RejectedRow
+	}
+
+	// RowChange is a row of the imported workbook adding or changing a record
+	//
+	//  - Line: the row of the sheet, the header is the row 1
+	//
+	//  - GUID: the GUID of the changed record, uuid.Nil for a new one
+	//
+	//  - Fields: the changed columns, all the filled ones for a new record
+	RowChange struct {
+		Line   int
+		GUID   uuid.UUID
+		Fields []FieldChange
+	}
+
+	// FieldChange is a changed column of a record, the values are formatted like in the sheet
+	//
+	//  - Column: the name of the column in the exported workbook
+	//
+	//  - Old: the value of the record, empty for a new one
+	//
+	//  - New: the value of the row
+	FieldChange struct {
+		Column string
+		Old    string
+		New    string
+	}
+
+	// RejectedRow is a row of the imported file that was not imported
+	//
+	//  - Line: the line of the file the row starts on, the header is the line 1
+	//
+	//  - Reason: why the row was rejected
+	RejectedRow struct {
+		Line   int
+		Reason string
+	}
+
+	// importRow is a valid row waiting to be mapped to its category,
+	// keepTags is set when the file has no tags column, so an updated record keeps its tags
+	importRow struct {
+		line     int
+		category string
+		kind     string
+		keepTags bool
+		record   ftracker.SpendingRecord
+	}
+)
+
+// NewImportService creates a new instance of ImportService with the provided repositories.
+func NewImportService(
+	categories repository.SpendingCategory,
+	records repository.SpendingRecord,
+	rates repository.ExchangeRate,
+	rules repository.PayeeRule,
+) *ImportService {
+	return &ImportService{
+		categories: categories,
+		records:    records,
+		rates:      rates,
+		rules:      rules,
+	}
+}
+
+// CreateCSVFromRecords generates a CSV file from a slice of SpendingRecord objects,
+// the file can be imported back with ImportRecordsCSV.
+//
+// Parameters:
+//   - records: A slice of SpendingRecord objects containing the data to be written to the file.
+//   - categories: The categories of the records, they give the category and the kind columns.
+//
+// Returns:
+//   - []byte: The content of the file, the header comes first.
+//   - error: An error if the category of a record is not among the categories, or nil if successful.
+func (s RecordService) CreateCSVFromRecords(records []ftracker.SpendingRecord, categories []ftracker.SpendingCategory) ([]byte, error) {
+	byGUID := make(map[uuid.UUID]ftracker.SpendingCategory, len(categories))
+	for _, category := range categories {
+		byGUID[category.GUID] = category
+	}
+
+	var buffer bytes.Buffer
+	w := csv.NewWriter(&buffer)
+	w.Write(recordsCSVHeader)
+	for _, record := range records {
+		category, ok := byGUID[record.CategoryGUID]
+		if !ok {
+			return nil, fmt.Errorf("CreateCSVFromRecords: no category %s of record %s", record.CategoryGUID, record.GUID)
+		}
+		w.Write([]string{
+			record.SpentAt.Format(csvTimeLayout),
+			category.Category,
+			category.Kind,
+			record.Amount.Format(record.Currency),
+			record.Currency,
+			record.Description,
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("CreateCSVFromRecords: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// CreateCSVFromCategories generates a CSV file containing a list of spending categories.
+//
+// Parameters:
+//   - categories: A slice of SpendingCategory objects containing the data to be written to the file,
+//     the parent column names the parent of a subcategory if it is among them.
+//   - currency: The base currency of the user the amounts of the categories are in.
+//
+// Returns:
+//   - []byte: The content of the file, the header comes first.
+//   - error: An error if the file can't be written, or nil if successful.
+func (s CategoryService) CreateCSVFromCategories(categories []ftracker.SpendingCategory, currency string) ([]byte, error) {
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		names[category.GUID] = category.Category
+	}
+
+	var buffer bytes.Buffer
+	w := csv.NewWriter(&buffer)
+	w.Write(categoriesCSVHeader)
+	for _, category := range categories {
+		w.Write([]string{
+			category.Category,
+			names[category.ParentGUID],
+			category.Kind,
+			category.Description,
+			category.Amount.Format(currency),
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("CreateCSVFromCategories: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// ImportRecordsCSV imports the records of a CSV file, e.g. the one made by CreateCSVFromRecords or an old spreadsheet.
+// The header names the columns in any order: category and amount are required, spent_at, kind, currency,
+// description and tags are optional, the other columns are skipped. The fields are separated by commas,
+// or by semicolons if the header has no commas. The rows that can't be imported are rejected one by one,
+// the valid ones are added together with the new categories in one transaction, so either all of them are added or none.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repositories.
+//   - userGUID: The GUID of the user the records belong to.
+//   - r: The content of the file.
+//   - opts: The options of the import, see ImportOptions.
+//
+// Returns:
+//   - ImportReport: The numbers of the imported and the rejected rows and the categories the user didn't have.
+//   - error: ErrInvalidCSV if the file has no valid header, ErrTooManyRows if it has more than MaxImportRows rows,
+//     an error if the repositories fail, or nil if successful, the rejected rows are not errors.
+func (s *ImportService) ImportRecordsCSV(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	if userGUID == uuid.Nil {
+		return ImportReport{}, ErrOwnerRequired
+	}
+	opts.Currency = cmp.Or(opts.Currency, ftracker.DefaultCurrency)
+
+	rows, rejected, err := readImportRows(r, opts.Currency)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", err)
+	}
+	report := ImportReport{Rejected: rejected}
+
+	rows, err = s.rejectUnknownCurrencies(ctx, rows, &report)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", err)
+	}
+
+	existing, err := s.categories.GetCategories(ctx, userGUID, repository.CategoryOptions{})
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", err)
+	}
+	valid, created := mapImportCategories(rows, existing, opts, &report)
+
+	if opts.DryRun || len(valid) == 0 {
+		report.Imported = len(valid)
+		return report, nil
+	}
+
+	createdByName := assignImportCategories(created)
+	records := make([]ftracker.SpendingRecord, len(valid))
+	for i, row := range valid {
+		if row.record.CategoryGUID == uuid.Nil {
+			row.record.CategoryGUID = createdByName[row.category]
+		}
+		records[i] = row.record
+	}
+
+	if _, err := s.records.ImportRecords(ctx, userGUID, created, withTags(records), nil); err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsCSV: %w", err)
+	}
+
+	report.Imported = len(records)
+	return report, nil
+}
+
+// mapImportCategories maps the rows to the existing categories, the first row of a new category decides its kind,
+// it returns the rows left and the categories to create if the import creates them
+func mapImportCategories(rows []importRow, existing []ftracker.SpendingCategory, opts ImportOptions, report *ImportReport) ([]importRow, []ftracker.SpendingCategory) {
+	byName := make(map[string]ftracker.SpendingCategory, len(existing))
+	for _, category := range existing {
+		byName[category.Category] = category
+	}
+
+	var (
+		valid   []importRow
+		created []ftracker.SpendingCategory
+		newKind = make(map[string]string)
+	)
+	for _, row := range rows {
+		if category, ok := byName[row.category]; ok {
+			if row.kind != "" && row.kind != category.Kind {
+				report.reject(row.line, "category %s is of kind %s", row.category, category.Kind)
+				continue
+			}
+			row.record.CategoryGUID = category.GUID
+			valid = append(valid, row)
+			continue
+		}
+
+		// the new categories get the names the bot can show and look up
+		if !validCategoryName(row.category) {
+			report.reject(row.line, "invalid category name %s", row.category)
+			continue
+		}
+		kind, seen := newKind[row.category]
+		if !seen {
+			kind = cmp.Or(row.kind, ftracker.KindSpending)
+			newKind[row.category] = kind
+			report.NewCategories = append(report.NewCategories, row.category)
+			if opts.CreateCategories {
+				created = append(created, ftracker.SpendingCategory{Category: row.category, Kind: kind})
+			}
+		}
+		switch {
+		case !opts.CreateCategories:
+			report.reject(row.line, "unknown category %s", row.category)
+		case row.kind != "" && row.kind != kind:
+			report.reject(row.line, "category %s is of kind %s", row.category, kind)
+		default:
+			valid = append(valid, row)
+		}
+	}
+	sort.SliceStable(report.Rejected, func(i, j int) bool { return report.Rejected[i].Line < report.Rejected[j].Line })
+
+	return valid, created
+}
+
+// assignImportCategories sets the GUIDs of the new categories of the import, so its records refer to them
+// before they are written, it returns the GUIDs by the names of the categories
+func assignImportCategories(created []ftracker.SpendingCategory) map[string]uuid.UUID {
+	byName := make(map[string]uuid.UUID, len(created))
+	for i := range created {
+		created[i].GUID = uuid.New()
+		byName[created[i].Category] = created[i].GUID
+	}
+	return byName
+}
+
+// rejectUnknownCurrencies rejects the rows in the currencies without an exchange rate,
+// it returns the rows left
+func (s *ImportService) rejectUnknownCurrencies(ctx context.Context, rows []importRow, report *ImportReport) ([]importRow, error) {
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !seen[row.record.Currency] {
+			seen[row.record.Currency] = true
+			currencies = append(currencies, row.record.Currency)
+		}
+	}
+	if len(currencies) == 0 {
+		return rows, nil
+	}
+
+	rates, err := s.rates.GetExchangeRates(ctx, currencies)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(rates))
+	for _, rate := range rates {
+		known[rate.Currency] = true
+	}
+
+	left := rows[:0]
+	for _, row := range rows {
+		if !known[row.record.Currency] {
+			report.reject(row.line, "unknown currency %s", row.record.Currency)
+			continue
+		}
+		left = append(left, row)
+	}
+	return left, nil
+}
+
+// readImportRows reads and validates the rows of the imported file,
+// it returns the valid rows and the rejected ones
+func readImportRows(r io.Reader, currency string) ([]importRow, []RejectedRow, error) {
+	reader, err := newImportReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: no header", ErrInvalidCSV)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"category", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: no %s column", ErrInvalidCSV, required)
+		}
+	}
+
+	var (
+		rows     []importRow
+		report   ImportReport
+		rowCount int
+	)
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rowCount++
+		if rowCount > MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d", ErrTooManyRows, MaxImportRows)
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.reject(parseErr.StartLine, "%s", parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		row, reason := parseImportRow(field, currency)
+		if reason != "" {
+			report.reject(line, "%s", reason)
+			continue
+		}
+		row.line = line
+		rows = append(rows, row)
+	}
+
+	return rows, report.Rejected, nil
+}
+
+// newImportReader makes the CSV reader of the imported file, the byte order mark spreadsheets put
+// at the start is skipped, the fields are separated by semicolons if the header has them and no commas
+func newImportReader(r io.Reader) (*csv.Reader, error) {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+		buffered.Discard(3)
+	}
+
+	header, err := buffered.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), buffered))
+	if !strings.Contains(header, ",") && strings.Contains(header, ";") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	return reader, nil
+}
+
+// parseImportRow validates the fields of a row, it returns the reason the row is rejected for, if any
+func parseImportRow(field func(name string) string, currency string) (importRow, string) {
+	row := importRow{
+		category: field("category"),
+		kind:     strings.ToLower(field("kind")),
+	}
+
+	switch {
+	case row.category == "":
+		return row, "no category"
+	case len(row.category) > maxCategoryLen:
+		return row, "category name is too long"
+	case row.kind != "" && row.kind != ftracker.KindSpending && row.kind != ftracker.KindIncome:
+		return row, fmt.Sprintf("unknown kind %s", row.kind)
+	}
+
+	row.record.Currency = strings.ToUpper(cmp.Or(field("currency"), currency))
+	if !currencyRgx.MatchString(row.record.Currency) {
+		return row, fmt.Sprintf("invalid currency %s", row.record.Currency)
+	}
+
+	amount, err := ftracker.ParseMoney(field("amount"), row.record.Currency)
+	switch {
+	case errors.Is(err, ftracker.ErrAmountOverflow):
+		return row, "amount is too large"
+	case err != nil:
+		return row, fmt.Sprintf("invalid amount %s", field("amount"))
+	case amount == 0:
+		return row, "zero amount"
+	}
+	row.record.Amount = amount
+
+	if spentAt := field("spent_at"); spentAt != "" {
+		row.record.SpentAt, err = parseCSVTime(spentAt)
+		if err != nil {
+			return row, fmt.Sprintf("invalid time %s", spentAt)
+		}
+		if row.record.SpentAt.After(time.Now()) {
+			return row, fmt.Sprintf("time %s is in the future", spentAt)
+		}
+	}
+
+	row.record.Description = field("description")
+	row.record.Tags = strings.FieldsFunc(field("tags"), func(r rune) bool { return r == ' ' || r == ',' || r == ';' })
+	return row, ""
+}
+
+// parseCSVTime parses the time of an imported record in one of csvTimeLayouts
+func parseCSVTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range csvTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// reject adds the row starting on the line to the rejected ones
+func (r *ImportReport) reject(line int, format string, args ...any) {
+	r.Rejected = append(r.Rejected, RejectedRow{Line: line, Reason: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+	repositorymock "github.com/iv-sukhanov/finance_tracker/internal/repository/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateCSVFromCategories(t *testing.T) {
+
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending, Description: "all the food", Amount: 123450}
+	categories := []ftracker.SpendingCategory{
+		food,
+		{GUID: uuid.New(), ParentGUID: food.GUID, Category: "groceries", Kind: ftracker.KindSpending, Description: "milk, eggs", Amount: 1500},
+		{GUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome, Amount: 500000},
+	}
+
+	got, err := CategoryService{}.CreateCSVFromCategories(categories, "EUR")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"category,parent,kind,description,amount\n"+
+		"food,,spending,all the food,1234.50\n"+
+		"groceries,food,spending,\"milk, eggs\",15.00\n"+
+		"salary,,income,,5000.00\n",
+		string(got),
+	)
+}
+
+func Test_CreateCSVFromRecords(t *testing.T) {
+
+	spentAt := time.Date(2024, 11, 26, 18, 30, 0, 0, time.UTC)
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	salary := ftracker.SpendingCategory{GUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome}
+	records := []ftracker.SpendingRecord{
+		{CategoryGUID: food.GUID, Amount: 1234, Currency: "EUR", Description: "dinner #vacation", SpentAt: spentAt},
+		{CategoryGUID: food.GUID, Amount: 1500, Currency: "JPY", Description: "sushi, \"fresh\"", SpentAt: spentAt.Add(time.Hour)},
+		{CategoryGUID: salary.GUID, Amount: 500000, Currency: "EUR", SpentAt: spentAt.Add(2 * time.Hour)},
+	}
+
+	t.Run("Round_trip", func(t *testing.T) {
+		cntr := gomock.NewController(t)
+		defer cntr.Finish()
+
+		file, err := RecordService{}.CreateCSVFromRecords(records, []ftracker.SpendingCategory{food, salary})
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(file, []byte("spent_at,category,kind,amount,currency,description\n2024-11-26 18:30:00,food,spending,12.34,EUR,dinner #vacation\n")))
+
+		userGUID := uuid.New()
+		mockCategories := repositorymock.NewMockSpendingCategory(cntr)
+		mockRecords := repositorymock.NewMockSpendingRecord(cntr)
+		mockRates := repositorymock.NewMockExchangeRate(cntr)
+		mockRates.EXPECT().GetExchangeRates(gomock.Any(), []string{"EUR", "JPY"}).
+			Return([]ftracker.ExchangeRate{{Currency: "EUR"}, {Currency: "JPY"}}, nil)
+		mockCategories.EXPECT().GetCategories(gomock.Any(), userGUID, repository.CategoryOptions{}).
+			Return([]ftracker.SpendingCategory{food, salary}, nil)
+		mockRecords.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), gomock.Any(), gomock.Len(0)).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, _ []ftracker.SpendingCategory, got, _ []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+				require.Len(t, got, len(records))
+				for i, record := range got {
+					require.Equal(t, records[i].CategoryGUID, record.CategoryGUID)
+					require.Equal(t, records[i].Amount, record.Amount)
+					require.Equal(t, records[i].Currency, record.Currency)
+					require.Equal(t, records[i].Description, record.Description)
+					require.True(t, records[i].SpentAt.Equal(record.SpentAt))
+				}
+				require.Equal(t, []string{"vacation"}, got[0].Tags)
+				return make([]uuid.UUID, len(got)), nil
+			})
+
+		report, err := NewImportService(mockCategories, mockRecords, mockRates, nil).
+			ImportRecordsCSV(context.Background(), userGUID, bytes.NewReader(file), ImportOptions{Currency: "EUR"})
+		require.NoError(t, err)
+		require.Equal(t, ImportReport{Imported: len(records)}, report)
+	})
+
+	t.Run("No_category", func(t *testing.T) {
+		_, err := RecordService{}.CreateCSVFromRecords(records, []ftracker.SpendingCategory{food})
+		require.Error(t, err)
+	})
+}
+
+func Test_ImportRecordsCSV(t *testing.T) {
+
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	salary := ftracker.SpendingCategory{GUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome}
+	errRepo := errors.New("repository error")
+
+	type mocks struct {
+		categories *repositorymock.MockSpendingCategory
+		records    *repositorymock.MockSpendingRecord
+		rates      *repositorymock.MockExchangeRate
+	}
+	// the rates and the categories of the user are looked up on every import with a header
+	lookup := func(m mocks) {
+		m.rates.EXPECT().GetExchangeRates(gomock.Any(), gomock.Any()).
+			Return([]ftracker.ExchangeRate{{Currency: "EUR"}, {Currency: "USD"}}, nil)
+		m.categories.EXPECT().GetCategories(gomock.Any(), userGUID, repository.CategoryOptions{}).
+			Return([]ftracker.SpendingCategory{food, salary}, nil)
+	}
+
+	tt := []struct {
+		name     string
+		input    string
+		opts     ImportOptions
+		behavior func(m mocks)
+		want     ImportReport
+		wantErr  error
+	}{
+		{
+			name:  "Semicolons_and_bom",
+			input: "\xEF\xBB\xBFCategory;Amount;Spent_at;Tags\nfood;12,50;02.11.2024;work, trip\n",
+			opts:  ImportOptions{Currency: "EUR"},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), []ftracker.SpendingRecord{{
+					CategoryGUID: food.GUID,
+					Amount:       1250,
+					Currency:     "EUR",
+					SpentAt:      time.Date(2024, 11, 2, 0, 0, 0, 0, time.UTC),
+					Tags:         []string{"work", "trip"},
+				}}, gomock.Len(0)).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			want: ImportReport{Imported: 1},
+		},
+		{
+			name: "Rejected_rows",
+			input: "" +
+				"category,amount,currency,kind,spent_at\n" +
+				"food,12.50,USD,,\n" +
+				",1,,,\n" +
+				"food,abc,,,\n" +
+				"food,0,,,\n" +
+				"food,1.234,,,\n" +
+				"food,1,XYZ,,\n" +
+				"food,1,,transfer,\n" +
+				"salary,1,,spending,\n" +
+				"food,1,,,yesterday\n" +
+				"food,1,,,2999-01-01\n" +
+				"food,92233720368547758.08,,,\n",
+			opts: ImportOptions{Currency: "EUR"},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), gomock.Len(1), gomock.Len(0)).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			want: ImportReport{
+				Imported: 1,
+				Rejected: []RejectedRow{
+					{Line: 3, Reason: "no category"},
+					{Line: 4, Reason: "invalid amount abc"},
+					{Line: 5, Reason: "zero amount"},
+					{Line: 6, Reason: "invalid amount 1.234"},
+					{Line: 7, Reason: "unknown currency XYZ"},
+					{Line: 8, Reason: "unknown kind transfer"},
+					{Line: 9, Reason: "category salary is of kind income"},
+					{Line: 10, Reason: "invalid time yesterday"},
+					{Line: 11, Reason: "time 2999-01-01 is in the future"},
+					{Line: 12, Reason: "amount is too large"},
+				},
+			},
+		},
+		{
+			name:  "Malformed_row",
+			input: "category,amount\nfood,1\"2\nfood,2\n",
+			opts:  ImportOptions{Currency: "EUR"},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), gomock.Len(1), gomock.Len(0)).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			want: ImportReport{Imported: 1, Rejected: []RejectedRow{{Line: 2, Reason: "bare \" in non-quoted-field"}}},
+		},
+		{
+			name:  "Unknown_category",
+			input: "category,amount\ntravel,100\nfood,5\ntravel,20\n",
+			opts:  ImportOptions{Currency: "EUR"},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), gomock.Len(1), gomock.Len(0)).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			want: ImportReport{
+				Imported:      1,
+				NewCategories: []string{"travel"},
+				Rejected: []RejectedRow{
+					{Line: 2, Reason: "unknown category travel"},
+					{Line: 4, Reason: "unknown category travel"},
+				},
+			},
+		},
+		{
+			name:  "Create_categories",
+			input: "category,amount,kind\nbonus,100,income\nbonus,20,spending\nbonus,30,\n",
+			opts:  ImportOptions{Currency: "EUR", CreateCategories: true},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Any(), gomock.Any(), gomock.Len(0)).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, categories []ftracker.SpendingCategory, records, _ []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+						require.Len(t, categories, 1)
+						require.NotEqual(t, uuid.Nil, categories[0].GUID)
+						require.Equal(t, "bonus", categories[0].Category)
+						require.Equal(t, ftracker.KindIncome, categories[0].Kind)
+						require.Len(t, records, 2)
+						for _, record := range records {
+							require.Equal(t, categories[0].GUID, record.CategoryGUID)
+						}
+						return make([]uuid.UUID, len(records)), nil
+					})
+			},
+			want: ImportReport{
+				Imported:      2,
+				NewCategories: []string{"bonus"},
+				Rejected:      []RejectedRow{{Line: 3, Reason: "category bonus is of kind income"}},
+			},
+		},
+		{
+			name:  "Invalid_new_category",
+			input: "category,amount\nfood/fruits,5\nsnacks,2\n",
+			opts:  ImportOptions{Currency: "EUR", CreateCategories: true},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(1), gomock.Len(1), gomock.Len(0)).Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			want: ImportReport{
+				Imported:      1,
+				NewCategories: []string{"snacks"},
+				Rejected:      []RejectedRow{{Line: 2, Reason: "invalid category name food/fruits"}},
+			},
+		},
+		{
+			name:  "Dry_run",
+			input: "category,amount\ntravel,100\nfood,5\n",
+			opts:  ImportOptions{Currency: "EUR", CreateCategories: true, DryRun: true},
+			behavior: func(m mocks) {
+				lookup(m)
+			},
+			want: ImportReport{Imported: 2, NewCategories: []string{"travel"}},
+		},
+		{
+			name:  "Import_failure",
+			input: "category,amount\ntravel,100\n",
+			opts:  ImportOptions{Currency: "EUR", CreateCategories: true},
+			behavior: func(m mocks) {
+				lookup(m)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(1), gomock.Len(1), gomock.Len(0)).Return(nil, errRepo)
+			},
+			wantErr: errRepo,
+		},
+		{
+			name:     "No_amount_column",
+			input:    "category,price\nfood,5\n",
+			behavior: func(m mocks) {},
+			wantErr:  ErrInvalidCSV,
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			behavior: func(m mocks) {},
+			wantErr:  ErrInvalidCSV,
+		},
+		{
+			name:     "Too_many_rows",
+			input:    "category,amount\n" + strings.Repeat("food,1\n", MaxImportRows+1),
+			behavior: func(m mocks) {},
+			wantErr:  ErrTooManyRows,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			m := mocks{
+				categories: repositorymock.NewMockSpendingCategory(cntr),
+				records:    repositorymock.NewMockSpendingRecord(cntr),
+				rates:      repositorymock.NewMockExchangeRate(cntr),
+			}
+			tc.behavior(m)
+
+			got, err := NewImportService(m.categories, m.records, m.rates, nil).
+				ImportRecordsCSV(context.Background(), userGUID, strings.NewReader(tc.input), tc.opts)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
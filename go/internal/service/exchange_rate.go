@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+var (
+	// ErrUnknownCurrency is returned when there is no exchange rate for the currency.
+	ErrUnknownCurrency = repository.ErrUnknownCurrency
+	// ErrInvalidExchangeRate is returned when an exchange rate can't be stored.
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+	currencyRgx = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// ExchangeRateService implements the ExchangeRate interface.
+type ExchangeRateService struct {
+	repo repository.ExchangeRate
+}
+
+// NewExchangeRateService creates a new instance of ExchangeRateService with the provided repository.
+func NewExchangeRateService(repo repository.ExchangeRate) *ExchangeRateService {
+	return &ExchangeRateService{
+		repo: repo,
+	}
+}
+
+// GetExchangeRates retrieves the exchange rates of the given currencies, all the rates if none are given.
+func (s *ExchangeRateService) GetExchangeRates(ctx context.Context, currencies ...string) ([]ftracker.ExchangeRate, error) {
+	return s.repo.GetExchangeRates(ctx, currencies)
+}
+
+// SetExchangeRates validates and stores the exchange rates.
+// Every rate is the amount of the currency worth one unit of ftracker.DefaultCurrency,
+// so the rate of ftracker.DefaultCurrency itself can only be 1. The scale of every currency is set from ftracker.CurrencyScale,
+// the repository converts the amounts between the currencies with it.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - rates: A slice of ExchangeRate objects to be stored.
+//
+// Returns:
+//   - error: ErrInvalidExchangeRate if any of the rates is malformed,
+//     an error if the operation fails, or nil if successful.
+func (s *ExchangeRateService) SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error {
+	for i, rate := range rates {
+		if !currencyRgx.MatchString(rate.Currency) {
+			return fmt.Errorf("%w: currency %q is not a three letter code", ErrInvalidExchangeRate, rate.Currency)
+		}
+		if rate.Rate <= 0 {
+			return fmt.Errorf("%w: rate of %s must be positive", ErrInvalidExchangeRate, rate.Currency)
+		}
+		if rate.Currency == ftracker.DefaultCurrency && rate.Rate != 1 {
+			return fmt.Errorf("%w: rate of %s must be 1", ErrInvalidExchangeRate, rate.Currency)
+		}
+		rates[i].Scale = ftracker.CurrencyScale(rate.Currency)
+	}
+
+	return s.repo.SetExchangeRates(ctx, rates)
+}
+
+// LoadExchangeRates reads the exchange rates from r and stores them.
+// Every line holds a currency code and its rate separated by a comma, e.g. "USD,1.08".
+// Empty lines and lines starting with # are skipped.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - r: The reader the rates are read from.
+//
+// Returns:
+//   - int: The number of stored rates.
+//   - error: An error if the input is malformed or the rates can't be stored, or nil if successful.
+func (s *ExchangeRateService) LoadExchangeRates(ctx context.Context, r io.Reader) (int, error) {
+	var rates []ftracker.ExchangeRate
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		currency, value, ok := strings.Cut(text, ",")
+		if !ok {
+			return 0, fmt.Errorf("LoadExchangeRates: line %d: %w: expected currency,rate", line, ErrInvalidExchangeRate)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("LoadExchangeRates: line %d: %w: %w", line, ErrInvalidExchangeRate, err)
+		}
+
+		rates = append(rates, ftracker.ExchangeRate{
+			Currency: strings.ToUpper(strings.TrimSpace(currency)),
+			Rate:     rate,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("LoadExchangeRates: %w", err)
+	}
+
+	if err := s.SetExchangeRates(ctx, rates); err != nil {
+		return 0, fmt.Errorf("LoadExchangeRates: %w", err)
+	}
+
+	return len(rates), nil
+}
@@ -1,23 +1,61 @@
 package service
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 	"github.com/xuri/excelize/v2"
 )
 
 const (
-	formatOut      = "Monday, 02 Jan, 15:04"
-	sheetName      = "records"
-	amountLen      = 10
-	descriptionLen = 30
-	timeLen        = 25
-	categoryLen    = 20
+	sheetName        = "records"
+	summarySheetName = "summary"
+	monthsSheetName  = "months"
+	amountLen        = 14
+	descriptionLen   = 30
+	timeLen          = 20
+	categoryLen      = 20
+	currencyLen      = 10
+	guidLen          = 38
+	kindLen          = 10
+	countLen         = 10
+	monthLen         = 12
+	tagsLen          = 20
 )
 
 var (
+	// ErrInvalidExel is returned when the imported file is not a workbook with a header naming its columns.
+	ErrInvalidExel = errors.New("invalid exel")
+
+	// the format of the times in the exel file, the import reads them back to the second
+	exelTimeFormat = "yyyy-mm-dd hh:mm:ss"
+	// the format of the months the records are summed up by
+	exelMonthFormat = "mmm yyyy"
+
+	// the columns of the imported workbooks by their names in the header, the names are case-insensitive,
+	// the older reports call the time column created at
+	exelColumns = map[string]string{
+		"amount":      "amount",
+		"description": "description",
+		"spent at":    "spent_at",
+		"created at":  "spent_at",
+		"currency":    "currency",
+		"category":    "category",
+		"kind":        "kind",
+		"tags":        "tags",
+		"guid":        "guid",
+	}
+
 	// header style for the exel file
 	headerStyle = excelize.Style{
 		Font: &excelize.Font{
@@ -47,17 +85,102 @@ var (
 			{Type: "right", Color: "000000", Style: 1},
 		},
 	}
+
+	// time style for the exel file, the times are dates spreadsheets can sort and filter
+	timeStyle = excelize.Style{
+		Border:       dataStyle.Border,
+		CustomNumFmt: &exelTimeFormat,
+	}
+
+	// month style for the exel file, the months are the dates of their first days
+	monthStyle = excelize.Style{
+		Border:       dataStyle.Border,
+		CustomNumFmt: &exelMonthFormat,
+	}
 )
 
-// CreateExelFromRecords generates an Excel file from a slice of SpendingRecord objects.
+// exelStyles are the styles of a workbook, the amounts are numbers styled by their currencies
+type exelStyles struct {
+	f       *excelize.File
+	header  int
+	data    int
+	time    int
+	month   int
+	amounts map[string]int
+}
+
+// newExelStyles adds the styles to the workbook
+func newExelStyles(f *excelize.File) (*exelStyles, error) {
+	styles := &exelStyles{f: f, amounts: make(map[string]int)}
+
+	var err error
+	for _, style := range []struct {
+		id    *int
+		style *excelize.Style
+	}{
+		{&styles.header, &headerStyle},
+		{&styles.data, &dataStyle},
+		{&styles.time, &timeStyle},
+		{&styles.month, &monthStyle},
+	} {
+		if *style.id, err = f.NewStyle(style.style); err != nil {
+			return nil, err
+		}
+	}
+	return styles, nil
+}
+
+// amount returns the style of the amounts in the currency, it is added to the workbook on the first use
+func (s *exelStyles) amount(currency string) (int, error) {
+	if id, ok := s.amounts[currency]; ok {
+		return id, nil
+	}
+
+	format := amountFormat(currency)
+	id, err := s.f.NewStyle(&excelize.Style{Border: dataStyle.Border, CustomNumFmt: &format})
+	if err != nil {
+		return 0, err
+	}
+	s.amounts[currency] = id
+	return id, nil
+}
+
+// amountFormat is the number format of the amounts in the currency, with its minor units and its code
+func amountFormat(currency string) string {
+	format := "#,##0"
+	if scale := ftracker.CurrencyScale(cmp.Or(currency, ftracker.DefaultCurrency)); scale > 0 {
+		format += "." + strings.Repeat("0", scale)
+	}
+	return fmt.Sprintf(`%s "%s"`, format, cmp.Or(currency, ftracker.DefaultCurrency))
+}
+
+// exelAmount is the number the amount is written as, the shortest decimal of its minor units,
+// so the import reads it back exactly
+func exelAmount(amount ftracker.Money, currency string) float64 {
+	value, _ := strconv.ParseFloat(amount.Format(currency), 64)
+	return value
+}
+
+// exelMonth is the month of the time as the date of its first day
+func exelMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// CreateExelFromRecords generates an Excel report from a slice of SpendingRecord objects. The records sheet
+// lists the records with their tags, the amounts are numbers in the number formats of their currencies, the file can be edited
+// and imported back with ImportRecordsExel. The summary sheet totals the categories and the months sheet totals
+// the spending of the months by the categories, both with formulas over the base amounts of the records sheet,
+// and draws them in charts.
 //
 // Parameters:
 //   - recods: A slice of SpendingRecord objects containing the data to be written to the Excel file.
+//   - categories: The categories of the records, they give the category column and the kinds of the totals.
+//   - currency: The base currency of the user the base amounts of the records are in.
 //
 // Returns:
 //   - f: A pointer to the generated excelize.File containing the formatted data.
 //   - outputError: An error object if any issues occur during the file creation process.
-func (s RecordService) CreateExelFromRecords(recods []ftracker.SpendingRecord) (f *excelize.File, outputError error) {
+func (s RecordService) CreateExelFromRecords(recods []ftracker.SpendingRecord, categories []ftracker.SpendingCategory, currency string) (f *excelize.File, outputError error) {
 
 	f = excelize.NewFile()
 	defer func() {
@@ -74,36 +197,71 @@ func (s RecordService) CreateExelFromRecords(recods []ftracker.SpendingRecord) (
 	f.DeleteSheet("Sheet1")
 	f.SetActiveSheet(index)
 
-	headerStyle, err := f.NewStyle(&headerStyle)
+	styles, err := newExelStyles(f)
 	if err != nil {
 		outputError = fmt.Errorf("CreateExelFromRecords: %w", err)
 		return nil, outputError
 	}
-
-	dataStyle, err := f.NewStyle(&dataStyle)
+	baseStyle, err := styles.amount(currency)
 	if err != nil {
 		outputError = fmt.Errorf("CreateExelFromRecords: %w", err)
 		return nil, outputError
 	}
 
-	f.SetSheetRow(sheetName, "A1", &[]any{"Amount", "Description", "Created At"})
-	f.SetCellStyle(sheetName, "A1", "C1", headerStyle)
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		names[category.GUID] = category.Category
+	}
+
+	f.SetSheetRow(sheetName, "A1", &[]any{"Amount", "Description", "Spent At", "Currency", "Category", "GUID", "Base Amount", "Month", "Tags"})
+	f.SetCellStyle(sheetName, "A1", "I1", styles.header)
 
 	for i, record := range recods {
-		start := fmt.Sprintf("A%d", i+2)
-		end := fmt.Sprintf("C%d", i+2)
-		left, right := utils.ExtractAmountParts(record.Amount)
-		f.SetSheetRow(sheetName, start, &[]any{
-			fmt.Sprintf("%s.%s", left, right),
+		row := i + 2
+		amountStyle, err := styles.amount(record.Currency)
+		if err != nil {
+			outputError = fmt.Errorf("CreateExelFromRecords: %w", err)
+			return nil, outputError
+		}
+
+		spentAt := record.SpentAt.Truncate(time.Second)
+		f.SetSheetRow(sheetName, fmt.Sprintf("A%d", row), &[]any{
+			exelAmount(record.Amount, record.Currency),
 			record.Description,
-			record.CreatedAt.Format(formatOut),
+			spentAt,
+			record.Currency,
+			names[record.CategoryGUID],
+			record.GUID.String(),
+			exelAmount(record.BaseAmount, currency),
+			exelMonth(spentAt),
+			formatExelTags(record.Tags),
 		})
-		f.SetCellStyle(sheetName, start, end, dataStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("I%d", row), styles.data)
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), amountStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("C%d", row), fmt.Sprintf("C%d", row), styles.time)
+		f.SetCellStyle(sheetName, fmt.Sprintf("G%d", row), fmt.Sprintf("G%d", row), baseStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), styles.month)
 	}
 
 	f.SetColWidth(sheetName, "A", "A", amountLen)
 	f.SetColWidth(sheetName, "B", "B", descriptionLen)
 	f.SetColWidth(sheetName, "C", "C", timeLen)
+	f.SetColWidth(sheetName, "D", "D", currencyLen)
+	f.SetColWidth(sheetName, "E", "E", categoryLen)
+	f.SetColWidth(sheetName, "F", "F", guidLen)
+	f.SetColWidth(sheetName, "G", "G", amountLen)
+	f.SetColWidth(sheetName, "H", "H", monthLen)
+	f.SetColWidth(sheetName, "I", "I", tagsLen)
+
+	report := newExelReport(recods, categories, baseStyle)
+	if err := report.writeSummary(f, styles); err != nil {
+		outputError = fmt.Errorf("CreateExelFromRecords: %w", err)
+		return nil, outputError
+	}
+	if err := report.writeMonths(f, styles); err != nil {
+		outputError = fmt.Errorf("CreateExelFromRecords: %w", err)
+		return nil, outputError
+	}
 
 	return f, nil
 }
@@ -112,11 +270,12 @@ func (s RecordService) CreateExelFromRecords(recods []ftracker.SpendingRecord) (
 //
 // Parameters:
 //   - categories: A slice of SpendingCategory objects containing the data to be written to the Excel file.
+//   - currency: The base currency of the user the amounts of the categories are in.
 //
 // Returns:
 //   - f: A pointer to the generated Excel file.
 //   - outputError: An error object if any issues occur during the file creation process.
-func (s CategoryService) CreateExelFromCategories(categories []ftracker.SpendingCategory) (f *excelize.File, outputError error) {
+func (s CategoryService) CreateExelFromCategories(categories []ftracker.SpendingCategory, currency string) (f *excelize.File, outputError error) {
 	f = excelize.NewFile()
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -132,31 +291,30 @@ func (s CategoryService) CreateExelFromCategories(categories []ftracker.Spending
 	f.DeleteSheet("Sheet1")
 	f.SetActiveSheet(index)
 
-	headerStyle, err := f.NewStyle(&headerStyle)
+	styles, err := newExelStyles(f)
 	if err != nil {
 		outputError = fmt.Errorf("CreateExelFromCategories: %w", err)
 		return nil, outputError
 	}
-
-	dataStyle, err := f.NewStyle(&dataStyle)
+	amountStyle, err := styles.amount(currency)
 	if err != nil {
 		outputError = fmt.Errorf("CreateExelFromCategories: %w", err)
 		return nil, outputError
 	}
 
 	f.SetSheetRow(sheetName, "A1", &[]any{"Category", "Description", "Amount"})
-	f.SetCellStyle(sheetName, "A1", "C1", headerStyle)
+	f.SetCellStyle(sheetName, "A1", "C1", styles.header)
 
 	for i, category := range categories {
 		start := fmt.Sprintf("A%d", i+2)
 		end := fmt.Sprintf("C%d", i+2)
-		left, right := utils.ExtractAmountParts(category.Amount)
 		f.SetSheetRow(sheetName, start, &[]any{
 			category.Category,
 			category.Description,
-			fmt.Sprintf("%s.%s", left, right),
+			exelAmount(category.Amount, currency),
 		})
-		f.SetCellStyle(sheetName, start, end, dataStyle)
+		f.SetCellStyle(sheetName, start, end, styles.data)
+		f.SetCellStyle(sheetName, end, end, amountStyle)
 	}
 
 	f.SetColWidth(sheetName, "A", "A", categoryLen)
@@ -165,3 +323,284 @@ func (s CategoryService) CreateExelFromCategories(categories []ftracker.Spending
 
 	return f, nil
 }
+
+// ImportRecordsExel imports the records of a workbook, usually the exported report edited by the user.
+// The rows with a GUID update the records of the user, the rows without one add new records, the records
+// missing from the sheet are kept. The records sheet is read, or the first sheet if there is none, its header
+// names the columns in any order like the export does: Category and Amount are required, Spent At (or Created At),
+// Currency, Description, Kind, Tags and GUID are optional, the other columns are skipped. An empty time keeps
+// the time of an updated record, a sheet without the Tags column keeps the tags of the updated records, but the hashtags of a changed description replace the old ones. The changes are listed row by row, so a dry run shows them before committing.
+// The new categories, the new records and the changed ones are written in one transaction, so either the whole
+// workbook is imported or nothing.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repositories.
+//   - userGUID: The GUID of the user the records belong to.
+//   - r: The content of the workbook.
+//   - opts: The options of the import, see ImportOptions.
+//
+// Returns:
+//   - ImportReport: The numbers of the added, the updated, the unchanged and the rejected rows, the changes
+//     of the rows and the categories the user didn't have.
+//   - error: ErrInvalidExel if the file is not a workbook with a valid header, ErrTooManyRows if its sheet
+//     has more than MaxImportRows rows, an error if the repositories fail, or nil if successful,
+//     the rejected rows are not errors.
+func (s *ImportService) ImportRecordsExel(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	if userGUID == uuid.Nil {
+		return ImportReport{}, ErrOwnerRequired
+	}
+	opts.Currency = cmp.Or(opts.Currency, ftracker.DefaultCurrency)
+
+	rows, rejected, err := readExelRows(r, opts.Currency)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", err)
+	}
+	report := ImportReport{Rejected: rejected}
+
+	rows, err = s.rejectUnknownCurrencies(ctx, rows, &report)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", err)
+	}
+
+	rows, existing, err := s.rejectUnknownRecords(ctx, userGUID, rows, &report)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", err)
+	}
+
+	categories, err := s.categories.GetCategories(ctx, userGUID, repository.CategoryOptions{})
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", err)
+	}
+	valid, created := mapImportCategories(rows, categories, opts, &report)
+
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		names[category.GUID] = category.Category
+	}
+
+	var added, changed []importRow
+	for _, row := range valid {
+		if row.record.GUID == uuid.Nil {
+			report.Changes = append(report.Changes, RowChange{Line: row.line, Fields: diffExelRow(ftracker.SpendingRecord{}, "", row)})
+			added = append(added, row)
+			continue
+		}
+
+		old := existing[row.record.GUID]
+		if row.keepTags {
+			row.record.Tags = retagRecord(old.Tags, old.Description, row.record.Description)
+		}
+		fields := diffExelRow(old, names[old.CategoryGUID], row)
+		if len(fields) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Changes = append(report.Changes, RowChange{Line: row.line, GUID: row.record.GUID, Fields: fields})
+		changed = append(changed, row)
+	}
+
+	if opts.DryRun || len(added)+len(changed) == 0 {
+		report.Imported, report.Updated = len(added), len(changed)
+		return report, nil
+	}
+
+	createdByName := assignImportCategories(created)
+	records := func(rows []importRow) []ftracker.SpendingRecord {
+		records := make([]ftracker.SpendingRecord, len(rows))
+		for i, row := range rows {
+			if row.record.CategoryGUID == uuid.Nil {
+				row.record.CategoryGUID = createdByName[row.category]
+			}
+			records[i] = row.record
+		}
+		return records
+	}
+
+	// the new categories, the added and the updated records are written in one transaction
+	if _, err := s.records.ImportRecords(ctx, userGUID, created, withTags(records(added)), withTags(records(changed))); err != nil {
+		return ImportReport{}, fmt.Errorf("ImportRecordsExel: %w", err)
+	}
+
+	report.Imported, report.Updated = len(added), len(changed)
+	return report, nil
+}
+
+// rejectUnknownRecords rejects the rows with the GUIDs of the records the user doesn't have,
+// it returns the rows left and the records of the rest by their GUIDs
+func (s *ImportService) rejectUnknownRecords(ctx context.Context, userGUID uuid.UUID, rows []importRow, report *ImportReport) ([]importRow, map[uuid.UUID]ftracker.SpendingRecord, error) {
+	var guids []uuid.UUID
+	for _, row := range rows {
+		if row.record.GUID != uuid.Nil {
+			guids = append(guids, row.record.GUID)
+		}
+	}
+	if len(guids) == 0 {
+		return rows, nil, nil
+	}
+
+	records, err := s.records.GetRecords(ctx, userGUID, repository.RecordOptions{GUIDs: guids})
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := make(map[uuid.UUID]ftracker.SpendingRecord, len(records))
+	for _, record := range records {
+		existing[record.GUID] = record
+	}
+
+	left := rows[:0]
+	for _, row := range rows {
+		if _, ok := existing[row.record.GUID]; row.record.GUID != uuid.Nil && !ok {
+			report.reject(row.line, "unknown record %s", row.record.GUID)
+			continue
+		}
+		left = append(left, row)
+	}
+	return left, existing, nil
+}
+
+// readExelRows reads and validates the rows of the imported workbook,
+// it returns the valid rows and the rejected ones
+func readExelRows(r io.Reader, currency string) ([]importRow, []RejectedRow, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidExel, err)
+	}
+	defer f.Close()
+
+	sheet := sheetName
+	if index, err := f.GetSheetIndex(sheetName); err != nil || index < 0 {
+		sheet = f.GetSheetName(0)
+	}
+
+	// the raw values are read, so the times are the serial numbers of the dates whatever their format is
+	cells, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidExel, err)
+	}
+	if len(cells) == 0 {
+		return nil, nil, fmt.Errorf("%w: no header", ErrInvalidExel)
+	}
+	if len(cells)-1 > MaxImportRows {
+		return nil, nil, fmt.Errorf("%w: more than %d", ErrTooManyRows, MaxImportRows)
+	}
+
+	columns := make(map[string]int, len(cells[0]))
+	for i, name := range cells[0] {
+		if column, ok := exelColumns[strings.Join(strings.Fields(strings.ToLower(name)), " ")]; ok {
+			columns[column] = i
+		}
+	}
+	_, hasTags := columns["tags"]
+	for _, required := range []string{"category", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: no %s column", ErrInvalidExel, required)
+		}
+	}
+
+	var (
+		rows   []importRow
+		report ImportReport
+		lines  = make(map[uuid.UUID]int)
+	)
+	for i, fields := range cells[1:] {
+		line := i + 2
+		if strings.TrimSpace(strings.Join(fields, "")) == "" {
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			value := strings.TrimSpace(fields[i])
+			if name == "spent_at" {
+				value = exelTime(value)
+			}
+			return value
+		}
+
+		row, reason := parseImportRow(field, currency)
+		if reason != "" {
+			report.reject(line, "%s", reason)
+			continue
+		}
+		row.keepTags = !hasTags
+
+		if guid := field("guid"); guid != "" {
+			row.record.GUID, err = uuid.Parse(guid)
+			if err != nil {
+				report.reject(line, "invalid guid %s", guid)
+				continue
+			}
+			if first, ok := lines[row.record.GUID]; ok {
+				report.reject(line, "guid %s is on the line %d too", guid, first)
+				continue
+			}
+			lines[row.record.GUID] = line
+		}
+
+		row.line = line
+		rows = append(rows, row)
+	}
+
+	return rows, report.Rejected, nil
+}
+
+// exelTime turns the serial number of a date cell into a time in the export layout,
+// the times typed as text are left for parseCSVTime
+func exelTime(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	t, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return value
+	}
+	return t.Round(time.Second).Format(csvTimeLayout)
+}
+
+// formatExelTags is the text of the tags in the exported workbook, sorted and separated with spaces
+func formatExelTags(tags []string) string {
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// diffExelRow lists the columns of the row differing from the record, the values are formatted
+// like in the exported workbook, every filled column differs from the zero record of a new row
+func diffExelRow(old ftracker.SpendingRecord, oldCategory string, row importRow) []FieldChange {
+	var (
+		fields []FieldChange
+		record = row.record
+	)
+	diff := func(column, oldValue, newValue string) {
+		if oldValue != newValue {
+			fields = append(fields, FieldChange{Column: column, Old: oldValue, New: newValue})
+		}
+	}
+
+	oldAmount := ""
+	if old.GUID != uuid.Nil {
+		oldAmount = old.Amount.Format(old.Currency)
+	}
+	diff("Category", oldCategory, row.category)
+	diff("Amount", oldAmount, record.Amount.Format(record.Currency))
+	diff("Currency", old.Currency, record.Currency)
+	diff("Description", old.Description, record.Description)
+
+	// the tags are compared as they are stored, with the hashtags of the description
+	diff("Tags", formatExelTags(old.Tags), formatExelTags(withTags([]ftracker.SpendingRecord{record})[0].Tags))
+
+	// an empty time keeps the time of the record
+	if !record.SpentAt.IsZero() {
+		oldTime := ""
+		if !old.SpentAt.IsZero() {
+			oldTime = old.SpentAt.Truncate(time.Second).Format(csvTimeLayout)
+		}
+		diff("Spent At", oldTime, record.SpentAt.Format(csvTimeLayout))
+	}
+
+	return fields
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/xuri/excelize/v2"
+)
+
+// exelReport is the analysis of the records sheet, the totals are formulas over its base amounts,
+// so they follow the edits of the sheet
+type exelReport struct {
+	// categories of the records, the spending ones first, both by name
+	categories []ftracker.SpendingCategory
+	// spending is the number of the spending categories
+	spending int
+	// months of the records in ascending order
+	months []time.Time
+	// last is the last row of the records sheet
+	last int
+	// baseStyle is the style of the amounts in the base currency
+	baseStyle int
+}
+
+// newExelReport collects the categories and the months of the records
+func newExelReport(records []ftracker.SpendingRecord, categories []ftracker.SpendingCategory, baseStyle int) *exelReport {
+	used := make(map[uuid.UUID]struct{}, len(categories))
+	months := make(map[time.Time]struct{})
+	for _, record := range records {
+		used[record.CategoryGUID] = struct{}{}
+		months[exelMonth(record.SpentAt.Truncate(time.Second))] = struct{}{}
+	}
+
+	report := &exelReport{last: len(records) + 1, baseStyle: baseStyle}
+	for _, category := range categories {
+		if _, ok := used[category.GUID]; !ok {
+			continue
+		}
+		if category.Kind != ftracker.KindIncome {
+			report.spending++
+		}
+		report.categories = append(report.categories, category)
+	}
+	sort.SliceStable(report.categories, func(i, j int) bool {
+		a, b := report.categories[i], report.categories[j]
+		if isIncome(a) != isIncome(b) {
+			return !isIncome(a)
+		}
+		return a.Category < b.Category
+	})
+
+	for month := range months {
+		report.months = append(report.months, month)
+	}
+	sort.Slice(report.months, func(i, j int) bool { return report.months[i].Before(report.months[j]) })
+
+	return report
+}
+
+// recordsRange is the absolute range of the column of the records sheet without its header
+func (r *exelReport) recordsRange(column string) string {
+	return fmt.Sprintf("%s!$%s$2:$%s$%d", sheetName, column, column, r.last)
+}
+
+// writeSummary writes the summary sheet, the number of the records and the total of every category
+// with the totals of the spending and the income below, the spending is drawn in a pie chart
+func (r *exelReport) writeSummary(f *excelize.File, styles *exelStyles) error {
+	if _, err := f.NewSheet(summarySheetName); err != nil {
+		return err
+	}
+
+	f.SetSheetRow(summarySheetName, "A1", &[]any{"Category", "Kind", "Records", "Total"})
+	f.SetCellStyle(summarySheetName, "A1", "D1", styles.header)
+
+	categories, bases := r.recordsRange("E"), r.recordsRange("G")
+	for i, category := range r.categories {
+		row := i + 2
+		f.SetSheetRow(summarySheetName, fmt.Sprintf("A%d", row), &[]any{category.Category, kindOf(category)})
+		f.SetCellFormula(summarySheetName, fmt.Sprintf("C%d", row), fmt.Sprintf("COUNTIF(%s,A%d)", categories, row))
+		f.SetCellFormula(summarySheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("SUMIF(%s,A%d,%s)", categories, row, bases))
+		f.SetCellStyle(summarySheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("D%d", row), styles.data)
+		f.SetCellStyle(summarySheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("D%d", row), r.baseStyle)
+	}
+
+	// the totals are a row apart from the categories
+	last := len(r.categories) + 1
+	totals := last + 2
+	kinds, amounts := fmt.Sprintf("$B$2:$B$%d", max(last, 2)), fmt.Sprintf("$D$2:$D$%d", max(last, 2))
+	f.SetCellValue(summarySheetName, fmt.Sprintf("A%d", totals), "Spending")
+	f.SetCellFormula(summarySheetName, fmt.Sprintf("D%d", totals), fmt.Sprintf(`SUMIF(%s,"%s",%s)`, kinds, ftracker.KindSpending, amounts))
+	f.SetCellValue(summarySheetName, fmt.Sprintf("A%d", totals+1), "Income")
+	f.SetCellFormula(summarySheetName, fmt.Sprintf("D%d", totals+1), fmt.Sprintf(`SUMIF(%s,"%s",%s)`, kinds, ftracker.KindIncome, amounts))
+	f.SetCellValue(summarySheetName, fmt.Sprintf("A%d", totals+2), "Balance")
+	f.SetCellFormula(summarySheetName, fmt.Sprintf("D%d", totals+2), fmt.Sprintf("D%d-D%d", totals+1, totals))
+	f.SetCellStyle(summarySheetName, fmt.Sprintf("A%d", totals), fmt.Sprintf("A%d", totals+2), styles.header)
+	f.SetCellStyle(summarySheetName, fmt.Sprintf("D%d", totals), fmt.Sprintf("D%d", totals+2), r.baseStyle)
+
+	f.SetColWidth(summarySheetName, "A", "A", categoryLen)
+	f.SetColWidth(summarySheetName, "B", "B", kindLen)
+	f.SetColWidth(summarySheetName, "C", "C", countLen)
+	f.SetColWidth(summarySheetName, "D", "D", amountLen)
+
+	if r.spending == 0 {
+		return nil
+	}
+	return f.AddChart(summarySheetName, "F2", &excelize.Chart{
+		Type: excelize.Pie,
+		Series: []excelize.ChartSeries{{
+			Name:       fmt.Sprintf("%s!$D$1", summarySheetName),
+			Categories: fmt.Sprintf("%s!$A$2:$A$%d", summarySheetName, r.spending+1),
+			Values:     fmt.Sprintf("%s!$D$2:$D$%d", summarySheetName, r.spending+1),
+		}},
+		Title:    []excelize.RichTextRun{{Text: "Spending by category"}},
+		PlotArea: excelize.ChartPlotArea{ShowPercent: true},
+	})
+}
+
+// writeMonths writes the months sheet, the spending of every month by the spending categories
+// with the total of the month on the right, the months are drawn in a stacked column chart
+func (r *exelReport) writeMonths(f *excelize.File, styles *exelStyles) error {
+	if _, err := f.NewSheet(monthsSheetName); err != nil {
+		return err
+	}
+
+	header := []any{"Month"}
+	for _, category := range r.categories[:r.spending] {
+		header = append(header, category.Category)
+	}
+	header = append(header, "Total")
+	// the last category column, the total is right after it
+	lastColumn, err := excelize.ColumnNumberToName(r.spending + 1)
+	if err != nil {
+		return err
+	}
+	totalColumn, err := excelize.ColumnNumberToName(r.spending + 2)
+	if err != nil {
+		return err
+	}
+
+	f.SetSheetRow(monthsSheetName, "A1", &header)
+	f.SetCellStyle(monthsSheetName, "A1", totalColumn+"1", styles.header)
+
+	categories, bases, months := r.recordsRange("E"), r.recordsRange("G"), r.recordsRange("H")
+	for i, month := range r.months {
+		row := i + 2
+		f.SetCellValue(monthsSheetName, fmt.Sprintf("A%d", row), month)
+		for j := range r.spending {
+			column, _ := excelize.ColumnNumberToName(j + 2)
+			f.SetCellFormula(monthsSheetName, fmt.Sprintf("%s%d", column, row), fmt.Sprintf(
+				"SUMIFS(%s,%s,%s$1,%s,$A%d)", bases, categories, column, months, row,
+			))
+		}
+		total := "0"
+		if r.spending > 0 {
+			total = fmt.Sprintf("SUM(B%d:%s%d)", row, lastColumn, row)
+		}
+		f.SetCellFormula(monthsSheetName, fmt.Sprintf("%s%d", totalColumn, row), total)
+		f.SetCellStyle(monthsSheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), styles.month)
+		f.SetCellStyle(monthsSheetName, fmt.Sprintf("B%d", row), fmt.Sprintf("%s%d", totalColumn, row), r.baseStyle)
+	}
+
+	f.SetColWidth(monthsSheetName, "A", "A", monthLen)
+	f.SetColWidth(monthsSheetName, "B", totalColumn, amountLen)
+
+	if r.spending == 0 || len(r.months) == 0 {
+		return nil
+	}
+	last := len(r.months) + 1
+	series := make([]excelize.ChartSeries, r.spending)
+	for i := range series {
+		column, _ := excelize.ColumnNumberToName(i + 2)
+		series[i] = excelize.ChartSeries{
+			Name:       fmt.Sprintf("%s!$%s$1", monthsSheetName, column),
+			Categories: fmt.Sprintf("%s!$A$2:$A$%d", monthsSheetName, last),
+			Values:     fmt.Sprintf("%s!$%s$2:$%s$%d", monthsSheetName, column, column, last),
+		}
+	}
+	anchor, err := excelize.ColumnNumberToName(r.spending + 4)
+	if err != nil {
+		return err
+	}
+	return f.AddChart(monthsSheetName, anchor+"2", &excelize.Chart{
+		Type:   excelize.ColStacked,
+		Series: series,
+		Title:  []excelize.RichTextRun{{Text: "Spending by month"}},
+		Legend: excelize.ChartLegend{Position: "bottom"},
+	})
+}
+
+// isIncome reports whether the category is an income one, the categories without a kind are spending ones
+func isIncome(category ftracker.SpendingCategory) bool {
+	return category.Kind == ftracker.KindIncome
+}
+
+// kindOf is the kind of the category the totals are summed up by
+func kindOf(category ftracker.SpendingCategory) string {
+	if isIncome(category) {
+		return ftracker.KindIncome
+	}
+	return ftracker.KindSpending
+}
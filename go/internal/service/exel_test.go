@@ -1,54 +1,84 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
-	"github.com/iv-sukhanov/finance_tracker/internal/utils"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+	repositorymock "github.com/iv-sukhanov/finance_tracker/internal/repository/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestExelService_CreateExelFromRecords(t *testing.T) {
 
 	initTime, _ := time.Parse("2006-01-02", "2024-11-26")
 	s := RecordService{}
+	categories := []ftracker.SpendingCategory{
+		{GUID: uuid.MustParse("00000000-0000-0000-0000-000000000011"), Category: "food"},
+		{GUID: uuid.MustParse("00000000-0000-0000-0000-000000000021"), Category: "fun"},
+	}
 
 	tests := []struct {
 		name    string
 		recods  []ftracker.SpendingRecord
+		amounts [][2]string
+		tags    []string
 		wantErr bool
 	}{
 		{
-			name: "Ok",
+			name:    "Ok",
+			amounts: [][2]string{{"12.34 EUR", "12.34 EUR"}, {"21.23 GBP", "25.12 EUR"}, {"1,200.00 EUR", "1,200.00 EUR"}},
+			tags:    []string{"", "bar beer", ""},
 			recods: []ftracker.SpendingRecord{
 				{
-					Amount:      1234,
-					Description: "zorbas cookies",
-					CreatedAt:   initTime,
+					GUID:         uuid.MustParse("00000000-0000-0000-0000-000000000111"),
+					CategoryGUID: categories[0].GUID,
+					Amount:       1234,
+					BaseAmount:   1234,
+					Currency:     "EUR",
+					Description:  "zorbas cookies",
+					SpentAt:      initTime,
 				},
 				{
-					Amount:      2123,
-					Description: "some beer in brewfellas",
-					CreatedAt:   initTime.Add(1 * time.Hour),
+					GUID:         uuid.MustParse("00000000-0000-0000-0000-000000000211"),
+					CategoryGUID: categories[1].GUID,
+					Amount:       2123,
+					BaseAmount:   2512,
+					Currency:     "GBP",
+					Description:  "some beer in brewfellas",
+					Tags:         []string{"beer", "bar"},
+					SpentAt:      initTime.Add(1*time.Hour + 500*time.Millisecond),
 				},
 				{
-					Amount:      1200,
-					Description: "4 tequila shots in karona karaoke bar",
-					CreatedAt:   initTime.Add(3 * time.Hour),
+					GUID:         uuid.MustParse("00000000-0000-0000-0000-000000000311"),
+					CategoryGUID: categories[1].GUID,
+					Amount:       120000,
+					BaseAmount:   120000,
+					Currency:     "EUR",
+					Description:  "4 tequila shots in karona karaoke bar",
+					SpentAt:      initTime.Add(3 * time.Hour),
 				},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			file, err := s.CreateExelFromRecords(tt.recods)
+			file, err := s.CreateExelFromRecords(tt.recods, categories, "EUR")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ExelService.CreateExelFromRecords() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			for i := range len(tt.recods) + 1 {
-				for j := range 3 {
+				for j := range 9 {
 					curCell := fmt.Sprintf("%c%d", 'A'+j, i+1)
 					content, err := file.GetCellValue(sheetName, curCell)
 					var expectedContent string
@@ -59,27 +89,142 @@ func TestExelService_CreateExelFromRecords(t *testing.T) {
 						case 1:
 							expectedContent = "Description"
 						case 2:
-							expectedContent = "Created At"
+							expectedContent = "Spent At"
+						case 3:
+							expectedContent = "Currency"
+						case 4:
+							expectedContent = "Category"
+						case 5:
+							expectedContent = "GUID"
+						case 6:
+							expectedContent = "Base Amount"
+						case 7:
+							expectedContent = "Month"
+						case 8:
+							expectedContent = "Tags"
 						}
 					} else {
 						switch j {
 						case 0:
-							left, right := utils.ExtractAmountParts(tt.recods[i-1].Amount)
-							expectedContent = fmt.Sprintf("%s.%s", left, right)
+							expectedContent = tt.amounts[i-1][0]
 						case 1:
 							expectedContent = tt.recods[i-1].Description
 						case 2:
-							expectedContent = tt.recods[i-1].CreatedAt.Format(formatOut)
+							expectedContent = tt.recods[i-1].SpentAt.Truncate(time.Second).Format(csvTimeLayout)
+						case 3:
+							expectedContent = tt.recods[i-1].Currency
+						case 4:
+							for _, category := range categories {
+								if category.GUID == tt.recods[i-1].CategoryGUID {
+									expectedContent = category.Category
+								}
+							}
+						case 5:
+							expectedContent = tt.recods[i-1].GUID.String()
+						case 6:
+							expectedContent = tt.amounts[i-1][1]
+						case 7:
+							expectedContent = tt.recods[i-1].SpentAt.Format("Jan 2006")
+						case 8:
+							expectedContent = tt.tags[i-1]
 						}
 					}
 					require.NoError(t, err)
 					require.Equal(t, expectedContent, content)
 				}
 			}
+
+			// the amounts are numbers the import reads back exactly
+			for i, record := range tt.recods {
+				content, err := file.GetCellValue(sheetName, fmt.Sprintf("A%d", i+2), excelize.Options{RawCellValue: true})
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprint(exelAmount(record.Amount, record.Currency)), content)
+			}
 		})
 	}
 }
 
+func Test_CreateExelFromRecords_report(t *testing.T) {
+
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	fun := ftracker.SpendingCategory{GUID: uuid.New(), Category: "fun", Kind: ftracker.KindSpending}
+	salary := ftracker.SpendingCategory{GUID: uuid.New(), Category: "salary", Kind: ftracker.KindIncome}
+	unused := ftracker.SpendingCategory{GUID: uuid.New(), Category: "unused", Kind: ftracker.KindSpending}
+	november := time.Date(2024, 11, 26, 18, 30, 0, 0, time.UTC)
+	december := time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)
+	records := []ftracker.SpendingRecord{
+		{GUID: uuid.New(), CategoryGUID: food.GUID, Amount: 1234, BaseAmount: 1234, Currency: "EUR", SpentAt: november},
+		{GUID: uuid.New(), CategoryGUID: fun.GUID, Amount: 2000, BaseAmount: 1850, Currency: "USD", SpentAt: november},
+		{GUID: uuid.New(), CategoryGUID: salary.GUID, Amount: 250000, BaseAmount: 250000, Currency: "EUR", SpentAt: november},
+		{GUID: uuid.New(), CategoryGUID: food.GUID, Amount: 766, BaseAmount: 766, Currency: "EUR", SpentAt: december},
+	}
+
+	file, err := RecordService{}.CreateExelFromRecords(records, []ftracker.SpendingCategory{unused, salary, fun, food}, "EUR")
+	require.NoError(t, err)
+	var buffer bytes.Buffer
+	require.NoError(t, file.Write(&buffer))
+
+	file, err = excelize.OpenReader(bytes.NewReader(buffer.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, []string{sheetName, summarySheetName, monthsSheetName}, file.GetSheetList())
+	require.Equal(t, sheetName, file.GetSheetName(file.GetActiveSheetIndex()))
+
+	formula, err := file.GetCellFormula(summarySheetName, "D2")
+	require.NoError(t, err)
+	require.Equal(t, "SUMIF(records!$E$2:$E$5,A2,records!$G$2:$G$5)", formula)
+	formula, err = file.GetCellFormula(monthsSheetName, "C3")
+	require.NoError(t, err)
+	require.Equal(t, "SUMIFS(records!$G$2:$G$5,records!$E$2:$E$5,C$1,records!$H$2:$H$5,$A3)", formula)
+
+	for _, tt := range []struct {
+		sheet string
+		cell  string
+		want  string
+	}{
+		// the spending categories come first, the categories without records are left out
+		{summarySheetName, "A2", "food"},
+		{summarySheetName, "A3", "fun"},
+		{summarySheetName, "A4", "salary"},
+		{summarySheetName, "B4", ftracker.KindIncome},
+		{summarySheetName, "A5", ""},
+		{summarySheetName, "A8", "Balance"},
+		{summarySheetName, "C2", "2"},
+		{summarySheetName, "C4", "1"},
+		{summarySheetName, "D2", "20.00 EUR"},
+		{summarySheetName, "D3", "18.50 EUR"},
+		{summarySheetName, "D4", "2,500.00 EUR"},
+		{summarySheetName, "D6", "38.50 EUR"},
+		{summarySheetName, "D7", "2,500.00 EUR"},
+		{summarySheetName, "D8", "2,461.50 EUR"},
+		{monthsSheetName, "A1", "Month"},
+		{monthsSheetName, "B1", "food"},
+		{monthsSheetName, "C1", "fun"},
+		{monthsSheetName, "D1", "Total"},
+		{monthsSheetName, "A2", "Nov 2024"},
+		{monthsSheetName, "B2", "12.34 EUR"},
+		{monthsSheetName, "C2", "18.50 EUR"},
+		{monthsSheetName, "D2", "30.84 EUR"},
+		{monthsSheetName, "A3", "Dec 2024"},
+		{monthsSheetName, "B3", "7.66 EUR"},
+		{monthsSheetName, "C3", "0.00 EUR"},
+		{monthsSheetName, "D3", "7.66 EUR"},
+	} {
+		got, err := file.CalcCellValue(tt.sheet, tt.cell)
+		require.NoError(t, err, "%s!%s", tt.sheet, tt.cell)
+		require.Equal(t, tt.want, got, "%s!%s", tt.sheet, tt.cell)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	charts := 0
+	for _, part := range reader.File {
+		if strings.HasPrefix(part.Name, "xl/charts/chart") {
+			charts++
+		}
+	}
+	require.Equal(t, 2, charts)
+}
+
 func TestExelService_CreateExelFromCategories(t *testing.T) {
 
 	s := CategoryService{}
@@ -87,6 +232,8 @@ func TestExelService_CreateExelFromCategories(t *testing.T) {
 	tests := []struct {
 		name       string
 		categories []ftracker.SpendingCategory
+		currency   string
+		amounts    []string
 		wantErr    bool
 	}{
 		{
@@ -108,11 +255,25 @@ func TestExelService_CreateExelFromCategories(t *testing.T) {
 					Amount:      0,
 				},
 			},
+			currency: ftracker.DefaultCurrency,
+			amounts:  []string{"12.34 EUR", "230.02 EUR", "0.00 EUR"},
+		},
+		{
+			name: "Zero_scale",
+			categories: []ftracker.SpendingCategory{
+				{
+					Category:    "Food",
+					Description: "money spent on ready food like delivery or restaurant",
+					Amount:      1234,
+				},
+			},
+			currency: "JPY",
+			amounts:  []string{"1,234 JPY"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			file, err := s.CreateExelFromCategories(tt.categories)
+			file, err := s.CreateExelFromCategories(tt.categories, tt.currency)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ExelService.CreateExelFromCategories() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -137,8 +298,7 @@ func TestExelService_CreateExelFromCategories(t *testing.T) {
 						case 1:
 							expectedContent = tt.categories[i-1].Description
 						case 2:
-							left, right := utils.ExtractAmountParts(tt.categories[i-1].Amount)
-							expectedContent = fmt.Sprintf("%s.%s", left, right)
+							expectedContent = tt.amounts[i-1]
 						}
 					}
 					require.NoError(t, err)
@@ -148,3 +308,253 @@ func TestExelService_CreateExelFromCategories(t *testing.T) {
 		})
 	}
 }
+
+// newWorkbook writes the rows into the only sheet of a new workbook, the nil rows are left empty
+func newWorkbook(t *testing.T, sheet string, rows ...[]any) []byte {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	require.NoError(t, f.SetSheetName("Sheet1", sheet))
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, err)
+		require.NoError(t, f.SetSheetRow(sheet, cell, &row))
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(t, f.Write(&buffer))
+	return buffer.Bytes()
+}
+
+func Test_ImportRecordsExel_round_trip(t *testing.T) {
+
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	fun := ftracker.SpendingCategory{GUID: uuid.New(), Category: "fun", Kind: ftracker.KindSpending}
+	spentAt := time.Date(2024, 11, 26, 18, 30, 15, 250000000, time.UTC)
+	records := []ftracker.SpendingRecord{
+		{GUID: uuid.New(), CategoryGUID: food.GUID, Amount: 1234, Currency: "EUR", Description: "zorbas cookies", SpentAt: spentAt},
+		{GUID: uuid.New(), CategoryGUID: fun.GUID, Amount: 2123, Currency: "USD", Description: "beer #bar", SpentAt: spentAt.Add(time.Hour), Tags: []string{"bar", "drinks"}},
+		{GUID: uuid.New(), CategoryGUID: fun.GUID, Amount: 1200, Currency: "EUR", Description: "karaoke", SpentAt: spentAt.Add(2 * time.Hour)},
+	}
+
+	file, err := RecordService{}.CreateExelFromRecords(records, []ftracker.SpendingCategory{food, fun}, "EUR")
+	require.NoError(t, err)
+
+	// the first record gets a tag, the second one another amount, the third one goes to food, and a new row is added
+	require.NoError(t, file.SetCellValue(sheetName, "I2", "Sweets"))
+	require.NoError(t, file.SetCellValue(sheetName, "A3", "25.00"))
+	require.NoError(t, file.SetCellValue(sheetName, "E4", "food"))
+	require.NoError(t, file.SetSheetRow(sheetName, "A5", &[]any{"3.5", "bread", nil, "EUR", "food"}))
+	var buffer bytes.Buffer
+	require.NoError(t, file.Write(&buffer))
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+	categories := repositorymock.NewMockSpendingCategory(cntr)
+	recordsRepo := repositorymock.NewMockSpendingRecord(cntr)
+	rates := repositorymock.NewMockExchangeRate(cntr)
+
+	rates.EXPECT().GetExchangeRates(gomock.Any(), []string{"EUR", "USD"}).
+		Return([]ftracker.ExchangeRate{{Currency: "EUR"}, {Currency: "USD"}}, nil)
+	recordsRepo.EXPECT().GetRecords(gomock.Any(), userGUID, repository.RecordOptions{GUIDs: []uuid.UUID{records[0].GUID, records[1].GUID, records[2].GUID}}).
+		Return(records, nil)
+	categories.EXPECT().GetCategories(gomock.Any(), userGUID, repository.CategoryOptions{}).
+		Return([]ftracker.SpendingCategory{food, fun}, nil)
+	recordsRepo.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(0), []ftracker.SpendingRecord{
+		{CategoryGUID: food.GUID, Amount: 350, Currency: "EUR", Description: "bread"},
+	}, []ftracker.SpendingRecord{
+		{GUID: records[0].GUID, CategoryGUID: food.GUID, Amount: 1234, Currency: "EUR", Description: "zorbas cookies", SpentAt: spentAt.Truncate(time.Second), Tags: []string{"sweets"}},
+		{GUID: records[1].GUID, CategoryGUID: fun.GUID, Amount: 2500, Currency: "USD", Description: "beer #bar", SpentAt: spentAt.Add(time.Hour).Truncate(time.Second), Tags: []string{"bar", "drinks"}},
+		{GUID: records[2].GUID, CategoryGUID: food.GUID, Amount: 1200, Currency: "EUR", Description: "karaoke", SpentAt: spentAt.Add(2 * time.Hour).Truncate(time.Second)},
+	}).Return([]uuid.UUID{uuid.New()}, nil)
+
+	got, err := NewImportService(categories, recordsRepo, rates, nil).
+		ImportRecordsExel(context.Background(), userGUID, &buffer, ImportOptions{Currency: "EUR"})
+	require.NoError(t, err)
+	require.Equal(t, ImportReport{
+		Imported: 1,
+		Updated:  3,
+		Changes: []RowChange{
+			{Line: 2, GUID: records[0].GUID, Fields: []FieldChange{{Column: "Tags", New: "sweets"}}},
+			{Line: 3, GUID: records[1].GUID, Fields: []FieldChange{{Column: "Amount", Old: "21.23", New: "25.00"}}},
+			{Line: 4, GUID: records[2].GUID, Fields: []FieldChange{{Column: "Category", Old: "fun", New: "food"}}},
+			{Line: 5, Fields: []FieldChange{
+				{Column: "Category", New: "food"},
+				{Column: "Amount", New: "3.50"},
+				{Column: "Currency", New: "EUR"},
+				{Column: "Description", New: "bread"},
+			}},
+		},
+	}, got)
+}
+
+func Test_ImportRecordsExel(t *testing.T) {
+
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Kind: ftracker.KindSpending}
+	record := ftracker.SpendingRecord{
+		GUID:         uuid.New(),
+		CategoryGUID: food.GUID,
+		Amount:       500,
+		Currency:     "EUR",
+		Description:  "lunch",
+		SpentAt:      time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC),
+		Tags:         []string{"work"},
+	}
+	unknownGUID := uuid.New()
+	errRepo := errors.New("repository error")
+	header := []any{"Amount", "Description", "Spent At", "Currency", "Category", "GUID"}
+
+	type mocks struct {
+		categories *repositorymock.MockSpendingCategory
+		records    *repositorymock.MockSpendingRecord
+		rates      *repositorymock.MockExchangeRate
+	}
+	lookup := func(m mocks, guids ...uuid.UUID) {
+		m.rates.EXPECT().GetExchangeRates(gomock.Any(), gomock.Any()).
+			Return([]ftracker.ExchangeRate{{Currency: "EUR"}, {Currency: "USD"}}, nil)
+		if len(guids) > 0 {
+			m.records.EXPECT().GetRecords(gomock.Any(), userGUID, repository.RecordOptions{GUIDs: guids}).
+				Return([]ftracker.SpendingRecord{record}, nil)
+		}
+		m.categories.EXPECT().GetCategories(gomock.Any(), userGUID, repository.CategoryOptions{}).
+			Return([]ftracker.SpendingCategory{food}, nil)
+	}
+
+	tt := []struct {
+		name     string
+		input    []byte
+		opts     ImportOptions
+		behavior func(m mocks)
+		want     ImportReport
+		wantErr  error
+	}{
+		{
+			name: "Rejected_rows",
+			input: newWorkbook(t, sheetName,
+				header,
+				[]any{"5.00", "lunch", "2024-11-02 12:00:00", "EUR", "food", record.GUID.String()},
+				[]any{"1", "", "", "", "food", "not-a-guid"},
+				[]any{"1", "", "", "", "food", record.GUID.String()},
+				[]any{"1", "", "", "", "food", unknownGUID.String()},
+				nil,
+				[]any{"abc", "", "", "", "food"},
+			),
+			opts: ImportOptions{Currency: "EUR"},
+			behavior: func(m mocks) {
+				lookup(m, record.GUID, unknownGUID)
+			},
+			want: ImportReport{
+				Unchanged: 1,
+				Rejected: []RejectedRow{
+					{Line: 3, Reason: "invalid guid not-a-guid"},
+					{Line: 4, Reason: fmt.Sprintf("guid %s is on the line 2 too", record.GUID)},
+					{Line: 5, Reason: fmt.Sprintf("unknown record %s", unknownGUID)},
+					{Line: 7, Reason: "invalid amount abc"},
+				},
+			},
+		},
+		{
+			name: "Dry_run",
+			input: newWorkbook(t, "Sheet1",
+				[]any{"category", "AMOUNT", "created at"},
+				[]any{"travel", "100", time.Date(2024, 11, 3, 9, 15, 0, 0, time.UTC)},
+			),
+			opts: ImportOptions{Currency: "EUR", CreateCategories: true, DryRun: true},
+			behavior: func(m mocks) {
+				lookup(m)
+			},
+			want: ImportReport{
+				Imported:      1,
+				NewCategories: []string{"travel"},
+				Changes: []RowChange{{Line: 2, Fields: []FieldChange{
+					{Column: "Category", New: "travel"},
+					{Column: "Amount", New: "100.00"},
+					{Column: "Currency", New: "EUR"},
+					{Column: "Spent At", New: "2024-11-03 09:15:00"},
+				}}},
+			},
+		},
+		{
+			name: "Update_moves_to_created_category",
+			input: newWorkbook(t, sheetName,
+				header,
+				[]any{"5.00", "lunch", nil, "EUR", "travel", record.GUID.String()},
+			),
+			opts: ImportOptions{Currency: "EUR", CreateCategories: true},
+			behavior: func(m mocks) {
+				lookup(m, record.GUID)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Any(), gomock.Len(0), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, categories []ftracker.SpendingCategory, _, updated []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+						require.Len(t, categories, 1)
+						require.NotEqual(t, uuid.Nil, categories[0].GUID)
+						require.Equal(t, ftracker.SpendingCategory{GUID: categories[0].GUID, Category: "travel", Kind: ftracker.KindSpending}, categories[0])
+						// the sheet has no tags column, so the record keeps its tags
+						require.Equal(t, []ftracker.SpendingRecord{
+							{GUID: record.GUID, CategoryGUID: categories[0].GUID, Amount: 500, Currency: "EUR", Description: "lunch", Tags: []string{"work"}},
+						}, updated)
+						return []uuid.UUID{}, nil
+					})
+			},
+			want: ImportReport{
+				Updated:       1,
+				NewCategories: []string{"travel"},
+				Changes:       []RowChange{{Line: 2, GUID: record.GUID, Fields: []FieldChange{{Column: "Category", Old: "food", New: "travel"}}}},
+			},
+		},
+		{
+			name: "Import_failure",
+			input: newWorkbook(t, sheetName,
+				header,
+				[]any{"7.00", "lunch", nil, "EUR", "food", record.GUID.String()},
+				[]any{"3.00", "coffee", nil, "EUR", "snacks"},
+			),
+			opts: ImportOptions{Currency: "EUR", CreateCategories: true},
+			behavior: func(m mocks) {
+				lookup(m, record.GUID)
+				m.records.EXPECT().ImportRecords(gomock.Any(), userGUID, gomock.Len(1), gomock.Len(1), gomock.Len(1)).Return(nil, errRepo)
+			},
+			wantErr: errRepo,
+		},
+		{
+			name:     "Not_a_workbook",
+			input:    []byte("category,amount\nfood,5\n"),
+			behavior: func(m mocks) {},
+			wantErr:  ErrInvalidExel,
+		},
+		{
+			name:     "No_category_column",
+			input:    newWorkbook(t, sheetName, []any{"Amount", "Description"}, []any{"5", "lunch"}),
+			behavior: func(m mocks) {},
+			wantErr:  ErrInvalidExel,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			m := mocks{
+				categories: repositorymock.NewMockSpendingCategory(cntr),
+				records:    repositorymock.NewMockSpendingRecord(cntr),
+				rates:      repositorymock.NewMockExchangeRate(cntr),
+			}
+			tc.behavior(m)
+
+			got, err := NewImportService(m.categories, m.records, m.rates, nil).
+				ImportRecordsExel(context.Background(), userGUID, bytes.NewReader(tc.input), tc.opts)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -5,6 +5,8 @@
 package mock_service
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
@@ -39,24 +41,24 @@ func (m *MockUser) EXPECT() *MockUserMockRecorder {
 }
 
 // AddUsers mocks base method.
-func (m *MockUser) AddUsers(users []ftracker.User) ([]uuid.UUID, error) {
+func (m *MockUser) AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUsers", users)
+	ret := m.ctrl.Call(m, "AddUsers", ctx, users)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddUsers indicates an expected call of AddUsers.
-func (mr *MockUserMockRecorder) AddUsers(users interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) AddUsers(ctx, users interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockUser)(nil).AddUsers), users)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockUser)(nil).AddUsers), ctx, users)
 }
 
 // GetUsers mocks base method.
-func (m *MockUser) GetUsers(opts ...service.UserOption) ([]ftracker.User, error) {
+func (m *MockUser) GetUsers(ctx context.Context, opts ...service.UserOption) ([]ftracker.User, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx}
 	for _, a := range opts {
 		varargs = append(varargs, a)
 	}
@@ -67,9 +69,53 @@ func (m *MockUser) GetUsers(opts ...service.UserOption) ([]ftracker.User, error)
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserMockRecorder) GetUsers(opts ...interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) GetUsers(ctx interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers), opts...)
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers), varargs...)
+}
+
+// IssueAPIToken mocks base method.
+func (m *MockUser) IssueAPIToken(ctx context.Context, userGUID uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIToken", ctx, userGUID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIToken indicates an expected call of IssueAPIToken.
+func (mr *MockUserMockRecorder) IssueAPIToken(ctx, userGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIToken", reflect.TypeOf((*MockUser)(nil).IssueAPIToken), ctx, userGUID)
+}
+
+// SetUserCurrency mocks base method.
+func (m *MockUser) SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserCurrency", ctx, userGUID, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserCurrency indicates an expected call of SetUserCurrency.
+func (mr *MockUserMockRecorder) SetUserCurrency(ctx, userGUID, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserCurrency", reflect.TypeOf((*MockUser)(nil).SetUserCurrency), ctx, userGUID, currency)
+}
+
+// UsersWithAPIToken mocks base method.
+func (m *MockUser) UsersWithAPIToken(token string) service.UserOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsersWithAPIToken", token)
+	ret0, _ := ret[0].(service.UserOption)
+	return ret0
+}
+
+// UsersWithAPIToken indicates an expected call of UsersWithAPIToken.
+func (mr *MockUserMockRecorder) UsersWithAPIToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsersWithAPIToken", reflect.TypeOf((*MockUser)(nil).UsersWithAPIToken), token)
 }
 
 // UsersWithGUIDs mocks base method.
@@ -152,39 +198,68 @@ func (m *MockSpendingCategory) EXPECT() *MockSpendingCategoryMockRecorder {
 }
 
 // AddCategories mocks base method.
-func (m *MockSpendingCategory) AddCategories(categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+func (m *MockSpendingCategory) AddCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddCategories", categories)
+	ret := m.ctrl.Call(m, "AddCategories", ctx, userGUID, categories)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddCategories indicates an expected call of AddCategories.
-func (mr *MockSpendingCategoryMockRecorder) AddCategories(categories interface{}) *gomock.Call {
+func (mr *MockSpendingCategoryMockRecorder) AddCategories(ctx, userGUID, categories interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockSpendingCategory)(nil).AddCategories), ctx, userGUID, categories)
+}
+
+// CreateCSVFromCategories mocks base method.
+func (m *MockSpendingCategory) CreateCSVFromCategories(categories []ftracker.SpendingCategory, currency string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCSVFromCategories", categories, currency)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCSVFromCategories indicates an expected call of CreateCSVFromCategories.
+func (mr *MockSpendingCategoryMockRecorder) CreateCSVFromCategories(categories, currency interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockSpendingCategory)(nil).AddCategories), categories)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCSVFromCategories", reflect.TypeOf((*MockSpendingCategory)(nil).CreateCSVFromCategories), categories, currency)
 }
 
 // CreateExelFromCategories mocks base method.
-func (m *MockSpendingCategory) CreateExelFromCategories(categories []ftracker.SpendingCategory) (*excelize.File, error) {
+func (m *MockSpendingCategory) CreateExelFromCategories(categories []ftracker.SpendingCategory, currency string) (*excelize.File, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateExelFromCategories", categories)
+	ret := m.ctrl.Call(m, "CreateExelFromCategories", categories, currency)
 	ret0, _ := ret[0].(*excelize.File)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateExelFromCategories indicates an expected call of CreateExelFromCategories.
-func (mr *MockSpendingCategoryMockRecorder) CreateExelFromCategories(categories interface{}) *gomock.Call {
+func (mr *MockSpendingCategoryMockRecorder) CreateExelFromCategories(categories, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromCategories", reflect.TypeOf((*MockSpendingCategory)(nil).CreateExelFromCategories), categories, currency)
+}
+
+// DeleteCategories mocks base method.
+func (m *MockSpendingCategory) DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategories", ctx, userGUID, guids, moveTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategories indicates an expected call of DeleteCategories.
+func (mr *MockSpendingCategoryMockRecorder) DeleteCategories(ctx, userGUID, guids, moveTo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromCategories", reflect.TypeOf((*MockSpendingCategory)(nil).CreateExelFromCategories), categories)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategories", reflect.TypeOf((*MockSpendingCategory)(nil).DeleteCategories), ctx, userGUID, guids, moveTo)
 }
 
 // GetCategories mocks base method.
-func (m *MockSpendingCategory) GetCategories(opts ...service.CategoryOption) ([]ftracker.SpendingCategory, error) {
+func (m *MockSpendingCategory) GetCategories(ctx context.Context, userGUID uuid.UUID, opts ...service.CategoryOption) ([]ftracker.SpendingCategory, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx, userGUID}
 	for _, a := range opts {
 		varargs = append(varargs, a)
 	}
@@ -195,9 +270,30 @@ func (m *MockSpendingCategory) GetCategories(opts ...service.CategoryOption) ([]
 }
 
 // GetCategories indicates an expected call of GetCategories.
-func (mr *MockSpendingCategoryMockRecorder) GetCategories(opts ...interface{}) *gomock.Call {
+func (mr *MockSpendingCategoryMockRecorder) GetCategories(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockSpendingCategory)(nil).GetCategories), varargs...)
+}
+
+// GetCategoryTree mocks base method.
+func (m *MockSpendingCategory) GetCategoryTree(ctx context.Context, userGUID uuid.UUID, opts ...service.CategoryOption) ([]ftracker.CategoryTree, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetCategoryTree", varargs...)
+	ret0, _ := ret[0].([]ftracker.CategoryTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryTree indicates an expected call of GetCategoryTree.
+func (mr *MockSpendingCategoryMockRecorder) GetCategoryTree(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockSpendingCategory)(nil).GetCategories), opts...)
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryTree", reflect.TypeOf((*MockSpendingCategory)(nil).GetCategoryTree), varargs...)
 }
 
 // SpendingCategoriesWithCategories mocks base method.
@@ -228,6 +324,20 @@ func (mr *MockSpendingCategoryMockRecorder) SpendingCategoriesWithGUIDs(guids in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithGUIDs", reflect.TypeOf((*MockSpendingCategory)(nil).SpendingCategoriesWithGUIDs), guids)
 }
 
+// SpendingCategoriesWithKinds mocks base method.
+func (m *MockSpendingCategory) SpendingCategoriesWithKinds(kinds []string) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithKinds", kinds)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithKinds indicates an expected call of SpendingCategoriesWithKinds.
+func (mr *MockSpendingCategoryMockRecorder) SpendingCategoriesWithKinds(kinds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithKinds", reflect.TypeOf((*MockSpendingCategory)(nil).SpendingCategoriesWithKinds), kinds)
+}
+
 // SpendingCategoriesWithLimit mocks base method.
 func (m *MockSpendingCategory) SpendingCategoriesWithLimit(limit int) service.CategoryOption {
 	m.ctrl.T.Helper()
@@ -256,18 +366,18 @@ func (mr *MockSpendingCategoryMockRecorder) SpendingCategoriesWithOrder(order, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithOrder", reflect.TypeOf((*MockSpendingCategory)(nil).SpendingCategoriesWithOrder), order, asc)
 }
 
-// SpendingCategoriesWithUserGUIDs mocks base method.
-func (m *MockSpendingCategory) SpendingCategoriesWithUserGUIDs(guids []uuid.UUID) service.CategoryOption {
+// UpdateCategories mocks base method.
+func (m *MockSpendingCategory) UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithUserGUIDs", guids)
-	ret0, _ := ret[0].(service.CategoryOption)
+	ret := m.ctrl.Call(m, "UpdateCategories", ctx, userGUID, categories)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SpendingCategoriesWithUserGUIDs indicates an expected call of SpendingCategoriesWithUserGUIDs.
-func (mr *MockSpendingCategoryMockRecorder) SpendingCategoriesWithUserGUIDs(guids interface{}) *gomock.Call {
+// UpdateCategories indicates an expected call of UpdateCategories.
+func (mr *MockSpendingCategoryMockRecorder) UpdateCategories(ctx, userGUID, categories interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithUserGUIDs", reflect.TypeOf((*MockSpendingCategory)(nil).SpendingCategoriesWithUserGUIDs), guids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategories", reflect.TypeOf((*MockSpendingCategory)(nil).UpdateCategories), ctx, userGUID, categories)
 }
 
 // MockSpendingRecord is a mock of SpendingRecord interface.
@@ -294,39 +404,83 @@ func (m *MockSpendingRecord) EXPECT() *MockSpendingRecordMockRecorder {
 }
 
 // AddRecords mocks base method.
-func (m *MockSpendingRecord) AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+func (m *MockSpendingRecord) AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddRecords", records)
+	ret := m.ctrl.Call(m, "AddRecords", ctx, userGUID, records)
 	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddRecords indicates an expected call of AddRecords.
-func (mr *MockSpendingRecordMockRecorder) AddRecords(records interface{}) *gomock.Call {
+func (mr *MockSpendingRecordMockRecorder) AddRecords(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockSpendingRecord)(nil).AddRecords), ctx, userGUID, records)
+}
+
+// CreateCSVFromRecords mocks base method.
+func (m *MockSpendingRecord) CreateCSVFromRecords(records []ftracker.SpendingRecord, categories []ftracker.SpendingCategory) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCSVFromRecords", records, categories)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCSVFromRecords indicates an expected call of CreateCSVFromRecords.
+func (mr *MockSpendingRecordMockRecorder) CreateCSVFromRecords(records, categories interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockSpendingRecord)(nil).AddRecords), records)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCSVFromRecords", reflect.TypeOf((*MockSpendingRecord)(nil).CreateCSVFromRecords), records, categories)
 }
 
 // CreateExelFromRecords mocks base method.
-func (m *MockSpendingRecord) CreateExelFromRecords(recods []ftracker.SpendingRecord) (*excelize.File, error) {
+func (m *MockSpendingRecord) CreateExelFromRecords(recods []ftracker.SpendingRecord, categories []ftracker.SpendingCategory, currency string) (*excelize.File, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateExelFromRecords", recods)
+	ret := m.ctrl.Call(m, "CreateExelFromRecords", recods, categories, currency)
 	ret0, _ := ret[0].(*excelize.File)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateExelFromRecords indicates an expected call of CreateExelFromRecords.
-func (mr *MockSpendingRecordMockRecorder) CreateExelFromRecords(recods interface{}) *gomock.Call {
+func (mr *MockSpendingRecordMockRecorder) CreateExelFromRecords(recods, categories, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromRecords", reflect.TypeOf((*MockSpendingRecord)(nil).CreateExelFromRecords), recods, categories, currency)
+}
+
+// DeleteRecords mocks base method.
+func (m *MockSpendingRecord) DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecords", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecords indicates an expected call of DeleteRecords.
+func (mr *MockSpendingRecordMockRecorder) DeleteRecords(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecords", reflect.TypeOf((*MockSpendingRecord)(nil).DeleteRecords), ctx, userGUID, guids)
+}
+
+// GetBalance mocks base method.
+func (m *MockSpendingRecord) GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", ctx, userGUID, from, to)
+	ret0, _ := ret[0].(ftracker.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockSpendingRecordMockRecorder) GetBalance(ctx, userGUID, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromRecords", reflect.TypeOf((*MockSpendingRecord)(nil).CreateExelFromRecords), recods)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockSpendingRecord)(nil).GetBalance), ctx, userGUID, from, to)
 }
 
 // GetRecords mocks base method.
-func (m *MockSpendingRecord) GetRecords(opts ...service.RecordOption) ([]ftracker.SpendingRecord, error) {
+func (m *MockSpendingRecord) GetRecords(ctx context.Context, userGUID uuid.UUID, opts ...service.RecordOption) ([]ftracker.SpendingRecord, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx, userGUID}
 	for _, a := range opts {
 		varargs = append(varargs, a)
 	}
@@ -337,9 +491,25 @@ func (m *MockSpendingRecord) GetRecords(opts ...service.RecordOption) ([]ftracke
 }
 
 // GetRecords indicates an expected call of GetRecords.
-func (mr *MockSpendingRecordMockRecorder) GetRecords(opts ...interface{}) *gomock.Call {
+func (mr *MockSpendingRecordMockRecorder) GetRecords(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockSpendingRecord)(nil).GetRecords), varargs...)
+}
+
+// GetTagSpending mocks base method.
+func (m *MockSpendingRecord) GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagSpending", ctx, userGUID, from, to)
+	ret0, _ := ret[0].([]ftracker.TagSpending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagSpending indicates an expected call of GetTagSpending.
+func (mr *MockSpendingRecordMockRecorder) GetTagSpending(ctx, userGUID, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockSpendingRecord)(nil).GetRecords), opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagSpending", reflect.TypeOf((*MockSpendingRecord)(nil).GetTagSpending), ctx, userGUID, from, to)
 }
 
 // SpendingRecordsWithCategoryGUIDs mocks base method.
@@ -398,6 +568,20 @@ func (mr *MockSpendingRecordMockRecorder) SpendingRecordsWithOrder(order, asc in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithOrder", reflect.TypeOf((*MockSpendingRecord)(nil).SpendingRecordsWithOrder), order, asc)
 }
 
+// SpendingRecordsWithTags mocks base method.
+func (m *MockSpendingRecord) SpendingRecordsWithTags(tags []string, all bool) service.RecordOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingRecordsWithTags", tags, all)
+	ret0, _ := ret[0].(service.RecordOption)
+	return ret0
+}
+
+// SpendingRecordsWithTags indicates an expected call of SpendingRecordsWithTags.
+func (mr *MockSpendingRecordMockRecorder) SpendingRecordsWithTags(tags, all interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithTags", reflect.TypeOf((*MockSpendingRecord)(nil).SpendingRecordsWithTags), tags, all)
+}
+
 // SpendingRecordsWithTimeFrame mocks base method.
 func (m *MockSpendingRecord) SpendingRecordsWithTimeFrame(from, to time.Time) service.RecordOption {
 	m.ctrl.T.Helper()
@@ -412,257 +596,1283 @@ func (mr *MockSpendingRecordMockRecorder) SpendingRecordsWithTimeFrame(from, to
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithTimeFrame", reflect.TypeOf((*MockSpendingRecord)(nil).SpendingRecordsWithTimeFrame), from, to)
 }
 
-// MockServiceInterface is a mock of ServiceInterface interface.
-type MockServiceInterface struct {
+// UpdateRecords mocks base method.
+func (m *MockSpendingRecord) UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecords", ctx, userGUID, records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecords indicates an expected call of UpdateRecords.
+func (mr *MockSpendingRecordMockRecorder) UpdateRecords(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecords", reflect.TypeOf((*MockSpendingRecord)(nil).UpdateRecords), ctx, userGUID, records)
+}
+
+// MockBudget is a mock of Budget interface.
+type MockBudget struct {
 	ctrl     *gomock.Controller
-	recorder *MockServiceInterfaceMockRecorder
+	recorder *MockBudgetMockRecorder
 }
 
-// MockServiceInterfaceMockRecorder is the mock recorder for MockServiceInterface.
-type MockServiceInterfaceMockRecorder struct {
-	mock *MockServiceInterface
+// MockBudgetMockRecorder is the mock recorder for MockBudget.
+type MockBudgetMockRecorder struct {
+	mock *MockBudget
 }
 
-// NewMockServiceInterface creates a new mock instance.
-func NewMockServiceInterface(ctrl *gomock.Controller) *MockServiceInterface {
-	mock := &MockServiceInterface{ctrl: ctrl}
-	mock.recorder = &MockServiceInterfaceMockRecorder{mock}
+// NewMockBudget creates a new mock instance.
+func NewMockBudget(ctrl *gomock.Controller) *MockBudget {
+	mock := &MockBudget{ctrl: ctrl}
+	mock.recorder = &MockBudgetMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockServiceInterface) EXPECT() *MockServiceInterfaceMockRecorder {
+func (m *MockBudget) EXPECT() *MockBudgetMockRecorder {
 	return m.recorder
 }
 
-// AddCategories mocks base method.
-func (m *MockServiceInterface) AddCategories(categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+// DeleteBudgets mocks base method.
+func (m *MockBudget) DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddCategories", categories)
-	ret0, _ := ret[0].([]uuid.UUID)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteBudgets", ctx, userGUID, categoryGUIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// AddCategories indicates an expected call of AddCategories.
-func (mr *MockServiceInterfaceMockRecorder) AddCategories(categories interface{}) *gomock.Call {
+// DeleteBudgets indicates an expected call of DeleteBudgets.
+func (mr *MockBudgetMockRecorder) DeleteBudgets(ctx, userGUID, categoryGUIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockServiceInterface)(nil).AddCategories), categories)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBudgets", reflect.TypeOf((*MockBudget)(nil).DeleteBudgets), ctx, userGUID, categoryGUIDs)
 }
 
-// AddRecords mocks base method.
-func (m *MockServiceInterface) AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+// EvaluateBudget mocks base method.
+func (m *MockBudget) EvaluateBudget(ctx context.Context, userGUID, recordGUID uuid.UUID) (service.BudgetAlert, ftracker.BudgetStatus, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddRecords", records)
-	ret0, _ := ret[0].([]uuid.UUID)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "EvaluateBudget", ctx, userGUID, recordGUID)
+	ret0, _ := ret[0].(service.BudgetAlert)
+	ret1, _ := ret[1].(ftracker.BudgetStatus)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
-// AddRecords indicates an expected call of AddRecords.
-func (mr *MockServiceInterfaceMockRecorder) AddRecords(records interface{}) *gomock.Call {
+// EvaluateBudget indicates an expected call of EvaluateBudget.
+func (mr *MockBudgetMockRecorder) EvaluateBudget(ctx, userGUID, recordGUID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockServiceInterface)(nil).AddRecords), records)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateBudget", reflect.TypeOf((*MockBudget)(nil).EvaluateBudget), ctx, userGUID, recordGUID)
 }
 
-// AddUsers mocks base method.
-func (m *MockServiceInterface) AddUsers(users []ftracker.User) ([]uuid.UUID, error) {
+// GetBudgets mocks base method.
+func (m *MockBudget) GetBudgets(ctx context.Context, userGUID uuid.UUID, month time.Time, categoryGUIDs ...uuid.UUID) ([]ftracker.BudgetStatus, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUsers", users)
-	ret0, _ := ret[0].([]uuid.UUID)
+	varargs := []interface{}{ctx, userGUID, month}
+	for _, a := range categoryGUIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetBudgets", varargs...)
+	ret0, _ := ret[0].([]ftracker.BudgetStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddUsers indicates an expected call of AddUsers.
-func (mr *MockServiceInterfaceMockRecorder) AddUsers(users interface{}) *gomock.Call {
+// GetBudgets indicates an expected call of GetBudgets.
+func (mr *MockBudgetMockRecorder) GetBudgets(ctx, userGUID, month interface{}, categoryGUIDs ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockServiceInterface)(nil).AddUsers), users)
+	varargs := append([]interface{}{ctx, userGUID, month}, categoryGUIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBudgets", reflect.TypeOf((*MockBudget)(nil).GetBudgets), varargs...)
 }
 
-// CreateExelFromCategories mocks base method.
-func (m *MockServiceInterface) CreateExelFromCategories(categories []ftracker.SpendingCategory) (*excelize.File, error) {
+// SetBudgets mocks base method.
+func (m *MockBudget) SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateExelFromCategories", categories)
-	ret0, _ := ret[0].(*excelize.File)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SetBudgets", ctx, userGUID, budgets)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CreateExelFromCategories indicates an expected call of CreateExelFromCategories.
-func (mr *MockServiceInterfaceMockRecorder) CreateExelFromCategories(categories interface{}) *gomock.Call {
+// SetBudgets indicates an expected call of SetBudgets.
+func (mr *MockBudgetMockRecorder) SetBudgets(ctx, userGUID, budgets interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromCategories", reflect.TypeOf((*MockServiceInterface)(nil).CreateExelFromCategories), categories)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBudgets", reflect.TypeOf((*MockBudget)(nil).SetBudgets), ctx, userGUID, budgets)
 }
 
-// CreateExelFromRecords mocks base method.
-func (m *MockServiceInterface) CreateExelFromRecords(recods []ftracker.SpendingRecord) (*excelize.File, error) {
+// MockRecurring is a mock of Recurring interface.
+type MockRecurring struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecurringMockRecorder
+}
+
+// MockRecurringMockRecorder is the mock recorder for MockRecurring.
+type MockRecurringMockRecorder struct {
+	mock *MockRecurring
+}
+
+// NewMockRecurring creates a new mock instance.
+func NewMockRecurring(ctrl *gomock.Controller) *MockRecurring {
+	mock := &MockRecurring{ctrl: ctrl}
+	mock.recorder = &MockRecurringMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecurring) EXPECT() *MockRecurringMockRecorder {
+	return m.recorder
+}
+
+// AddRecurring mocks base method.
+func (m *MockRecurring) AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateExelFromRecords", recods)
-	ret0, _ := ret[0].(*excelize.File)
+	ret := m.ctrl.Call(m, "AddRecurring", ctx, userGUID, records)
+	ret0, _ := ret[0].([]uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateExelFromRecords indicates an expected call of CreateExelFromRecords.
-func (mr *MockServiceInterfaceMockRecorder) CreateExelFromRecords(recods interface{}) *gomock.Call {
+// AddRecurring indicates an expected call of AddRecurring.
+func (mr *MockRecurringMockRecorder) AddRecurring(ctx, userGUID, records interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromRecords", reflect.TypeOf((*MockServiceInterface)(nil).CreateExelFromRecords), recods)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecurring", reflect.TypeOf((*MockRecurring)(nil).AddRecurring), ctx, userGUID, records)
 }
 
-// GetCategories mocks base method.
-func (m *MockServiceInterface) GetCategories(opts ...service.CategoryOption) ([]ftracker.SpendingCategory, error) {
+// AdvanceRecurring mocks base method.
+func (m *MockRecurring) AdvanceRecurring(ctx context.Context, record ftracker.RecurringRecord) (time.Time, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "GetCategories", varargs...)
-	ret0, _ := ret[0].([]ftracker.SpendingCategory)
+	ret := m.ctrl.Call(m, "AdvanceRecurring", ctx, record)
+	ret0, _ := ret[0].(time.Time)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetCategories indicates an expected call of GetCategories.
-func (mr *MockServiceInterfaceMockRecorder) GetCategories(opts ...interface{}) *gomock.Call {
+// AdvanceRecurring indicates an expected call of AdvanceRecurring.
+func (mr *MockRecurringMockRecorder) AdvanceRecurring(ctx, record interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockServiceInterface)(nil).GetCategories), opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceRecurring", reflect.TypeOf((*MockRecurring)(nil).AdvanceRecurring), ctx, record)
 }
 
-// GetRecords mocks base method.
-func (m *MockServiceInterface) GetRecords(opts ...service.RecordOption) ([]ftracker.SpendingRecord, error) {
+// DeleteRecurring mocks base method.
+func (m *MockRecurring) DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range opts {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "GetRecords", varargs...)
-	ret0, _ := ret[0].([]ftracker.SpendingRecord)
+	ret := m.ctrl.Call(m, "DeleteRecurring", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecurring indicates an expected call of DeleteRecurring.
+func (mr *MockRecurringMockRecorder) DeleteRecurring(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecurring", reflect.TypeOf((*MockRecurring)(nil).DeleteRecurring), ctx, userGUID, guids)
+}
+
+// GetDueRecurring mocks base method.
+func (m *MockRecurring) GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueRecurring", ctx, now, limit)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetRecords indicates an expected call of GetRecords.
-func (mr *MockServiceInterfaceMockRecorder) GetRecords(opts ...interface{}) *gomock.Call {
+// GetDueRecurring indicates an expected call of GetDueRecurring.
+func (mr *MockRecurringMockRecorder) GetDueRecurring(ctx, now, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockServiceInterface)(nil).GetRecords), opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueRecurring", reflect.TypeOf((*MockRecurring)(nil).GetDueRecurring), ctx, now, limit)
 }
 
-// GetUsers mocks base method.
-func (m *MockServiceInterface) GetUsers(opts ...service.UserOption) ([]ftracker.User, error) {
+// GetRecurring mocks base method.
+func (m *MockRecurring) GetRecurring(ctx context.Context, userGUID uuid.UUID, opts ...service.RecurringOption) ([]ftracker.RecurringRecord, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx, userGUID}
 	for _, a := range opts {
 		varargs = append(varargs, a)
 	}
-	ret := m.ctrl.Call(m, "GetUsers", varargs...)
-	ret0, _ := ret[0].([]ftracker.User)
+	ret := m.ctrl.Call(m, "GetRecurring", varargs...)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUsers indicates an expected call of GetUsers.
-func (mr *MockServiceInterfaceMockRecorder) GetUsers(opts ...interface{}) *gomock.Call {
+// GetRecurring indicates an expected call of GetRecurring.
+func (mr *MockRecurringMockRecorder) GetRecurring(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockServiceInterface)(nil).GetUsers), opts...)
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurring", reflect.TypeOf((*MockRecurring)(nil).GetRecurring), varargs...)
 }
 
-// SpendingCategoriesWithCategories mocks base method.
-func (m *MockServiceInterface) SpendingCategoriesWithCategories(categories []string) service.CategoryOption {
+// RecurringRecordsWithCategoryGUIDs mocks base method.
+func (m *MockRecurring) RecurringRecordsWithCategoryGUIDs(guids []uuid.UUID) service.RecurringOption {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithCategories", categories)
-	ret0, _ := ret[0].(service.CategoryOption)
+	ret := m.ctrl.Call(m, "RecurringRecordsWithCategoryGUIDs", guids)
+	ret0, _ := ret[0].(service.RecurringOption)
 	return ret0
 }
 
-// SpendingCategoriesWithCategories indicates an expected call of SpendingCategoriesWithCategories.
-func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithCategories(categories interface{}) *gomock.Call {
+// RecurringRecordsWithCategoryGUIDs indicates an expected call of RecurringRecordsWithCategoryGUIDs.
+func (mr *MockRecurringMockRecorder) RecurringRecordsWithCategoryGUIDs(guids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithCategories", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithCategories), categories)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringRecordsWithCategoryGUIDs", reflect.TypeOf((*MockRecurring)(nil).RecurringRecordsWithCategoryGUIDs), guids)
 }
 
-// SpendingCategoriesWithGUIDs mocks base method.
-func (m *MockServiceInterface) SpendingCategoriesWithGUIDs(guids []uuid.UUID) service.CategoryOption {
+// RecurringRecordsWithGUIDs mocks base method.
+func (m *MockRecurring) RecurringRecordsWithGUIDs(guids []uuid.UUID) service.RecurringOption {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithGUIDs", guids)
-	ret0, _ := ret[0].(service.CategoryOption)
+	ret := m.ctrl.Call(m, "RecurringRecordsWithGUIDs", guids)
+	ret0, _ := ret[0].(service.RecurringOption)
 	return ret0
 }
 
-// SpendingCategoriesWithGUIDs indicates an expected call of SpendingCategoriesWithGUIDs.
-func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithGUIDs(guids interface{}) *gomock.Call {
+// RecurringRecordsWithGUIDs indicates an expected call of RecurringRecordsWithGUIDs.
+func (mr *MockRecurringMockRecorder) RecurringRecordsWithGUIDs(guids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithGUIDs), guids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringRecordsWithGUIDs", reflect.TypeOf((*MockRecurring)(nil).RecurringRecordsWithGUIDs), guids)
 }
 
-// SpendingCategoriesWithLimit mocks base method.
-func (m *MockServiceInterface) SpendingCategoriesWithLimit(limit int) service.CategoryOption {
+// MockExchangeRate is a mock of ExchangeRate interface.
+type MockExchangeRate struct {
+	ctrl     *gomock.Controller
+	recorder *MockExchangeRateMockRecorder
+}
+
+// MockExchangeRateMockRecorder is the mock recorder for MockExchangeRate.
+type MockExchangeRateMockRecorder struct {
+	mock *MockExchangeRate
+}
+
+// NewMockExchangeRate creates a new mock instance.
+func NewMockExchangeRate(ctrl *gomock.Controller) *MockExchangeRate {
+	mock := &MockExchangeRate{ctrl: ctrl}
+	mock.recorder = &MockExchangeRateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExchangeRate) EXPECT() *MockExchangeRateMockRecorder {
+	return m.recorder
+}
+
+// GetExchangeRates mocks base method.
+func (m *MockExchangeRate) GetExchangeRates(ctx context.Context, currencies ...string) ([]ftracker.ExchangeRate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithLimit", limit)
-	ret0, _ := ret[0].(service.CategoryOption)
-	return ret0
+	varargs := []interface{}{ctx}
+	for _, a := range currencies {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetExchangeRates", varargs...)
+	ret0, _ := ret[0].([]ftracker.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SpendingCategoriesWithLimit indicates an expected call of SpendingCategoriesWithLimit.
-func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithLimit(limit interface{}) *gomock.Call {
+// GetExchangeRates indicates an expected call of GetExchangeRates.
+func (mr *MockExchangeRateMockRecorder) GetExchangeRates(ctx interface{}, currencies ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithLimit", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithLimit), limit)
+	varargs := append([]interface{}{ctx}, currencies...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockExchangeRate)(nil).GetExchangeRates), varargs...)
 }
 
-// SpendingCategoriesWithOrder mocks base method.
-func (m *MockServiceInterface) SpendingCategoriesWithOrder(order service.CategoryOrder, asc bool) service.CategoryOption {
+// LoadExchangeRates mocks base method.
+func (m *MockExchangeRate) LoadExchangeRates(ctx context.Context, r io.Reader) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithOrder", order, asc)
-	ret0, _ := ret[0].(service.CategoryOption)
-	return ret0
+	ret := m.ctrl.Call(m, "LoadExchangeRates", ctx, r)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SpendingCategoriesWithOrder indicates an expected call of SpendingCategoriesWithOrder.
-func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithOrder(order, asc interface{}) *gomock.Call {
+// LoadExchangeRates indicates an expected call of LoadExchangeRates.
+func (mr *MockExchangeRateMockRecorder) LoadExchangeRates(ctx, r interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithOrder", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithOrder), order, asc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadExchangeRates", reflect.TypeOf((*MockExchangeRate)(nil).LoadExchangeRates), ctx, r)
 }
 
-// SpendingCategoriesWithUserGUIDs mocks base method.
-func (m *MockServiceInterface) SpendingCategoriesWithUserGUIDs(guids []uuid.UUID) service.CategoryOption {
+// SetExchangeRates mocks base method.
+func (m *MockExchangeRate) SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingCategoriesWithUserGUIDs", guids)
-	ret0, _ := ret[0].(service.CategoryOption)
+	ret := m.ctrl.Call(m, "SetExchangeRates", ctx, rates)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SpendingCategoriesWithUserGUIDs indicates an expected call of SpendingCategoriesWithUserGUIDs.
-func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithUserGUIDs(guids interface{}) *gomock.Call {
+// SetExchangeRates indicates an expected call of SetExchangeRates.
+func (mr *MockExchangeRateMockRecorder) SetExchangeRates(ctx, rates interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithUserGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithUserGUIDs), guids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRates", reflect.TypeOf((*MockExchangeRate)(nil).SetExchangeRates), ctx, rates)
 }
 
-// SpendingRecordsWithCategoryGUIDs mocks base method.
-func (m *MockServiceInterface) SpendingRecordsWithCategoryGUIDs(guids []uuid.UUID) service.RecordOption {
+// MockSession is a mock of Session interface.
+type MockSession struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionMockRecorder
+}
+
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
+}
+
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
+	return m.recorder
+}
+
+// DeleteSession mocks base method.
+func (m *MockSession) DeleteSession(ctx context.Context, chatID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingRecordsWithCategoryGUIDs", guids)
-	ret0, _ := ret[0].(service.RecordOption)
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, chatID)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SpendingRecordsWithCategoryGUIDs indicates an expected call of SpendingRecordsWithCategoryGUIDs.
-func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithCategoryGUIDs(guids interface{}) *gomock.Call {
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockSessionMockRecorder) DeleteSession(ctx, chatID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithCategoryGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithCategoryGUIDs), guids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSession)(nil).DeleteSession), ctx, chatID)
 }
 
-// SpendingRecordsWithGUIDs mocks base method.
-func (m *MockServiceInterface) SpendingRecordsWithGUIDs(guids []uuid.UUID) service.RecordOption {
+// GetSessions mocks base method.
+func (m *MockSession) GetSessions(ctx context.Context) ([]ftracker.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SpendingRecordsWithGUIDs", guids)
-	ret0, _ := ret[0].(service.RecordOption)
-	return ret0
+	ret := m.ctrl.Call(m, "GetSessions", ctx)
+	ret0, _ := ret[0].([]ftracker.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SpendingRecordsWithGUIDs indicates an expected call of SpendingRecordsWithGUIDs.
-func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithGUIDs(guids interface{}) *gomock.Call {
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionMockRecorder) GetSessions(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithGUIDs), guids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSession)(nil).GetSessions), ctx)
+}
+
+// SaveSession mocks base method.
+func (m *MockSession) SaveSession(ctx context.Context, session ftracker.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MockSessionMockRecorder) SaveSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockSession)(nil).SaveSession), ctx, session)
+}
+
+// MockPayeeRule is a mock of PayeeRule interface.
+type MockPayeeRule struct {
+	ctrl     *gomock.Controller
+	recorder *MockPayeeRuleMockRecorder
+}
+
+// MockPayeeRuleMockRecorder is the mock recorder for MockPayeeRule.
+type MockPayeeRuleMockRecorder struct {
+	mock *MockPayeeRule
+}
+
+// NewMockPayeeRule creates a new mock instance.
+func NewMockPayeeRule(ctrl *gomock.Controller) *MockPayeeRule {
+	mock := &MockPayeeRule{ctrl: ctrl}
+	mock.recorder = &MockPayeeRuleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPayeeRule) EXPECT() *MockPayeeRuleMockRecorder {
+	return m.recorder
+}
+
+// AddPayeeRules mocks base method.
+func (m *MockPayeeRule) AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPayeeRules", ctx, userGUID, rules)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPayeeRules indicates an expected call of AddPayeeRules.
+func (mr *MockPayeeRuleMockRecorder) AddPayeeRules(ctx, userGUID, rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).AddPayeeRules), ctx, userGUID, rules)
+}
+
+// DeletePayeeRules mocks base method.
+func (m *MockPayeeRule) DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayeeRules", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePayeeRules indicates an expected call of DeletePayeeRules.
+func (mr *MockPayeeRuleMockRecorder) DeletePayeeRules(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).DeletePayeeRules), ctx, userGUID, guids)
+}
+
+// GetPayeeRules mocks base method.
+func (m *MockPayeeRule) GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayeeRules", ctx, userGUID)
+	ret0, _ := ret[0].([]ftracker.PayeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayeeRules indicates an expected call of GetPayeeRules.
+func (mr *MockPayeeRuleMockRecorder) GetPayeeRules(ctx, userGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeRules", reflect.TypeOf((*MockPayeeRule)(nil).GetPayeeRules), ctx, userGUID)
+}
+
+// MockImport is a mock of Import interface.
+type MockImport struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportMockRecorder
+}
+
+// MockImportMockRecorder is the mock recorder for MockImport.
+type MockImportMockRecorder struct {
+	mock *MockImport
+}
+
+// NewMockImport creates a new mock instance.
+func NewMockImport(ctrl *gomock.Controller) *MockImport {
+	mock := &MockImport{ctrl: ctrl}
+	mock.recorder = &MockImportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImport) EXPECT() *MockImportMockRecorder {
+	return m.recorder
+}
+
+// ImportBankTransactions mocks base method.
+func (m *MockImport) ImportBankTransactions(ctx context.Context, userGUID uuid.UUID, transactions []ftracker.BankTransaction) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportBankTransactions", ctx, userGUID, transactions)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportBankTransactions indicates an expected call of ImportBankTransactions.
+func (mr *MockImportMockRecorder) ImportBankTransactions(ctx, userGUID, transactions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBankTransactions", reflect.TypeOf((*MockImport)(nil).ImportBankTransactions), ctx, userGUID, transactions)
+}
+
+// ImportRecordsCSV mocks base method.
+func (m *MockImport) ImportRecordsCSV(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts service.ImportOptions) (service.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRecordsCSV", ctx, userGUID, r, opts)
+	ret0, _ := ret[0].(service.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRecordsCSV indicates an expected call of ImportRecordsCSV.
+func (mr *MockImportMockRecorder) ImportRecordsCSV(ctx, userGUID, r, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRecordsCSV", reflect.TypeOf((*MockImport)(nil).ImportRecordsCSV), ctx, userGUID, r, opts)
+}
+
+// ImportRecordsExel mocks base method.
+func (m *MockImport) ImportRecordsExel(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts service.ImportOptions) (service.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRecordsExel", ctx, userGUID, r, opts)
+	ret0, _ := ret[0].(service.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRecordsExel indicates an expected call of ImportRecordsExel.
+func (mr *MockImportMockRecorder) ImportRecordsExel(ctx, userGUID, r, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRecordsExel", reflect.TypeOf((*MockImport)(nil).ImportRecordsExel), ctx, userGUID, r, opts)
+}
+
+// ParseBankStatement mocks base method.
+func (m *MockImport) ParseBankStatement(ctx context.Context, userGUID uuid.UUID, r io.Reader, currency string) (service.BankStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseBankStatement", ctx, userGUID, r, currency)
+	ret0, _ := ret[0].(service.BankStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseBankStatement indicates an expected call of ParseBankStatement.
+func (mr *MockImportMockRecorder) ParseBankStatement(ctx, userGUID, r, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseBankStatement", reflect.TypeOf((*MockImport)(nil).ParseBankStatement), ctx, userGUID, r, currency)
+}
+
+// MockServiceInterface is a mock of ServiceInterface interface.
+type MockServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceInterfaceMockRecorder
+}
+
+// MockServiceInterfaceMockRecorder is the mock recorder for MockServiceInterface.
+type MockServiceInterfaceMockRecorder struct {
+	mock *MockServiceInterface
+}
+
+// NewMockServiceInterface creates a new mock instance.
+func NewMockServiceInterface(ctrl *gomock.Controller) *MockServiceInterface {
+	mock := &MockServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceInterface) EXPECT() *MockServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddCategories mocks base method.
+func (m *MockServiceInterface) AddCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCategories", ctx, userGUID, categories)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCategories indicates an expected call of AddCategories.
+func (mr *MockServiceInterfaceMockRecorder) AddCategories(ctx, userGUID, categories interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategories", reflect.TypeOf((*MockServiceInterface)(nil).AddCategories), ctx, userGUID, categories)
+}
+
+// AddPayeeRules mocks base method.
+func (m *MockServiceInterface) AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPayeeRules", ctx, userGUID, rules)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPayeeRules indicates an expected call of AddPayeeRules.
+func (mr *MockServiceInterfaceMockRecorder) AddPayeeRules(ctx, userGUID, rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPayeeRules", reflect.TypeOf((*MockServiceInterface)(nil).AddPayeeRules), ctx, userGUID, rules)
+}
+
+// AddRecords mocks base method.
+func (m *MockServiceInterface) AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecords", ctx, userGUID, records)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRecords indicates an expected call of AddRecords.
+func (mr *MockServiceInterfaceMockRecorder) AddRecords(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockServiceInterface)(nil).AddRecords), ctx, userGUID, records)
+}
+
+// AddRecurring mocks base method.
+func (m *MockServiceInterface) AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecurring", ctx, userGUID, records)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRecurring indicates an expected call of AddRecurring.
+func (mr *MockServiceInterfaceMockRecorder) AddRecurring(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecurring", reflect.TypeOf((*MockServiceInterface)(nil).AddRecurring), ctx, userGUID, records)
+}
+
+// AddUsers mocks base method.
+func (m *MockServiceInterface) AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUsers", ctx, users)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUsers indicates an expected call of AddUsers.
+func (mr *MockServiceInterfaceMockRecorder) AddUsers(ctx, users interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockServiceInterface)(nil).AddUsers), ctx, users)
+}
+
+// AdvanceRecurring mocks base method.
+func (m *MockServiceInterface) AdvanceRecurring(ctx context.Context, record ftracker.RecurringRecord) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceRecurring", ctx, record)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceRecurring indicates an expected call of AdvanceRecurring.
+func (mr *MockServiceInterfaceMockRecorder) AdvanceRecurring(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceRecurring", reflect.TypeOf((*MockServiceInterface)(nil).AdvanceRecurring), ctx, record)
+}
+
+// CreateCSVFromCategories mocks base method.
+func (m *MockServiceInterface) CreateCSVFromCategories(categories []ftracker.SpendingCategory, currency string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCSVFromCategories", categories, currency)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCSVFromCategories indicates an expected call of CreateCSVFromCategories.
+func (mr *MockServiceInterfaceMockRecorder) CreateCSVFromCategories(categories, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCSVFromCategories", reflect.TypeOf((*MockServiceInterface)(nil).CreateCSVFromCategories), categories, currency)
+}
+
+// CreateCSVFromRecords mocks base method.
+func (m *MockServiceInterface) CreateCSVFromRecords(records []ftracker.SpendingRecord, categories []ftracker.SpendingCategory) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCSVFromRecords", records, categories)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCSVFromRecords indicates an expected call of CreateCSVFromRecords.
+func (mr *MockServiceInterfaceMockRecorder) CreateCSVFromRecords(records, categories interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCSVFromRecords", reflect.TypeOf((*MockServiceInterface)(nil).CreateCSVFromRecords), records, categories)
+}
+
+// CreateExelFromCategories mocks base method.
+func (m *MockServiceInterface) CreateExelFromCategories(categories []ftracker.SpendingCategory, currency string) (*excelize.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExelFromCategories", categories, currency)
+	ret0, _ := ret[0].(*excelize.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExelFromCategories indicates an expected call of CreateExelFromCategories.
+func (mr *MockServiceInterfaceMockRecorder) CreateExelFromCategories(categories, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromCategories", reflect.TypeOf((*MockServiceInterface)(nil).CreateExelFromCategories), categories, currency)
+}
+
+// CreateExelFromRecords mocks base method.
+func (m *MockServiceInterface) CreateExelFromRecords(recods []ftracker.SpendingRecord, categories []ftracker.SpendingCategory, currency string) (*excelize.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExelFromRecords", recods, categories, currency)
+	ret0, _ := ret[0].(*excelize.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExelFromRecords indicates an expected call of CreateExelFromRecords.
+func (mr *MockServiceInterfaceMockRecorder) CreateExelFromRecords(recods, categories, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExelFromRecords", reflect.TypeOf((*MockServiceInterface)(nil).CreateExelFromRecords), recods, categories, currency)
+}
+
+// DeleteBudgets mocks base method.
+func (m *MockServiceInterface) DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBudgets", ctx, userGUID, categoryGUIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBudgets indicates an expected call of DeleteBudgets.
+func (mr *MockServiceInterfaceMockRecorder) DeleteBudgets(ctx, userGUID, categoryGUIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBudgets", reflect.TypeOf((*MockServiceInterface)(nil).DeleteBudgets), ctx, userGUID, categoryGUIDs)
+}
+
+// DeleteCategories mocks base method.
+func (m *MockServiceInterface) DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategories", ctx, userGUID, guids, moveTo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategories indicates an expected call of DeleteCategories.
+func (mr *MockServiceInterfaceMockRecorder) DeleteCategories(ctx, userGUID, guids, moveTo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategories", reflect.TypeOf((*MockServiceInterface)(nil).DeleteCategories), ctx, userGUID, guids, moveTo)
+}
+
+// DeletePayeeRules mocks base method.
+func (m *MockServiceInterface) DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayeeRules", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePayeeRules indicates an expected call of DeletePayeeRules.
+func (mr *MockServiceInterfaceMockRecorder) DeletePayeeRules(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayeeRules", reflect.TypeOf((*MockServiceInterface)(nil).DeletePayeeRules), ctx, userGUID, guids)
+}
+
+// DeleteRecords mocks base method.
+func (m *MockServiceInterface) DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecords", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecords indicates an expected call of DeleteRecords.
+func (mr *MockServiceInterfaceMockRecorder) DeleteRecords(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecords", reflect.TypeOf((*MockServiceInterface)(nil).DeleteRecords), ctx, userGUID, guids)
+}
+
+// DeleteRecurring mocks base method.
+func (m *MockServiceInterface) DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecurring", ctx, userGUID, guids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecurring indicates an expected call of DeleteRecurring.
+func (mr *MockServiceInterfaceMockRecorder) DeleteRecurring(ctx, userGUID, guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecurring", reflect.TypeOf((*MockServiceInterface)(nil).DeleteRecurring), ctx, userGUID, guids)
+}
+
+// DeleteSession mocks base method.
+func (m *MockServiceInterface) DeleteSession(ctx context.Context, chatID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockServiceInterfaceMockRecorder) DeleteSession(ctx, chatID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockServiceInterface)(nil).DeleteSession), ctx, chatID)
+}
+
+// EvaluateBudget mocks base method.
+func (m *MockServiceInterface) EvaluateBudget(ctx context.Context, userGUID, recordGUID uuid.UUID) (service.BudgetAlert, ftracker.BudgetStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateBudget", ctx, userGUID, recordGUID)
+	ret0, _ := ret[0].(service.BudgetAlert)
+	ret1, _ := ret[1].(ftracker.BudgetStatus)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EvaluateBudget indicates an expected call of EvaluateBudget.
+func (mr *MockServiceInterfaceMockRecorder) EvaluateBudget(ctx, userGUID, recordGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateBudget", reflect.TypeOf((*MockServiceInterface)(nil).EvaluateBudget), ctx, userGUID, recordGUID)
+}
+
+// GetBalance mocks base method.
+func (m *MockServiceInterface) GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", ctx, userGUID, from, to)
+	ret0, _ := ret[0].(ftracker.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockServiceInterfaceMockRecorder) GetBalance(ctx, userGUID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockServiceInterface)(nil).GetBalance), ctx, userGUID, from, to)
+}
+
+// GetBudgets mocks base method.
+func (m *MockServiceInterface) GetBudgets(ctx context.Context, userGUID uuid.UUID, month time.Time, categoryGUIDs ...uuid.UUID) ([]ftracker.BudgetStatus, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID, month}
+	for _, a := range categoryGUIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetBudgets", varargs...)
+	ret0, _ := ret[0].([]ftracker.BudgetStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBudgets indicates an expected call of GetBudgets.
+func (mr *MockServiceInterfaceMockRecorder) GetBudgets(ctx, userGUID, month interface{}, categoryGUIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID, month}, categoryGUIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBudgets", reflect.TypeOf((*MockServiceInterface)(nil).GetBudgets), varargs...)
+}
+
+// GetCategories mocks base method.
+func (m *MockServiceInterface) GetCategories(ctx context.Context, userGUID uuid.UUID, opts ...service.CategoryOption) ([]ftracker.SpendingCategory, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetCategories", varargs...)
+	ret0, _ := ret[0].([]ftracker.SpendingCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories.
+func (mr *MockServiceInterfaceMockRecorder) GetCategories(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockServiceInterface)(nil).GetCategories), varargs...)
+}
+
+// GetCategoryTree mocks base method.
+func (m *MockServiceInterface) GetCategoryTree(ctx context.Context, userGUID uuid.UUID, opts ...service.CategoryOption) ([]ftracker.CategoryTree, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetCategoryTree", varargs...)
+	ret0, _ := ret[0].([]ftracker.CategoryTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryTree indicates an expected call of GetCategoryTree.
+func (mr *MockServiceInterfaceMockRecorder) GetCategoryTree(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryTree", reflect.TypeOf((*MockServiceInterface)(nil).GetCategoryTree), varargs...)
+}
+
+// GetDueRecurring mocks base method.
+func (m *MockServiceInterface) GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueRecurring", ctx, now, limit)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueRecurring indicates an expected call of GetDueRecurring.
+func (mr *MockServiceInterfaceMockRecorder) GetDueRecurring(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueRecurring", reflect.TypeOf((*MockServiceInterface)(nil).GetDueRecurring), ctx, now, limit)
+}
+
+// GetExchangeRates mocks base method.
+func (m *MockServiceInterface) GetExchangeRates(ctx context.Context, currencies ...string) ([]ftracker.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range currencies {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetExchangeRates", varargs...)
+	ret0, _ := ret[0].([]ftracker.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRates indicates an expected call of GetExchangeRates.
+func (mr *MockServiceInterfaceMockRecorder) GetExchangeRates(ctx interface{}, currencies ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, currencies...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockServiceInterface)(nil).GetExchangeRates), varargs...)
+}
+
+// GetPayeeRules mocks base method.
+func (m *MockServiceInterface) GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayeeRules", ctx, userGUID)
+	ret0, _ := ret[0].([]ftracker.PayeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayeeRules indicates an expected call of GetPayeeRules.
+func (mr *MockServiceInterfaceMockRecorder) GetPayeeRules(ctx, userGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeRules", reflect.TypeOf((*MockServiceInterface)(nil).GetPayeeRules), ctx, userGUID)
+}
+
+// GetRecords mocks base method.
+func (m *MockServiceInterface) GetRecords(ctx context.Context, userGUID uuid.UUID, opts ...service.RecordOption) ([]ftracker.SpendingRecord, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRecords", varargs...)
+	ret0, _ := ret[0].([]ftracker.SpendingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecords indicates an expected call of GetRecords.
+func (mr *MockServiceInterfaceMockRecorder) GetRecords(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockServiceInterface)(nil).GetRecords), varargs...)
+}
+
+// GetRecurring mocks base method.
+func (m *MockServiceInterface) GetRecurring(ctx context.Context, userGUID uuid.UUID, opts ...service.RecurringOption) ([]ftracker.RecurringRecord, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userGUID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRecurring", varargs...)
+	ret0, _ := ret[0].([]ftracker.RecurringRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecurring indicates an expected call of GetRecurring.
+func (mr *MockServiceInterfaceMockRecorder) GetRecurring(ctx, userGUID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userGUID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurring", reflect.TypeOf((*MockServiceInterface)(nil).GetRecurring), varargs...)
+}
+
+// GetSessions mocks base method.
+func (m *MockServiceInterface) GetSessions(ctx context.Context) ([]ftracker.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx)
+	ret0, _ := ret[0].([]ftracker.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockServiceInterfaceMockRecorder) GetSessions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockServiceInterface)(nil).GetSessions), ctx)
+}
+
+// GetTagSpending mocks base method.
+func (m *MockServiceInterface) GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagSpending", ctx, userGUID, from, to)
+	ret0, _ := ret[0].([]ftracker.TagSpending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagSpending indicates an expected call of GetTagSpending.
+func (mr *MockServiceInterfaceMockRecorder) GetTagSpending(ctx, userGUID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagSpending", reflect.TypeOf((*MockServiceInterface)(nil).GetTagSpending), ctx, userGUID, from, to)
+}
+
+// GetUsers mocks base method.
+func (m *MockServiceInterface) GetUsers(ctx context.Context, opts ...service.UserOption) ([]ftracker.User, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetUsers", varargs...)
+	ret0, _ := ret[0].([]ftracker.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockServiceInterfaceMockRecorder) GetUsers(ctx interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockServiceInterface)(nil).GetUsers), varargs...)
+}
+
+// ImportBankTransactions mocks base method.
+func (m *MockServiceInterface) ImportBankTransactions(ctx context.Context, userGUID uuid.UUID, transactions []ftracker.BankTransaction) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportBankTransactions", ctx, userGUID, transactions)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportBankTransactions indicates an expected call of ImportBankTransactions.
+func (mr *MockServiceInterfaceMockRecorder) ImportBankTransactions(ctx, userGUID, transactions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBankTransactions", reflect.TypeOf((*MockServiceInterface)(nil).ImportBankTransactions), ctx, userGUID, transactions)
+}
+
+// ImportRecordsCSV mocks base method.
+func (m *MockServiceInterface) ImportRecordsCSV(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts service.ImportOptions) (service.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRecordsCSV", ctx, userGUID, r, opts)
+	ret0, _ := ret[0].(service.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRecordsCSV indicates an expected call of ImportRecordsCSV.
+func (mr *MockServiceInterfaceMockRecorder) ImportRecordsCSV(ctx, userGUID, r, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRecordsCSV", reflect.TypeOf((*MockServiceInterface)(nil).ImportRecordsCSV), ctx, userGUID, r, opts)
+}
+
+// ImportRecordsExel mocks base method.
+func (m *MockServiceInterface) ImportRecordsExel(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts service.ImportOptions) (service.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRecordsExel", ctx, userGUID, r, opts)
+	ret0, _ := ret[0].(service.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRecordsExel indicates an expected call of ImportRecordsExel.
+func (mr *MockServiceInterfaceMockRecorder) ImportRecordsExel(ctx, userGUID, r, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRecordsExel", reflect.TypeOf((*MockServiceInterface)(nil).ImportRecordsExel), ctx, userGUID, r, opts)
+}
+
+// IssueAPIToken mocks base method.
+func (m *MockServiceInterface) IssueAPIToken(ctx context.Context, userGUID uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIToken", ctx, userGUID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIToken indicates an expected call of IssueAPIToken.
+func (mr *MockServiceInterfaceMockRecorder) IssueAPIToken(ctx, userGUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIToken", reflect.TypeOf((*MockServiceInterface)(nil).IssueAPIToken), ctx, userGUID)
+}
+
+// LoadExchangeRates mocks base method.
+func (m *MockServiceInterface) LoadExchangeRates(ctx context.Context, r io.Reader) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadExchangeRates", ctx, r)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadExchangeRates indicates an expected call of LoadExchangeRates.
+func (mr *MockServiceInterfaceMockRecorder) LoadExchangeRates(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadExchangeRates", reflect.TypeOf((*MockServiceInterface)(nil).LoadExchangeRates), ctx, r)
+}
+
+// ParseBankStatement mocks base method.
+func (m *MockServiceInterface) ParseBankStatement(ctx context.Context, userGUID uuid.UUID, r io.Reader, currency string) (service.BankStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseBankStatement", ctx, userGUID, r, currency)
+	ret0, _ := ret[0].(service.BankStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseBankStatement indicates an expected call of ParseBankStatement.
+func (mr *MockServiceInterfaceMockRecorder) ParseBankStatement(ctx, userGUID, r, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseBankStatement", reflect.TypeOf((*MockServiceInterface)(nil).ParseBankStatement), ctx, userGUID, r, currency)
+}
+
+// RecurringRecordsWithCategoryGUIDs mocks base method.
+func (m *MockServiceInterface) RecurringRecordsWithCategoryGUIDs(guids []uuid.UUID) service.RecurringOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecurringRecordsWithCategoryGUIDs", guids)
+	ret0, _ := ret[0].(service.RecurringOption)
+	return ret0
+}
+
+// RecurringRecordsWithCategoryGUIDs indicates an expected call of RecurringRecordsWithCategoryGUIDs.
+func (mr *MockServiceInterfaceMockRecorder) RecurringRecordsWithCategoryGUIDs(guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringRecordsWithCategoryGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).RecurringRecordsWithCategoryGUIDs), guids)
+}
+
+// RecurringRecordsWithGUIDs mocks base method.
+func (m *MockServiceInterface) RecurringRecordsWithGUIDs(guids []uuid.UUID) service.RecurringOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecurringRecordsWithGUIDs", guids)
+	ret0, _ := ret[0].(service.RecurringOption)
+	return ret0
+}
+
+// RecurringRecordsWithGUIDs indicates an expected call of RecurringRecordsWithGUIDs.
+func (mr *MockServiceInterfaceMockRecorder) RecurringRecordsWithGUIDs(guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringRecordsWithGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).RecurringRecordsWithGUIDs), guids)
+}
+
+// SaveSession mocks base method.
+func (m *MockServiceInterface) SaveSession(ctx context.Context, session ftracker.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MockServiceInterfaceMockRecorder) SaveSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockServiceInterface)(nil).SaveSession), ctx, session)
+}
+
+// SetBudgets mocks base method.
+func (m *MockServiceInterface) SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBudgets", ctx, userGUID, budgets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBudgets indicates an expected call of SetBudgets.
+func (mr *MockServiceInterfaceMockRecorder) SetBudgets(ctx, userGUID, budgets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBudgets", reflect.TypeOf((*MockServiceInterface)(nil).SetBudgets), ctx, userGUID, budgets)
+}
+
+// SetExchangeRates mocks base method.
+func (m *MockServiceInterface) SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeRates", ctx, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExchangeRates indicates an expected call of SetExchangeRates.
+func (mr *MockServiceInterfaceMockRecorder) SetExchangeRates(ctx, rates interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRates", reflect.TypeOf((*MockServiceInterface)(nil).SetExchangeRates), ctx, rates)
+}
+
+// SetUserCurrency mocks base method.
+func (m *MockServiceInterface) SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserCurrency", ctx, userGUID, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserCurrency indicates an expected call of SetUserCurrency.
+func (mr *MockServiceInterfaceMockRecorder) SetUserCurrency(ctx, userGUID, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserCurrency", reflect.TypeOf((*MockServiceInterface)(nil).SetUserCurrency), ctx, userGUID, currency)
+}
+
+// SpendingCategoriesWithCategories mocks base method.
+func (m *MockServiceInterface) SpendingCategoriesWithCategories(categories []string) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithCategories", categories)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithCategories indicates an expected call of SpendingCategoriesWithCategories.
+func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithCategories(categories interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithCategories", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithCategories), categories)
+}
+
+// SpendingCategoriesWithGUIDs mocks base method.
+func (m *MockServiceInterface) SpendingCategoriesWithGUIDs(guids []uuid.UUID) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithGUIDs", guids)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithGUIDs indicates an expected call of SpendingCategoriesWithGUIDs.
+func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithGUIDs(guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithGUIDs), guids)
+}
+
+// SpendingCategoriesWithKinds mocks base method.
+func (m *MockServiceInterface) SpendingCategoriesWithKinds(kinds []string) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithKinds", kinds)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithKinds indicates an expected call of SpendingCategoriesWithKinds.
+func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithKinds(kinds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithKinds", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithKinds), kinds)
+}
+
+// SpendingCategoriesWithLimit mocks base method.
+func (m *MockServiceInterface) SpendingCategoriesWithLimit(limit int) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithLimit", limit)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithLimit indicates an expected call of SpendingCategoriesWithLimit.
+func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithLimit(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithLimit", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithLimit), limit)
+}
+
+// SpendingCategoriesWithOrder mocks base method.
+func (m *MockServiceInterface) SpendingCategoriesWithOrder(order service.CategoryOrder, asc bool) service.CategoryOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingCategoriesWithOrder", order, asc)
+	ret0, _ := ret[0].(service.CategoryOption)
+	return ret0
+}
+
+// SpendingCategoriesWithOrder indicates an expected call of SpendingCategoriesWithOrder.
+func (mr *MockServiceInterfaceMockRecorder) SpendingCategoriesWithOrder(order, asc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingCategoriesWithOrder", reflect.TypeOf((*MockServiceInterface)(nil).SpendingCategoriesWithOrder), order, asc)
+}
+
+// SpendingRecordsWithCategoryGUIDs mocks base method.
+func (m *MockServiceInterface) SpendingRecordsWithCategoryGUIDs(guids []uuid.UUID) service.RecordOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingRecordsWithCategoryGUIDs", guids)
+	ret0, _ := ret[0].(service.RecordOption)
+	return ret0
+}
+
+// SpendingRecordsWithCategoryGUIDs indicates an expected call of SpendingRecordsWithCategoryGUIDs.
+func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithCategoryGUIDs(guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithCategoryGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithCategoryGUIDs), guids)
+}
+
+// SpendingRecordsWithGUIDs mocks base method.
+func (m *MockServiceInterface) SpendingRecordsWithGUIDs(guids []uuid.UUID) service.RecordOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingRecordsWithGUIDs", guids)
+	ret0, _ := ret[0].(service.RecordOption)
+	return ret0
+}
+
+// SpendingRecordsWithGUIDs indicates an expected call of SpendingRecordsWithGUIDs.
+func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithGUIDs(guids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithGUIDs", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithGUIDs), guids)
 }
 
 // SpendingRecordsWithLimit mocks base method.
@@ -693,6 +1903,20 @@ func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithOrder(order, asc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithOrder", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithOrder), order, asc)
 }
 
+// SpendingRecordsWithTags mocks base method.
+func (m *MockServiceInterface) SpendingRecordsWithTags(tags []string, all bool) service.RecordOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendingRecordsWithTags", tags, all)
+	ret0, _ := ret[0].(service.RecordOption)
+	return ret0
+}
+
+// SpendingRecordsWithTags indicates an expected call of SpendingRecordsWithTags.
+func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithTags(tags, all interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithTags", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithTags), tags, all)
+}
+
 // SpendingRecordsWithTimeFrame mocks base method.
 func (m *MockServiceInterface) SpendingRecordsWithTimeFrame(from, to time.Time) service.RecordOption {
 	m.ctrl.T.Helper()
@@ -707,6 +1931,48 @@ func (mr *MockServiceInterfaceMockRecorder) SpendingRecordsWithTimeFrame(from, t
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendingRecordsWithTimeFrame", reflect.TypeOf((*MockServiceInterface)(nil).SpendingRecordsWithTimeFrame), from, to)
 }
 
+// UpdateCategories mocks base method.
+func (m *MockServiceInterface) UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategories", ctx, userGUID, categories)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategories indicates an expected call of UpdateCategories.
+func (mr *MockServiceInterfaceMockRecorder) UpdateCategories(ctx, userGUID, categories interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategories", reflect.TypeOf((*MockServiceInterface)(nil).UpdateCategories), ctx, userGUID, categories)
+}
+
+// UpdateRecords mocks base method.
+func (m *MockServiceInterface) UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecords", ctx, userGUID, records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecords indicates an expected call of UpdateRecords.
+func (mr *MockServiceInterfaceMockRecorder) UpdateRecords(ctx, userGUID, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecords", reflect.TypeOf((*MockServiceInterface)(nil).UpdateRecords), ctx, userGUID, records)
+}
+
+// UsersWithAPIToken mocks base method.
+func (m *MockServiceInterface) UsersWithAPIToken(token string) service.UserOption {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsersWithAPIToken", token)
+	ret0, _ := ret[0].(service.UserOption)
+	return ret0
+}
+
+// UsersWithAPIToken indicates an expected call of UsersWithAPIToken.
+func (mr *MockServiceInterfaceMockRecorder) UsersWithAPIToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsersWithAPIToken", reflect.TypeOf((*MockServiceInterface)(nil).UsersWithAPIToken), token)
+}
+
 // UsersWithGUIDs mocks base method.
 func (m *MockServiceInterface) UsersWithGUIDs(guids []uuid.UUID) service.UserOption {
 	m.ctrl.T.Helper()
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+// maxPatternLen is the length of the pattern column
+const maxPatternLen = 255
+
+// ErrInvalidPattern is returned when the pattern of a payee rule is empty or too long.
+var ErrInvalidPattern = errors.New("invalid payee pattern")
+
+// PayeeRuleService implements the PayeeRule interface.
+type PayeeRuleService struct {
+	repo repository.PayeeRule
+}
+
+// NewPayeeRuleService creates a new instance of PayeeRuleService with the provided repository.
+func NewPayeeRuleService(repo repository.PayeeRule) *PayeeRuleService {
+	return &PayeeRuleService{
+		repo: repo,
+	}
+}
+
+// AddPayeeRules adds multiple payee rules to the repository.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - rules: A slice of PayeeRule objects to be added, the patterns are matched case-insensitively,
+//     so they are stored in lowercase without the surrounding spaces.
+//
+// Returns:
+//   - A slice of UUIDs representing the IDs of the newly added rules.
+//   - An error if the operation fails, ErrInvalidPattern if one of the patterns is empty or too long.
+func (s *PayeeRuleService) AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
+	toAdd := make([]ftracker.PayeeRule, len(rules))
+	for i, rule := range rules {
+		rule.Pattern = normalizePayee(rule.Pattern)
+		if rule.Pattern == "" || len(rule.Pattern) > maxPatternLen {
+			return nil, fmt.Errorf("AddPayeeRules: %w: %q", ErrInvalidPattern, rule.Pattern)
+		}
+		toAdd[i] = rule
+	}
+
+	return s.repo.AddPayeeRules(ctx, userGUID, toAdd)
+}
+
+// GetPayeeRules retrieves the payee rules of the user ordered by their patterns.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//
+// Returns:
+//   - []ftracker.PayeeRule: The rules with the names and the kinds of their categories.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *PayeeRuleService) GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+	return s.repo.GetPayeeRules(ctx, userGUID)
+}
+
+// DeletePayeeRules deletes multiple payee rules, the records imported with them are kept.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - guids: A slice of UUIDs of the rules to be deleted.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *PayeeRuleService) DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.DeletePayeeRules(ctx, userGUID, guids)
+}
+
+// normalizePayee lowercases the payee and collapses its spaces, so the patterns match
+// however the banks spell the payees
+func normalizePayee(payee string) string {
+	return strings.Join(strings.Fields(strings.ToLower(payee)), " ")
+}
+
+// matchPayeeRule finds the rule for the transaction, the rules of the categories of another kind are skipped,
+// the longest of the patterns the payee contains wins, so "lidl" may go to groceries and "lidl mobile" to the phone
+func matchPayeeRule(rules []ftracker.PayeeRule, transaction ftracker.BankTransaction) (ftracker.PayeeRule, bool) {
+	payee := normalizePayee(transaction.Payee)
+	if payee == "" {
+		payee = normalizePayee(transaction.Description)
+	}
+
+	var (
+		best  ftracker.PayeeRule
+		found bool
+	)
+	for _, rule := range rules {
+		if rule.Kind != transaction.Kind || !strings.Contains(payee, rule.Pattern) {
+			continue
+		}
+		if !found || len(rule.Pattern) > len(best.Pattern) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
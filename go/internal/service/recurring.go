@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+type (
+	// RecurringService implements the Recurring interface.
+	RecurringService struct {
+		repo repository.Recurring
+	}
+
+	// RecurringOption is a function to modify the RecurringOptions.
+	RecurringOption func(*repository.RecurringOptions)
+)
+
+// NewRecurringService creates a new instance of RecurringService with the provided repository.
+func NewRecurringService(repo repository.Recurring) *RecurringService {
+	return &RecurringService{
+		repo: repo,
+	}
+}
+
+// RecurringRecordsWithGUIDs is a function that sets the GUIDs for the recurring records to be returned.
+func (RecurringService) RecurringRecordsWithGUIDs(guids []uuid.UUID) RecurringOption {
+	return func(o *repository.RecurringOptions) {
+		o.GUIDs = guids
+	}
+}
+
+// RecurringRecordsWithCategoryGUIDs is a function that sets the category GUIDs for the recurring records to be returned.
+func (RecurringService) RecurringRecordsWithCategoryGUIDs(guids []uuid.UUID) RecurringOption {
+	return func(o *repository.RecurringOptions) {
+		o.CategoryGUIDs = guids
+	}
+}
+
+// AddRecurring adds multiple recurring records to the repository.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - records: A slice of RecurringRecord objects to be added, a record without StartsAt starts now,
+//     the first occurrence of a cron schedule is the first one not before StartsAt.
+//
+// Returns:
+//   - A slice of UUIDs representing the IDs of the newly added recurring records.
+//   - An error if the operation fails, ErrInvalidSchedule if one of the schedules can't be parsed.
+func (s *RecurringService) AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
+	toAdd := make([]ftracker.RecurringRecord, len(records))
+	for i, record := range records {
+		schedule, err := ParseSchedule(record.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("AddRecurring: %w", err)
+		}
+
+		start := record.StartsAt
+		if start.IsZero() {
+			start = time.Now().UTC().Truncate(time.Minute)
+		}
+		record.StartsAt = schedule.Next(start, start.Add(-time.Nanosecond))
+		toAdd[i] = record
+	}
+
+	return s.repo.AddRecurring(ctx, userGUID, toAdd)
+}
+
+// GetRecurring retrieves the recurring records of the user based on the provided options.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - options: A variadic list of RecurringOption functions used to configure the query options.
+//
+// Returns:
+//   - []ftracker.RecurringRecord: The recurring records ordered by their next occurrence.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *RecurringService) GetRecurring(ctx context.Context, userGUID uuid.UUID, options ...RecurringOption) ([]ftracker.RecurringRecord, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
+	var opts repository.RecurringOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return s.repo.GetRecurring(ctx, userGUID, opts)
+}
+
+// DeleteRecurring deletes multiple recurring records, the records made from them are kept.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - guids: A slice of UUIDs of the recurring records to be deleted.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *RecurringService) DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.DeleteRecurring(ctx, userGUID, guids)
+}
+
+// GetDueRecurring retrieves the recurring records of all the users which are due by now.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - now: The moment the occurrences are due by.
+//   - limit: The maximum number of recurring records to return, 0 means no limit.
+//
+// Returns:
+//   - []ftracker.RecurringRecord: The due recurring records, the earliest first.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *RecurringService) GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error) {
+	return s.repo.GetDueRecurring(ctx, now, limit)
+}
+
+// AdvanceRecurring moves the recurring record to the occurrence following its NextRunAt.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - record: The recurring record whose NextRunAt has been recorded.
+//
+// Returns:
+//   - time.Time: The new next occurrence.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *RecurringService) AdvanceRecurring(ctx context.Context, record ftracker.RecurringRecord) (time.Time, error) {
+	schedule, err := ParseSchedule(record.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("AdvanceRecurring: %w", err)
+	}
+
+	next := schedule.Next(record.StartsAt, record.NextRunAt)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("AdvanceRecurring: %w: no occurrence after %s", ErrInvalidSchedule, record.NextRunAt)
+	}
+
+	err = s.repo.AdvanceRecurring(ctx, record.GUID, next)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("AdvanceRecurring: %w", err)
+	}
+	return next, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a schedule of a recurring record can't be parsed.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// cronHorizon is how far ahead a cron schedule is searched for the next occurrence,
+// a schedule without an occurrence within it never occurs.
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+type (
+	// Schedule tells when a recurring record occurs.
+	Schedule interface {
+		// Next returns the first occurrence later than after,
+		// start is the first occurrence of the recurring record, the ones before it are skipped.
+		Next(start, after time.Time) time.Time
+	}
+
+	// intervalSchedule repeats the first occurrence every days and months.
+	intervalSchedule struct {
+		days   int
+		months int
+	}
+
+	// cronSchedule occurs at the minutes matching all its fields,
+	// every field is a bit set of the allowed values.
+	cronSchedule struct {
+		minutes  uint64
+		hours    uint64
+		days     uint64
+		months   uint64
+		weekdays uint64
+		// if both days and weekdays are restricted, matching either of them is enough
+		anyDay bool
+	}
+)
+
+// cron fields in the order of a cron expression with their allowed values
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseSchedule parses the schedule of a recurring record.
+//
+// Parameters:
+//   - s: Either daily, weekly or monthly to repeat the first occurrence,
+//     or a cron expression of 5 fields: minute, hour, day of month, month and day of week,
+//     each field is *, a value, a range a-b, a list of them or any of them with a /step.
+//
+// Returns:
+//   - The parsed Schedule.
+//   - ErrInvalidSchedule if the schedule can't be parsed or never occurs.
+func ParseSchedule(s string) (Schedule, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "daily":
+		return intervalSchedule{days: 1}, nil
+	case "weekly":
+		return intervalSchedule{days: 7}, nil
+	case "monthly":
+		return intervalSchedule{months: 1}, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("ParseSchedule: %w: %q has %d fields", ErrInvalidSchedule, s, len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("ParseSchedule: %w: %s: %s", ErrInvalidSchedule, cronFields[i].name, err.Error())
+		}
+		sets[i] = set
+	}
+	// both 0 and 7 stand for Sunday
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	schedule := cronSchedule{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+		anyDay:   fields[2] != "*" && fields[4] != "*",
+	}
+	now := time.Now()
+	if schedule.Next(now, now).IsZero() {
+		return nil, fmt.Errorf("ParseSchedule: %w: %q never occurs", ErrInvalidSchedule, s)
+	}
+
+	return schedule, nil
+}
+
+// parseCronField turns a field of a cron expression into the bit set of its values.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		from, to := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step != 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Next implements the Schedule interface.
+func (s intervalSchedule) Next(start, after time.Time) time.Time {
+	if start.After(after) {
+		return start
+	}
+
+	// the estimate is never later than the next occurrence, so it is only moved forward
+	var n int
+	if s.months > 0 {
+		n = ((after.Year()-start.Year())*12 + int(after.Month()-start.Month())) / s.months
+	} else {
+		n = int(after.Sub(start) / (time.Duration(s.days) * 24 * time.Hour))
+	}
+	next := s.occurrence(start, n)
+	for !next.After(after) {
+		n++
+		next = s.occurrence(start, n)
+	}
+	return next
+}
+
+// occurrence returns the n-th repetition of the start, a monthly occurrence falls on the last day
+// of the month if the month is shorter than the day of the start.
+func (s intervalSchedule) occurrence(start time.Time, n int) time.Time {
+	if s.months == 0 {
+		return start.AddDate(0, 0, n*s.days)
+	}
+
+	year, month := start.Year(), start.Month()+time.Month(n*s.months)
+	day := start.Day()
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location()).Day(); day > last {
+		day = last
+	}
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
+
+// Next implements the Schedule interface, it returns the zero time
+// if the schedule has no occurrence within the cronHorizon.
+func (s cronSchedule) Next(start, after time.Time) time.Time {
+	if start.After(after) {
+		after = start.Add(-time.Nanosecond)
+	}
+
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	horizon := next.Add(cronHorizon)
+	for next.Before(horizon) {
+		switch {
+		case s.months&(1<<next.Month()) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !s.matchDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case s.hours&(1<<next.Hour()) == 0:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case s.minutes&(1<<next.Minute()) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay checks the day of month and the day of week of the moment.
+func (s cronSchedule) matchDay(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<t.Weekday()) != 0
+	if s.anyDay {
+		return day || weekday
+	}
+	return day && weekday
+}
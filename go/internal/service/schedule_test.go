@@ -0,0 +1,142 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseSchedule(t *testing.T) {
+
+	tt := []struct {
+		name     string
+		schedule string
+		wantErr  bool
+	}{
+		{name: "Daily", schedule: "daily"},
+		{name: "Monthly_uppercase", schedule: " Monthly "},
+		{name: "Cron", schedule: "0 9 1 * *"},
+		{name: "Cron_lists_and_steps", schedule: "*/15 8-18/2 * 1,6-8 1-5"},
+		{name: "Cron_sunday_as_seven", schedule: "0 0 * * 7"},
+		{name: "Unknown_word", schedule: "yearly", wantErr: true},
+		{name: "Few_fields", schedule: "0 9 1 *", wantErr: true},
+		{name: "Out_of_range", schedule: "60 9 1 * *", wantErr: true},
+		{name: "Reversed_range", schedule: "0 9 10-1 * *", wantErr: true},
+		{name: "Zero_step", schedule: "*/0 * * * *", wantErr: true},
+		{name: "Not_a_number", schedule: "0 nine * * *", wantErr: true},
+		{name: "Never_occurs", schedule: "0 0 31 2 *", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSchedule(tc.schedule)
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrInvalidSchedule)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_Schedule_Next(t *testing.T) {
+
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tt := []struct {
+		name     string
+		schedule string
+		start    time.Time
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "Before_start",
+			schedule: "daily",
+			start:    date(2024, 10, 1, 9, 0),
+			after:    date(2024, 9, 1, 0, 0),
+			want:     date(2024, 10, 1, 9, 0),
+		},
+		{
+			name:     "Daily_same_time",
+			schedule: "daily",
+			start:    date(2024, 10, 1, 9, 0),
+			after:    date(2024, 10, 5, 9, 0),
+			want:     date(2024, 10, 6, 9, 0),
+		},
+		{
+			name:     "Weekly",
+			schedule: "weekly",
+			start:    date(2024, 10, 1, 9, 0),
+			after:    date(2024, 10, 9, 12, 0),
+			want:     date(2024, 10, 15, 9, 0),
+		},
+		{
+			name:     "Monthly_short_month",
+			schedule: "monthly",
+			start:    date(2024, 1, 31, 9, 0),
+			after:    date(2024, 1, 31, 9, 0),
+			want:     date(2024, 2, 29, 9, 0),
+		},
+		{
+			name:     "Monthly_no_drift",
+			schedule: "monthly",
+			start:    date(2024, 1, 31, 9, 0),
+			after:    date(2024, 2, 29, 9, 0),
+			want:     date(2024, 3, 31, 9, 0),
+		},
+		{
+			name:     "Monthly_after_long_downtime",
+			schedule: "monthly",
+			start:    date(2024, 1, 15, 9, 0),
+			after:    date(2025, 6, 20, 0, 0),
+			want:     date(2025, 7, 15, 9, 0),
+		},
+		{
+			name:     "Cron_first_of_month",
+			schedule: "0 9 1 * *",
+			start:    date(2024, 10, 1, 9, 0),
+			after:    date(2024, 10, 1, 9, 0),
+			want:     date(2024, 11, 1, 9, 0),
+		},
+		{
+			name:     "Cron_start_matches",
+			schedule: "0 9 1 * *",
+			start:    date(2024, 10, 1, 9, 0),
+			after:    date(2024, 10, 1, 8, 59),
+			want:     date(2024, 10, 1, 9, 0),
+		},
+		{
+			name:     "Cron_weekdays",
+			schedule: "30 18 * * 1-5",
+			start:    date(2024, 10, 1, 0, 0),
+			after:    date(2024, 10, 4, 19, 0), // Friday
+			want:     date(2024, 10, 7, 18, 30),
+		},
+		{
+			name:     "Cron_day_or_weekday",
+			schedule: "0 0 13 * 5",
+			start:    date(2024, 9, 1, 0, 0),
+			after:    date(2024, 9, 1, 0, 0),
+			want:     date(2024, 9, 6, 0, 0), // Friday comes before the 13th
+		},
+		{
+			name:     "Cron_leap_day",
+			schedule: "0 0 29 2 *",
+			start:    date(2024, 3, 1, 0, 0),
+			after:    date(2024, 3, 1, 0, 0),
+			want:     date(2028, 2, 29, 0, 0),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.schedule)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, schedule.Next(tc.start, tc.after))
+		})
+	}
+}
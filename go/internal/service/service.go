@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,38 +12,108 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// ErrOwnerRequired is returned when a category or record operation is called without the owner user GUID.
+var ErrOwnerRequired = errors.New("owner user guid is required")
+
 // User defines the interface for user service.
 type User interface {
-	AddUsers(users []ftracker.User) ([]uuid.UUID, error)
-	GetUsers(opts ...UserOption) ([]ftracker.User, error)
+	AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error)
+	GetUsers(ctx context.Context, opts ...UserOption) ([]ftracker.User, error)
 	UsersWithLimit(limit int) UserOption
 	UsersWithGUIDs(guids []uuid.UUID) UserOption
 	UsersWithUsernames(usernames []string) UserOption
 	UsersWithTelegramIDs(telegramIDs []string) UserOption
+	UsersWithAPIToken(token string) UserOption
+	SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error
+	IssueAPIToken(ctx context.Context, userGUID uuid.UUID) (string, error)
 }
 
 // SpendingCategory defines the interface for spending category service.
+// Every operation is scoped to the categories of the owner user.
 type SpendingCategory interface {
-	AddCategories(categories []ftracker.SpendingCategory) ([]uuid.UUID, error)
-	GetCategories(opts ...CategoryOption) ([]ftracker.SpendingCategory, error)
+	AddCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error)
+	GetCategories(ctx context.Context, userGUID uuid.UUID, opts ...CategoryOption) ([]ftracker.SpendingCategory, error)
+	GetCategoryTree(ctx context.Context, userGUID uuid.UUID, opts ...CategoryOption) ([]ftracker.CategoryTree, error)
+	UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error
+	DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error
 	SpendingCategoriesWithLimit(limit int) CategoryOption
 	SpendingCategoriesWithGUIDs(guids []uuid.UUID) CategoryOption
-	SpendingCategoriesWithUserGUIDs(guids []uuid.UUID) CategoryOption
 	SpendingCategoriesWithCategories(categories []string) CategoryOption
+	SpendingCategoriesWithKinds(kinds []string) CategoryOption
 	SpendingCategoriesWithOrder(order CategoryOrder, asc bool) CategoryOption
-	CreateExelFromCategories(categories []ftracker.SpendingCategory) (*excelize.File, error)
+	CreateExelFromCategories(categories []ftracker.SpendingCategory, currency string) (*excelize.File, error)
+	CreateCSVFromCategories(categories []ftracker.SpendingCategory, currency string) ([]byte, error)
 }
 
 // SpendingRecord defines the interface for spending record service.
+// Every operation is scoped to the records in the categories of the owner user.
 type SpendingRecord interface {
-	AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error)
-	GetRecords(opts ...RecordOption) ([]ftracker.SpendingRecord, error)
+	AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error)
+	GetRecords(ctx context.Context, userGUID uuid.UUID, opts ...RecordOption) ([]ftracker.SpendingRecord, error)
+	GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error)
+	GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error)
+	UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error
+	DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
 	SpendingRecordsWithLimit(limit int) RecordOption
 	SpendingRecordsWithGUIDs(guids []uuid.UUID) RecordOption
 	SpendingRecordsWithCategoryGUIDs(guids []uuid.UUID) RecordOption
 	SpendingRecordsWithTimeFrame(from, to time.Time) RecordOption
+	SpendingRecordsWithTags(tags []string, all bool) RecordOption
 	SpendingRecordsWithOrder(order RecordOrder, asc bool) RecordOption
-	CreateExelFromRecords(recods []ftracker.SpendingRecord) (*excelize.File, error)
+	CreateExelFromRecords(recods []ftracker.SpendingRecord, categories []ftracker.SpendingCategory, currency string) (*excelize.File, error)
+	CreateCSVFromRecords(records []ftracker.SpendingRecord, categories []ftracker.SpendingCategory) ([]byte, error)
+}
+
+// Budget defines the interface for budget service.
+// Every operation is scoped to the budgets of the owner user's categories.
+type Budget interface {
+	SetBudgets(ctx context.Context, userGUID uuid.UUID, budgets []ftracker.Budget) error
+	GetBudgets(ctx context.Context, userGUID uuid.UUID, month time.Time, categoryGUIDs ...uuid.UUID) ([]ftracker.BudgetStatus, error)
+	DeleteBudgets(ctx context.Context, userGUID uuid.UUID, categoryGUIDs []uuid.UUID) error
+	EvaluateBudget(ctx context.Context, userGUID, recordGUID uuid.UUID) (BudgetAlert, ftracker.BudgetStatus, error)
+}
+
+// Recurring defines the interface for recurring record service.
+// Every operation but GetDueRecurring and AdvanceRecurring is scoped to the recurring records
+// in the categories of the owner user, those two serve the scheduler of all the users.
+type Recurring interface {
+	AddRecurring(ctx context.Context, userGUID uuid.UUID, records []ftracker.RecurringRecord) ([]uuid.UUID, error)
+	GetRecurring(ctx context.Context, userGUID uuid.UUID, opts ...RecurringOption) ([]ftracker.RecurringRecord, error)
+	DeleteRecurring(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
+	GetDueRecurring(ctx context.Context, now time.Time, limit int) ([]ftracker.RecurringRecord, error)
+	AdvanceRecurring(ctx context.Context, record ftracker.RecurringRecord) (time.Time, error)
+	RecurringRecordsWithGUIDs(guids []uuid.UUID) RecurringOption
+	RecurringRecordsWithCategoryGUIDs(guids []uuid.UUID) RecurringOption
+}
+
+// ExchangeRate defines the interface for exchange rate service.
+type ExchangeRate interface {
+	SetExchangeRates(ctx context.Context, rates []ftracker.ExchangeRate) error
+	GetExchangeRates(ctx context.Context, currencies ...string) ([]ftracker.ExchangeRate, error)
+	LoadExchangeRates(ctx context.Context, r io.Reader) (int, error)
+}
+
+// Session defines the interface for the service of the saved bot sessions.
+type Session interface {
+	SaveSession(ctx context.Context, session ftracker.Session) error
+	GetSessions(ctx context.Context) ([]ftracker.Session, error)
+	DeleteSession(ctx context.Context, chatID int64) error
+}
+
+// PayeeRule defines the interface for the service of the rules mapping the payees of the bank transactions to the categories.
+// Every operation is scoped to the rules of the owner user's categories.
+type PayeeRule interface {
+	AddPayeeRules(ctx context.Context, userGUID uuid.UUID, rules []ftracker.PayeeRule) ([]uuid.UUID, error)
+	GetPayeeRules(ctx context.Context, userGUID uuid.UUID) ([]ftracker.PayeeRule, error)
+	DeletePayeeRules(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error
+}
+
+// Import defines the interface for the service importing the records of the users from files.
+type Import interface {
+	ImportRecordsCSV(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts ImportOptions) (ImportReport, error)
+	ImportRecordsExel(ctx context.Context, userGUID uuid.UUID, r io.Reader, opts ImportOptions) (ImportReport, error)
+	ParseBankStatement(ctx context.Context, userGUID uuid.UUID, r io.Reader, currency string) (BankStatement, error)
+	ImportBankTransactions(ctx context.Context, userGUID uuid.UUID, transactions []ftracker.BankTransaction) (int, error)
 }
 
 // ServiceInterface defines the interface for the service layer.
@@ -48,6 +121,12 @@ type ServiceInterface interface {
 	User
 	SpendingCategory
 	SpendingRecord
+	Budget
+	Recurring
+	ExchangeRate
+	Session
+	PayeeRule
+	Import
 }
 
 // Service implements the ServiceInterface.
@@ -55,6 +134,12 @@ type Service struct {
 	User
 	SpendingCategory
 	SpendingRecord
+	Budget
+	Recurring
+	ExchangeRate
+	Session
+	PayeeRule
+	Import
 }
 
 // New creates a new instance of Service with the provided repository.
@@ -63,5 +148,11 @@ func New(repo *repository.Repostitory) *Service {
 		User:             NewUserService(repo),
 		SpendingCategory: NewCategoryService(repo),
 		SpendingRecord:   NewRecordService(repo),
+		Budget:           NewBudgetService(repo, repo),
+		Recurring:        NewRecurringService(repo),
+		ExchangeRate:     NewExchangeRateService(repo),
+		Session:          NewSessionService(repo),
+		PayeeRule:        NewPayeeRuleService(repo),
+		Import:           NewImportService(repo, repo, repo, repo),
 	}
 }
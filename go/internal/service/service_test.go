@@ -1,14 +1,19 @@
 package service
 
 import (
+	"context"
+	"math"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
 	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 	repositorymock "github.com/iv-sukhanov/finance_tracker/internal/repository/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_GetUsers(t *testing.T) {
@@ -42,6 +47,14 @@ func Test_GetUsers(t *testing.T) {
 			},
 			want: repository.UserOptions{GUIDs: randomGUIDs, Limit: 2, TelegramIDs: []string{"1", "2", "3"}, Usernames: []string{"user1", "user2", "user3"}},
 		},
+		{
+			name: "By_api_token",
+			opts: []UserOption{
+				usrSrv.UsersWithAPIToken("token"),
+			},
+			// sha256 of "token"
+			want: repository.UserOptions{APITokenHashes: []string{"3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0"}},
+		},
 		{
 			name: "Empty_(all)",
 			opts: []UserOption{},
@@ -56,13 +69,35 @@ func Test_GetUsers(t *testing.T) {
 			defer cntr.Finish()
 
 			mockRepo := repositorymock.NewMockUser(cntr)
-			mockRepo.EXPECT().GetUsers(tc.want)
+			mockRepo.EXPECT().GetUsers(gomock.Any(), tc.want)
 
-			NewUserService(mockRepo).GetUsers(tc.opts...)
+			NewUserService(mockRepo).GetUsers(context.Background(), tc.opts...)
 		})
 	}
 }
 
+func Test_IssueAPIToken(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	userGUID := uuid.New()
+	var stored string
+	mockRepo := repositorymock.NewMockUser(cntr)
+	mockRepo.EXPECT().SetUserAPIToken(gomock.Any(), userGUID, gomock.Any()).DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string) error {
+		stored = hash
+		return nil
+	})
+
+	token, err := NewUserService(mockRepo).IssueAPIToken(context.Background(), userGUID)
+	require.NoError(t, err)
+	require.Len(t, token, 2*apiTokenBytes)
+	require.Equal(t, hashAPIToken(token), stored)
+	require.NotEqual(t, token, stored)
+
+	_, err = NewUserService(mockRepo).IssueAPIToken(context.Background(), uuid.Nil)
+	require.ErrorIs(t, err, ErrOwnerRequired)
+}
+
 func Test_GetCategories(t *testing.T) {
 	ctgSrvc := CategoryService{}
 	randomGUIDs := []uuid.UUID{
@@ -90,10 +125,10 @@ func Test_GetCategories(t *testing.T) {
 				ctgSrvc.SpendingCategoriesWithGUIDs(randomGUIDs[:2]),
 				ctgSrvc.SpendingCategoriesWithLimit(2),
 				ctgSrvc.SpendingCategoriesWithCategories([]string{"beer", "gym", "daytona"}),
-				ctgSrvc.SpendingCategoriesWithUserGUIDs(randomGUIDs[2:]),
 				ctgSrvc.SpendingCategoriesWithOrder(OrderCategoriesByCategory, true),
+				ctgSrvc.SpendingCategoriesWithKinds([]string{ftracker.KindSpending}),
 			},
-			want: repository.CategoryOptions{GUIDs: randomGUIDs[:2], Limit: 2, Categories: []string{"beer", "gym", "daytona"}, UserGUIDs: randomGUIDs[2:], Order: repository.CategoryOrder{Column: "category", Asc: true}},
+			want: repository.CategoryOptions{GUIDs: randomGUIDs[:2], Limit: 2, Categories: []string{"beer", "gym", "daytona"}, Kinds: []string{ftracker.KindSpending}, Order: repository.CategoryOrder{Column: "category", Asc: true}},
 		},
 		{
 			name: "Empty_(all)",
@@ -109,9 +144,83 @@ func Test_GetCategories(t *testing.T) {
 			defer cntr.Finish()
 
 			mockRepo := repositorymock.NewMockSpendingCategory(cntr)
-			mockRepo.EXPECT().GetCategories(tc.want)
+			mockRepo.EXPECT().GetCategories(gomock.Any(), randomGUIDs[3], tc.want)
+
+			NewCategoryService(mockRepo).GetCategories(context.Background(), randomGUIDs[3], tc.opts...)
+		})
+	}
+}
+
+func Test_GetCategoryTree(t *testing.T) {
+	userGUID := uuid.New()
+	food := ftracker.SpendingCategory{GUID: uuid.New(), Category: "food", Amount: 100}
+	groceries := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: food.GUID, Category: "groceries", Amount: 200}
+	fruits := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: groceries.GUID, Category: "fruits", Amount: 300}
+	cafe := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: food.GUID, Category: "cafe", Amount: 400}
+	gym := ftracker.SpendingCategory{GUID: uuid.New(), Category: "gym", Amount: 500}
+	orphan := ftracker.SpendingCategory{GUID: uuid.New(), ParentGUID: uuid.New(), Category: "orphan", Amount: 600}
+
+	withAmount := func(category ftracker.SpendingCategory, amount ftracker.Money) ftracker.SpendingCategory {
+		category.Amount = amount
+		return category
+	}
+
+	tt := []struct {
+		name       string
+		categories []ftracker.SpendingCategory
+		want       []ftracker.CategoryTree
+		wantErr    error
+	}{
+		{
+			name:       "Nested",
+			categories: []ftracker.SpendingCategory{fruits, food, gym, cafe, groceries},
+			want: []ftracker.CategoryTree{
+				{
+					SpendingCategory: withAmount(food, 1000),
+					Children: []ftracker.CategoryTree{
+						{SpendingCategory: cafe},
+						{
+							SpendingCategory: withAmount(groceries, 500),
+							Children:         []ftracker.CategoryTree{{SpendingCategory: fruits}},
+						},
+					},
+				},
+				{SpendingCategory: gym},
+			},
+		},
+		{
+			name:       "Missing_parent",
+			categories: []ftracker.SpendingCategory{orphan, gym},
+			want:       []ftracker.CategoryTree{{SpendingCategory: orphan}, {SpendingCategory: gym}},
+		},
+		{
+			name:       "Empty",
+			categories: nil,
+			want:       []ftracker.CategoryTree{},
+		},
+		{
+			name:       "Overflow",
+			categories: []ftracker.SpendingCategory{withAmount(food, math.MaxInt64), withAmount(cafe, 1)},
+			wantErr:    ftracker.ErrAmountOverflow,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			mockRepo := repositorymock.NewMockSpendingCategory(cntr)
+			mockRepo.EXPECT().GetCategories(gomock.Any(), userGUID, repository.CategoryOptions{}).Return(tc.categories, nil)
 
-			NewCategoryService(mockRepo).GetCategories(tc.opts...)
+			trees, err := NewCategoryService(mockRepo).GetCategoryTree(context.Background(), userGUID)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, trees)
 		})
 	}
 }
@@ -152,6 +261,13 @@ func Test_GetRecords(t *testing.T) {
 			},
 			want: repository.RecordOptions{GUIDs: randomGUIDs[:2], Limit: 2, TimeFrom: timeFrom, TimeTo: timeTo, ByTime: true, CategoryGUIDs: randomGUIDs[2:], Order: repository.RecordOrder{Column: "updated_at", Asc: true}},
 		},
+		{
+			name: "By_tags",
+			opts: []RecordOption{
+				rcdSrvc.SpendingRecordsWithTags([]string{"#Vacation", "food", "vacation"}, true),
+			},
+			want: repository.RecordOptions{Tags: []string{"vacation", "food"}, AllTags: true},
+		},
 		{
 			name: "Empty_(all)",
 			opts: []RecordOption{},
@@ -166,9 +282,448 @@ func Test_GetRecords(t *testing.T) {
 			defer cntr.Finish()
 
 			mockRepo := repositorymock.NewMockSpendingRecord(cntr)
-			mockRepo.EXPECT().GetRecords(tc.want)
+			mockRepo.EXPECT().GetRecords(gomock.Any(), randomGUIDs[3], tc.want)
+
+			NewRecordService(mockRepo).GetRecords(context.Background(), randomGUIDs[3], tc.opts...)
+		})
+	}
+}
+
+func Test_OwnerRequired(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	// no calls are expected to reach the repository
+	ctgSrvc := NewCategoryService(repositorymock.NewMockSpendingCategory(cntr))
+	rcdSrvc := NewRecordService(repositorymock.NewMockSpendingRecord(cntr))
+	bdgSrvc := NewBudgetService(repositorymock.NewMockBudget(cntr), repositorymock.NewMockSpendingRecord(cntr))
+	rcrSrvc := NewRecurringService(repositorymock.NewMockRecurring(cntr))
+
+	tt := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "Add_categories",
+			call: func() error {
+				_, err := ctgSrvc.AddCategories(context.Background(), uuid.Nil, nil)
+				return err
+			},
+		},
+		{
+			name: "Get_categories",
+			call: func() error {
+				_, err := ctgSrvc.GetCategories(context.Background(), uuid.Nil)
+				return err
+			},
+		},
+		{
+			name: "Get_category_tree",
+			call: func() error {
+				_, err := ctgSrvc.GetCategoryTree(context.Background(), uuid.Nil)
+				return err
+			},
+		},
+		{
+			name: "Update_categories",
+			call: func() error { return ctgSrvc.UpdateCategories(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Delete_categories",
+			call: func() error { return ctgSrvc.DeleteCategories(context.Background(), uuid.Nil, nil, uuid.Nil) },
+		},
+		{
+			name: "Add_records",
+			call: func() error {
+				_, err := rcdSrvc.AddRecords(context.Background(), uuid.Nil, nil)
+				return err
+			},
+		},
+		{
+			name: "Get_records",
+			call: func() error {
+				_, err := rcdSrvc.GetRecords(context.Background(), uuid.Nil)
+				return err
+			},
+		},
+		{
+			name: "Get_balance",
+			call: func() error {
+				_, err := rcdSrvc.GetBalance(context.Background(), uuid.Nil, time.Now(), time.Now())
+				return err
+			},
+		},
+		{
+			name: "Get_tag_spending",
+			call: func() error {
+				_, err := rcdSrvc.GetTagSpending(context.Background(), uuid.Nil, time.Now(), time.Now())
+				return err
+			},
+		},
+		{
+			name: "Update_records",
+			call: func() error { return rcdSrvc.UpdateRecords(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Delete_records",
+			call: func() error { return rcdSrvc.DeleteRecords(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Set_budgets",
+			call: func() error { return bdgSrvc.SetBudgets(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Get_budgets",
+			call: func() error {
+				_, err := bdgSrvc.GetBudgets(context.Background(), uuid.Nil, time.Now())
+				return err
+			},
+		},
+		{
+			name: "Delete_budgets",
+			call: func() error { return bdgSrvc.DeleteBudgets(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Add_recurring",
+			call: func() error {
+				_, err := rcrSrvc.AddRecurring(context.Background(), uuid.Nil, nil)
+				return err
+			},
+		},
+		{
+			name: "Get_recurring",
+			call: func() error {
+				_, err := rcrSrvc.GetRecurring(context.Background(), uuid.Nil)
+				return err
+			},
+		},
+		{
+			name: "Delete_recurring",
+			call: func() error { return rcrSrvc.DeleteRecurring(context.Background(), uuid.Nil, nil) },
+		},
+		{
+			name: "Evaluate_budget",
+			call: func() error {
+				_, _, err := bdgSrvc.EvaluateBudget(context.Background(), uuid.Nil, uuid.New())
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, tc.call(), ErrOwnerRequired)
+		})
+	}
+}
+
+func Test_CategoryNames(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	userGUID := uuid.New()
+	repo := repositorymock.NewMockSpendingCategory(cntr)
+	ctgSrvc := NewCategoryService(repo)
+
+	tt := []struct {
+		name     string
+		category string
+		wantErr  error
+	}{
+		{name: "Ok", category: "food court"},
+		{name: "Path", category: "food/groceries", wantErr: ErrInvalidCategoryName},
+		{name: "Markdown", category: "*food*", wantErr: ErrInvalidCategoryName},
+		{name: "Too_long", category: strings.Repeat("a", 21), wantErr: ErrInvalidCategoryName},
+		{name: "Empty", wantErr: ErrInvalidCategoryName},
+	}
 
-			NewRecordService(mockRepo).GetRecords(tc.opts...)
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			categories := []ftracker.SpendingCategory{{Category: tc.category}}
+			// only the valid names reach the repository
+			if tc.wantErr == nil {
+				repo.EXPECT().AddCategories(gomock.Any(), userGUID, categories).Return([]uuid.UUID{uuid.New()}, nil)
+				repo.EXPECT().UpdateCategories(gomock.Any(), userGUID, categories).Return(nil)
+			}
+
+			_, err := ctgSrvc.AddCategories(context.Background(), userGUID, categories)
+			require.ErrorIs(t, err, tc.wantErr)
+			err = ctgSrvc.UpdateCategories(context.Background(), userGUID, categories)
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func Test_LoadExchangeRates(t *testing.T) {
+
+	tt := []struct {
+		name    string
+		input   string
+		want    []ftracker.ExchangeRate
+		wantErr error
+	}{
+		{
+			name:  "Ok",
+			input: "# rates per 1 EUR\nEUR,1\n\nusd, 1.08\nGBP,0.85\nJPY,162.5\n",
+			want: []ftracker.ExchangeRate{
+				{Currency: "EUR", Rate: 1, Scale: 2},
+				{Currency: "USD", Rate: 1.08, Scale: 2},
+				{Currency: "GBP", Rate: 0.85, Scale: 2},
+				{Currency: "JPY", Rate: 162.5, Scale: 0},
+			},
+		},
+		{
+			name:    "No_separator",
+			input:   "USD 1.08",
+			wantErr: ErrInvalidExchangeRate,
+		},
+		{
+			name:    "Not_a_number",
+			input:   "USD,one",
+			wantErr: ErrInvalidExchangeRate,
+		},
+		{
+			name:    "Long_code",
+			input:   "USDT,1",
+			wantErr: ErrInvalidExchangeRate,
+		},
+		{
+			name:    "Negative_rate",
+			input:   "USD,-1.08",
+			wantErr: ErrInvalidExchangeRate,
+		},
+		{
+			name:    "Default_currency_rate",
+			input:   "EUR,1.5",
+			wantErr: ErrInvalidExchangeRate,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			mockRepo := repositorymock.NewMockExchangeRate(cntr)
+			if tc.wantErr == nil {
+				mockRepo.EXPECT().SetExchangeRates(gomock.Any(), tc.want)
+			}
+
+			n, err := NewExchangeRateService(mockRepo).LoadExchangeRates(context.Background(), strings.NewReader(tc.input))
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, len(tc.want), n)
 		})
 	}
 }
+
+func Test_EvaluateBudget(t *testing.T) {
+
+	userGUID := uuid.New()
+	record := ftracker.SpendingRecord{
+		GUID:         uuid.New(),
+		CategoryGUID: uuid.New(),
+		BaseAmount:   1000,
+		SpentAt:      time.Date(2024, 10, 25, 12, 0, 0, 0, time.UTC),
+		// added later, the budget of the month the money was spent in is evaluated
+		CreatedAt: time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC),
+	}
+	wantOpts := repository.BudgetOptions{
+		CategoryGUIDs: []uuid.UUID{record.CategoryGUID},
+		TimeFrom:      time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		TimeTo:        time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tt := []struct {
+		name    string
+		budgets []ftracker.BudgetStatus
+		want    BudgetAlert
+	}{
+		{
+			name: "No_budget",
+			want: BudgetAlertNone,
+		},
+		{
+			name:    "Below_warning",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 10000}, Spent: 7900}},
+			want:    BudgetAlertNone,
+		},
+		{
+			name:    "Crossed_warning",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 10000}, Spent: 8000}},
+			want:    BudgetAlertWarning,
+		},
+		{
+			name:    "Already_warned",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 10000}, Spent: 9500}},
+			want:    BudgetAlertNone,
+		},
+		{
+			name:    "Crossed_limit",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 10000}, Spent: 10500}},
+			want:    BudgetAlertExceeded,
+		},
+		{
+			name:    "Already_exceeded",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 10000}, Spent: 12000}},
+			want:    BudgetAlertNone,
+		},
+		{
+			name:    "Single_record_over_limit",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: 500}, Spent: 1000}},
+			want:    BudgetAlertExceeded,
+		},
+		{
+			name:    "Huge_limit",
+			budgets: []ftracker.BudgetStatus{{Budget: ftracker.Budget{Amount: math.MaxInt64}, Spent: math.MaxInt64/5*4 + 1000}},
+			want:    BudgetAlertWarning,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			mockRecords := repositorymock.NewMockSpendingRecord(cntr)
+			mockRecords.EXPECT().
+				GetRecords(context.Background(), userGUID, repository.RecordOptions{GUIDs: []uuid.UUID{record.GUID}}).
+				Return([]ftracker.SpendingRecord{record}, nil)
+			mockBudgets := repositorymock.NewMockBudget(cntr)
+			mockBudgets.EXPECT().GetBudgets(gomock.Any(), userGUID, wantOpts).Return(tc.budgets, nil)
+
+			got, _, err := NewBudgetService(mockBudgets, mockRecords).EvaluateBudget(context.Background(), userGUID, record.GUID)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_AddRecurring(t *testing.T) {
+
+	userGUID := uuid.New()
+	startsAt := time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name         string
+		record       ftracker.RecurringRecord
+		wantStartsAt time.Time
+		wantErr      error
+	}{
+		{
+			name:         "Interval_starts_as_is",
+			record:       ftracker.RecurringRecord{Schedule: "monthly", StartsAt: startsAt},
+			wantStartsAt: startsAt,
+		},
+		{
+			name:         "Cron_starts_on_occurrence",
+			record:       ftracker.RecurringRecord{Schedule: "0 9 1 * *", StartsAt: startsAt},
+			wantStartsAt: time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Invalid_schedule",
+			record:  ftracker.RecurringRecord{Schedule: "sometimes", StartsAt: startsAt},
+			wantErr: ErrInvalidSchedule,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cntr := gomock.NewController(t)
+			defer cntr.Finish()
+
+			mockRepo := repositorymock.NewMockRecurring(cntr)
+			if tc.wantErr == nil {
+				want := tc.record
+				want.StartsAt = tc.wantStartsAt
+				mockRepo.EXPECT().AddRecurring(gomock.Any(), userGUID, []ftracker.RecurringRecord{want})
+			}
+
+			_, err := NewRecurringService(mockRepo).AddRecurring(context.Background(), userGUID, []ftracker.RecurringRecord{tc.record})
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_AdvanceRecurring(t *testing.T) {
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	record := ftracker.RecurringRecord{
+		GUID:      uuid.New(),
+		Schedule:  "weekly",
+		StartsAt:  time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+		NextRunAt: time.Date(2024, 10, 8, 9, 0, 0, 0, time.UTC),
+	}
+	want := time.Date(2024, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	mockRepo := repositorymock.NewMockRecurring(cntr)
+	mockRepo.EXPECT().AdvanceRecurring(gomock.Any(), record.GUID, want)
+
+	got, err := NewRecurringService(mockRepo).AdvanceRecurring(context.Background(), record)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func Test_AddRecords_tags(t *testing.T) {
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	userGUID := uuid.New()
+	records := []ftracker.SpendingRecord{
+		{Description: "dinner #Vacation #food", Tags: []string{"#work", "vacation"}},
+		{Description: "no tags"},
+	}
+
+	mockRepo := repositorymock.NewMockSpendingRecord(cntr)
+	mockRepo.EXPECT().AddRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{
+		{Description: "dinner #Vacation #food", Tags: []string{"work", "vacation", "food"}},
+		{Description: "no tags"},
+	})
+
+	_, err := NewRecordService(mockRepo).AddRecords(context.Background(), userGUID, records)
+	require.NoError(t, err)
+	// the records of the caller are left untouched
+	require.Equal(t, []string{"#work", "vacation"}, records[0].Tags)
+}
+
+func Test_UpdateRecords_tags(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	userGUID := uuid.New()
+	stored := []ftracker.SpendingRecord{
+		{GUID: uuid.New(), Description: "dinner #work", Tags: []string{"work", "trip"}},
+		{GUID: uuid.New(), Description: "taxi #work", Tags: []string{"work"}},
+		{GUID: uuid.New(), Description: "lunch"},
+	}
+	// the records got for the edit, with another description or amount
+	records := []ftracker.SpendingRecord{
+		{GUID: stored[0].GUID, Description: "dinner", Tags: []string{"work", "trip"}},
+		{GUID: stored[1].GUID, Amount: 500, Description: "taxi #work", Tags: []string{"work"}},
+		{GUID: stored[2].GUID, Description: "lunch #food"},
+	}
+
+	mockRepo := repositorymock.NewMockSpendingRecord(cntr)
+	mockRepo.EXPECT().GetRecords(gomock.Any(), userGUID, repository.RecordOptions{GUIDs: []uuid.UUID{stored[0].GUID, stored[1].GUID, stored[2].GUID}}).
+		Return(stored, nil)
+	mockRepo.EXPECT().UpdateRecords(gomock.Any(), userGUID, []ftracker.SpendingRecord{
+		{GUID: stored[0].GUID, Description: "dinner", Tags: []string{"trip"}},
+		{GUID: stored[1].GUID, Amount: 500, Description: "taxi #work", Tags: []string{"work"}},
+		{GUID: stored[2].GUID, Description: "lunch #food", Tags: []string{"food"}},
+	})
+
+	require.NoError(t, NewRecordService(mockRepo).UpdateRecords(context.Background(), userGUID, records))
+}
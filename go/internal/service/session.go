@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
+	"github.com/iv-sukhanov/finance_tracker/internal/repository"
+)
+
+// SessionService implements the Session interface.
+type SessionService struct {
+	repo repository.Session
+}
+
+// NewSessionService creates a new instance of SessionService with the provided repository.
+func NewSessionService(repo repository.Session) *SessionService {
+	return &SessionService{
+		repo: repo,
+	}
+}
+
+// SaveSession saves the state of the flow in the chat, replacing the previously saved one.
+func (s *SessionService) SaveSession(ctx context.Context, session ftracker.Session) error {
+	return s.repo.SaveSession(ctx, session)
+}
+
+// GetSessions retrieves all the saved sessions, the least recently updated first.
+func (s *SessionService) GetSessions(ctx context.Context) ([]ftracker.Session, error) {
+	return s.repo.GetSessions(ctx)
+}
+
+// DeleteSession deletes the saved session of the chat.
+func (s *SessionService) DeleteSession(ctx context.Context, chatID int64) error {
+	return s.repo.DeleteSession(ctx, chatID)
+}
@@ -1,11 +1,25 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
 	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 )
 
+var (
+	// ErrInvalidCategoryName is returned when the name of a category is not the one the bot can show and look up,
+	// e.g. it has the "/" separating the names of a category path.
+	ErrInvalidCategoryName = errors.New("invalid category name")
+
+	// categoryNameRgx matches the names the bot takes for a category
+	categoryNameRgx = regexp.MustCompile(`^[a-zA-Z0-9 ]{1,20}$`)
+)
+
 type (
 	// CategoryService implements the SpendingCategory interface.
 	CategoryService struct {
@@ -69,45 +83,180 @@ func (CategoryService) SpendingCategoriesWithGUIDs(guids []uuid.UUID) CategoryOp
 	}
 }
 
-// SpendingCategoriesWithUserGUIDs is a function that sets the user GUIDs of the categories to be returned.
-func (CategoryService) SpendingCategoriesWithUserGUIDs(guids []uuid.UUID) CategoryOption {
+// SpendingCategoriesWithCategories is a function that sets the category names of the categories to be returned.
+func (CategoryService) SpendingCategoriesWithCategories(categories []string) CategoryOption {
 	return func(o *repository.CategoryOptions) {
-		o.UserGUIDs = guids
+		o.Categories = categories
 	}
 }
 
-// SpendingCategoriesWithCategories is a function that sets the category names of the categories to be returned.
-func (CategoryService) SpendingCategoriesWithCategories(categories []string) CategoryOption {
+// SpendingCategoriesWithKinds is a function that sets the kinds of the categories to be returned.
+func (CategoryService) SpendingCategoriesWithKinds(kinds []string) CategoryOption {
 	return func(o *repository.CategoryOptions) {
-		o.Categories = categories
+		o.Kinds = kinds
 	}
 }
 
 // GetCategories retrieves a list of spending categories based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
 //   - options: A variadic list of CategoryOption functions used to configure the query options.
 //
 // Returns:
 //   - []ftracker.SpendingCategory: A slice of spending categories that match the query options.
 //   - error: An error if the operation fails, or nil if successful.
-func (s *CategoryService) GetCategories(options ...CategoryOption) ([]ftracker.SpendingCategory, error) {
+func (s *CategoryService) GetCategories(ctx context.Context, userGUID uuid.UUID, options ...CategoryOption) ([]ftracker.SpendingCategory, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
 	var opts repository.CategoryOptions
 	for _, option := range options {
 		option(&opts)
 	}
 
-	return s.repo.GetCategories(opts)
+	return s.repo.GetCategories(ctx, userGUID, opts)
+}
+
+// GetCategoryTree retrieves the spending categories based on the provided options arranged into trees of subcategories.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - options: A variadic list of CategoryOption functions used to configure the query options,
+//     a category whose parent is filtered out becomes a root.
+//
+// Returns:
+//   - []ftracker.CategoryTree: The top level categories, their Amount includes the amounts of all the subcategories,
+//     the order of the query is kept among the siblings.
+//   - error: An error if the operation fails, or nil if successful.
+func (s *CategoryService) GetCategoryTree(ctx context.Context, userGUID uuid.UUID, options ...CategoryOption) ([]ftracker.CategoryTree, error) {
+	categories, err := s.GetCategories(ctx, userGUID, options...)
+	if err != nil {
+		return nil, err
+	}
+	trees, err := buildCategoryTree(categories)
+	if err != nil {
+		return nil, fmt.Errorf("GetCategoryTree: %w", err)
+	}
+	return trees, nil
 }
 
 // AddCategories adds a list of spending categories to the repository.
 //
 // Parameters:
-//   - categories: A slice of SpendingCategory objects to be added.
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - categories: A slice of SpendingCategory objects to be added,
+//     a subcategory has the ParentGUID of an existing category of the same kind.
 //
 // Returns:
 //   - A slice of UUIDs representing the IDs of the newly added categories.
+//   - An error if the operation fails, ErrInvalidCategoryName if one of the names is not valid, or nil if it succeeds.
+func (s *CategoryService) AddCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+	if err := checkCategoryNames(categories); err != nil {
+		return nil, fmt.Errorf("AddCategories: %w", err)
+	}
+	return s.repo.AddCategories(ctx, userGUID, categories)
+}
+
+// UpdateCategories updates the name and description of multiple spending categories.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - categories: A slice of SpendingCategory objects identified by their GUIDs, containing the new values.
+//
+// Returns:
+//   - An error if the operation fails, ErrInvalidCategoryName if one of the new names is not valid, or nil if it succeeds.
+func (s *CategoryService) UpdateCategories(ctx context.Context, userGUID uuid.UUID, categories []ftracker.SpendingCategory) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	if err := checkCategoryNames(categories); err != nil {
+		return fmt.Errorf("UpdateCategories: %w", err)
+	}
+	return s.repo.UpdateCategories(ctx, userGUID, categories)
+}
+
+// DeleteCategories deletes multiple spending categories from the repository.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the categories belong to.
+//   - guids: A slice of UUIDs of the categories to be deleted.
+//   - moveTo: The UUID of the category the records of the deleted categories are moved to,
+//     if it is uuid.Nil, the records are deleted as well.
+//
+// Returns:
 //   - An error if the operation fails, or nil if it succeeds.
-func (s *CategoryService) AddCategories(categories []ftracker.SpendingCategory) ([]uuid.UUID, error) {
-	return s.repo.AddCategories(categories)
+func (s *CategoryService) DeleteCategories(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID, moveTo uuid.UUID) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.DeleteCategories(ctx, userGUID, guids, moveTo)
+}
+
+// checkCategoryNames fails with ErrInvalidCategoryName if one of the categories has a name the bot can't take
+func checkCategoryNames(categories []ftracker.SpendingCategory) error {
+	for _, category := range categories {
+		if !validCategoryName(category.Category) {
+			return fmt.Errorf("%w %q", ErrInvalidCategoryName, category.Category)
+		}
+	}
+	return nil
+}
+
+// validCategoryName reports whether the name is one the bot can show and look up
+func validCategoryName(name string) bool {
+	return categoryNameRgx.MatchString(name)
+}
+
+// buildCategoryTree arranges the categories into trees and rolls the amounts of the subcategories up to their parents,
+// it fails with ftracker.ErrAmountOverflow if a rolled up amount is too large.
+func buildCategoryTree(categories []ftracker.SpendingCategory) ([]ftracker.CategoryTree, error) {
+	present := make(map[uuid.UUID]bool, len(categories))
+	for _, category := range categories {
+		present[category.GUID] = true
+	}
+
+	var roots []ftracker.SpendingCategory
+	children := make(map[uuid.UUID][]ftracker.SpendingCategory)
+	for _, category := range categories {
+		if category.ParentGUID != uuid.Nil && present[category.ParentGUID] {
+			children[category.ParentGUID] = append(children[category.ParentGUID], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var grow func(category ftracker.SpendingCategory) (ftracker.CategoryTree, error)
+	grow = func(category ftracker.SpendingCategory) (ftracker.CategoryTree, error) {
+		node := ftracker.CategoryTree{SpendingCategory: category}
+		for _, child := range children[category.GUID] {
+			subtree, err := grow(child)
+			if err != nil {
+				return node, err
+			}
+			if node.Amount, err = node.Amount.Add(subtree.Amount); err != nil {
+				return node, err
+			}
+			node.Children = append(node.Children, subtree)
+		}
+		return node, nil
+	}
+
+	trees := make([]ftracker.CategoryTree, len(roots))
+	for i, root := range roots {
+		var err error
+		if trees[i], err = grow(root); err != nil {
+			return nil, err
+		}
+	}
+	return trees, nil
 }
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +29,7 @@ const (
 	OrderRecordsByAmount                       // order by amount
 	OrderRecordsByCreatedAt                    // order by created_at
 	OrderRecordsByUpdatedAt                    // order by updated_at
+	OrderRecordsBySpentAt                      // order by spent_at
 )
 
 // NewRecordService creates a new instance of RecordService with the provided repository.
@@ -43,7 +46,7 @@ func (RecordService) SpendingRecordsWithLimit(limit int) RecordOption {
 	}
 }
 
-// SpendingRecordsWithTimeFrame is a function that sets the time frame for the records to be returned.
+// SpendingRecordsWithTimeFrame is a function that sets the time frame the records to be returned were spent in.
 func (RecordService) SpendingRecordsWithTimeFrame(from, to time.Time) RecordOption {
 	return func(o *repository.RecordOptions) {
 		o.TimeFrom = from
@@ -66,6 +69,16 @@ func (RecordService) SpendingRecordsWithCategoryGUIDs(guids []uuid.UUID) RecordO
 	}
 }
 
+// SpendingRecordsWithTags is a function that sets the tags of the records to be returned,
+// the records need to have all the tags if all is set, or any of them otherwise.
+func (RecordService) SpendingRecordsWithTags(tags []string, all bool) RecordOption {
+	tags = normalizeTags(tags)
+	return func(o *repository.RecordOptions) {
+		o.Tags = tags
+		o.AllTags = all
+	}
+}
+
 // SpendingRecordsWithOrder is a function that sets the order of the records to be returned.
 func (RecordService) SpendingRecordsWithOrder(order RecordOrder, asc bool) RecordOption {
 	repOrder := repository.RecordOrder{Asc: asc}
@@ -76,6 +89,8 @@ func (RecordService) SpendingRecordsWithOrder(order RecordOrder, asc bool) Recor
 		repOrder.Column = "created_at"
 	case OrderRecordsByUpdatedAt:
 		repOrder.Column = "updated_at"
+	case OrderRecordsBySpentAt:
+		repOrder.Column = "spent_at"
 	}
 
 	return func(o *repository.RecordOptions) {
@@ -86,28 +101,149 @@ func (RecordService) SpendingRecordsWithOrder(order RecordOrder, asc bool) Recor
 // GetRecords retrieves a list of spending records based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
 //   - options: A variadic list of RecordOption functions used to configure the query options.
 //
 // Returns:
 //   - []ftracker.SpendingRecord: A slice of spending records that match the query options.
 //   - error: An error if the operation fails, otherwise nil.
-func (s *RecordService) GetRecords(options ...RecordOption) ([]ftracker.SpendingRecord, error) {
+func (s *RecordService) GetRecords(ctx context.Context, userGUID uuid.UUID, options ...RecordOption) ([]ftracker.SpendingRecord, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+
 	var opts repository.RecordOptions
 	for _, option := range options {
 		option(&opts)
 	}
 
-	return s.repo.GetRecords(opts)
+	return s.repo.GetRecords(ctx, userGUID, opts)
+}
+
+// GetBalance sums up the income and the spending of the user in the time frame.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
+//   - from: The start of the time frame, inclusive.
+//   - to: The end of the time frame, exclusive.
+//
+// Returns:
+//   - ftracker.Balance: The totals in the base currency of the user.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *RecordService) GetBalance(ctx context.Context, userGUID uuid.UUID, from, to time.Time) (ftracker.Balance, error) {
+	if userGUID == uuid.Nil {
+		return ftracker.Balance{}, ErrOwnerRequired
+	}
+	return s.repo.GetBalance(ctx, userGUID, from, to)
+}
+
+// GetTagSpending sums up the spending of the user in the time frame by the tags of the records.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
+//   - from: The start of the time frame, inclusive.
+//   - to: The end of the time frame, exclusive.
+//
+// Returns:
+//   - []ftracker.TagSpending: The totals in the base currency of the user, the biggest first.
+//   - error: An error if the operation fails, otherwise nil.
+func (s *RecordService) GetTagSpending(ctx context.Context, userGUID uuid.UUID, from, to time.Time) ([]ftracker.TagSpending, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+	return s.repo.GetTagSpending(ctx, userGUID, from, to)
 }
 
 // AddRecords adds multiple spending records to the repository.
 //
 // Parameters:
-//   - records: A slice of SpendingRecord objects to be added.
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
+//   - records: A slice of SpendingRecord objects to be added,
+//     the hashtags of their descriptions are added to their Tags.
 //
 // Returns:
 //   - A slice of UUIDs representing the IDs of the newly added records.
 //   - An error if the operation fails, or nil if it succeeds.
-func (s *RecordService) AddRecords(records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
-	return s.repo.AddRecords(records)
+func (s *RecordService) AddRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) ([]uuid.UUID, error) {
+	if userGUID == uuid.Nil {
+		return nil, ErrOwnerRequired
+	}
+	return s.repo.AddRecords(ctx, userGUID, withTags(records))
+}
+
+// UpdateRecords updates the amount and description of multiple spending records, and their category,
+// currency and time if they are set, the amounts of the corresponding categories are corrected accordingly.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
+//   - records: A slice of SpendingRecord objects identified by their GUIDs, containing the new values,
+//     the tags of the records are replaced by their Tags and the hashtags of their descriptions,
+//     so the records to be changed are got with GetRecords first, which loads their tags.
+//     The hashtags of a changed description replace the ones of the stored description among the Tags.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *RecordService) UpdateRecords(ctx context.Context, userGUID uuid.UUID, records []ftracker.SpendingRecord) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+
+	guids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		guids[i] = record.GUID
+	}
+	stored, err := s.repo.GetRecords(ctx, userGUID, repository.RecordOptions{GUIDs: guids})
+	if err != nil {
+		return fmt.Errorf("UpdateRecords: %w", err)
+	}
+	descriptions := make(map[uuid.UUID]string, len(stored))
+	for _, record := range stored {
+		descriptions[record.GUID] = record.Description
+	}
+
+	retagged := make([]ftracker.SpendingRecord, len(records))
+	for i, record := range records {
+		if description, ok := descriptions[record.GUID]; ok {
+			record.Tags = retagRecord(record.Tags, description, record.Description)
+		}
+		retagged[i] = record
+	}
+	return s.repo.UpdateRecords(ctx, userGUID, withTags(retagged))
+}
+
+// DeleteRecords deletes multiple spending records from the repository,
+// their amounts are subtracted from the corresponding categories.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user the records belong to.
+//   - guids: A slice of UUIDs of the records to be deleted.
+//
+// Returns:
+//   - An error if the operation fails, or nil if it succeeds.
+func (s *RecordService) DeleteRecords(ctx context.Context, userGUID uuid.UUID, guids []uuid.UUID) error {
+	if userGUID == uuid.Nil {
+		return ErrOwnerRequired
+	}
+	return s.repo.DeleteRecords(ctx, userGUID, guids)
+}
+
+// withTags returns the copies of the records with their Tags normalized
+// and the hashtags of their descriptions added to them.
+func withTags(records []ftracker.SpendingRecord) []ftracker.SpendingRecord {
+	tagged := make([]ftracker.SpendingRecord, len(records))
+	for i, record := range records {
+		tags := normalizeTags(record.Tags)
+		for _, tag := range ParseTags(record.Description) {
+			tags = appendTag(tags, tag)
+		}
+		record.Tags = tags
+		tagged[i] = record
+	}
+	return tagged
 }
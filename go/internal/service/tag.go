@@ -0,0 +1,66 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tagRgx matches a hashtag at the start of the text or after a space,
+// the hashtags longer than the tag column allows are not matched
+var tagRgx = regexp.MustCompile(`(?:^|\s)#([a-zA-Z0-9_]{1,64})\b`)
+
+// ParseTags extracts the hashtags of the description of a record.
+//
+// Parameters:
+//   - description: The description of the record, e.g. "dinner #vacation #Food".
+//
+// Returns:
+//   - The lowercase tags without the leading #, each tag once, in the order of the description.
+func ParseTags(description string) []string {
+	var tags []string
+	for _, match := range tagRgx.FindAllStringSubmatch(description, -1) {
+		tags = appendTag(tags, match[1])
+	}
+	return tags
+}
+
+// normalizeTags lowercases the tags, strips the leading # and drops the empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		normalized = appendTag(normalized, strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	}
+	return normalized
+}
+
+// appendTag appends the lowercase tag unless it is empty or already in the tags.
+func appendTag(tags []string, tag string) []string {
+	tag = strings.ToLower(tag)
+	if tag == "" {
+		return tags
+	}
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// retagRecord returns the tags of a record whose description changes from the old one to the new one,
+// the hashtags of the old description are dropped, so withTags puts the ones of the new description instead,
+// the other tags are kept. A tag given both ways goes away with its hashtag.
+func retagRecord(tags []string, oldDescription, description string) []string {
+	if oldDescription == description {
+		return tags
+	}
+	stale := ParseTags(oldDescription)
+	var kept []string
+	for _, tag := range normalizeTags(tags) {
+		if !slices.Contains(stale, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
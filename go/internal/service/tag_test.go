@@ -0,0 +1,54 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseTags(t *testing.T) {
+
+	tt := []struct {
+		name        string
+		description string
+		want        []string
+	}{
+		{name: "Several", description: "dinner #vacation #food", want: []string{"vacation", "food"}},
+		{name: "At_start", description: "#work lunch", want: []string{"work"}},
+		{name: "Lowercased_once", description: "#Food and more #FOOD", want: []string{"food"}},
+		{name: "Underscore", description: "trip #summer_2025", want: []string{"summer_2025"}},
+		{name: "Inside_word", description: "c#sharp book", want: nil},
+		{name: "Lone_hash", description: "number # one", want: nil},
+		{name: "Too_long", description: "#" + strings.Repeat("a", 65), want: nil},
+		{name: "None", description: "spending", want: nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ParseTags(tc.description))
+		})
+	}
+}
+
+func Test_retagRecord(t *testing.T) {
+
+	tt := []struct {
+		name           string
+		tags           []string
+		oldDescription string
+		description    string
+		want           []string
+	}{
+		{name: "Hashtag_removed", tags: []string{"work", "trip"}, oldDescription: "dinner #work", description: "dinner", want: []string{"trip"}},
+		{name: "Hashtag_replaced", tags: []string{"work"}, oldDescription: "dinner #work", description: "dinner #date", want: nil},
+		{name: "Same_description", tags: []string{"work"}, oldDescription: "dinner #work", description: "dinner #work", want: []string{"work"}},
+		{name: "No_hashtags", tags: []string{"#Trip"}, oldDescription: "dinner", description: "lunch", want: []string{"trip"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, retagRecord(tc.tags, tc.oldDescription, tc.description))
+		})
+	}
+}
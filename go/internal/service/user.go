@@ -1,11 +1,21 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
 	"github.com/google/uuid"
 	ftracker "github.com/iv-sukhanov/finance_tracker/internal"
 	"github.com/iv-sukhanov/finance_tracker/internal/repository"
 )
 
+// apiTokenBytes is the number of random bytes in an API token.
+const apiTokenBytes = 32
+
 type (
 	// UserService implements the User interface.
 	UserService struct {
@@ -51,32 +61,89 @@ func (UserService) UsersWithTelegramIDs(telegramIDs []string) UserOption {
 	}
 }
 
+// UsersWithAPIToken is a function that sets the API token of the user to be returned.
+func (UserService) UsersWithAPIToken(token string) UserOption {
+	hash := hashAPIToken(token)
+	return func(o *repository.UserOptions) {
+		o.APITokenHashes = []string{hash}
+	}
+}
+
 // GetUsers retrieves a list of users based on the provided options.
 //
 // Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
 //   - options: A variadic list of UserOption functions that modify the
 //     UserOptions struct to filter or customize the user retrieval.
 //
 // Returns:
 //   - []ftracker.User: A slice of User objects that match the specified options.
 //   - error: An error if the operation fails, or nil if successful.
-func (s *UserService) GetUsers(options ...UserOption) ([]ftracker.User, error) {
+func (s *UserService) GetUsers(ctx context.Context, options ...UserOption) ([]ftracker.User, error) {
 	var opts repository.UserOptions
 	for _, option := range options {
 		option(&opts)
 	}
 
-	return s.repo.GetUsers(opts)
+	return s.repo.GetUsers(ctx, opts)
 }
 
 // AddUsers adds a list of users to the repository.
 //
 // Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
 //   - users: A slice of User objects to be added.
 //
 // Returns:
 //   - []uuid.UUID: A slice of UUIDs representing the GUIDs of the added users.
 //   - error: An error if the operation fails, or nil if successful.
-func (s *UserService) AddUsers(users []ftracker.User) ([]uuid.UUID, error) {
-	return s.repo.AddUsers(users)
+func (s *UserService) AddUsers(ctx context.Context, users []ftracker.User) ([]uuid.UUID, error) {
+	return s.repo.AddUsers(ctx, users)
+}
+
+// SetUserCurrency changes the base currency of the user,
+// the amounts of the user's records and categories are converted into it.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user.
+//   - currency: The code of the new base currency, case insensitive.
+//
+// Returns:
+//   - error: ErrUnknownCurrency if there is no exchange rate for the currency,
+//     an error if the operation fails, or nil if successful.
+func (s *UserService) SetUserCurrency(ctx context.Context, userGUID uuid.UUID, currency string) error {
+	return s.repo.SetUserCurrency(ctx, userGUID, strings.ToUpper(currency))
+}
+
+// IssueAPIToken generates a new API token for the user, the previous one stops working.
+//
+// Parameters:
+//   - ctx: The context of the call, it is passed to the repository.
+//   - userGUID: The GUID of the user.
+//
+// Returns:
+//   - string: The new token, it is not stored and can't be shown again.
+//   - error: An error if the operation fails, or nil if successful.
+func (s *UserService) IssueAPIToken(ctx context.Context, userGUID uuid.UUID) (string, error) {
+	if userGUID == uuid.Nil {
+		return "", ErrOwnerRequired
+	}
+
+	raw := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("IssueAPIToken: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.repo.SetUserAPIToken(ctx, userGUID, hashAPIToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashAPIToken returns the hex encoded SHA-256 of the token, the form the tokens are stored and looked up in.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -7,7 +7,8 @@ import (
 )
 
 const (
-	ErrSQLUniqueViolation = "23505"
+	ErrSQLUniqueViolation     = "23505"
+	ErrSQLForeignKeyViolation = "23503"
 )
 
 // GetItitialError traverses the chain of wrapped errors and returns the
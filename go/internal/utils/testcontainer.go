@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -150,6 +151,26 @@ func NewPGContainer(filenames ...string) (db *sqlx.DB, shut func(), err error) {
 	return db, shut, nil
 }
 
+// UpMigrations lists the up migrations in the directory in the order they are applied,
+// so a container gets the same schema as the database migrated by the bot
+//
+// Parameters:
+//   - dir: The directory with the migration files.
+//
+// Returns:
+//   - []string: The paths of the up migrations ordered by the version.
+//   - error: An error if the directory can't be listed or has no migrations.
+func UpMigrations(dir string) ([]string, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("UpMigrations: %w", err)
+	}
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("UpMigrations: no migrations in %s", dir)
+	}
+	return filenames, nil // the versions are zero-padded, so the names sort in the order of the versions
+}
+
 // filenamesToMounts converts a list of filenames to a list of mounts
 func filenamesToMounts(filenames ...string) []mount.Mount {
 	mounts := make([]mount.Mount, 0, len(filenames))
@@ -0,0 +1,7 @@
+drop table spending_records;
+
+drop table spending_categories;
+
+drop table users;
+
+drop function update_modified_column();
@@ -0,0 +1,14 @@
+DROP TRIGGER update_notifications_modtime ON spending_records;
+
+CREATE TRIGGER update_notifications_modtime
+    BEFORE UPDATE ON spending_records
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
+
+DROP FUNCTION update_record_modified_column();
+
+alter table spending_records drop column base_amount;
+alter table spending_records drop column currency;
+
+alter table users drop column currency;
+
+drop table exchange_rates;
@@ -0,0 +1,38 @@
+create table exchange_rates (
+    currency VARCHAR(3) not null primary key,
+    rate NUMERIC(20, 10) not null check (rate > 0),
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now()
+);
+
+insert into exchange_rates (currency, rate) values ('EUR', 1);
+
+alter table users add column currency VARCHAR(3) not null default 'EUR' references exchange_rates (currency);
+
+alter table spending_records add column currency VARCHAR(3) not null default 'EUR' references exchange_rates (currency);
+alter table spending_records add column base_amount NUMERIC(10, 0) not null default 0;
+
+update spending_records set base_amount = amount;
+
+CREATE TRIGGER update_exchange_rates_modtime
+    BEFORE UPDATE ON exchange_rates
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
+
+-- base_amount is recalculated when the user changes the currency,
+-- that is not an edit of the record, so it must not move updated_at
+CREATE OR REPLACE FUNCTION update_record_modified_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF ROW(NEW.category_guid, NEW.amount, NEW.currency, NEW.description) IS DISTINCT FROM
+       ROW(OLD.category_guid, OLD.amount, OLD.currency, OLD.description) THEN
+        NEW.updated_at = now();
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER update_notifications_modtime ON spending_records;
+
+CREATE TRIGGER update_notifications_modtime
+    BEFORE UPDATE ON spending_records
+    FOR EACH ROW EXECUTE FUNCTION update_record_modified_column();
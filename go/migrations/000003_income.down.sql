@@ -0,0 +1,4 @@
+delete from spending_records where category_guid in (select guid from spending_categories where kind = 'income');
+delete from spending_categories where kind = 'income';
+
+alter table spending_categories drop column kind;
@@ -0,0 +1 @@
+alter table spending_categories add column kind VARCHAR(10) not null default 'spending' check (kind in ('spending', 'income'));
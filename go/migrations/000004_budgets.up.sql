@@ -0,0 +1,11 @@
+create table budgets (
+    guid UUID not null default uuid_generate_v4() primary key,
+    category_guid UUID not null unique references spending_categories (guid) on delete cascade,
+    amount NUMERIC(20, 0) not null check (amount > 0),
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now()
+);
+
+CREATE TRIGGER update_budgets_modtime
+    BEFORE UPDATE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
@@ -0,0 +1,4 @@
+alter table spending_records drop column occurred_at;
+alter table spending_records drop column recurring_guid;
+
+drop table recurring_records;
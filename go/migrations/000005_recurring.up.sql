@@ -0,0 +1,25 @@
+create table recurring_records (
+    guid UUID not null default uuid_generate_v4() primary key,
+    category_guid UUID not null references spending_categories (guid) on delete cascade,
+    amount NUMERIC(10, 0) not null check (amount > 0),
+    currency VARCHAR(3) not null references exchange_rates (currency),
+    description TEXT,
+    schedule VARCHAR(255) not null,
+    starts_at TIMESTAMP without time zone not null,
+    next_run_at TIMESTAMP without time zone not null,
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now()
+);
+
+create index recurring_records_next_run_at on recurring_records (next_run_at);
+
+CREATE TRIGGER update_recurring_records_modtime
+    BEFORE UPDATE ON recurring_records
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
+
+-- a record made from a recurring record remembers the occurrence it was made for,
+-- so an occurrence can't be recorded twice when the scheduler catches up after downtime
+alter table spending_records add column recurring_guid UUID references recurring_records (guid) on delete set null;
+alter table spending_records add column occurred_at TIMESTAMP without time zone;
+
+create unique index spending_records_recurring_occurrence on spending_records (recurring_guid, occurred_at);
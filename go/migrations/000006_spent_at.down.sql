@@ -0,0 +1 @@
+alter table spending_records drop column spent_at;
@@ -0,0 +1,12 @@
+-- the day the money was spent, it may be earlier than the day the record was added
+alter table spending_records add column spent_at TIMESTAMP without time zone;
+
+-- backfilling must not touch updated_at
+alter table spending_records disable trigger update_notifications_modtime;
+update spending_records set spent_at = coalesce(occurred_at, created_at);
+alter table spending_records enable trigger update_notifications_modtime;
+
+alter table spending_records alter column spent_at set default now();
+alter table spending_records alter column spent_at set not null;
+
+create index spending_records_spent_at on spending_records (spent_at);
@@ -0,0 +1,2 @@
+drop table record_tags;
+drop table tags;
@@ -0,0 +1,20 @@
+create table tags (
+    guid UUID not null default uuid_generate_v4() primary key,
+    user_guid UUID not null references users (guid) on delete cascade,
+    tag VARCHAR(64) not null,
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now(),
+    unique (user_guid, tag)
+);
+
+CREATE TRIGGER update_tags_modtime
+    BEFORE UPDATE ON tags
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
+
+create table record_tags (
+    record_guid UUID not null references spending_records (guid) on delete cascade,
+    tag_guid UUID not null references tags (guid) on delete cascade,
+    primary key (record_guid, tag_guid)
+);
+
+create index record_tags_tag_guid on record_tags (tag_guid);
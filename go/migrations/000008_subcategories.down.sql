@@ -0,0 +1,4 @@
+drop index spending_categories_unique_name;
+alter table spending_categories add CONSTRAINT unique_columns UNIQUE (user_guid, category);
+
+alter table spending_categories drop column parent_guid;
@@ -0,0 +1,11 @@
+alter table spending_categories add column parent_guid UUID references spending_categories (guid) on delete set null;
+
+create index spending_categories_parent_guid on spending_categories (parent_guid);
+
+-- the names are unique among the siblings, so food/other and travel/other can both exist
+alter table spending_categories drop constraint unique_columns;
+create unique index spending_categories_unique_name on spending_categories (
+    user_guid,
+    COALESCE(parent_guid, '00000000-0000-0000-0000-000000000000'),
+    category
+);
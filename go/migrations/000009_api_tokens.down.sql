@@ -0,0 +1 @@
+alter table users drop column api_token_hash;
@@ -0,0 +1 @@
+alter table users add column api_token_hash VARCHAR(64) unique;
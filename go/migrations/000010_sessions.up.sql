@@ -0,0 +1,15 @@
+-- the state of the multi-step flows of the bot, so they can be resumed after a restart
+create table sessions (
+    chat_id BIGINT not null primary key,
+    user_id BIGINT not null,
+    username VARCHAR(255) not null default '',
+    command_id INT not null,
+    batch_kind VARCHAR(32) not null default '',
+    batch JSONB,
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now()
+);
+
+CREATE TRIGGER update_sessions_modtime
+    BEFORE UPDATE ON sessions
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
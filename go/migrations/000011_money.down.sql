@@ -0,0 +1,37 @@
+-- the amounts go back to hundredths whatever the currency is
+alter table spending_records disable trigger update_notifications_modtime;
+alter table spending_categories disable trigger update_notifications_modtime;
+alter table budgets disable trigger update_budgets_modtime;
+alter table recurring_records disable trigger update_recurring_records_modtime;
+
+update spending_records r set amount = round(r.amount * power(10::numeric, 2 - e.scale))
+    from exchange_rates e
+    where e.currency = r.currency and e.scale <> 2;
+
+update spending_records r set base_amount = round(r.base_amount * power(10::numeric, 2 - e.scale))
+    from spending_categories c, users u, exchange_rates e
+    where c.guid = r.category_guid and u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update spending_categories c set amount = coalesce((select sum(base_amount) from spending_records where category_guid = c.guid), 0)
+    from users u, exchange_rates e
+    where u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update budgets b set amount = greatest(round(b.amount * power(10::numeric, 2 - e.scale)), 1)
+    from spending_categories c, users u, exchange_rates e
+    where c.guid = b.category_guid and u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update recurring_records r set amount = greatest(round(r.amount * power(10::numeric, 2 - e.scale)), 1)
+    from exchange_rates e
+    where e.currency = r.currency and e.scale <> 2;
+
+alter table spending_records enable trigger update_notifications_modtime;
+alter table spending_categories enable trigger update_notifications_modtime;
+alter table budgets enable trigger update_budgets_modtime;
+alter table recurring_records enable trigger update_recurring_records_modtime;
+
+alter table spending_records alter column amount type NUMERIC(10, 0), alter column base_amount type NUMERIC(10, 0);
+alter table spending_categories alter column amount type NUMERIC(20, 0);
+alter table budgets alter column amount type NUMERIC(20, 0);
+alter table recurring_records alter column amount type NUMERIC(10, 0);
+
+alter table exchange_rates drop column scale;
@@ -0,0 +1,44 @@
+-- the number of the decimal places of the currency, the amounts are kept in its minor units
+alter table exchange_rates add column scale SMALLINT not null default 2 check (scale between 0 and 4);
+
+update exchange_rates set scale = 0
+    where currency in ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF');
+update exchange_rates set scale = 3 where currency in ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND');
+update exchange_rates set scale = 4 where currency in ('CLF', 'UYW');
+
+-- widened before the rescaling, so multiplying the amounts can't overflow
+alter table spending_records alter column amount type BIGINT, alter column base_amount type BIGINT;
+alter table spending_categories alter column amount type BIGINT;
+alter table budgets alter column amount type BIGINT;
+alter table recurring_records alter column amount type BIGINT;
+
+-- the amounts were kept in hundredths whatever the currency was, rescaling them must not touch updated_at
+alter table spending_records disable trigger update_notifications_modtime;
+alter table spending_categories disable trigger update_notifications_modtime;
+alter table budgets disable trigger update_budgets_modtime;
+alter table recurring_records disable trigger update_recurring_records_modtime;
+
+update spending_records r set amount = round(r.amount * power(10::numeric, e.scale - 2))
+    from exchange_rates e
+    where e.currency = r.currency and e.scale <> 2;
+
+update spending_records r set base_amount = round(r.base_amount * power(10::numeric, e.scale - 2))
+    from spending_categories c, users u, exchange_rates e
+    where c.guid = r.category_guid and u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update spending_categories c set amount = coalesce((select sum(base_amount) from spending_records where category_guid = c.guid), 0)
+    from users u, exchange_rates e
+    where u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update budgets b set amount = greatest(round(b.amount * power(10::numeric, e.scale - 2)), 1)
+    from spending_categories c, users u, exchange_rates e
+    where c.guid = b.category_guid and u.guid = c.user_guid and e.currency = u.currency and e.scale <> 2;
+
+update recurring_records r set amount = greatest(round(r.amount * power(10::numeric, e.scale - 2)), 1)
+    from exchange_rates e
+    where e.currency = r.currency and e.scale <> 2;
+
+alter table spending_records enable trigger update_notifications_modtime;
+alter table spending_categories enable trigger update_notifications_modtime;
+alter table budgets enable trigger update_budgets_modtime;
+alter table recurring_records enable trigger update_recurring_records_modtime;
@@ -0,0 +1,2 @@
+drop table payee_rules;
+drop table bank_transactions;
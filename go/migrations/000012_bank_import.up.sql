@@ -0,0 +1,23 @@
+-- the transactions imported from the bank statements, a transaction is imported once,
+-- even if its record is deleted afterwards, so a statement overlapping the previous one doesn't bring it back
+create table bank_transactions (
+    user_guid UUID not null references users (guid) on delete cascade,
+    transaction_id VARCHAR(255) not null,
+    record_guid UUID references spending_records (guid) on delete set null,
+    created_at TIMESTAMP without time zone not null default now(),
+    primary key (user_guid, transaction_id)
+);
+
+-- the payees of the imported transactions are mapped to the categories by the patterns they contain
+create table payee_rules (
+    guid UUID not null default uuid_generate_v4() primary key,
+    category_guid UUID not null references spending_categories (guid) on delete cascade,
+    pattern VARCHAR(255) not null,
+    updated_at TIMESTAMP without time zone not null default now(),
+    created_at TIMESTAMP without time zone not null default now(),
+    unique (category_guid, pattern)
+);
+
+CREATE TRIGGER update_payee_rules_modtime
+    BEFORE UPDATE ON payee_rules
+    FOR EACH ROW EXECUTE FUNCTION update_modified_column();
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations of the database into the binary,
+// the files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+package migrations
+
+import "embed"
+
+// FS contains the migration files, the test data is not embedded.
+//
+//go:embed *.sql
+var FS embed.FS
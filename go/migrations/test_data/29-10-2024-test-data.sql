@@ -0,0 +1,189 @@
+begin;
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000001', 'for_records', '00000001'),
+       ('00000000-0000-0000-0000-000000000002', 'for_categories', '00000002'),
+       ('00000000-0000-0000-0000-000000000003', 'for_get_users1', '00000003'),
+       ('00000000-0000-0000-0000-000000000004', 'for_get_users2', '00000004'),
+       ('00000000-0000-0000-0000-000000000005', 'for_get_users3', '00000005'),
+       ('00000000-0000-0000-0000-000000000006', 'for_get_users4', '00000006');
+
+insert into spending_categories (guid, user_guid, category, description)
+values ('00000000-0000-0000-0000-000000000011', '00000000-0000-0000-0000-000000000001', 'for_add_record1', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000021', '00000000-0000-0000-0000-000000000001', 'for_add_record2', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000031', '00000000-0000-0000-0000-000000000001', 'for_add_record3', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000041', '00000000-0000-0000-0000-000000000001', 'for_add_record4', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000051', '00000000-0000-0000-0000-000000000001', 'for_get_records1', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000061', '00000000-0000-0000-0000-000000000001', 'for_get_records2', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000071', '00000000-0000-0000-0000-000000000001', 'for_get_categories1', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000081', '00000000-0000-0000-0000-000000000002', 'for_get_categories2', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000091', '00000000-0000-0000-0000-000000000001', 'for_get_categories3', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000101', '00000000-0000-0000-0000-000000000002', 'for_get_categories4', 'bla bla bla');
+
+
+insert into spending_records (guid, category_guid, amount, description, updated_at, spent_at)
+values ('00000000-0000-0000-0000-000000000111', '00000000-0000-0000-0000-000000000051', 1250, 'bla bla bla', '2024-10-29 14:35:22', '2024-10-29 14:35:22'),
+       ('00000000-0000-0000-0000-000000000211', '00000000-0000-0000-0000-000000000051', 1410, 'bla bla bla', '2024-10-29 14:35:22', '2024-10-29 14:35:22'),
+       ('00000000-0000-0000-0000-000000000311', '00000000-0000-0000-0000-000000000051', 2710, 'bla bla bla', '2024-10-26 14:35:22', '2024-10-26 14:35:22'),
+       ('00000000-0000-0000-0000-000000000411', '00000000-0000-0000-0000-000000000061', 891, 'bla bla bla', '2024-10-25 14:35:22', '2024-10-25 14:35:22');
+
+insert into spending_categories (guid, user_guid, category, description, amount)
+values ('00000000-0000-0000-0000-000000000111', '00000000-0000-0000-0000-000000000001', 'for_update_records', 'bla bla bla', 2000),
+       ('00000000-0000-0000-0000-000000000121', '00000000-0000-0000-0000-000000000001', 'for_delete_records', 'bla bla bla', 4000);
+
+insert into spending_records (guid, category_guid, amount, description)
+values ('00000000-0000-0000-0000-000000000511', '00000000-0000-0000-0000-000000000111', 2000, 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000611', '00000000-0000-0000-0000-000000000121', 3000, 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000711', '00000000-0000-0000-0000-000000000121', 1000, 'bla bla bla');
+
+insert into spending_categories (guid, user_guid, category, description, amount)
+values ('00000000-0000-0000-0000-000000000131', '00000000-0000-0000-0000-000000000002', 'for_update_categories1', 'bla bla bla', 0),
+       ('00000000-0000-0000-0000-000000000141', '00000000-0000-0000-0000-000000000002', 'for_update_categories2', 'bla bla bla', 0),
+       ('00000000-0000-0000-0000-000000000151', '00000000-0000-0000-0000-000000000002', 'for_delete_categories1', 'bla bla bla', 1500),
+       ('00000000-0000-0000-0000-000000000161', '00000000-0000-0000-0000-000000000002', 'for_delete_categories2', 'bla bla bla', 2500),
+       ('00000000-0000-0000-0000-000000000171', '00000000-0000-0000-0000-000000000002', 'for_delete_categories3', 'bla bla bla', 500);
+
+insert into spending_records (guid, category_guid, amount, description)
+values ('00000000-0000-0000-0000-000000000811', '00000000-0000-0000-0000-000000000151', 1500, 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000000911', '00000000-0000-0000-0000-000000000161', 2500, 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000001011', '00000000-0000-0000-0000-000000000171', 500, 'bla bla bla');
+
+
+insert into exchange_rates (currency, rate)
+values ('USD', 2),
+       ('GBP', 0.5);
+
+insert into users (guid, username, telegram_id, currency)
+values ('00000000-0000-0000-0000-000000000007', 'for_currencies', '00000007', 'EUR');
+
+insert into spending_categories (guid, user_guid, category, description, amount)
+values ('00000000-0000-0000-0000-000000000181', '00000000-0000-0000-0000-000000000007', 'for_currencies1', 'bla bla bla', 3000);
+
+insert into spending_records (guid, category_guid, amount, currency, description)
+values ('00000000-0000-0000-0000-000000001111', '00000000-0000-0000-0000-000000000181', 1000, 'EUR', 'bla bla bla'),
+       ('00000000-0000-0000-0000-000000001211', '00000000-0000-0000-0000-000000000181', 4000, 'USD', 'bla bla bla');
+
+update spending_records set base_amount = amount where currency = 'EUR';
+update spending_records set base_amount = amount / 2 where currency = 'USD';
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000008', 'for_income', '00000008');
+
+insert into spending_categories (guid, user_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000191', '00000000-0000-0000-0000-000000000008', 'for_income1', 'bla bla bla', 1500, 'spending'),
+       ('00000000-0000-0000-0000-000000000201', '00000000-0000-0000-0000-000000000008', 'for_income2', 'bla bla bla', 7000, 'income');
+
+insert into spending_records (guid, category_guid, amount, base_amount, description, created_at, updated_at, spent_at)
+values ('00000000-0000-0000-0000-000000001311', '00000000-0000-0000-0000-000000000191', 1500, 1500, 'bla bla bla', '2024-10-25 12:00:00', '2024-10-25 12:00:00', '2024-10-25 12:00:00'),
+       ('00000000-0000-0000-0000-000000001411', '00000000-0000-0000-0000-000000000201', 5000, 5000, 'bla bla bla', '2024-10-26 12:00:00', '2024-10-26 12:00:00', '2024-10-26 12:00:00'),
+       ('00000000-0000-0000-0000-000000001511', '00000000-0000-0000-0000-000000000201', 2000, 2000, 'bla bla bla', '2024-11-10 12:00:00', '2024-11-10 12:00:00', '2024-11-10 12:00:00');
+
+insert into budgets (guid, category_guid, amount)
+values ('00000000-0000-0000-0000-000000000182', '00000000-0000-0000-0000-000000000181', 3000);
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000009', 'for_budgets', '00000009'),
+       ('00000000-0000-0000-0000-000000000010', 'for_budgets_set', '00000010');
+
+insert into spending_categories (guid, user_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000211', '00000000-0000-0000-0000-000000000009', 'for_budgets1', 'bla bla bla', 4000, 'spending'),
+       ('00000000-0000-0000-0000-000000000221', '00000000-0000-0000-0000-000000000009', 'for_budgets2', 'bla bla bla', 0, 'spending'),
+       ('00000000-0000-0000-0000-000000000231', '00000000-0000-0000-0000-000000000009', 'for_budgets3', 'bla bla bla', 0, 'spending'),
+       ('00000000-0000-0000-0000-000000000241', '00000000-0000-0000-0000-000000000010', 'for_budgets_set1', 'bla bla bla', 0, 'spending'),
+       ('00000000-0000-0000-0000-000000000251', '00000000-0000-0000-0000-000000000010', 'for_budgets_set2', 'bla bla bla', 0, 'spending'),
+       ('00000000-0000-0000-0000-000000000261', '00000000-0000-0000-0000-000000000010', 'for_budgets_set3', 'bla bla bla', 0, 'income');
+
+insert into spending_records (guid, category_guid, amount, base_amount, description, created_at, updated_at, spent_at)
+values ('00000000-0000-0000-0000-000000001611', '00000000-0000-0000-0000-000000000211', 1000, 1000, 'bla bla bla', '2024-10-25 12:00:00', '2024-10-25 12:00:00', '2024-10-25 12:00:00'),
+       ('00000000-0000-0000-0000-000000001711', '00000000-0000-0000-0000-000000000211', 2500, 2500, 'bla bla bla', '2024-10-26 12:00:00', '2024-10-26 12:00:00', '2024-10-26 12:00:00'),
+       ('00000000-0000-0000-0000-000000001811', '00000000-0000-0000-0000-000000000211', 500, 500, 'bla bla bla', '2024-11-02 12:00:00', '2024-11-02 12:00:00', '2024-11-02 12:00:00');
+
+insert into budgets (guid, category_guid, amount)
+values ('00000000-0000-0000-0000-000000000212', '00000000-0000-0000-0000-000000000211', 3000),
+       ('00000000-0000-0000-0000-000000000222', '00000000-0000-0000-0000-000000000221', 1000),
+       ('00000000-0000-0000-0000-000000000252', '00000000-0000-0000-0000-000000000251', 2000);
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000011', 'for_recurring', '00000011');
+
+insert into spending_categories (guid, user_guid, category, description, amount)
+values ('00000000-0000-0000-0000-000000000271', '00000000-0000-0000-0000-000000000011', 'for_recurring1', 'bla bla bla', 1000);
+
+insert into recurring_records (guid, category_guid, amount, currency, description, schedule, starts_at, next_run_at)
+values ('00000000-0000-0000-0000-000000000272', '00000000-0000-0000-0000-000000000271', 1000, 'EUR', 'rent', 'monthly', '2024-09-01 09:00:00', '2024-10-01 09:00:00'),
+       ('00000000-0000-0000-0000-000000000282', '00000000-0000-0000-0000-000000000271', 500, 'USD', 'gym', 'weekly', '2030-01-01 09:00:00', '2030-01-01 09:00:00'),
+       ('00000000-0000-0000-0000-000000000292', '00000000-0000-0000-0000-000000000271', 500, 'EUR', 'phone', 'monthly', '2030-01-01 09:00:00', '2030-01-01 09:00:00'),
+       ('00000000-0000-0000-0000-000000000302', '00000000-0000-0000-0000-000000000271', 500, 'EUR', 'music', '0 9 1 * *', '2030-01-01 09:00:00', '2030-01-01 09:00:00');
+
+-- the occurrence of 01.10.2024 is recorded, but the recurring record was not advanced
+insert into spending_records (guid, category_guid, amount, base_amount, description, recurring_guid, occurred_at, created_at, updated_at, spent_at)
+values ('00000000-0000-0000-0000-000000001911', '00000000-0000-0000-0000-000000000271', 1000, 1000, 'rent', '00000000-0000-0000-0000-000000000272', '2024-10-01 09:00:00', '2024-10-01 09:00:00', '2024-10-01 09:00:00', '2024-10-01 09:00:00');
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000012', 'for_tags', '00000012');
+
+insert into spending_categories (guid, user_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000281', '00000000-0000-0000-0000-000000000012', 'for_tags1', 'bla bla bla', 1800, 'spending'),
+       ('00000000-0000-0000-0000-000000000291', '00000000-0000-0000-0000-000000000012', 'for_tags2', 'bla bla bla', 2000, 'income');
+
+insert into spending_records (guid, category_guid, amount, base_amount, description, spent_at)
+values ('00000000-0000-0000-0000-000000002011', '00000000-0000-0000-0000-000000000281', 1000, 1000, 'dinner #vacation #food', '2024-10-05 12:00:00'),
+       ('00000000-0000-0000-0000-000000002111', '00000000-0000-0000-0000-000000000281', 500, 500, 'taxi #vacation', '2024-10-06 12:00:00'),
+       ('00000000-0000-0000-0000-000000002211', '00000000-0000-0000-0000-000000000281', 300, 300, 'lunch #food', '2024-11-01 12:00:00'),
+       ('00000000-0000-0000-0000-000000002311', '00000000-0000-0000-0000-000000000291', 2000, 2000, 'refund #vacation', '2024-10-07 12:00:00');
+
+insert into tags (guid, user_guid, tag)
+values ('00000000-0000-0000-0000-000000000313', '00000000-0000-0000-0000-000000000012', 'vacation'),
+       ('00000000-0000-0000-0000-000000000323', '00000000-0000-0000-0000-000000000012', 'food'),
+       ('00000000-0000-0000-0000-000000000333', '00000000-0000-0000-0000-000000000012', 'work');
+
+insert into record_tags (record_guid, tag_guid)
+values ('00000000-0000-0000-0000-000000002011', '00000000-0000-0000-0000-000000000313'),
+       ('00000000-0000-0000-0000-000000002011', '00000000-0000-0000-0000-000000000323'),
+       ('00000000-0000-0000-0000-000000002111', '00000000-0000-0000-0000-000000000313'),
+       ('00000000-0000-0000-0000-000000002211', '00000000-0000-0000-0000-000000000323'),
+       ('00000000-0000-0000-0000-000000002311', '00000000-0000-0000-0000-000000000313');
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000013', 'for_subcategories', '00000013');
+
+insert into spending_categories (guid, user_guid, parent_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000301', '00000000-0000-0000-0000-000000000013', null, 'for_subcategories1', 'bla bla bla', 1000, 'spending'),
+       ('00000000-0000-0000-0000-000000000311', '00000000-0000-0000-0000-000000000013', '00000000-0000-0000-0000-000000000301', 'for_subcategories2', 'bla bla bla', 2000, 'spending'),
+       ('00000000-0000-0000-0000-000000000321', '00000000-0000-0000-0000-000000000013', '00000000-0000-0000-0000-000000000311', 'for_subcategories3', 'bla bla bla', 500, 'spending'),
+       ('00000000-0000-0000-0000-000000000331', '00000000-0000-0000-0000-000000000013', null, 'for_subcategories4', 'bla bla bla', 3000, 'income');
+
+insert into users (guid, username, telegram_id, api_token_hash)
+values ('00000000-0000-0000-0000-000000000014', 'for_api_tokens1', '00000014', 'existing_hash'),
+       ('00000000-0000-0000-0000-000000000015', 'for_api_tokens2', '00000015', null);
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000016', 'for_bank', '00000016');
+
+insert into spending_categories (guid, user_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000341', '00000000-0000-0000-0000-000000000016', 'for_bank1', 'bla bla bla', 1000, 'spending'),
+       ('00000000-0000-0000-0000-000000000351', '00000000-0000-0000-0000-000000000016', 'for_bank2', 'bla bla bla', 0, 'income');
+
+insert into spending_records (guid, category_guid, amount, base_amount, description, spent_at)
+values ('00000000-0000-0000-0000-000000002411', '00000000-0000-0000-0000-000000000341', 1000, 1000, 'lidl', '2024-10-05 12:00:00');
+
+insert into bank_transactions (user_guid, transaction_id, record_guid)
+values ('00000000-0000-0000-0000-000000000016', 'fitid-1', '00000000-0000-0000-0000-000000002411'),
+       ('00000000-0000-0000-0000-000000000016', 'fitid-2', null);
+
+insert into payee_rules (guid, category_guid, pattern)
+values ('00000000-0000-0000-0000-000000000342', '00000000-0000-0000-0000-000000000341', 'lidl'),
+       ('00000000-0000-0000-0000-000000000352', '00000000-0000-0000-0000-000000000351', 'acme payroll'),
+       ('00000000-0000-0000-0000-000000000362', '00000000-0000-0000-0000-000000000341', 'rewe');
+
+insert into users (guid, username, telegram_id)
+values ('00000000-0000-0000-0000-000000000017', 'for_workbook', '00000017');
+
+insert into spending_categories (guid, user_guid, category, description, amount, kind)
+values ('00000000-0000-0000-0000-000000000361', '00000000-0000-0000-0000-000000000017', 'for_workbook1', 'bla bla bla', 1000, 'spending'),
+       ('00000000-0000-0000-0000-000000000371', '00000000-0000-0000-0000-000000000017', 'for_workbook2', 'bla bla bla', 0, 'spending');
+
+insert into spending_records (guid, category_guid, amount, base_amount, description, spent_at)
+values ('00000000-0000-0000-0000-000000002511', '00000000-0000-0000-0000-000000000361', 1000, 1000, 'cinema', '2024-10-05 12:00:00');
+
+commit;
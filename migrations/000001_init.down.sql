@@ -1,5 +0,0 @@
-drop table spending_records;
-
-drop table spending_types;
-
-drop table users;
\ No newline at end of file
@@ -1,31 +0,0 @@
-begin;
-
-insert into users (guid, username, telegram_id)
-values ('00000000-0000-0000-0000-000000000001', 'for_records', '00000001'),
-       ('00000000-0000-0000-0000-000000000002', 'for_categories', '00000002'),
-       ('00000000-0000-0000-0000-000000000003', 'for_get_users1', '00000003'),
-       ('00000000-0000-0000-0000-000000000004', 'for_get_users2', '00000004'),
-       ('00000000-0000-0000-0000-000000000005', 'for_get_users3', '00000005'),
-       ('00000000-0000-0000-0000-000000000006', 'for_get_users4', '00000006');
-
-insert into spending_categories (guid, user_guid, category, description)
-values ('00000000-0000-0000-0000-000000000011', '00000000-0000-0000-0000-000000000001', 'for_add_record1', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000021', '00000000-0000-0000-0000-000000000001', 'for_add_record2', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000031', '00000000-0000-0000-0000-000000000001', 'for_add_record3', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000041', '00000000-0000-0000-0000-000000000001', 'for_add_record4', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000051', '00000000-0000-0000-0000-000000000001', 'for_get_records1', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000061', '00000000-0000-0000-0000-000000000001', 'for_get_records2', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000071', '00000000-0000-0000-0000-000000000001', 'for_get_categories1', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000081', '00000000-0000-0000-0000-000000000002', 'for_get_categories2', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000091', '00000000-0000-0000-0000-000000000001', 'for_get_categories3', 'bla bla bla'),
-       ('00000000-0000-0000-0000-000000000101', '00000000-0000-0000-0000-000000000002', 'for_get_categories4', 'bla bla bla');
-
-
-insert into spending_records (guid, category_guid, amount, description, updated_at)
-values ('00000000-0000-0000-0000-000000000111', '00000000-0000-0000-0000-000000000051', 1250, 'bla bla bla', '2024-10-29 14:35:22'),
-       ('00000000-0000-0000-0000-000000000211', '00000000-0000-0000-0000-000000000051', 1410, 'bla bla bla', '2024-10-29 14:35:22'),
-       ('00000000-0000-0000-0000-000000000311', '00000000-0000-0000-0000-000000000051', 2710, 'bla bla bla', '2024-10-26 14:35:22'),
-       ('00000000-0000-0000-0000-000000000411', '00000000-0000-0000-0000-000000000061', 891, 'bla bla bla', '2024-10-25 14:35:22');
-
-
-commit;
\ No newline at end of file